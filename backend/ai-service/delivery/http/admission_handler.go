@@ -0,0 +1,81 @@
+package http
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/augment-local-manus-clone/backend/ai-service/domain"
+	"github.com/augment-local-manus-clone/backend/ai-service/usecase"
+	"github.com/gin-gonic/gin"
+)
+
+// Headers that identify who a request is for and how urgent it is
+const (
+	clientIDHeader = "X-Client-ID"
+	priorityHeader = "X-Priority"
+)
+
+// AdmissionHandler handles HTTP requests for the admission queue
+type AdmissionHandler struct {
+	admissionController *usecase.AdmissionController
+}
+
+// NewAdmissionHandler creates a new AdmissionHandler
+func NewAdmissionHandler(router *gin.Engine, admissionController *usecase.AdmissionController) *AdmissionHandler {
+	handler := &AdmissionHandler{
+		admissionController: admissionController,
+	}
+
+	// Register routes
+	router.GET("/ai/queue", handler.GetStats)
+
+	return handler
+}
+
+// GetStats handles reporting the number of running and queued model calls
+func (h *AdmissionHandler) GetStats(c *gin.Context) {
+	c.JSON(http.StatusOK, h.admissionController.Stats())
+}
+
+// Admission returns middleware that tags each request's context with its
+// client and priority for admission control. The client is the X-Client-ID
+// header, or the caller's IP address; the priority is the X-Priority header,
+// which defaults to interactive.
+func Admission() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		priority := domain.Priority(c.GetHeader(priorityHeader))
+		if err := priority.Validate(); err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		client := c.GetHeader(clientIDHeader)
+		if client == "" {
+			client = c.ClientIP()
+		}
+
+		ctx := domain.WithAdmission(c.Request.Context(), domain.Admission{Client: client, Priority: priority})
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
+
+// writeError sends an error response, telling the client when to retry a
+// request that was rejected because the queue is full, and which findings
+// made a guardrail block a request
+func writeError(c *gin.Context, status int, err error) {
+	var queueFull *domain.QueueFullError
+	if errors.As(err, &queueFull) {
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(queueFull.RetryAfter.Seconds()))))
+	}
+
+	var blocked *domain.GuardrailError
+	if errors.As(err, &blocked) {
+		c.JSON(status, gin.H{"error": err.Error(), "guardrails": domain.GuardrailReport{Findings: blocked.Findings}})
+		return
+	}
+
+	c.JSON(status, gin.H{"error": err.Error()})
+}
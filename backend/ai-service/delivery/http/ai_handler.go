@@ -1,7 +1,10 @@
 package http
 
 import (
+	"context"
+	"errors"
 	"net/http"
+	"strings"
 
 	"github.com/augment-local-manus-clone/backend/ai-service/domain"
 	"github.com/augment-local-manus-clone/backend/ai-service/usecase"
@@ -11,16 +14,25 @@ import (
 // AIHandler handles HTTP requests for AI processing
 type AIHandler struct {
 	processAIRequestUseCase *usecase.ProcessAIRequestUseCase
+	chatUseCase             *usecase.ChatUseCase
 }
 
 // NewAIHandler creates a new AIHandler
-func NewAIHandler(router *gin.Engine, processAIRequestUseCase *usecase.ProcessAIRequestUseCase) *AIHandler {
+func NewAIHandler(
+	router *gin.Engine,
+	processAIRequestUseCase *usecase.ProcessAIRequestUseCase,
+	chatUseCase *usecase.ChatUseCase,
+) *AIHandler {
 	handler := &AIHandler{
 		processAIRequestUseCase: processAIRequestUseCase,
+		chatUseCase:             chatUseCase,
 	}
 
 	// Register routes
 	router.POST("/ai/process", handler.ProcessAIRequest)
+	router.POST("/ai/stream", handler.StreamAIRequest)
+	router.POST("/ai/chat", handler.Chat)
+	router.GET("/ai/routes", handler.GetRoutes)
 
 	return handler
 }
@@ -32,12 +44,139 @@ func (h *AIHandler) ProcessAIRequest(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	request.NoCache = noCache(c)
 
-	response, err := h.processAIRequestUseCase.Execute(&request)
+	response, err := h.processAIRequestUseCase.Execute(c.Request.Context(), &request)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		writeError(c, errorStatus(err), err)
 		return
 	}
 
 	c.JSON(http.StatusOK, response)
 }
+
+// Chat handles processing a multi-message chat request
+func (h *AIHandler) Chat(c *gin.Context) {
+	var request domain.ChatRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	request.NoCache = noCache(c)
+
+	response, err := h.chatUseCase.Execute(c.Request.Context(), &request)
+	if err != nil {
+		writeError(c, errorStatus(err), err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// GetRoutes handles reporting the model routes and the health of their models
+func (h *AIHandler) GetRoutes(c *gin.Context) {
+	status := h.processAIRequestUseCase.RoutingStatus()
+	if status == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "model routing is not configured"})
+		return
+	}
+
+	c.JSON(http.StatusOK, status)
+}
+
+// StreamAIRequest handles processing an AI request, relaying the response as
+// server-sent events: "chunk" events carry text as it is generated, followed
+// by a final "done" event with the complete response and usage, or an "error" event
+func (h *AIHandler) StreamAIRequest(c *gin.Context) {
+	var request domain.AIRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	request.NoCache = noCache(c)
+
+	// The request context is cancelled when the client disconnects, which
+	// aborts the upstream generation
+	ctx := c.Request.Context()
+	stream := newEventStream(c)
+
+	response, err := h.processAIRequestUseCase.ExecuteStream(ctx, &request, func(chunk *domain.AIStreamChunk) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		stream.send("chunk", chunk)
+		return nil
+	})
+	if err != nil {
+		// Nothing has been streamed yet, so a regular error response can still be sent
+		if !stream.started {
+			writeError(c, errorStatus(err), err)
+			return
+		}
+		stream.send("error", gin.H{"error": err.Error()})
+		return
+	}
+
+	stream.send("done", response)
+}
+
+// noCache reports whether the client asked to bypass the response cache
+func noCache(c *gin.Context) bool {
+	for _, directive := range strings.Split(c.GetHeader("Cache-Control"), ",") {
+		if strings.EqualFold(strings.TrimSpace(directive), "no-cache") {
+			return true
+		}
+	}
+	return false
+}
+
+// errorStatus maps a use case error to an HTTP status code
+func errorStatus(err error) int {
+	switch {
+	// Checked first, as it wraps the invalid plan the model last produced
+	case errors.Is(err, domain.ErrPlanFailed):
+		return http.StatusBadGateway
+	case errors.Is(err, domain.ErrUnknownProvider), errors.Is(err, domain.ErrUnknownRoute),
+		errors.Is(err, domain.ErrTemplateNotFound), errors.Is(err, domain.ErrTemplateRender),
+		errors.Is(err, domain.ErrInvalidMemory), errors.Is(err, domain.ErrInvalidPlan),
+		errors.Is(err, domain.ErrInvalidBatch), errors.Is(err, domain.ErrImagesNotSupported):
+		return http.StatusBadRequest
+	case errors.Is(err, domain.ErrContextOverflow):
+		return http.StatusRequestEntityTooLarge
+	case errors.Is(err, domain.ErrGuardrailBlocked):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, domain.ErrNoModelAvailable):
+		return http.StatusServiceUnavailable
+	case errors.Is(err, domain.ErrQueueFull):
+		return http.StatusTooManyRequests
+	case errors.Is(err, context.DeadlineExceeded):
+		return http.StatusGatewayTimeout
+	}
+	return http.StatusInternalServerError
+}
+
+// eventStream writes server-sent events, deferring the SSE headers until the first event
+type eventStream struct {
+	c       *gin.Context
+	started bool
+}
+
+// newEventStream creates an eventStream for the given request
+func newEventStream(c *gin.Context) *eventStream {
+	return &eventStream{c: c}
+}
+
+// send writes a single event and flushes it to the client
+func (s *eventStream) send(event string, data interface{}) {
+	if !s.started {
+		s.c.Header("Content-Type", "text/event-stream")
+		s.c.Header("Cache-Control", "no-cache")
+		s.c.Header("Connection", "keep-alive")
+		s.c.Header("X-Accel-Buffering", "no")
+		s.c.Status(http.StatusOK)
+		s.started = true
+	}
+
+	s.c.SSEvent(event, data)
+	s.c.Writer.Flush()
+}
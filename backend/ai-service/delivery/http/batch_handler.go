@@ -0,0 +1,145 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/augment-local-manus-clone/backend/ai-service/domain"
+	"github.com/augment-local-manus-clone/backend/ai-service/usecase"
+	"github.com/gin-gonic/gin"
+)
+
+// BatchHandler handles HTTP requests for batch jobs
+type BatchHandler struct {
+	batchUseCase *usecase.BatchUseCase
+}
+
+// NewBatchHandler creates a new BatchHandler
+func NewBatchHandler(router *gin.Engine, batchUseCase *usecase.BatchUseCase) *BatchHandler {
+	handler := &BatchHandler{
+		batchUseCase: batchUseCase,
+	}
+
+	// Register routes
+	batch := router.Group("/ai/batch")
+	{
+		batch.POST("", handler.SubmitBatch)
+		batch.GET("", handler.ListBatches)
+		batch.GET("/:id", handler.GetBatch)
+		batch.GET("/:id/events", handler.StreamBatch)
+		batch.POST("/:id/cancel", handler.CancelBatch)
+	}
+
+	return handler
+}
+
+// SubmitBatch handles submitting a batch of requests; it replies as soon as
+// the job is stored, before its items run
+func (h *BatchHandler) SubmitBatch(c *gin.Context) {
+	var request domain.BatchRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	job, err := h.batchUseCase.Submit(c.Request.Context(), &request)
+	if err != nil {
+		c.JSON(batchErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("Location", "/ai/batch/"+job.ID)
+	c.JSON(http.StatusAccepted, job)
+}
+
+// ListBatches handles listing the batch jobs and the batch worker stats
+func (h *BatchHandler) ListBatches(c *gin.Context) {
+	list, err := h.batchUseCase.List()
+	if err != nil {
+		c.JSON(batchErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, list)
+}
+
+// GetBatch handles polling a batch job's progress and the outcome of its items
+func (h *BatchHandler) GetBatch(c *gin.Context) {
+	job, err := h.batchUseCase.Get(c.Param("id"))
+	if err != nil {
+		c.JSON(batchErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, job)
+}
+
+// StreamBatch handles following a batch job with server-sent events: an
+// "item" event for every item that has finished or finishes, then a "done"
+// event carrying the job without its items
+func (h *BatchHandler) StreamBatch(c *gin.Context) {
+	job, events, unsubscribe, err := h.batchUseCase.Subscribe(c.Param("id"))
+	if err != nil {
+		c.JSON(batchErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	defer unsubscribe()
+
+	stream := newEventStream(c)
+	for _, item := range job.Items {
+		if item.Status.Finished() {
+			stream.send("item", item)
+		}
+	}
+
+	if events != nil {
+	follow:
+		for {
+			select {
+			case item, ok := <-events:
+				if !ok {
+					break follow
+				}
+				stream.send("item", item)
+			case <-c.Request.Context().Done():
+				return
+			}
+		}
+
+		// The job has finished; read its final counts
+		if job, err = h.batchUseCase.Get(job.ID); err != nil {
+			stream.send("error", gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	job.Items = nil
+	stream.send("done", job)
+}
+
+// CancelBatch handles cancelling a batch job; items already finished keep their outcome
+func (h *BatchHandler) CancelBatch(c *gin.Context) {
+	if err := h.batchUseCase.Cancel(c.Param("id")); err != nil {
+		c.JSON(batchErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	job, err := h.batchUseCase.Get(c.Param("id"))
+	if err != nil {
+		c.JSON(batchErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, job)
+}
+
+// batchErrorStatus maps a batch error to an HTTP status code
+func batchErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, domain.ErrBatchNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, domain.ErrBatchFinished):
+		return http.StatusConflict
+	}
+	return errorStatus(err)
+}
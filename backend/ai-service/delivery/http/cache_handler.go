@@ -0,0 +1,41 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/augment-local-manus-clone/backend/ai-service/usecase"
+	"github.com/gin-gonic/gin"
+)
+
+// CacheHandler handles HTTP requests for the response cache
+type CacheHandler struct {
+	responseCache *usecase.ResponseCache
+}
+
+// NewCacheHandler creates a new CacheHandler
+func NewCacheHandler(router *gin.Engine, responseCache *usecase.ResponseCache) *CacheHandler {
+	handler := &CacheHandler{
+		responseCache: responseCache,
+	}
+
+	// Register routes
+	router.GET("/ai/cache", handler.GetStats)
+	router.DELETE("/ai/cache", handler.Clear)
+
+	return handler
+}
+
+// GetStats handles reporting the response cache's hit rate and size
+func (h *CacheHandler) GetStats(c *gin.Context) {
+	c.JSON(http.StatusOK, h.responseCache.Stats())
+}
+
+// Clear handles removing every cached response
+func (h *CacheHandler) Clear(c *gin.Context) {
+	if err := h.responseCache.Clear(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
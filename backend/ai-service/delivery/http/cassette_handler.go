@@ -0,0 +1,39 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/augment-local-manus-clone/backend/ai-service/usecase"
+	"github.com/gin-gonic/gin"
+)
+
+// CassetteHandler handles HTTP requests for recording and replaying LLM interactions
+type CassetteHandler struct {
+	recorder *usecase.CassetteRecorder
+	replayer *usecase.CassetteReplayer
+}
+
+// NewCassetteHandler creates a new CassetteHandler; whichever of recorder and
+// replayer is in use is reported, and the other is nil
+func NewCassetteHandler(router *gin.Engine, recorder *usecase.CassetteRecorder, replayer *usecase.CassetteReplayer) *CassetteHandler {
+	handler := &CassetteHandler{
+		recorder: recorder,
+		replayer: replayer,
+	}
+
+	// Register routes
+	router.GET("/ai/cassette", handler.GetStats)
+
+	return handler
+}
+
+// GetStats handles reporting how many interactions were recorded, or how the
+// cassette has been replayed, including the requests that matched nothing
+func (h *CassetteHandler) GetStats(c *gin.Context) {
+	if h.replayer != nil {
+		c.JSON(http.StatusOK, gin.H{"mode": "replay", "replay": h.replayer.Stats()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"mode": "record", "record": h.recorder.Stats()})
+}
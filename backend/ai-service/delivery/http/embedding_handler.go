@@ -0,0 +1,121 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/augment-local-manus-clone/backend/ai-service/domain"
+	"github.com/augment-local-manus-clone/backend/ai-service/usecase"
+	"github.com/gin-gonic/gin"
+)
+
+// EmbeddingHandler handles HTTP requests for embeddings and the vector index
+type EmbeddingHandler struct {
+	embedUseCase       *usecase.EmbedUseCase
+	vectorIndexUseCase *usecase.VectorIndexUseCase
+}
+
+// NewEmbeddingHandler creates a new EmbeddingHandler
+func NewEmbeddingHandler(
+	router *gin.Engine,
+	embedUseCase *usecase.EmbedUseCase,
+	vectorIndexUseCase *usecase.VectorIndexUseCase,
+) *EmbeddingHandler {
+	handler := &EmbeddingHandler{
+		embedUseCase:       embedUseCase,
+		vectorIndexUseCase: vectorIndexUseCase,
+	}
+
+	// Register routes
+	router.POST("/ai/embed", handler.Embed)
+
+	vectors := router.Group("/ai/vectors/:collection")
+	{
+		vectors.PUT("", handler.UpsertVectors)
+		vectors.DELETE("", handler.DeleteVectors)
+		vectors.POST("/query", handler.QueryVectors)
+	}
+
+	return handler
+}
+
+// Embed handles embedding one or more texts
+func (h *EmbeddingHandler) Embed(c *gin.Context) {
+	var request domain.EmbedRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	response, err := h.embedUseCase.Execute(c.Request.Context(), &request)
+	if err != nil {
+		writeError(c, errorStatus(err), err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// UpsertVectors handles indexing documents into a collection
+func (h *EmbeddingHandler) UpsertVectors(c *gin.Context) {
+	var input usecase.UpsertVectorsInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	input.Collection = c.Param("collection")
+
+	count, err := h.vectorIndexUseCase.Upsert(c.Request.Context(), input)
+	if err != nil {
+		writeError(c, vectorErrorStatus(err), err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"upserted": count})
+}
+
+// DeleteVectors handles removing documents from a collection
+func (h *EmbeddingHandler) DeleteVectors(c *gin.Context) {
+	var input usecase.DeleteVectorsInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	input.Collection = c.Param("collection")
+
+	if err := h.vectorIndexUseCase.Delete(input); err != nil {
+		writeError(c, vectorErrorStatus(err), err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// QueryVectors handles finding the documents most similar to a text or vector
+func (h *EmbeddingHandler) QueryVectors(c *gin.Context) {
+	var input usecase.QueryVectorsInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	input.Collection = c.Param("collection")
+
+	matches, err := h.vectorIndexUseCase.Query(c.Request.Context(), input)
+	if err != nil {
+		writeError(c, vectorErrorStatus(err), err)
+		return
+	}
+
+	if matches == nil {
+		matches = []domain.VectorMatch{}
+	}
+	c.JSON(http.StatusOK, gin.H{"matches": matches})
+}
+
+// vectorErrorStatus maps a vector index error to an HTTP status code
+func vectorErrorStatus(err error) int {
+	if errors.Is(err, domain.ErrDimensionMismatch) {
+		return http.StatusBadRequest
+	}
+	return errorStatus(err)
+}
@@ -0,0 +1,132 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/augment-local-manus-clone/backend/ai-service/domain"
+	"github.com/augment-local-manus-clone/backend/ai-service/usecase"
+	"github.com/gin-gonic/gin"
+)
+
+// MemoryHandler handles HTTP requests for conversation memory
+type MemoryHandler struct {
+	memoryUseCase *usecase.MemoryUseCase
+}
+
+// addFactInput is the body of a request adding a long-term fact
+type addFactInput struct {
+	Content string `json:"content"`
+}
+
+// NewMemoryHandler creates a new MemoryHandler
+func NewMemoryHandler(router *gin.Engine, memoryUseCase *usecase.MemoryUseCase) *MemoryHandler {
+	handler := &MemoryHandler{
+		memoryUseCase: memoryUseCase,
+	}
+
+	// Register routes
+	memory := router.Group("/ai/memory")
+	{
+		memory.GET("", handler.ListMemories)
+		memory.GET("/:id", handler.GetMemory)
+		memory.DELETE("/:id", handler.DeleteMemory)
+		memory.POST("/:id/turns", handler.AppendTurns)
+		memory.POST("/:id/facts", handler.AddFact)
+		memory.DELETE("/:id/facts/:fact", handler.DeleteFact)
+	}
+
+	return handler
+}
+
+// ListMemories handles listing the remembered conversations and the memory stats
+func (h *MemoryHandler) ListMemories(c *gin.Context) {
+	list, err := h.memoryUseCase.List()
+	if err != nil {
+		c.JSON(memoryErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, list)
+}
+
+// GetMemory handles getting the turns, summary and facts of a conversation
+func (h *MemoryHandler) GetMemory(c *gin.Context) {
+	memory, err := h.memoryUseCase.Get(c.Param("id"))
+	if err != nil {
+		c.JSON(memoryErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, memory)
+}
+
+// DeleteMemory handles forgetting a conversation
+func (h *MemoryHandler) DeleteMemory(c *gin.Context) {
+	if err := h.memoryUseCase.Delete(c.Param("id")); err != nil {
+		c.JSON(memoryErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// AppendTurns handles adding turns to a conversation's memory
+func (h *MemoryHandler) AppendTurns(c *gin.Context) {
+	var input usecase.AppendTurnsInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	input.ConversationID = c.Param("id")
+
+	memory, err := h.memoryUseCase.AppendTurns(c.Request.Context(), input)
+	if err != nil {
+		writeError(c, memoryErrorStatus(err), err)
+		return
+	}
+
+	c.JSON(http.StatusOK, memory)
+}
+
+// AddFact handles adding a long-term fact to a conversation's memory
+func (h *MemoryHandler) AddFact(c *gin.Context) {
+	var input addFactInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	fact, err := h.memoryUseCase.AddFact(c.Param("id"), input.Content)
+	if err != nil {
+		c.JSON(memoryErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, fact)
+}
+
+// DeleteFact handles removing a long-term fact from a conversation's memory
+func (h *MemoryHandler) DeleteFact(c *gin.Context) {
+	factID, err := strconv.ParseInt(c.Param("fact"), 10, 64)
+	if err != nil || factID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "fact must be a positive integer"})
+		return
+	}
+
+	if err := h.memoryUseCase.DeleteFact(c.Param("id"), factID); err != nil {
+		c.JSON(memoryErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// memoryErrorStatus maps a memory error to an HTTP status code
+func memoryErrorStatus(err error) int {
+	if errors.Is(err, domain.ErrMemoryNotFound) {
+		return http.StatusNotFound
+	}
+	return errorStatus(err)
+}
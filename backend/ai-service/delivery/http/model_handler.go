@@ -0,0 +1,119 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/augment-local-manus-clone/backend/ai-service/domain"
+	"github.com/augment-local-manus-clone/backend/ai-service/usecase"
+	"github.com/gin-gonic/gin"
+)
+
+// ModelHandler handles HTTP requests for managing local models
+type ModelHandler struct {
+	modelManagementUseCase *usecase.ModelManagementUseCase
+}
+
+// pullModelRequest is the body of a model pull request
+type pullModelRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+// NewModelHandler creates a new ModelHandler
+func NewModelHandler(router *gin.Engine, modelManagementUseCase *usecase.ModelManagementUseCase) *ModelHandler {
+	handler := &ModelHandler{
+		modelManagementUseCase: modelManagementUseCase,
+	}
+
+	// Register routes; model names such as library/llama3.2:3b contain slashes
+	router.GET("/ai/models", handler.ListModels)
+	router.POST("/ai/models/pull", handler.PullModel)
+	router.GET("/ai/models/*name", handler.ShowModel)
+	router.DELETE("/ai/models/*name", handler.DeleteModel)
+
+	return handler
+}
+
+// ListModels handles listing the installed models with their context length and capabilities
+func (h *ModelHandler) ListModels(c *gin.Context) {
+	models, err := h.modelManagementUseCase.ListModels(c.Request.Context())
+	if err != nil {
+		c.JSON(modelErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"models": models})
+}
+
+// ShowModel handles describing an installed model
+func (h *ModelHandler) ShowModel(c *gin.Context) {
+	details, err := h.modelManagementUseCase.ShowModel(c.Request.Context(), modelParam(c))
+	if err != nil {
+		c.JSON(modelErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, details)
+}
+
+// PullModel handles downloading a model, relaying its progress as
+// server-sent events: "progress" events carry the status and byte counts,
+// followed by a final "done" event, or an "error" event
+func (h *ModelHandler) PullModel(c *gin.Context) {
+	var request pullModelRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// The request context is cancelled when the client disconnects, which
+	// aborts the download
+	ctx := c.Request.Context()
+	stream := newEventStream(c)
+
+	err := h.modelManagementUseCase.PullModel(ctx, request.Name, func(progress *domain.PullProgress) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		stream.send("progress", progress)
+		return nil
+	})
+	if err != nil {
+		// Nothing has been streamed yet, so a regular error response can still be sent
+		if !stream.started {
+			c.JSON(modelErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+		stream.send("error", gin.H{"error": err.Error()})
+		return
+	}
+
+	stream.send("done", gin.H{"name": request.Name})
+}
+
+// DeleteModel handles removing an installed model
+func (h *ModelHandler) DeleteModel(c *gin.Context) {
+	if err := h.modelManagementUseCase.DeleteModel(c.Request.Context(), modelParam(c)); err != nil {
+		c.JSON(modelErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// modelParam returns the model name from the catch-all path parameter
+func modelParam(c *gin.Context) string {
+	return strings.TrimPrefix(c.Param("name"), "/")
+}
+
+// modelErrorStatus maps model management errors to HTTP status codes
+func modelErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, domain.ErrModelNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, domain.ErrInvalidModelName):
+		return http.StatusBadRequest
+	}
+	return errorStatus(err)
+}
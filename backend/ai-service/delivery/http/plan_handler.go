@@ -0,0 +1,44 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/augment-local-manus-clone/backend/ai-service/domain"
+	"github.com/augment-local-manus-clone/backend/ai-service/usecase"
+	"github.com/gin-gonic/gin"
+)
+
+// PlanHandler handles HTTP requests for task planning
+type PlanHandler struct {
+	plannerUseCase *usecase.PlannerUseCase
+}
+
+// NewPlanHandler creates a new PlanHandler
+func NewPlanHandler(router *gin.Engine, plannerUseCase *usecase.PlannerUseCase) *PlanHandler {
+	handler := &PlanHandler{
+		plannerUseCase: plannerUseCase,
+	}
+
+	// Register routes
+	router.POST("/ai/plan", handler.Plan)
+
+	return handler
+}
+
+// Plan handles planning a task, or replanning it from observations of its steps
+func (h *PlanHandler) Plan(c *gin.Context) {
+	var request domain.PlanRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	request.NoCache = noCache(c)
+
+	response, err := h.plannerUseCase.Execute(c.Request.Context(), &request)
+	if err != nil {
+		writeError(c, errorStatus(err), err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,162 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/augment-local-manus-clone/backend/ai-service/domain"
+	"github.com/augment-local-manus-clone/backend/ai-service/usecase"
+	"github.com/gin-gonic/gin"
+)
+
+// PromptTemplateHandler handles HTTP requests for prompt templates
+type PromptTemplateHandler struct {
+	promptTemplateUseCase *usecase.PromptTemplateUseCase
+}
+
+// NewPromptTemplateHandler creates a new PromptTemplateHandler
+func NewPromptTemplateHandler(router *gin.Engine, promptTemplateUseCase *usecase.PromptTemplateUseCase) *PromptTemplateHandler {
+	handler := &PromptTemplateHandler{
+		promptTemplateUseCase: promptTemplateUseCase,
+	}
+
+	// Register routes
+	templates := router.Group("/ai/templates")
+	{
+		templates.GET("", handler.ListTemplates)
+		templates.POST("", handler.CreateTemplate)
+		templates.GET("/:name", handler.ListVersions)
+		templates.DELETE("/:name", handler.DeleteTemplate)
+		templates.GET("/:name/versions/:version", handler.GetVersion)
+		templates.PATCH("/:name/versions/:version", handler.UpdateVersion)
+		templates.DELETE("/:name/versions/:version", handler.DeleteVersion)
+	}
+
+	return handler
+}
+
+// ListTemplates handles listing the latest version of every template
+func (h *PromptTemplateHandler) ListTemplates(c *gin.Context) {
+	templates, err := h.promptTemplateUseCase.List()
+	if err != nil {
+		c.JSON(templateErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, templates)
+}
+
+// CreateTemplate handles creating a template or a new version of it
+func (h *PromptTemplateHandler) CreateTemplate(c *gin.Context) {
+	var input usecase.CreatePromptTemplateInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	template, err := h.promptTemplateUseCase.Create(input)
+	if err != nil {
+		c.JSON(templateErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, template)
+}
+
+// ListVersions handles listing every version of a template
+func (h *PromptTemplateHandler) ListVersions(c *gin.Context) {
+	templates, err := h.promptTemplateUseCase.ListVersions(c.Param("name"))
+	if err != nil {
+		c.JSON(templateErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, templates)
+}
+
+// DeleteTemplate handles deleting every version of a template
+func (h *PromptTemplateHandler) DeleteTemplate(c *gin.Context) {
+	if err := h.promptTemplateUseCase.Delete(c.Param("name"), 0); err != nil {
+		c.JSON(templateErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// GetVersion handles getting a single template version
+func (h *PromptTemplateHandler) GetVersion(c *gin.Context) {
+	version, ok := versionParam(c)
+	if !ok {
+		return
+	}
+
+	template, err := h.promptTemplateUseCase.Get(c.Param("name"), version)
+	if err != nil {
+		c.JSON(templateErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, template)
+}
+
+// UpdateVersion handles changing the description or weight of a template version
+func (h *PromptTemplateHandler) UpdateVersion(c *gin.Context) {
+	version, ok := versionParam(c)
+	if !ok {
+		return
+	}
+
+	var input usecase.UpdatePromptTemplateInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	input.Name = c.Param("name")
+	input.Version = version
+
+	template, err := h.promptTemplateUseCase.Update(input)
+	if err != nil {
+		c.JSON(templateErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, template)
+}
+
+// DeleteVersion handles deleting a single template version
+func (h *PromptTemplateHandler) DeleteVersion(c *gin.Context) {
+	version, ok := versionParam(c)
+	if !ok {
+		return
+	}
+
+	if err := h.promptTemplateUseCase.Delete(c.Param("name"), version); err != nil {
+		c.JSON(templateErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// versionParam parses the version path parameter, responding with 400 if it is invalid
+func versionParam(c *gin.Context) (int, bool) {
+	version, err := strconv.Atoi(c.Param("version"))
+	if err != nil || version <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "version must be a positive integer"})
+		return 0, false
+	}
+	return version, true
+}
+
+// templateErrorStatus maps a prompt template error to an HTTP status code
+func templateErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, domain.ErrTemplateNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, domain.ErrInvalidTemplate):
+		return http.StatusBadRequest
+	}
+	return errorStatus(err)
+}
@@ -0,0 +1,56 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/augment-local-manus-clone/backend/ai-service/domain"
+	"github.com/augment-local-manus-clone/backend/ai-service/usecase"
+	"github.com/gin-gonic/gin"
+)
+
+// RAGHandler handles HTTP requests for retrieval-augmented generation over the workspace
+type RAGHandler struct {
+	ragUseCase *usecase.RAGUseCase
+}
+
+// NewRAGHandler creates a new RAGHandler
+func NewRAGHandler(router *gin.Engine, ragUseCase *usecase.RAGUseCase) *RAGHandler {
+	handler := &RAGHandler{
+		ragUseCase: ragUseCase,
+	}
+
+	// Register routes
+	router.POST("/ai/rag", handler.Query)
+	router.POST("/ai/rag/index", handler.Reindex)
+
+	return handler
+}
+
+// Query handles answering a question from workspace files
+func (h *RAGHandler) Query(c *gin.Context) {
+	var request domain.RAGRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	request.NoCache = noCache(c)
+
+	response, err := h.ragUseCase.Execute(c.Request.Context(), &request)
+	if err != nil {
+		writeError(c, errorStatus(err), err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// Reindex handles bringing the workspace index up to date
+func (h *RAGHandler) Reindex(c *gin.Context) {
+	report, err := h.ragUseCase.Reindex(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
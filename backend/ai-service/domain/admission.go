@@ -0,0 +1,96 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrQueueFull is returned when a request cannot be admitted because the wait queue is full
+var ErrQueueFull = errors.New("request queue is full")
+
+// Priority selects the lane a request waits in; higher lanes are served first
+type Priority string
+
+const (
+	// PriorityInteractive is for requests a user is waiting on, such as chat
+	PriorityInteractive Priority = "interactive"
+
+	// PriorityBatch is for background work, such as bulk summarization, which
+	// waits while interactive requests are queued and may be pre-empted by them
+	PriorityBatch Priority = "batch"
+)
+
+// Priorities lists the priority lanes from highest to lowest
+var Priorities = []Priority{PriorityInteractive, PriorityBatch}
+
+// Validate validates the priority; empty selects PriorityInteractive
+func (p Priority) Validate() error {
+	switch p {
+	case "", PriorityInteractive, PriorityBatch:
+		return nil
+	}
+	return fmt.Errorf("invalid priority %q", p)
+}
+
+// Admission identifies who a request is made for and how urgent it is, for
+// admission control and fair queuing
+type Admission struct {
+	Client   string
+	Priority Priority
+}
+
+// admissionKey is the context key under which the admission is stored
+type admissionKey struct{}
+
+// WithAdmission returns a context carrying the admission, which applies to
+// every model call made with the context
+func WithAdmission(ctx context.Context, admission Admission) context.Context {
+	return context.WithValue(ctx, admissionKey{}, admission)
+}
+
+// AdmissionFrom returns the admission carried by the context. Without one,
+// requests are interactive and share an anonymous client.
+func AdmissionFrom(ctx context.Context) Admission {
+	admission, _ := ctx.Value(admissionKey{}).(Admission)
+	if admission.Priority == "" {
+		admission.Priority = PriorityInteractive
+	}
+	return admission
+}
+
+// QueueFullError reports a rejected request and when the client should retry
+type QueueFullError struct {
+	RetryAfter time.Duration
+}
+
+// Error implements the error interface
+func (e *QueueFullError) Error() string {
+	return fmt.Sprintf("%v, retry after %s", ErrQueueFull, e.RetryAfter)
+}
+
+// Unwrap allows errors.Is(err, ErrQueueFull)
+func (e *QueueFullError) Unwrap() error {
+	return ErrQueueFull
+}
+
+// AdmissionStats reports the admission controller's load
+type AdmissionStats struct {
+	MaxConcurrent int `json:"max_concurrent"`
+	MaxQueue      int `json:"max_queue"`
+
+	// Running and Queued count requests by priority
+	Running map[Priority]int `json:"running"`
+	Queued  map[Priority]int `json:"queued"`
+
+	// QueueDepth is the total number of waiting requests
+	QueueDepth int `json:"queue_depth"`
+
+	// QueuedByClient counts waiting requests per client
+	QueuedByClient map[string]int `json:"queued_by_client,omitempty"`
+
+	Admitted  int64 `json:"admitted"`
+	Rejected  int64 `json:"rejected"`
+	Preempted int64 `json:"preempted"`
+}
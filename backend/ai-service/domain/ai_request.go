@@ -1,44 +1,274 @@
 package domain
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"time"
 )
 
 // AIRequest represents a request to the AI model
 type AIRequest struct {
-	Prompt     string                 `json:"prompt"`
-	MaxTokens  int                    `json:"max_tokens,omitempty"`
-	Temperature float64               `json:"temperature,omitempty"`
+	// Provider and Model select the backend and model; empty values use the configured defaults
+	Provider string `json:"provider,omitempty"`
+	Model    string `json:"model,omitempty"`
+
+	// Route names the routing policy, such as "fast", "smart" or a task type,
+	// used to pick a model when Model is empty; MinContextLength excludes
+	// models with a smaller context window
+	Route            string `json:"route,omitempty"`
+	MinContextLength int    `json:"min_context_length,omitempty"`
+
+	Prompt      string                 `json:"prompt"`
+	MaxTokens   int                    `json:"max_tokens,omitempty"`
+	Temperature *float64               `json:"temperature,omitempty"`
 	Context     map[string]interface{} `json:"context,omitempty"`
+
+	// Untrusted holds content from external sources, such as browsed pages,
+	// which is checked by the guardrails and appended to the prompt as tagged data
+	Untrusted []UntrustedContent `json:"untrusted,omitempty"`
+
+	// Images holds base64-encoded images, such as page screenshots, for
+	// vision models; they are sent with the prompt
+	Images []string `json:"images,omitempty"`
+
+	// Seed fixes the sampling seed so that the same request produces the same output
+	Seed *int `json:"seed,omitempty"`
+
+	// Options sets the model's generation parameters; its num_predict,
+	// temperature and seed take precedence over the top-level fields
+	Options *GenerationOptions `json:"options,omitempty"`
+
+	// NoCache skips the response cache lookup, as requested by a
+	// "Cache-Control: no-cache" header; the fresh response is still cached
+	NoCache bool `json:"-"`
+
+	// TimeoutSeconds bounds how long the request may take, including retries
+	// and fallbacks; zero leaves it bounded only by the caller
+	TimeoutSeconds int `json:"timeout_seconds,omitempty"`
+
+	// Template renders the prompt from a stored prompt template instead of
+	// Prompt. TemplateVersion pins a version; otherwise one is chosen by the
+	// versions' weights. The template is executed against Context merged with
+	// Variables, with Variables taking precedence.
+	Template        string                 `json:"template,omitempty"`
+	TemplateVersion int                    `json:"template_version,omitempty"`
+	Variables       map[string]interface{} `json:"variables,omitempty"`
+
+	// ResponseSchema requests JSON output matching the schema; the parsed
+	// value is returned in AIResponse.JSON
+	ResponseSchema JSONSchema `json:"response_schema,omitempty"`
+
+	// SchemaRetries bounds how many times the model is re-prompted when its
+	// reply does not match ResponseSchema; defaults to 2
+	SchemaRetries *int `json:"schema_retries,omitempty"`
+
+	// ContextStrategy selects how a prompt too large for the model is fitted; defaults to truncate
+	ContextStrategy ContextStrategy `json:"context_strategy,omitempty"`
+
+	// Reasoning selects whether a reasoning model's thinking is returned
+	// separately or dropped; defaults to separate
+	Reasoning ReasoningMode `json:"reasoning,omitempty"`
+
+	// ConversationID names the conversation or task whose memory is injected
+	// into the prompt; the prompt and the reply are then remembered as turns
+	ConversationID string `json:"conversation_id,omitempty"`
 }
 
 // AIResponse represents a response from the AI model
 type AIResponse struct {
-	Text       string  `json:"text"`
+	Text string `json:"text"`
+
+	// Reasoning holds the thinking a reasoning model produced before its answer
+	Reasoning string `json:"reasoning,omitempty"`
+
 	TokensUsed int     `json:"tokens_used,omitempty"`
 	Model      string  `json:"model,omitempty"`
 	Elapsed    float64 `json:"elapsed,omitempty"`
+
+	// Usage splits TokensUsed into prompt and completion tokens, as reported by the provider
+	Usage *TokenUsage `json:"usage,omitempty"`
+
+	// JSON holds the parsed output when the request set a response schema
+	JSON interface{} `json:"json,omitempty"`
+
+	// ContextWindow reports how the prompt was fitted into the model's context
+	ContextWindow *ContextReport `json:"context_window,omitempty"`
+
+	// Template identifies the template version the prompt was rendered from
+	Template *TemplateRef `json:"template,omitempty"`
+
+	// Cached is true when the response was served from the response cache
+	Cached bool `json:"cached,omitempty"`
+
+	// Guardrails reports suspicious content found in the request's untrusted
+	// content and in the response
+	Guardrails *GuardrailReport `json:"guardrails,omitempty"`
+
+	// Memory reports what was injected from the conversation's memory
+	Memory *MemoryReport `json:"memory,omitempty"`
+}
+
+// ErrUnknownProvider is returned when a request names a provider that is not configured
+var ErrUnknownProvider = errors.New("unknown LLM provider")
+
+// AIStreamChunk represents an incremental piece of a streamed AI response
+type AIStreamChunk struct {
+	Text string `json:"text"`
+
+	// Reasoning carries thinking, which is streamed apart from the answer text
+	Reasoning string `json:"reasoning,omitempty"`
 }
 
+// StreamHandler receives chunks of a streamed response as they arrive;
+// returning an error aborts the stream
+type StreamHandler func(chunk *AIStreamChunk) error
+
 // Validate validates the AI request
 func (r *AIRequest) Validate() error {
-	if r.Prompt == "" {
+	if r.Template == "" && r.Prompt == "" {
 		return errors.New("prompt cannot be empty")
 	}
-	
+
+	if r.Template != "" && r.Prompt != "" {
+		return errors.New("prompt and template cannot both be set")
+	}
+
+	if r.Template == "" && (r.TemplateVersion != 0 || len(r.Variables) > 0) {
+		return errors.New("template_version and variables require a template")
+	}
+
+	if r.TemplateVersion < 0 {
+		return errors.New("template_version cannot be negative")
+	}
+
+	for i, untrusted := range r.Untrusted {
+		if untrusted.Content == "" {
+			return fmt.Errorf("untrusted %d: content cannot be empty", i)
+		}
+	}
+
+	if err := ValidateImages(r.Images); err != nil {
+		return err
+	}
+
 	if r.MaxTokens < 0 {
 		return errors.New("max_tokens cannot be negative")
 	}
-	
-	if r.Temperature < 0 || r.Temperature > 2 {
+
+	if r.Temperature != nil && (*r.Temperature < 0 || *r.Temperature > 2) {
 		return errors.New("temperature must be between 0 and 2")
 	}
-	
+
+	if r.MinContextLength < 0 {
+		return errors.New("min_context_length cannot be negative")
+	}
+
+	if r.TimeoutSeconds < 0 {
+		return errors.New("timeout_seconds cannot be negative")
+	}
+
+	if r.ConversationID != "" {
+		if err := ValidateConversationID(r.ConversationID); err != nil {
+			return err
+		}
+	}
+
+	if err := r.ContextStrategy.Validate(); err != nil {
+		return err
+	}
+
+	if err := r.Options.Validate(); err != nil {
+		return err
+	}
+
+	if err := r.Reasoning.Validate(); err != nil {
+		return err
+	}
+
+	if err := ValidateSchemaRetries(r.SchemaRetries); err != nil {
+		return err
+	}
+
 	return nil
 }
 
+// GenerationOptions returns the request's options with the top-level
+// max_tokens, temperature and seed filled in where the options leave them unset
+func (r *AIRequest) GenerationOptions() GenerationOptions {
+	return resolveOptions(r.Options, r.MaxTokens, r.Temperature, r.Seed)
+}
+
+// Deterministic reports whether the request should always produce the same
+// output: sampling is greedy (temperature 0) or uses a fixed seed
+func (r *AIRequest) Deterministic() bool {
+	return r.GenerationOptions().deterministic()
+}
+
+// Timeout returns how long the request may take, or zero for no limit
+func (r *AIRequest) Timeout() time.Duration {
+	return time.Duration(r.TimeoutSeconds) * time.Second
+}
+
+// Clone returns a copy of the request that shares none of its slices, maps
+// or options, so that processing the copy leaves the request as it was.
+// Values nested in Context, Variables and ResponseSchema are still shared.
+func (r *AIRequest) Clone() AIRequest {
+	clone := *r
+	clone.Untrusted = append([]UntrustedContent(nil), r.Untrusted...)
+	clone.Images = append([]string(nil), r.Images...)
+	clone.Context = cloneMap(r.Context)
+	clone.Variables = cloneMap(r.Variables)
+	clone.ResponseSchema = cloneMap(r.ResponseSchema)
+
+	if r.Temperature != nil {
+		temperature := *r.Temperature
+		clone.Temperature = &temperature
+	}
+	if r.Seed != nil {
+		seed := *r.Seed
+		clone.Seed = &seed
+	}
+	if r.SchemaRetries != nil {
+		retries := *r.SchemaRetries
+		clone.SchemaRetries = &retries
+	}
+	if r.Options != nil {
+		options := *r.Options
+		options.Stop = append([]string(nil), r.Options.Stop...)
+		clone.Options = &options
+	}
+
+	return clone
+}
+
+// cloneMap returns a copy of a map's top level, or nil for a nil map
+func cloneMap(m map[string]interface{}) map[string]interface{} {
+	if m == nil {
+		return nil
+	}
+
+	clone := make(map[string]interface{}, len(m))
+	for key, value := range m {
+		clone[key] = value
+	}
+	return clone
+}
+
 // LLMClient defines the interface for interacting with the LLM
 type LLMClient interface {
-	// Process sends a request to the LLM and returns a response
-	Process(request *AIRequest) (*AIResponse, error)
+	// Process sends a request to the LLM and returns a response. Cancelling
+	// the context aborts the upstream request.
+	Process(ctx context.Context, request *AIRequest) (*AIResponse, error)
+
+	// Chat sends a multi-message conversation to the LLM and returns its reply
+	Chat(ctx context.Context, request *ChatRequest) (*ChatResponse, error)
+
+	// ProcessStream sends a request to the LLM, passes response chunks to the handler
+	// as they are generated and returns the complete response once the stream ends.
+	// Cancelling the context aborts the upstream request.
+	ProcessStream(ctx context.Context, request *AIRequest, handler StreamHandler) (*AIResponse, error)
+
+	// Embed returns an embedding vector for each input text
+	Embed(ctx context.Context, request *EmbedRequest) (*EmbedResponse, error)
 }
@@ -1,12 +1,17 @@
 package domain_test
 
 import (
+	"reflect"
 	"testing"
 
 	"github.com/augment-local-manus-clone/backend/ai-service/domain"
 )
 
 func TestAIRequestValidate(t *testing.T) {
+	warm := 0.7
+	low := -0.1
+	high := 2.1
+
 	tests := []struct {
 		name    string
 		request domain.AIRequest
@@ -17,7 +22,7 @@ func TestAIRequestValidate(t *testing.T) {
 			request: domain.AIRequest{
 				Prompt:      "Hello, world!",
 				MaxTokens:   100,
-				Temperature: 0.7,
+				Temperature: &warm,
 			},
 			wantErr: false,
 		},
@@ -26,7 +31,7 @@ func TestAIRequestValidate(t *testing.T) {
 			request: domain.AIRequest{
 				Prompt:      "",
 				MaxTokens:   100,
-				Temperature: 0.7,
+				Temperature: &warm,
 			},
 			wantErr: true,
 		},
@@ -35,7 +40,7 @@ func TestAIRequestValidate(t *testing.T) {
 			request: domain.AIRequest{
 				Prompt:      "Hello, world!",
 				MaxTokens:   -1,
-				Temperature: 0.7,
+				Temperature: &warm,
 			},
 			wantErr: true,
 		},
@@ -44,7 +49,7 @@ func TestAIRequestValidate(t *testing.T) {
 			request: domain.AIRequest{
 				Prompt:      "Hello, world!",
 				MaxTokens:   100,
-				Temperature: -0.1,
+				Temperature: &low,
 			},
 			wantErr: true,
 		},
@@ -53,7 +58,31 @@ func TestAIRequestValidate(t *testing.T) {
 			request: domain.AIRequest{
 				Prompt:      "Hello, world!",
 				MaxTokens:   100,
-				Temperature: 2.1,
+				Temperature: &high,
+			},
+			wantErr: true,
+		},
+		{
+			name: "Template instead of prompt",
+			request: domain.AIRequest{
+				Template:  "plan",
+				Variables: map[string]interface{}{"goal": "ship it"},
+			},
+			wantErr: false,
+		},
+		{
+			name: "Prompt and template",
+			request: domain.AIRequest{
+				Prompt:   "Hello, world!",
+				Template: "plan",
+			},
+			wantErr: true,
+		},
+		{
+			name: "Variables without template",
+			request: domain.AIRequest{
+				Prompt:    "Hello, world!",
+				Variables: map[string]interface{}{"goal": "ship it"},
 			},
 			wantErr: true,
 		},
@@ -76,3 +105,51 @@ func TestAIRequestValidate(t *testing.T) {
 		})
 	}
 }
+
+func TestAIRequestClone(t *testing.T) {
+	seed := 7
+	warm := 0.7
+	numCtx := 4096
+	request := domain.AIRequest{
+		Prompt:         "Summarize the page",
+		Temperature:    &warm,
+		Seed:           &seed,
+		Context:        map[string]interface{}{"lang": "en"},
+		Variables:      map[string]interface{}{"goal": "ship it"},
+		Untrusted:      []domain.UntrustedContent{{Source: "https://example.com", Content: "Welcome"}},
+		Images:         []string{"aGVsbG8="},
+		Options:        &domain.GenerationOptions{Stop: []string{"\n\n"}, NumCtx: &numCtx},
+		ResponseSchema: domain.JSONSchema{"type": "object"},
+	}
+	original := domain.AIRequest{
+		Prompt:         request.Prompt,
+		Temperature:    &warm,
+		Seed:           &seed,
+		Context:        map[string]interface{}{"lang": "en"},
+		Variables:      map[string]interface{}{"goal": "ship it"},
+		Untrusted:      []domain.UntrustedContent{{Source: "https://example.com", Content: "Welcome"}},
+		Images:         []string{"aGVsbG8="},
+		Options:        &domain.GenerationOptions{Stop: []string{"\n\n"}, NumCtx: &numCtx},
+		ResponseSchema: domain.JSONSchema{"type": "object"},
+	}
+
+	// Changing every part of the clone leaves the request as it was
+	clone := request.Clone()
+	clone.Prompt = "Ignore that"
+	*clone.Temperature = 0
+	*clone.Seed = 1
+	clone.Context["lang"] = "fr"
+	clone.Variables["goal"] = "wait"
+	clone.Untrusted[0].Content = "<untrusted>Welcome</untrusted>"
+	clone.Images[0] = "d29ybGQ="
+	clone.Options.Stop[0] = "END"
+	clone.Options.NumCtx = nil
+	clone.ResponseSchema["type"] = "array"
+
+	if !reflect.DeepEqual(request, original) {
+		t.Errorf("request = %+v, want %+v", request, original)
+	}
+	if warm != 0.7 || seed != 7 {
+		t.Errorf("temperature = %v, seed = %v, want them unchanged", warm, seed)
+	}
+}
@@ -0,0 +1,136 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// MaxBatchItems is the largest number of requests a batch may hold
+const MaxBatchItems = 1000
+
+// ErrBatchNotFound is returned when a batch job does not exist
+var ErrBatchNotFound = errors.New("batch job not found")
+
+// ErrInvalidBatch is returned when a batch request is rejected
+var ErrInvalidBatch = errors.New("invalid batch")
+
+// ErrBatchFinished is returned when a batch job that has already finished is cancelled
+var ErrBatchFinished = errors.New("batch job already finished")
+
+// BatchStatus is the state of a batch job
+type BatchStatus string
+
+const (
+	BatchQueued    BatchStatus = "queued"
+	BatchRunning   BatchStatus = "running"
+	BatchCompleted BatchStatus = "completed"
+	BatchCancelled BatchStatus = "cancelled"
+)
+
+// Finished reports whether a job in this state will not run any more items
+func (s BatchStatus) Finished() bool {
+	return s == BatchCompleted || s == BatchCancelled
+}
+
+// BatchItemStatus is the state of one request of a batch job
+type BatchItemStatus string
+
+const (
+	BatchItemPending   BatchItemStatus = "pending"
+	BatchItemRunning   BatchItemStatus = "running"
+	BatchItemSucceeded BatchItemStatus = "succeeded"
+	BatchItemFailed    BatchItemStatus = "failed"
+	BatchItemCancelled BatchItemStatus = "cancelled"
+)
+
+// Finished reports whether an item in this state has its outcome
+func (s BatchItemStatus) Finished() bool {
+	return s == BatchItemSucceeded || s == BatchItemFailed || s == BatchItemCancelled
+}
+
+// BatchRequest submits many AI requests to run in the background
+type BatchRequest struct {
+	Requests []AIRequest `json:"requests"`
+
+	// Concurrency bounds how many of the batch's requests run at once; it
+	// cannot exceed the service's batch workers, which is the default
+	Concurrency int `json:"concurrency,omitempty"`
+}
+
+// BatchJob is a batch of AI requests and its progress
+type BatchJob struct {
+	ID     string      `json:"id"`
+	Status BatchStatus `json:"status"`
+
+	// Client is who submitted the batch, for admission control
+	Client      string `json:"client,omitempty"`
+	Concurrency int    `json:"concurrency"`
+
+	Total     int `json:"total"`
+	Succeeded int `json:"succeeded"`
+	Failed    int `json:"failed"`
+	Cancelled int `json:"cancelled,omitempty"`
+
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+
+	// Items is left out when jobs are listed
+	Items []BatchItem `json:"items,omitempty"`
+}
+
+// BatchItem is one request of a batch job and its outcome
+type BatchItem struct {
+	Index  int             `json:"index"`
+	Status BatchItemStatus `json:"status"`
+
+	Request  AIRequest   `json:"request"`
+	Response *AIResponse `json:"response,omitempty"`
+	Error    string      `json:"error,omitempty"`
+
+	StartedAt  *time.Time `json:"started_at,omitempty"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+}
+
+// BatchRepository defines the interface for persisting batch jobs
+type BatchRepository interface {
+	// Create stores a new job and its items
+	Create(job *BatchJob) error
+
+	// Get returns a job with its items
+	Get(id string) (*BatchJob, error)
+
+	// List returns every job without its items, newest first
+	List() ([]*BatchJob, error)
+
+	// UpdateJob stores the job's status
+	UpdateJob(job *BatchJob) error
+
+	// UpdateItem stores an item's status and outcome, and updates the job's
+	// counts from its items together with it
+	UpdateItem(jobID string, item *BatchItem) error
+}
+
+// Validate validates the batch request
+func (r *BatchRequest) Validate() error {
+	if len(r.Requests) == 0 {
+		return fmt.Errorf("%w: requests cannot be empty", ErrInvalidBatch)
+	}
+
+	if len(r.Requests) > MaxBatchItems {
+		return fmt.Errorf("%w: a batch can hold at most %d requests", ErrInvalidBatch, MaxBatchItems)
+	}
+
+	for i := range r.Requests {
+		if err := r.Requests[i].Validate(); err != nil {
+			return fmt.Errorf("%w: request %d: %v", ErrInvalidBatch, i, err)
+		}
+	}
+
+	if r.Concurrency < 0 {
+		return fmt.Errorf("%w: concurrency cannot be negative", ErrInvalidBatch)
+	}
+
+	return nil
+}
@@ -0,0 +1,34 @@
+package domain_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/augment-local-manus-clone/backend/ai-service/domain"
+)
+
+func TestBatchRequestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		request domain.BatchRequest
+		wantErr bool
+	}{
+		{name: "Valid batch", request: domain.BatchRequest{Requests: []domain.AIRequest{{Prompt: "Hi"}}}},
+		{name: "Empty batch", request: domain.BatchRequest{}, wantErr: true},
+		{name: "Invalid item", request: domain.BatchRequest{Requests: []domain.AIRequest{{Prompt: "Hi"}, {}}}, wantErr: true},
+		{name: "Negative concurrency", request: domain.BatchRequest{Requests: []domain.AIRequest{{Prompt: "Hi"}}, Concurrency: -1}, wantErr: true},
+		{name: "Too many items", request: domain.BatchRequest{Requests: make([]domain.AIRequest, domain.MaxBatchItems+1)}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.request.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, domain.ErrInvalidBatch) {
+				t.Errorf("Validate() error = %v, want ErrInvalidBatch", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package domain
+
+import "time"
+
+// CacheEntry is a cached value with its expiry time
+type CacheEntry struct {
+	Value     []byte    `json:"value"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Expired reports whether the entry has expired at the given time
+func (e *CacheEntry) Expired(now time.Time) bool {
+	return !e.ExpiresAt.IsZero() && !now.Before(e.ExpiresAt)
+}
+
+// CacheStore defines the interface for one tier of the response cache
+type CacheStore interface {
+	// Get returns the entry stored under key, or nil if there is none
+	Get(key string) (*CacheEntry, error)
+
+	// Set stores an entry under key, replacing any existing entry
+	Set(key string, entry *CacheEntry) error
+
+	// Delete removes the entry stored under key, if any
+	Delete(key string) error
+
+	// Clear removes every entry
+	Clear() error
+
+	// Len returns the number of stored entries
+	Len() int
+}
+
+// CacheTierStats reports the activity of one cache tier
+type CacheTierStats struct {
+	Name    string `json:"name"`
+	Entries int    `json:"entries"`
+	Hits    int64  `json:"hits"`
+}
+
+// CacheStats reports the response cache's hit rate
+type CacheStats struct {
+	// Lookups counts cacheable requests that checked the cache; Hits and
+	// Misses split them by outcome
+	Lookups int64   `json:"lookups"`
+	Hits    int64   `json:"hits"`
+	Misses  int64   `json:"misses"`
+	HitRate float64 `json:"hit_rate"`
+
+	// Bypassed counts cacheable requests sent with Cache-Control: no-cache
+	Bypassed int64 `json:"bypassed"`
+
+	// Uncacheable counts requests that were not deterministic
+	Uncacheable int64 `json:"uncacheable"`
+
+	// Stores counts responses written to the cache
+	Stores int64 `json:"stores"`
+
+	// Errors counts failed cache reads and writes, which are otherwise ignored
+	Errors int64 `json:"errors"`
+
+	Tiers []CacheTierStats `json:"tiers"`
+}
@@ -0,0 +1,124 @@
+package domain
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrNoRecording is returned when a replayed request has no matching interaction in the cassette
+var ErrNoRecording = errors.New("no recorded interaction matches request")
+
+// recordableErrors lists the errors that RecordedError restores from their messages
+var recordableErrors = []error{
+	ErrToolsNotSupported,
+	ErrImagesNotSupported,
+	ErrQueueFull,
+	ErrModelNotFound,
+	ErrUnknownProvider,
+	context.DeadlineExceeded,
+	context.Canceled,
+}
+
+// RecordedError turns an error message written to a recording or script back
+// into an error. A message that starts with the message of a well-known
+// error wraps that error, so that callers checking with errors.Is still
+// recognise it.
+func RecordedError(message string) error {
+	for _, known := range recordableErrors {
+		if strings.HasPrefix(message, known.Error()) {
+			return fmt.Errorf("%w%s", known, strings.TrimPrefix(message, known.Error()))
+		}
+	}
+	return errors.New(message)
+}
+
+// Interaction methods, one per LLMClient method
+const (
+	InteractionProcess = "process"
+	InteractionChat    = "chat"
+	InteractionStream  = "stream"
+	InteractionEmbed   = "embed"
+)
+
+// Interaction is one recorded call to an LLM client. Request and Response
+// hold the JSON of the method's request and response types.
+type Interaction struct {
+	Method   string          `json:"method"`
+	Request  json.RawMessage `json:"request"`
+	Response json.RawMessage `json:"response,omitempty"`
+
+	// Chunks holds the chunks of a streamed response, in order
+	Chunks []AIStreamChunk `json:"chunks,omitempty"`
+
+	// Error is the message of the error the call returned, if any
+	Error string `json:"error,omitempty"`
+
+	RecordedAt time.Time `json:"recorded_at"`
+}
+
+// CassetteMatch selects how replayed requests are matched to recorded interactions
+type CassetteMatch string
+
+const (
+	// CassetteMatchStrict requires the method and the whole request to be
+	// equal, apart from its timeout, and replays each interaction once
+	CassetteMatchStrict CassetteMatch = "strict"
+
+	// CassetteMatchLenient only compares the prompt, messages or inputs, so
+	// that changed models and sampling options still match; a request whose
+	// interactions have all been replayed gets the last of them again
+	CassetteMatchLenient CassetteMatch = "lenient"
+)
+
+// Validate validates the match mode
+func (m CassetteMatch) Validate() error {
+	switch m {
+	case CassetteMatchStrict, CassetteMatchLenient:
+		return nil
+	default:
+		return fmt.Errorf("invalid cassette match %q", m)
+	}
+}
+
+// Cassette defines the interface for storing recorded interactions
+type Cassette interface {
+	// Append adds an interaction to the end of the cassette
+	Append(interaction *Interaction) error
+
+	// Load returns every interaction in the cassette, in recording order
+	Load() ([]Interaction, error)
+}
+
+// UnmatchedRequest is a replayed request that no interaction matched
+type UnmatchedRequest struct {
+	Method  string          `json:"method"`
+	Request json.RawMessage `json:"request"`
+	At      time.Time       `json:"at"`
+}
+
+// ReplayStats reports how a cassette has been replayed
+type ReplayStats struct {
+	Match        CassetteMatch `json:"match"`
+	Interactions int           `json:"interactions"`
+	Replayed     int64         `json:"replayed"`
+
+	// Unused counts interactions that have not been replayed yet
+	Unused int `json:"unused"`
+
+	Unmatched []UnmatchedRequest `json:"unmatched"`
+}
+
+// RecordStats reports how many interactions have been recorded
+type RecordStats struct {
+	Recorded int64 `json:"recorded"`
+
+	// Errors counts interactions that could not be written, which are otherwise ignored
+	Errors int64 `json:"errors"`
+
+	// Skipped counts calls that were cancelled or timed out, which are not recorded
+	Skipped int64 `json:"skipped"`
+}
@@ -0,0 +1,278 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ChatRole identifies the author of a chat message
+type ChatRole string
+
+const (
+	ChatRoleSystem    ChatRole = "system"
+	ChatRoleUser      ChatRole = "user"
+	ChatRoleAssistant ChatRole = "assistant"
+	ChatRoleTool      ChatRole = "tool"
+)
+
+// ChatMessage represents a single turn in a conversation
+type ChatMessage struct {
+	Role    ChatRole `json:"role"`
+	Content string   `json:"content"`
+
+	// ToolCalls holds the tools an assistant message asked to invoke
+	ToolCalls []ToolCall `json:"tool_calls,omitempty"`
+
+	// ToolName identifies the tool whose result a tool message carries
+	ToolName string `json:"tool_name,omitempty"`
+
+	// ToolCallID links a tool message to the call it answers, for providers that require it
+	ToolCallID string `json:"tool_call_id,omitempty"`
+
+	// Untrusted marks content from an external source, such as a browsed
+	// page, to be checked by the guardrails and tagged as data; tool
+	// messages are always untrusted
+	Untrusted bool `json:"untrusted,omitempty"`
+
+	// Images holds base64-encoded images for vision models; only user
+	// messages may carry them
+	Images []string `json:"images,omitempty"`
+}
+
+// ChatRequest represents a multi-message request to the AI model
+type ChatRequest struct {
+	// Provider and Model select the backend and model; empty values use the configured defaults
+	Provider string `json:"provider,omitempty"`
+	Model    string `json:"model,omitempty"`
+
+	Messages    []ChatMessage          `json:"messages"`
+	MaxTokens   int                    `json:"max_tokens,omitempty"`
+	Temperature *float64               `json:"temperature,omitempty"`
+	Context     map[string]interface{} `json:"context,omitempty"`
+
+	// Seed fixes the sampling seed so that the same request produces the same output
+	Seed *int `json:"seed,omitempty"`
+
+	// Options sets the model's generation parameters; its num_predict,
+	// temperature and seed take precedence over the top-level fields
+	Options *GenerationOptions `json:"options,omitempty"`
+
+	// NoCache skips the response cache lookup; the fresh response is still cached
+	NoCache bool `json:"-"`
+
+	// TimeoutSeconds bounds how long the request may take, including retries
+	// and corrections; zero leaves it bounded only by the caller
+	TimeoutSeconds int `json:"timeout_seconds,omitempty"`
+
+	// Tools lists the tools the model may call in its reply
+	Tools []ToolDefinition `json:"tools,omitempty"`
+
+	// ToolMode selects native or prompt-based tool calling; defaults to auto
+	ToolMode ToolMode `json:"tool_mode,omitempty"`
+
+	// ResponseSchema requests JSON output matching the schema; the parsed
+	// value is returned in ChatResponse.JSON
+	ResponseSchema JSONSchema `json:"response_schema,omitempty"`
+
+	// SchemaRetries bounds how many times the model is re-prompted when its
+	// reply does not match ResponseSchema; defaults to 2
+	SchemaRetries *int `json:"schema_retries,omitempty"`
+
+	// ContextStrategy selects how a conversation too large for the model is fitted; defaults to truncate
+	ContextStrategy ContextStrategy `json:"context_strategy,omitempty"`
+
+	// Reasoning selects whether a reasoning model's thinking is returned
+	// separately or dropped; defaults to separate
+	Reasoning ReasoningMode `json:"reasoning,omitempty"`
+
+	// ConversationID names the conversation or task whose memory is added
+	// to the messages; the last user message and the reply are then
+	// remembered as turns
+	ConversationID string `json:"conversation_id,omitempty"`
+}
+
+// ChatResponse represents the model's reply to a chat request
+type ChatResponse struct {
+	Message ChatMessage `json:"message"`
+
+	// Reasoning holds the thinking a reasoning model produced before its reply
+	Reasoning string `json:"reasoning,omitempty"`
+
+	TokensUsed int     `json:"tokens_used,omitempty"`
+	Model      string  `json:"model,omitempty"`
+	Elapsed    float64 `json:"elapsed,omitempty"`
+
+	// Usage splits TokensUsed into prompt and completion tokens, as reported by the provider
+	Usage *TokenUsage `json:"usage,omitempty"`
+
+	// JSON holds the parsed output when the request set a response schema
+	JSON interface{} `json:"json,omitempty"`
+
+	// ContextWindow reports how the conversation was fitted into the model's context
+	ContextWindow *ContextReport `json:"context_window,omitempty"`
+
+	// Cached is true when the response was served from the response cache
+	Cached bool `json:"cached,omitempty"`
+
+	// Guardrails reports suspicious content found in the conversation's
+	// untrusted messages and in the reply
+	Guardrails *GuardrailReport `json:"guardrails,omitempty"`
+
+	// Memory reports what was added from the conversation's memory
+	Memory *MemoryReport `json:"memory,omitempty"`
+}
+
+// GenerationOptions returns the request's options with the top-level
+// max_tokens, temperature and seed filled in where the options leave them unset
+func (r *ChatRequest) GenerationOptions() GenerationOptions {
+	return resolveOptions(r.Options, r.MaxTokens, r.Temperature, r.Seed)
+}
+
+// Deterministic reports whether the request should always produce the same
+// output: sampling is greedy (temperature 0) or uses a fixed seed
+func (r *ChatRequest) Deterministic() bool {
+	return r.GenerationOptions().deterministic()
+}
+
+// Timeout returns how long the request may take, or zero for no limit
+func (r *ChatRequest) Timeout() time.Duration {
+	return time.Duration(r.TimeoutSeconds) * time.Second
+}
+
+// Validate validates the chat request
+func (r *ChatRequest) Validate() error {
+	if len(r.Messages) == 0 {
+		return errors.New("messages cannot be empty")
+	}
+
+	for i, message := range r.Messages {
+		if err := message.Validate(); err != nil {
+			return fmt.Errorf("message %d: %w", i, err)
+		}
+	}
+
+	if r.MaxTokens < 0 {
+		return errors.New("max_tokens cannot be negative")
+	}
+
+	if r.Temperature != nil && (*r.Temperature < 0 || *r.Temperature > 2) {
+		return errors.New("temperature must be between 0 and 2")
+	}
+
+	if r.TimeoutSeconds < 0 {
+		return errors.New("timeout_seconds cannot be negative")
+	}
+
+	if r.ConversationID != "" {
+		if err := ValidateConversationID(r.ConversationID); err != nil {
+			return err
+		}
+	}
+
+	switch r.ToolMode {
+	case "", ToolModeAuto, ToolModeNative, ToolModePrompt:
+	default:
+		return fmt.Errorf("invalid tool_mode %q", r.ToolMode)
+	}
+
+	names := make(map[string]bool, len(r.Tools))
+	for i := range r.Tools {
+		if err := r.Tools[i].Validate(); err != nil {
+			return err
+		}
+		if names[r.Tools[i].Name] {
+			return fmt.Errorf("duplicate tool %q", r.Tools[i].Name)
+		}
+		names[r.Tools[i].Name] = true
+	}
+
+	if err := r.ContextStrategy.Validate(); err != nil {
+		return err
+	}
+
+	if err := r.Options.Validate(); err != nil {
+		return err
+	}
+
+	if err := r.Reasoning.Validate(); err != nil {
+		return err
+	}
+
+	if err := ValidateSchemaRetries(r.SchemaRetries); err != nil {
+		return err
+	}
+
+	if r.ResponseSchema != nil && len(r.Tools) > 0 {
+		return errors.New("response_schema cannot be combined with tools")
+	}
+
+	return nil
+}
+
+// Validate validates the chat message
+func (m *ChatMessage) Validate() error {
+	switch m.Role {
+	case ChatRoleSystem, ChatRoleUser, ChatRoleAssistant, ChatRoleTool:
+	default:
+		return fmt.Errorf("invalid role %q", m.Role)
+	}
+
+	if len(m.ToolCalls) > 0 && m.Role != ChatRoleAssistant {
+		return errors.New("only assistant messages can contain tool calls")
+	}
+
+	// An assistant turn that only calls tools has no content
+	if m.Content == "" && len(m.ToolCalls) == 0 {
+		return errors.New("content cannot be empty")
+	}
+
+	if len(m.Images) > 0 && m.Role != ChatRoleUser {
+		return errors.New("only user messages can contain images")
+	}
+
+	if err := ValidateImages(m.Images); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ToChatRequest converts a single-prompt request into an equivalent chat request
+func (r *AIRequest) ToChatRequest() *ChatRequest {
+	return &ChatRequest{
+		Provider: r.Provider,
+		Model:    r.Model,
+		Messages: []ChatMessage{
+			{Role: ChatRoleUser, Content: r.Prompt, Images: r.Images},
+		},
+		MaxTokens:       r.MaxTokens,
+		Temperature:     r.Temperature,
+		Context:         r.Context,
+		Seed:            r.Seed,
+		Options:         r.Options,
+		NoCache:         r.NoCache,
+		TimeoutSeconds:  r.TimeoutSeconds,
+		ResponseSchema:  r.ResponseSchema,
+		SchemaRetries:   r.SchemaRetries,
+		ContextStrategy: r.ContextStrategy,
+		Reasoning:       r.Reasoning,
+	}
+}
+
+// ToAIResponse converts a chat response into a single-prompt response
+func (r *ChatResponse) ToAIResponse() *AIResponse {
+	return &AIResponse{
+		Text:          r.Message.Content,
+		Reasoning:     r.Reasoning,
+		TokensUsed:    r.TokensUsed,
+		Usage:         r.Usage,
+		Model:         r.Model,
+		Elapsed:       r.Elapsed,
+		JSON:          r.JSON,
+		ContextWindow: r.ContextWindow,
+		Cached:        r.Cached,
+		Guardrails:    r.Guardrails,
+		Memory:        r.Memory,
+	}
+}
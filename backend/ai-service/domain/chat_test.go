@@ -0,0 +1,125 @@
+package domain_test
+
+import (
+	"testing"
+
+	"github.com/augment-local-manus-clone/backend/ai-service/domain"
+)
+
+func TestChatRequestValidate(t *testing.T) {
+	warm := 0.7
+	high := 2.1
+
+	tests := []struct {
+		name    string
+		request domain.ChatRequest
+		wantErr bool
+	}{
+		{
+			name: "Valid conversation",
+			request: domain.ChatRequest{
+				Messages: []domain.ChatMessage{
+					{Role: domain.ChatRoleSystem, Content: "You are helpful."},
+					{Role: domain.ChatRoleUser, Content: "Hello"},
+					{Role: domain.ChatRoleAssistant, Content: "Hi!"},
+					{Role: domain.ChatRoleTool, Content: `{"ok":true}`},
+				},
+				Temperature: &warm,
+			},
+			wantErr: false,
+		},
+		{
+			name:    "No messages",
+			request: domain.ChatRequest{},
+			wantErr: true,
+		},
+		{
+			name: "User message with an image",
+			request: domain.ChatRequest{
+				Messages: []domain.ChatMessage{{Role: domain.ChatRoleUser, Content: "What is this?", Images: []string{"iVBORw0KGgo="}}},
+			},
+			wantErr: false,
+		},
+		{
+			name: "Image that is not base64",
+			request: domain.ChatRequest{
+				Messages: []domain.ChatMessage{{Role: domain.ChatRoleUser, Content: "What is this?", Images: []string{"data:image/png;base64,iVBORw0KGgo="}}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Image on an assistant message",
+			request: domain.ChatRequest{
+				Messages: []domain.ChatMessage{{Role: domain.ChatRoleAssistant, Content: "Here it is", Images: []string{"iVBORw0KGgo="}}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Invalid role",
+			request: domain.ChatRequest{
+				Messages: []domain.ChatMessage{{Role: "narrator", Content: "Hello"}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Empty content",
+			request: domain.ChatRequest{
+				Messages: []domain.ChatMessage{{Role: domain.ChatRoleUser, Content: ""}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Negative max tokens",
+			request: domain.ChatRequest{
+				Messages:  []domain.ChatMessage{{Role: domain.ChatRoleUser, Content: "Hello"}},
+				MaxTokens: -1,
+			},
+			wantErr: true,
+		},
+		{
+			name: "Temperature too high",
+			request: domain.ChatRequest{
+				Messages:    []domain.ChatMessage{{Role: domain.ChatRoleUser, Content: "Hello"}},
+				Temperature: &high,
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.request.Validate()
+
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Validate() error = nil, wantErr %v", tt.wantErr)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestAIRequestImages(t *testing.T) {
+	request := domain.AIRequest{Prompt: "Describe the screenshot", Images: []string{"iVBORw0KGgo="}}
+	if err := request.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+
+	chat := request.ToChatRequest()
+	if !chat.HasImages() || len(chat.Messages[0].Images) != 1 {
+		t.Errorf("ToChatRequest() = %+v, want the images on the user message", chat.Messages)
+	}
+
+	request.Images = make([]string, domain.MaxImages+1)
+	for i := range request.Images {
+		request.Images[i] = "iVBORw0KGgo="
+	}
+	if err := request.Validate(); err == nil {
+		t.Error("Validate() error = nil, want too many images rejected")
+	}
+}
@@ -0,0 +1,67 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrContextOverflow is returned when an input does not fit the model's
+// context window and the request's strategy is to reject it
+var ErrContextOverflow = errors.New("input exceeds the model context window")
+
+// ContextStrategy selects how inputs larger than the model's context window are fitted
+type ContextStrategy string
+
+const (
+	// ContextStrategyTruncate drops the oldest chat turns and cuts the middle
+	// out of oversized text, keeping its head and tail
+	ContextStrategyTruncate ContextStrategy = "truncate"
+
+	// ContextStrategySummarize condenses oversized text with map-reduce
+	// summarization and replaces dropped chat turns with a summary
+	ContextStrategySummarize ContextStrategy = "summarize"
+
+	// ContextStrategyReject fails requests that do not fit
+	ContextStrategyReject ContextStrategy = "reject"
+
+	// ContextStrategyOff sends requests unchanged
+	ContextStrategyOff ContextStrategy = "off"
+)
+
+// Tokenizer counts the tokens a model would see for a text
+type Tokenizer interface {
+	CountTokens(text string) int
+}
+
+// ContextReport describes how a request was fitted into the model's context
+// window; token counts are tokenizer estimates
+type ContextReport struct {
+	Strategy      ContextStrategy `json:"strategy"`
+	ContextLength int             `json:"context_length"`
+
+	// Budget is the number of input tokens available after reserving max_tokens for the reply
+	Budget       int `json:"budget"`
+	InputTokens  int `json:"input_tokens"`
+	OutputTokens int `json:"fitted_tokens"`
+
+	TruncatedTokens    int `json:"truncated_tokens,omitempty"`
+	DroppedMessages    int `json:"dropped_messages,omitempty"`
+	SummarizedMessages int `json:"summarized_messages,omitempty"`
+	SummarizedTokens   int `json:"summarized_tokens,omitempty"`
+
+	// SummaryTokensUsed counts the tokens spent on summarization requests
+	SummaryTokensUsed int `json:"summary_tokens_used,omitempty"`
+
+	// Actions describes each change made to fit the input
+	Actions []string `json:"actions,omitempty"`
+}
+
+// Validate validates a context strategy; the empty strategy selects the default
+func (s ContextStrategy) Validate() error {
+	switch s {
+	case "", ContextStrategyTruncate, ContextStrategySummarize, ContextStrategyReject, ContextStrategyOff:
+		return nil
+	default:
+		return fmt.Errorf("invalid context_strategy %q", s)
+	}
+}
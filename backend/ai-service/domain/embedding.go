@@ -0,0 +1,37 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+)
+
+// EmbedRequest represents a request to embed one or more texts
+type EmbedRequest struct {
+	// Provider and Model select the backend and embedding model; empty values use the configured defaults
+	Provider string `json:"provider,omitempty"`
+	Model    string `json:"model,omitempty"`
+
+	Input []string `json:"input"`
+}
+
+// EmbedResponse holds one embedding per input text, in input order
+type EmbedResponse struct {
+	Embeddings [][]float64 `json:"embeddings"`
+	Model      string      `json:"model,omitempty"`
+	TokensUsed int         `json:"tokens_used,omitempty"`
+}
+
+// Validate validates the embed request
+func (r *EmbedRequest) Validate() error {
+	if len(r.Input) == 0 {
+		return errors.New("input cannot be empty")
+	}
+
+	for i, text := range r.Input {
+		if text == "" {
+			return fmt.Errorf("input %d cannot be empty", i)
+		}
+	}
+
+	return nil
+}
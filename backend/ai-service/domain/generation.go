@@ -0,0 +1,105 @@
+package domain
+
+import "errors"
+
+// GenerationOptions holds the sampling and runtime parameters of a request,
+// named after Ollama's model options. Unset fields use the model's defaults.
+type GenerationOptions struct {
+	// NumPredict is the maximum number of tokens to generate
+	NumPredict *int `json:"num_predict,omitempty"`
+
+	Temperature   *float64 `json:"temperature,omitempty"`
+	TopP          *float64 `json:"top_p,omitempty"`
+	TopK          *int     `json:"top_k,omitempty"`
+	Seed          *int     `json:"seed,omitempty"`
+	Stop          []string `json:"stop,omitempty"`
+	RepeatPenalty *float64 `json:"repeat_penalty,omitempty"`
+
+	// NumCtx is the context window size the model is loaded with
+	NumCtx *int `json:"num_ctx,omitempty"`
+}
+
+// TokenUsage breaks down the tokens consumed by a request
+type TokenUsage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
+// Validate validates the generation options
+func (o *GenerationOptions) Validate() error {
+	if o == nil {
+		return nil
+	}
+
+	if o.NumPredict != nil && *o.NumPredict < -2 {
+		return errors.New("num_predict must be -1 (unlimited), -2 (fill context) or positive")
+	}
+
+	if o.Temperature != nil && (*o.Temperature < 0 || *o.Temperature > 2) {
+		return errors.New("temperature must be between 0 and 2")
+	}
+
+	if o.TopP != nil && (*o.TopP < 0 || *o.TopP > 1) {
+		return errors.New("top_p must be between 0 and 1")
+	}
+
+	if o.TopK != nil && *o.TopK < 0 {
+		return errors.New("top_k cannot be negative")
+	}
+
+	if o.RepeatPenalty != nil && *o.RepeatPenalty < 0 {
+		return errors.New("repeat_penalty cannot be negative")
+	}
+
+	if o.NumCtx != nil && *o.NumCtx <= 0 {
+		return errors.New("num_ctx must be positive")
+	}
+
+	return nil
+}
+
+// resolveOptions merges a request's top-level max_tokens, temperature and
+// seed into its options; values set in the options take precedence
+func resolveOptions(options *GenerationOptions, maxTokens int, temperature *float64, seed *int) GenerationOptions {
+	var resolved GenerationOptions
+	if options != nil {
+		resolved = *options
+	}
+
+	if resolved.NumPredict == nil && maxTokens > 0 {
+		resolved.NumPredict = &maxTokens
+	}
+
+	if resolved.Temperature == nil {
+		resolved.Temperature = temperature
+	}
+
+	if resolved.Seed == nil {
+		resolved.Seed = seed
+	}
+
+	return resolved
+}
+
+// deterministic reports whether options produce the same output every time:
+// sampling is greedy (temperature 0) or uses a fixed seed
+func (o GenerationOptions) deterministic() bool {
+	return o.Seed != nil || (o.Temperature != nil && *o.Temperature == 0)
+}
+
+// AddUsage returns the sum of two usages, either of which may be nil
+func AddUsage(a, b *TokenUsage) *TokenUsage {
+	if a == nil {
+		return b
+	}
+	if b == nil {
+		return a
+	}
+
+	return &TokenUsage{
+		PromptTokens:     a.PromptTokens + b.PromptTokens,
+		CompletionTokens: a.CompletionTokens + b.CompletionTokens,
+		TotalTokens:      a.TotalTokens + b.TotalTokens,
+	}
+}
@@ -0,0 +1,70 @@
+package domain_test
+
+import (
+	"testing"
+
+	"github.com/augment-local-manus-clone/backend/ai-service/domain"
+)
+
+func TestGenerationOptions(t *testing.T) {
+	seed := 1
+	zero := 0.0
+	warm := 0.7
+	high := 1.5
+	negative := -3
+	unlimited := -1
+
+	tests := []struct {
+		name              string
+		request           domain.AIRequest
+		wantErr           bool
+		wantDeterministic bool
+	}{
+		{
+			name:    "Sampled by default",
+			request: domain.AIRequest{Prompt: "p", Temperature: &warm},
+		},
+		{
+			name:              "Fixed seed",
+			request:           domain.AIRequest{Prompt: "p", Temperature: &warm, Seed: &seed},
+			wantDeterministic: true,
+		},
+		{
+			name:              "Greedy temperature in options",
+			request:           domain.AIRequest{Prompt: "p", Temperature: &warm, Options: &domain.GenerationOptions{Temperature: &zero}},
+			wantDeterministic: true,
+		},
+		{
+			name:    "Unlimited num_predict",
+			request: domain.AIRequest{Prompt: "p", Options: &domain.GenerationOptions{NumPredict: &unlimited}},
+		},
+		{
+			name:    "Invalid num_predict",
+			request: domain.AIRequest{Prompt: "p", Options: &domain.GenerationOptions{NumPredict: &negative}},
+			wantErr: true,
+		},
+		{
+			name:    "top_p out of range",
+			request: domain.AIRequest{Prompt: "p", Options: &domain.GenerationOptions{TopP: &high}},
+			wantErr: true,
+		},
+		{
+			name:    "Negative top_k",
+			request: domain.AIRequest{Prompt: "p", Options: &domain.GenerationOptions{TopK: &negative}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.request.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if got := tt.request.Deterministic(); got != tt.wantDeterministic {
+				t.Errorf("Deterministic() = %v, want %v", got, tt.wantDeterministic)
+			}
+		})
+	}
+}
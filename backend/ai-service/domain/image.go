@@ -0,0 +1,43 @@
+package domain
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+)
+
+// MaxImages is the largest number of images a request or message may carry
+const MaxImages = 10
+
+// ErrImagesNotSupported is returned by an LLMClient when the model cannot read images
+var ErrImagesNotSupported = errors.New("model does not support images")
+
+// ValidateImages checks that images are base64-encoded, as Ollama expects
+// them and as screenshots from the web browsing service are returned. Data
+// URLs are not accepted; send only the part after "base64,".
+func ValidateImages(images []string) error {
+	if len(images) > MaxImages {
+		return fmt.Errorf("at most %d images are allowed", MaxImages)
+	}
+
+	for i, image := range images {
+		if image == "" {
+			return fmt.Errorf("image %d cannot be empty", i)
+		}
+		if _, err := base64.StdEncoding.DecodeString(image); err != nil {
+			return fmt.Errorf("image %d is not valid base64: %v", i, err)
+		}
+	}
+
+	return nil
+}
+
+// HasImages reports whether any message of the request carries images
+func (r *ChatRequest) HasImages() bool {
+	for _, message := range r.Messages {
+		if len(message.Images) > 0 {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,227 @@
+package domain
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// JSONSchema is a JSON schema document decoded into generic JSON values.
+// Validate supports the subset of the specification models are commonly
+// asked to follow: type, enum, const, properties, required,
+// additionalProperties, items, minItems, maxItems, minLength, maxLength,
+// pattern, minimum and maximum.
+type JSONSchema map[string]interface{}
+
+// MaxSchemaRetries is the largest number of times a request may have the
+// model re-prompted for JSON matching its response schema
+const MaxSchemaRetries = 5
+
+// ValidateSchemaRetries checks a request's schema_retries
+func ValidateSchemaRetries(retries *int) error {
+	if retries != nil && (*retries < 0 || *retries > MaxSchemaRetries) {
+		return fmt.Errorf("schema_retries must be between 0 and %d", MaxSchemaRetries)
+	}
+	return nil
+}
+
+// SchemaError lists every way a value failed to match a schema
+type SchemaError struct {
+	Violations []string
+}
+
+// Error implements the error interface
+func (e *SchemaError) Error() string {
+	return "value does not match schema: " + strings.Join(e.Violations, "; ")
+}
+
+// Validate checks a value decoded from JSON against the schema and returns a
+// *SchemaError describing all violations, or nil if the value matches
+func (s JSONSchema) Validate(value interface{}) error {
+	var violations []string
+	validateSchema(s, value, "$", &violations)
+
+	if len(violations) > 0 {
+		return &SchemaError{Violations: violations}
+	}
+	return nil
+}
+
+// validateSchema recursively checks a value against a schema node
+func validateSchema(schema map[string]interface{}, value interface{}, path string, violations *[]string) {
+	fail := func(format string, args ...interface{}) {
+		*violations = append(*violations, path+": "+fmt.Sprintf(format, args...))
+	}
+
+	if expected, ok := schema["type"]; ok && !matchesType(expected, value) {
+		fail("expected %s, got %s", describeType(expected), jsonTypeOf(value))
+		return
+	}
+
+	if enum, ok := schema["enum"].([]interface{}); ok {
+		matched := false
+		for _, candidate := range enum {
+			if reflect.DeepEqual(candidate, value) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			fail("must be one of %v", enum)
+		}
+	}
+
+	if constant, ok := schema["const"]; ok && !reflect.DeepEqual(constant, value) {
+		fail("must equal %v", constant)
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		properties, _ := schema["properties"].(map[string]interface{})
+
+		if required, ok := schema["required"].([]interface{}); ok {
+			for _, name := range required {
+				if key, ok := name.(string); ok {
+					if _, present := v[key]; !present {
+						fail("missing required property %q", key)
+					}
+				}
+			}
+		}
+
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			childPath := path + "." + key
+			if propertySchema, ok := properties[key].(map[string]interface{}); ok {
+				validateSchema(propertySchema, v[key], childPath, violations)
+				continue
+			}
+
+			switch additional := schema["additionalProperties"].(type) {
+			case bool:
+				if !additional {
+					fail("unexpected property %q", key)
+				}
+			case map[string]interface{}:
+				validateSchema(additional, v[key], childPath, violations)
+			}
+		}
+
+	case []interface{}:
+		if min, ok := schemaNumber(schema, "minItems"); ok && float64(len(v)) < min {
+			fail("must contain at least %v items", min)
+		}
+		if max, ok := schemaNumber(schema, "maxItems"); ok && float64(len(v)) > max {
+			fail("must contain at most %v items", max)
+		}
+		if items, ok := schema["items"].(map[string]interface{}); ok {
+			for i, item := range v {
+				validateSchema(items, item, fmt.Sprintf("%s[%d]", path, i), violations)
+			}
+		}
+
+	case string:
+		length := float64(len([]rune(v)))
+		if min, ok := schemaNumber(schema, "minLength"); ok && length < min {
+			fail("must be at least %v characters", min)
+		}
+		if max, ok := schemaNumber(schema, "maxLength"); ok && length > max {
+			fail("must be at most %v characters", max)
+		}
+		if pattern, ok := schema["pattern"].(string); ok {
+			if re, err := regexp.Compile(pattern); err == nil && !re.MatchString(v) {
+				fail("must match pattern %q", pattern)
+			}
+		}
+
+	case float64:
+		if min, ok := schemaNumber(schema, "minimum"); ok && v < min {
+			fail("must be >= %v", min)
+		}
+		if max, ok := schemaNumber(schema, "maximum"); ok && v > max {
+			fail("must be <= %v", max)
+		}
+	}
+}
+
+// matchesType reports whether a value matches a schema "type" keyword, which
+// may be a single type name or a list of them
+func matchesType(expected interface{}, value interface{}) bool {
+	switch t := expected.(type) {
+	case string:
+		return matchesTypeName(t, value)
+	case []interface{}:
+		for _, name := range t {
+			if s, ok := name.(string); ok && matchesTypeName(s, value) {
+				return true
+			}
+		}
+		return false
+	default:
+		return true
+	}
+}
+
+// matchesTypeName reports whether a value is of the named JSON schema type
+func matchesTypeName(name string, value interface{}) bool {
+	actual := jsonTypeOf(value)
+	if name == "number" && actual == "integer" {
+		return true
+	}
+	return name == actual
+}
+
+// jsonTypeOf returns the JSON schema type name of a decoded JSON value
+func jsonTypeOf(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case float64:
+		if v == math.Trunc(v) && !math.IsInf(v, 0) {
+			return "integer"
+		}
+		return "number"
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
+
+// describeType renders a schema "type" keyword for error messages
+func describeType(expected interface{}) string {
+	if names, ok := expected.([]interface{}); ok {
+		parts := make([]string, 0, len(names))
+		for _, name := range names {
+			parts = append(parts, fmt.Sprint(name))
+		}
+		return strings.Join(parts, " or ")
+	}
+	return fmt.Sprint(expected)
+}
+
+// schemaNumber reads a numeric schema keyword
+func schemaNumber(schema map[string]interface{}, keyword string) (float64, bool) {
+	switch n := schema[keyword].(type) {
+	case float64:
+		return n, true
+	case int:
+		return float64(n), true
+	default:
+		return 0, false
+	}
+}
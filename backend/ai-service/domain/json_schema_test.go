@@ -0,0 +1,98 @@
+package domain_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/augment-local-manus-clone/backend/ai-service/domain"
+)
+
+func TestJSONSchemaValidate(t *testing.T) {
+	schema := domain.JSONSchema{}
+	_ = json.Unmarshal([]byte(`{
+		"type": "object",
+		"required": ["url", "depth"],
+		"additionalProperties": false,
+		"properties": {
+			"url": {"type": "string", "pattern": "^https?://"},
+			"depth": {"type": "integer", "minimum": 1, "maximum": 3},
+			"mode": {"enum": ["fast", "full"]},
+			"tags": {"type": "array", "items": {"type": "string", "minLength": 1}, "maxItems": 2},
+			"score": {"type": ["number", "null"]}
+		}
+	}`), &schema)
+
+	tests := []struct {
+		name           string
+		value          string
+		wantViolations int
+	}{
+		{
+			name:           "Valid value",
+			value:          `{"url": "https://example.com", "depth": 2, "mode": "fast", "tags": ["a"], "score": 0.5}`,
+			wantViolations: 0,
+		},
+		{
+			name:           "Null in type list",
+			value:          `{"url": "https://example.com", "depth": 1, "score": null}`,
+			wantViolations: 0,
+		},
+		{
+			name:           "Missing required properties",
+			value:          `{}`,
+			wantViolations: 2,
+		},
+		{
+			name:           "Wrong type at root",
+			value:          `[]`,
+			wantViolations: 1,
+		},
+		{
+			name:           "Non-integer depth",
+			value:          `{"url": "https://example.com", "depth": 1.5}`,
+			wantViolations: 1,
+		},
+		{
+			name:           "Out of range, bad pattern and enum",
+			value:          `{"url": "ftp://example.com", "depth": 9, "mode": "slow"}`,
+			wantViolations: 3,
+		},
+		{
+			name:           "Unexpected property",
+			value:          `{"url": "https://example.com", "depth": 1, "extra": true}`,
+			wantViolations: 1,
+		},
+		{
+			name:           "Invalid array items",
+			value:          `{"url": "https://example.com", "depth": 1, "tags": ["", 1, "c"]}`,
+			wantViolations: 3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var value interface{}
+			if err := json.Unmarshal([]byte(tt.value), &value); err != nil {
+				t.Fatalf("invalid test value: %v", err)
+			}
+
+			err := schema.Validate(value)
+
+			if tt.wantViolations == 0 {
+				if err != nil {
+					t.Errorf("Validate() error = %v, want nil", err)
+				}
+				return
+			}
+
+			schemaErr, ok := err.(*domain.SchemaError)
+			if !ok {
+				t.Fatalf("Validate() error = %v, want *SchemaError", err)
+			}
+
+			if len(schemaErr.Violations) != tt.wantViolations {
+				t.Errorf("Violations = %v, want %d violations", schemaErr.Violations, tt.wantViolations)
+			}
+		})
+	}
+}
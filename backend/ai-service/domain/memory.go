@@ -0,0 +1,160 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// ErrMemoryNotFound is returned when a conversation has no memory, or a fact does not exist
+var ErrMemoryNotFound = errors.New("memory not found")
+
+// ErrInvalidMemory is returned when a conversation ID, turn or fact fails validation
+var ErrInvalidMemory = errors.New("invalid memory")
+
+// conversationIDPattern restricts conversation IDs to URL-safe identifiers
+var conversationIDPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.:-]{0,127}$`)
+
+// MemoryTurn is one message of a remembered conversation
+type MemoryTurn struct {
+	Role      ChatRole  `json:"role"`
+	Content   string    `json:"content"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// MemoryFact is a durable fact learned in a conversation, such as a user
+// preference or a decision, which outlives the turns it was learned from
+type MemoryFact struct {
+	ID        int64     `json:"id"`
+	Content   string    `json:"content"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Memory is what is remembered of a conversation or task: its recent turns
+// verbatim, a summary of the older turns, and long-term facts
+type Memory struct {
+	ConversationID string `json:"conversation_id"`
+
+	// Summary condenses the turns that no longer fit the turn budget;
+	// SummarizedTurns counts them
+	Summary         string `json:"summary,omitempty"`
+	SummarizedTurns int    `json:"summarized_turns"`
+
+	Turns []MemoryTurn `json:"turns"`
+	Facts []MemoryFact `json:"facts"`
+
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// MemoryReport describes how memory was used for a request
+type MemoryReport struct {
+	ConversationID string `json:"conversation_id"`
+
+	// InjectedTurns and InjectedFacts count what was added to the request;
+	// Summary is true when the summary of older turns was added too
+	InjectedTurns int  `json:"injected_turns"`
+	InjectedFacts int  `json:"injected_facts"`
+	Summary       bool `json:"summary,omitempty"`
+}
+
+// MemoryRepository defines the interface for storing conversation memory
+type MemoryRepository interface {
+	// Get returns the memory of a conversation, or ErrMemoryNotFound
+	Get(conversationID string) (*Memory, error)
+
+	// List returns the memory of every conversation without its turns and
+	// facts, most recently updated first
+	List() ([]*Memory, error)
+
+	// Save replaces the memory of a conversation, assigning IDs to new facts
+	Save(memory *Memory) error
+
+	// Delete removes the memory of a conversation
+	Delete(conversationID string) error
+}
+
+// ValidateConversationID checks that a conversation ID can be used as a memory key
+func ValidateConversationID(id string) error {
+	if !conversationIDPattern.MatchString(id) {
+		return fmt.Errorf("%w: conversation_id must be 1-128 letters, digits, '.', '_', ':' or '-'", ErrInvalidMemory)
+	}
+	return nil
+}
+
+// Empty reports whether there is nothing to inject from the memory
+func (m *Memory) Empty() bool {
+	return m.Summary == "" && len(m.Turns) == 0 && len(m.Facts) == 0
+}
+
+// Background renders the facts and summary of the memory as text for a
+// system prompt, or returns "" when there are neither
+func (m *Memory) Background() string {
+	var blocks []string
+	if len(m.Facts) > 0 {
+		lines := make([]string, 0, len(m.Facts)+1)
+		lines = append(lines, "Known facts:")
+		for _, fact := range m.Facts {
+			lines = append(lines, "- "+fact.Content)
+		}
+		blocks = append(blocks, strings.Join(lines, "\n"))
+	}
+	if m.Summary != "" {
+		blocks = append(blocks, "Summary of the earlier conversation:\n"+m.Summary)
+	}
+	return strings.Join(blocks, "\n\n")
+}
+
+// Transcript renders turns as plain text
+func Transcript(turns []MemoryTurn) string {
+	var b strings.Builder
+	for _, turn := range turns {
+		fmt.Fprintf(&b, "%s: %s\n", turn.Role, turn.Content)
+	}
+	return b.String()
+}
+
+// InjectMemory prepends what is remembered of the conversation to the prompt
+func (r *AIRequest) InjectMemory(memory *Memory) {
+	if memory.Empty() {
+		return
+	}
+
+	var blocks []string
+	if background := memory.Background(); background != "" {
+		blocks = append(blocks, background)
+	}
+	if len(memory.Turns) > 0 {
+		blocks = append(blocks, "Recent conversation:\n"+strings.TrimRight(Transcript(memory.Turns), "\n"))
+	}
+	blocks = append(blocks, "Current request:\n"+r.Prompt)
+
+	r.Prompt = strings.Join(blocks, "\n\n")
+}
+
+// InjectMemory adds the facts and summary of the conversation to the system
+// prompt and replays its recent turns ahead of the request's own messages
+func (r *ChatRequest) InjectMemory(memory *Memory) {
+	if memory.Empty() {
+		return
+	}
+
+	// Leading system messages stay first
+	lead := 0
+	for lead < len(r.Messages) && r.Messages[lead].Role == ChatRoleSystem {
+		lead++
+	}
+
+	messages := make([]ChatMessage, 0, len(r.Messages)+len(memory.Turns)+1)
+	messages = append(messages, r.Messages[:lead]...)
+	if background := memory.Background(); background != "" {
+		messages = append(messages, ChatMessage{Role: ChatRoleSystem, Content: background})
+	}
+	for _, turn := range memory.Turns {
+		messages = append(messages, ChatMessage{Role: turn.Role, Content: turn.Content})
+	}
+	messages = append(messages, r.Messages[lead:]...)
+
+	r.Messages = messages
+}
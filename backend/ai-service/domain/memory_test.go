@@ -0,0 +1,78 @@
+package domain_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/augment-local-manus-clone/backend/ai-service/domain"
+)
+
+func TestChatRequestInjectMemory(t *testing.T) {
+	memory := &domain.Memory{
+		Summary: "The user is moving to Berlin.",
+		Turns:   []domain.MemoryTurn{{Role: domain.ChatRoleUser, Content: "Find flats"}, {Role: domain.ChatRoleAssistant, Content: "Here are three."}},
+		Facts:   []domain.MemoryFact{{Content: "The budget is 1500 EUR."}},
+	}
+
+	tests := []struct {
+		name     string
+		memory   *domain.Memory
+		messages []domain.ChatMessage
+		want     []domain.ChatMessage
+	}{
+		{
+			name:     "Empty memory changes nothing",
+			memory:   &domain.Memory{},
+			messages: []domain.ChatMessage{{Role: domain.ChatRoleUser, Content: "Hi"}},
+			want:     []domain.ChatMessage{{Role: domain.ChatRoleUser, Content: "Hi"}},
+		},
+		{
+			name:   "Memory goes after the system prompt and before the new messages",
+			memory: memory,
+			messages: []domain.ChatMessage{
+				{Role: domain.ChatRoleSystem, Content: "Be brief."},
+				{Role: domain.ChatRoleUser, Content: "Which is cheapest?"},
+			},
+			want: []domain.ChatMessage{
+				{Role: domain.ChatRoleSystem, Content: "Be brief."},
+				{Role: domain.ChatRoleSystem, Content: "Known facts:\n- The budget is 1500 EUR.\n\nSummary of the earlier conversation:\nThe user is moving to Berlin."},
+				{Role: domain.ChatRoleUser, Content: "Find flats"},
+				{Role: domain.ChatRoleAssistant, Content: "Here are three."},
+				{Role: domain.ChatRoleUser, Content: "Which is cheapest?"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := domain.ChatRequest{Messages: tt.messages}
+			request.InjectMemory(tt.memory)
+
+			if !reflect.DeepEqual(request.Messages, tt.want) {
+				t.Errorf("Messages = %+v, want %+v", request.Messages, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateConversationID(t *testing.T) {
+	tests := []struct {
+		id      string
+		wantErr bool
+	}{
+		{id: "task-1", wantErr: false},
+		{id: "user:42.session_7", wantErr: false},
+		{id: "", wantErr: true},
+		{id: "-leading-dash", wantErr: true},
+		{id: "has space", wantErr: true},
+		{id: "a/b", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.id, func(t *testing.T) {
+			if err := domain.ValidateConversationID(tt.id); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateConversationID(%q) error = %v, wantErr %v", tt.id, err, tt.wantErr)
+			}
+		})
+	}
+}
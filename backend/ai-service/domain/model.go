@@ -0,0 +1,111 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrModelNotFound is returned when a model is not installed
+	ErrModelNotFound = errors.New("model not found")
+
+	// ErrInvalidModelName is wrapped by the errors of ValidateModelName
+	ErrInvalidModelName = errors.New("invalid model name")
+)
+
+// Model capabilities, as reported by Ollama
+const (
+	CapabilityCompletion = "completion"
+	CapabilityTools      = "tools"
+	CapabilityThinking   = "thinking"
+	CapabilityVision     = "vision"
+	CapabilityEmbedding  = "embedding"
+)
+
+// ModelInfo describes an installed model
+type ModelInfo struct {
+	Name       string    `json:"name"`
+	Size       int64     `json:"size"`
+	Digest     string    `json:"digest,omitempty"`
+	ModifiedAt time.Time `json:"modified_at"`
+
+	Format            string `json:"format,omitempty"`
+	Family            string `json:"family,omitempty"`
+	ParameterSize     string `json:"parameter_size,omitempty"`
+	QuantizationLevel string `json:"quantization_level,omitempty"`
+
+	// ContextLength is the longest context the model was trained for; zero means unknown
+	ContextLength int `json:"context_length,omitempty"`
+
+	// Capabilities lists what the model supports, such as completion, tools,
+	// thinking, vision and embedding
+	Capabilities []string `json:"capabilities,omitempty"`
+}
+
+// HasCapability reports whether the model supports a capability
+func (m *ModelInfo) HasCapability(capability string) bool {
+	for _, c := range m.Capabilities {
+		if c == capability {
+			return true
+		}
+	}
+	return false
+}
+
+// ModelDetails describes an installed model in full
+type ModelDetails struct {
+	ModelInfo
+
+	// Architecture is the model's architecture, such as llama or qwen3
+	Architecture string `json:"architecture,omitempty"`
+
+	Parameters string `json:"parameters,omitempty"`
+	Template   string `json:"template,omitempty"`
+	License    string `json:"license,omitempty"`
+
+	// Metadata holds the model's GGUF metadata, such as its embedding length
+	Metadata map[string]interface{} `json:"metadata,omitempty"`
+}
+
+// PullProgress reports the progress of a model download
+type PullProgress struct {
+	Status    string `json:"status"`
+	Digest    string `json:"digest,omitempty"`
+	Total     int64  `json:"total,omitempty"`
+	Completed int64  `json:"completed,omitempty"`
+}
+
+// PullProgressHandler receives progress updates while a model is pulled;
+// returning an error aborts the pull
+type PullProgressHandler func(progress *PullProgress) error
+
+// ValidateModelName validates the name of a model to show, pull or delete
+func ValidateModelName(name string) error {
+	if strings.TrimSpace(name) == "" {
+		return fmt.Errorf("%w: name cannot be empty", ErrInvalidModelName)
+	}
+
+	if strings.ContainsAny(name, " \t\r\n") {
+		return fmt.Errorf("%w: name cannot contain whitespace", ErrInvalidModelName)
+	}
+
+	return nil
+}
+
+// ModelManager defines the interface for managing the models installed on a local LLM server
+type ModelManager interface {
+	// ListModels returns the installed models
+	ListModels(ctx context.Context) ([]ModelInfo, error)
+
+	// ShowModel returns the details of an installed model
+	ShowModel(ctx context.Context, name string) (*ModelDetails, error)
+
+	// PullModel downloads a model, passing progress updates to the handler
+	PullModel(ctx context.Context, name string, handler PullProgressHandler) error
+
+	// DeleteModel removes an installed model
+	DeleteModel(ctx context.Context, name string) error
+}
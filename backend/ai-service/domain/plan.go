@@ -0,0 +1,214 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// MaxPlanSteps is the largest number of steps a plan may have
+const MaxPlanSteps = 50
+
+// ErrInvalidPlan is returned when a plan breaks the rules checked by Validate
+var ErrInvalidPlan = errors.New("invalid plan")
+
+// ErrPlanFailed is returned when the model does not produce a valid plan
+// within its attempts; it wraps the last ErrInvalidPlan
+var ErrPlanFailed = errors.New("model did not produce a valid plan")
+
+// PlanRequest asks for a plan to carry out a task with tools. The embedded
+// AIRequest carries the task description in Prompt and the generation
+// settings. Setting Plan and Observations replans a plan that is partly done.
+type PlanRequest struct {
+	AIRequest
+
+	// Tools lists the tools the plan may use
+	Tools []ToolDefinition `json:"tools"`
+
+	// MaxSteps bounds the number of new steps; defaults to 10
+	MaxSteps int `json:"max_steps,omitempty"`
+
+	// Plan is the plan being carried out, and Observations report what
+	// happened to its steps so far
+	Plan         *Plan             `json:"plan,omitempty"`
+	Observations []StepObservation `json:"observations,omitempty"`
+}
+
+// Plan is an ordered list of tool calls that carries out a task
+type Plan struct {
+	Steps []PlanStep `json:"steps"`
+}
+
+// PlanStep is one tool call of a plan
+type PlanStep struct {
+	// ID identifies the step; IDs increase through the plan
+	ID          int    `json:"id"`
+	Description string `json:"description"`
+
+	Tool      string                 `json:"tool"`
+	Arguments map[string]interface{} `json:"arguments"`
+
+	// ExpectedOutput describes what the step should produce, so that its
+	// result can be checked before moving on
+	ExpectedOutput string `json:"expected_output"`
+
+	// DependsOn lists the earlier steps whose output this step needs
+	DependsOn []int `json:"depends_on,omitempty"`
+}
+
+// StepStatus is the outcome of a step that was carried out
+type StepStatus string
+
+const (
+	StepSucceeded StepStatus = "succeeded"
+	StepFailed    StepStatus = "failed"
+)
+
+// StepObservation reports what happened when a step was carried out
+type StepObservation struct {
+	StepID int        `json:"step_id"`
+	Status StepStatus `json:"status"`
+
+	// Output is what the tool returned, and Error why it failed
+	Output string `json:"output,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// PlanResponse is the model's plan with the response it came from
+type PlanResponse struct {
+	AIResponse
+
+	Plan *Plan `json:"plan"`
+
+	// Completed lists the steps of a replanned plan that had already
+	// succeeded; they are kept at the start of the plan
+	Completed []int `json:"completed,omitempty"`
+
+	// Attempts counts the plans the model produced, including rejected ones
+	Attempts int `json:"attempts"`
+}
+
+// Validate validates the plan request
+func (r *PlanRequest) Validate() error {
+	if err := r.AIRequest.Validate(); err != nil {
+		return err
+	}
+
+	if r.Template != "" {
+		return errors.New("template is not supported for plan requests")
+	}
+
+	if r.ResponseSchema != nil {
+		return errors.New("response_schema is not supported for plan requests")
+	}
+
+	if len(r.Tools) == 0 {
+		return errors.New("tools cannot be empty")
+	}
+
+	names := make(map[string]bool, len(r.Tools))
+	for i := range r.Tools {
+		if err := r.Tools[i].Validate(); err != nil {
+			return err
+		}
+		if names[r.Tools[i].Name] {
+			return fmt.Errorf("duplicate tool %q", r.Tools[i].Name)
+		}
+		names[r.Tools[i].Name] = true
+	}
+
+	if r.MaxSteps < 0 || r.MaxSteps > MaxPlanSteps {
+		return fmt.Errorf("max_steps must be between 1 and %d", MaxPlanSteps)
+	}
+
+	if r.Plan == nil {
+		if len(r.Observations) > 0 {
+			return errors.New("observations require a plan")
+		}
+		return nil
+	}
+
+	if err := r.Plan.Validate(r.Tools); err != nil {
+		return err
+	}
+
+	steps := make(map[int]bool, len(r.Plan.Steps))
+	for _, step := range r.Plan.Steps {
+		steps[step.ID] = true
+	}
+
+	observed := make(map[int]bool, len(r.Observations))
+	for i, observation := range r.Observations {
+		if !steps[observation.StepID] {
+			return fmt.Errorf("observation %d: unknown step %d", i, observation.StepID)
+		}
+		if observed[observation.StepID] {
+			return fmt.Errorf("observation %d: step %d is observed twice", i, observation.StepID)
+		}
+		observed[observation.StepID] = true
+
+		switch observation.Status {
+		case StepSucceeded, StepFailed:
+		default:
+			return fmt.Errorf("observation %d: invalid status %q", i, observation.Status)
+		}
+	}
+
+	return nil
+}
+
+// Validate checks that the plan only calls the given tools, with arguments
+// matching their parameter schemas, that step IDs increase and that steps
+// only depend on earlier steps. Every problem is reported in one error.
+func (p *Plan) Validate(tools []ToolDefinition) error {
+	byName := make(map[string]*ToolDefinition, len(tools))
+	for i := range tools {
+		byName[tools[i].Name] = &tools[i]
+	}
+
+	var problems []string
+	seen := make(map[int]bool, len(p.Steps))
+	previous := 0
+	for _, step := range p.Steps {
+		fail := func(format string, args ...interface{}) {
+			problems = append(problems, fmt.Sprintf("step %d: ", step.ID)+fmt.Sprintf(format, args...))
+		}
+
+		if step.ID <= previous {
+			fail("id must be greater than %d", previous)
+		}
+		if step.ID > previous {
+			previous = step.ID
+		}
+
+		if strings.TrimSpace(step.Description) == "" {
+			fail("description cannot be empty")
+		}
+		if strings.TrimSpace(step.ExpectedOutput) == "" {
+			fail("expected_output cannot be empty")
+		}
+
+		for _, dependency := range step.DependsOn {
+			if !seen[dependency] {
+				fail("depends on step %d, which does not come before it", dependency)
+			}
+		}
+		seen[step.ID] = true
+
+		tool, ok := byName[step.Tool]
+		if !ok {
+			fail("unknown tool %q", step.Tool)
+			continue
+		}
+		if tool.Parameters != nil {
+			if err := tool.Parameters.Validate(step.Arguments); err != nil {
+				fail("%s arguments: %v", step.Tool, err)
+			}
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("%w: %s", ErrInvalidPlan, strings.Join(problems, "; "))
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+package domain_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/augment-local-manus-clone/backend/ai-service/domain"
+)
+
+func TestPlanValidate(t *testing.T) {
+	tools := []domain.ToolDefinition{
+		{
+			Name:        "search",
+			Description: "Search the web",
+			Parameters: domain.JSONSchema{
+				"type":       "object",
+				"required":   []interface{}{"query"},
+				"properties": map[string]interface{}{"query": map[string]interface{}{"type": "string"}},
+			},
+		},
+	}
+	step := func(id int, arguments map[string]interface{}, dependsOn ...int) domain.PlanStep {
+		return domain.PlanStep{ID: id, Description: "Search", Tool: "search", Arguments: arguments, ExpectedOutput: "Results", DependsOn: dependsOn}
+	}
+	query := map[string]interface{}{"query": "go generics"}
+
+	tests := []struct {
+		name    string
+		steps   []domain.PlanStep
+		wantErr bool
+	}{
+		{
+			name:  "Valid plan",
+			steps: []domain.PlanStep{step(1, query), step(2, query, 1)},
+		},
+		{
+			name:  "IDs may skip numbers",
+			steps: []domain.PlanStep{step(1, query), step(4, query, 1)},
+		},
+		{
+			name:    "IDs must increase",
+			steps:   []domain.PlanStep{step(2, query), step(1, query)},
+			wantErr: true,
+		},
+		{
+			name:    "Unknown tool",
+			steps:   []domain.PlanStep{{ID: 1, Description: "Delete", Tool: "rm", ExpectedOutput: "Nothing"}},
+			wantErr: true,
+		},
+		{
+			name:    "Arguments must match the tool's parameters",
+			steps:   []domain.PlanStep{step(1, map[string]interface{}{"q": "go"})},
+			wantErr: true,
+		},
+		{
+			name:    "Dependencies must come first",
+			steps:   []domain.PlanStep{step(1, query, 2), step(2, query)},
+			wantErr: true,
+		},
+		{
+			name:    "Expected output is required",
+			steps:   []domain.PlanStep{{ID: 1, Description: "Search", Tool: "search", Arguments: query}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plan := domain.Plan{Steps: tt.steps}
+			err := plan.Validate(tools)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, domain.ErrInvalidPlan) {
+				t.Errorf("Validate() error = %v, want ErrInvalidPlan", err)
+			}
+		})
+	}
+}
+
+func TestPlanRequestValidate(t *testing.T) {
+	tools := []domain.ToolDefinition{{Name: "search", Description: "Search the web"}}
+	plan := &domain.Plan{Steps: []domain.PlanStep{{ID: 1, Description: "Search", Tool: "search", ExpectedOutput: "Results"}}}
+
+	tests := []struct {
+		name    string
+		request domain.PlanRequest
+		wantErr bool
+	}{
+		{
+			name:    "Valid request",
+			request: domain.PlanRequest{AIRequest: domain.AIRequest{Prompt: "Find it"}, Tools: tools},
+		},
+		{
+			name:    "Valid replan",
+			request: domain.PlanRequest{AIRequest: domain.AIRequest{Prompt: "Find it"}, Tools: tools, Plan: plan, Observations: []domain.StepObservation{{StepID: 1, Status: domain.StepFailed}}},
+		},
+		{
+			name:    "No tools",
+			request: domain.PlanRequest{AIRequest: domain.AIRequest{Prompt: "Find it"}},
+			wantErr: true,
+		},
+		{
+			name:    "Too many steps",
+			request: domain.PlanRequest{AIRequest: domain.AIRequest{Prompt: "Find it"}, Tools: tools, MaxSteps: domain.MaxPlanSteps + 1},
+			wantErr: true,
+		},
+		{
+			name:    "Observations without a plan",
+			request: domain.PlanRequest{AIRequest: domain.AIRequest{Prompt: "Find it"}, Tools: tools, Observations: []domain.StepObservation{{StepID: 1, Status: domain.StepSucceeded}}},
+			wantErr: true,
+		},
+		{
+			name:    "Observation of an unknown step",
+			request: domain.PlanRequest{AIRequest: domain.AIRequest{Prompt: "Find it"}, Tools: tools, Plan: plan, Observations: []domain.StepObservation{{StepID: 7, Status: domain.StepSucceeded}}},
+			wantErr: true,
+		},
+		{
+			name:    "Invalid observation status",
+			request: domain.PlanRequest{AIRequest: domain.AIRequest{Prompt: "Find it"}, Tools: tools, Plan: plan, Observations: []domain.StepObservation{{StepID: 1, Status: "done"}}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.request.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
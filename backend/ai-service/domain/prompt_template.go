@@ -0,0 +1,114 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// ErrTemplateNotFound is returned when a prompt template or version does not exist
+var ErrTemplateNotFound = errors.New("prompt template not found")
+
+// ErrInvalidTemplate is returned when a prompt template fails validation
+var ErrInvalidTemplate = errors.New("invalid prompt template")
+
+// ErrTemplateRender is returned when a template cannot be rendered with the
+// request's variables, such as when a referenced variable is missing
+var ErrTemplateRender = errors.New("failed to render prompt template")
+
+// templateNamePattern restricts template names to URL-safe identifiers
+var templateNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_.-]*$`)
+
+// PromptTemplate is one version of a named prompt, written in Go text/template
+// syntax. Versions are immutable once created; only their description and
+// traffic weight can change.
+type PromptTemplate struct {
+	Name        string `json:"name"`
+	Version     int    `json:"version"`
+	Description string `json:"description,omitempty"`
+	Template    string `json:"template"`
+
+	// Weight is this version's share of the requests that do not pin a
+	// version. When no version of a template has a weight, the latest
+	// version is used.
+	Weight int `json:"weight"`
+
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TemplateRef identifies the template version a prompt was rendered from
+type TemplateRef struct {
+	Name    string `json:"name"`
+	Version int    `json:"version"`
+}
+
+// Validate validates the prompt template, including its template syntax
+func (t *PromptTemplate) Validate() error {
+	if !templateNamePattern.MatchString(t.Name) {
+		return fmt.Errorf("%w: name must be lowercase letters, digits, '.', '_' or '-'", ErrInvalidTemplate)
+	}
+
+	if strings.TrimSpace(t.Template) == "" {
+		return fmt.Errorf("%w: template cannot be empty", ErrInvalidTemplate)
+	}
+
+	if t.Weight < 0 {
+		return fmt.Errorf("%w: weight cannot be negative", ErrInvalidTemplate)
+	}
+
+	if _, err := t.parse(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Render executes the template against data. Referencing a missing key is an
+// error rather than rendering "<no value>".
+func (t *PromptTemplate) Render(data map[string]interface{}) (string, error) {
+	tmpl, err := t.parse()
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	if err := tmpl.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("%w %q version %d: %v", ErrTemplateRender, t.Name, t.Version, err)
+	}
+
+	return b.String(), nil
+}
+
+// parse compiles the template text
+func (t *PromptTemplate) parse() (*template.Template, error) {
+	tmpl, err := template.New(t.Name).Option("missingkey=error").Parse(t.Template)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidTemplate, err)
+	}
+	return tmpl, nil
+}
+
+// PromptTemplateRepository defines the interface for storing prompt templates
+type PromptTemplateRepository interface {
+	// Create stores the template as the next version of its name and sets
+	// its Version and CreatedAt
+	Create(template *PromptTemplate) error
+
+	// Get returns a version of a template
+	Get(name string, version int) (*PromptTemplate, error)
+
+	// ListVersions returns every version of a template, oldest first
+	ListVersions(name string) ([]*PromptTemplate, error)
+
+	// List returns the latest version of every template, sorted by name
+	List() ([]*PromptTemplate, error)
+
+	// Update changes the description and weight of a version
+	Update(template *PromptTemplate) error
+
+	// Delete removes a version of a template, or every version when version is 0
+	Delete(name string, version int) error
+}
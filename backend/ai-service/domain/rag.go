@@ -0,0 +1,111 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// WorkspaceCollection is the vector index collection holding workspace file chunks
+const WorkspaceCollection = "workspace"
+
+// RAGRequest represents a question answered from retrieved workspace content.
+// The embedded AIRequest carries the prompt and generation settings.
+type RAGRequest struct {
+	AIRequest
+
+	// K is the number of chunks to retrieve
+	K int `json:"k,omitempty"`
+
+	// MinScore drops retrieved chunks less similar to the prompt than this
+	MinScore float64 `json:"min_score,omitempty"`
+}
+
+// RAGResponse is the model's answer with the sources it was given
+type RAGResponse struct {
+	AIResponse
+	Citations []Citation `json:"citations"`
+}
+
+// Citation identifies a retrieved chunk; Index is the number the model uses to cite it, e.g. [1]
+type Citation struct {
+	Index     int     `json:"index"`
+	Path      string  `json:"path"`
+	StartLine int     `json:"start_line"`
+	EndLine   int     `json:"end_line"`
+	Score     float64 `json:"score"`
+	Excerpt   string  `json:"excerpt"`
+}
+
+// Validate validates the RAG request
+func (r *RAGRequest) Validate() error {
+	if err := r.AIRequest.Validate(); err != nil {
+		return err
+	}
+
+	if r.Template != "" {
+		return errors.New("template is not supported for RAG requests")
+	}
+
+	if r.K < 0 {
+		return errors.New("k cannot be negative")
+	}
+
+	return nil
+}
+
+// WorkspaceFile describes a file in the agent's workspace
+type WorkspaceFile struct {
+	Path         string    `json:"path"`
+	Size         int64     `json:"size"`
+	ModifiedTime time.Time `json:"modified_time"`
+}
+
+// Workspace defines the interface for reading files in the agent's workspace
+type Workspace interface {
+	// ListFiles returns every non-hidden file in the workspace, recursively
+	ListFiles(ctx context.Context) ([]WorkspaceFile, error)
+
+	// ReadFile returns the content of a file
+	ReadFile(ctx context.Context, path string) (string, error)
+}
+
+// IndexedDocument records the version of a file the vector index was built from
+type IndexedDocument struct {
+	Collection   string    `json:"collection"`
+	Path         string    `json:"path"`
+	Size         int64     `json:"size"`
+	ModifiedTime time.Time `json:"modified_time"`
+	ContentHash  string    `json:"content_hash"`
+	ChunkCount   int       `json:"chunk_count"`
+	IndexedAt    time.Time `json:"indexed_at"`
+}
+
+// DocumentIndexRepository defines the interface for tracking indexed documents
+type DocumentIndexRepository interface {
+	// ListDocuments returns every document indexed in a collection
+	ListDocuments(collection string) ([]*IndexedDocument, error)
+
+	// SaveDocument inserts or replaces a document
+	SaveDocument(document *IndexedDocument) error
+
+	// DeleteDocument removes a document
+	DeleteDocument(collection, path string) error
+}
+
+// IndexReport summarises a workspace re-index
+type IndexReport struct {
+	Added     []string    `json:"added"`
+	Updated   []string    `json:"updated"`
+	Removed   []string    `json:"removed"`
+	Unchanged int         `json:"unchanged"`
+	Skipped   []string    `json:"skipped"`
+	Chunks    int         `json:"chunks"`
+	Errors    []FileError `json:"errors,omitempty"`
+}
+
+// FileError reports a file that could not be indexed
+type FileError struct {
+	Path  string `json:"path"`
+	Error string `json:"error"`
+}
@@ -0,0 +1,129 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ReasoningMode selects what happens to a reasoning model's thinking
+type ReasoningMode string
+
+const (
+	// ReasoningSeparate returns the reasoning in its own field, apart from the answer
+	ReasoningSeparate ReasoningMode = "separate"
+
+	// ReasoningDrop discards the reasoning so that only the answer is returned
+	ReasoningDrop ReasoningMode = "drop"
+)
+
+// Validate validates the reasoning mode; empty selects ReasoningSeparate
+func (m ReasoningMode) Validate() error {
+	switch m {
+	case "", ReasoningSeparate, ReasoningDrop:
+		return nil
+	}
+	return fmt.Errorf("invalid reasoning %q", m)
+}
+
+// Tags that reasoning models such as deepseek-r1 and qwen3 wrap their thinking in
+const (
+	thinkOpenTag  = "<think>"
+	thinkCloseTag = "</think>"
+)
+
+// SplitReasoning separates <think>...</think> blocks from the answer in a
+// model's output. Some chat templates open the block in the prompt, so text
+// before a closing tag without an opening tag is reasoning too. An unclosed
+// block, as left by a truncated generation, runs to the end of the text.
+func SplitReasoning(text string) (reasoning, answer string) {
+	var splitter ReasoningSplitter
+
+	// A leading closing tag with no opening tag before it closes an implicit block
+	if closeAt := strings.Index(text, thinkCloseTag); closeAt >= 0 && !strings.Contains(text[:closeAt], thinkOpenTag) {
+		splitter.inside = true
+	}
+
+	r, a := splitter.Write(text)
+	fr, fa := splitter.Flush()
+	return strings.TrimSpace(r + fr), a + fa
+}
+
+// ReasoningSplitter separates reasoning from the answer in streamed output,
+// where tags may be split across chunks
+type ReasoningSplitter struct {
+	inside bool
+
+	// pending holds text that may be the start of a tag
+	pending string
+
+	// answered is set once answer text has been emitted, after which
+	// whitespace is no longer trimmed from the start of the answer
+	answered bool
+}
+
+// Write consumes the next piece of output and returns the reasoning and
+// answer text that can be emitted so far
+func (s *ReasoningSplitter) Write(text string) (reasoning, answer string) {
+	text = s.pending + text
+	s.pending = ""
+
+	var r, a strings.Builder
+	for text != "" {
+		tag := thinkOpenTag
+		if s.inside {
+			tag = thinkCloseTag
+		}
+
+		at := strings.Index(text, tag)
+		if at < 0 {
+			// Hold back a suffix that could be the start of the tag
+			keep := partialTagSuffix(text, tag)
+			s.emit(&r, &a, text[:len(text)-keep])
+			s.pending = text[len(text)-keep:]
+			break
+		}
+
+		s.emit(&r, &a, text[:at])
+		text = text[at+len(tag):]
+		s.inside = !s.inside
+	}
+
+	return r.String(), a.String()
+}
+
+// Flush returns any text held back while waiting for a tag to complete
+func (s *ReasoningSplitter) Flush() (reasoning, answer string) {
+	var r, a strings.Builder
+	s.emit(&r, &a, s.pending)
+	s.pending = ""
+	return r.String(), a.String()
+}
+
+// emit appends text to the reasoning or the answer, trimming the whitespace
+// that separates the reasoning block from the answer
+func (s *ReasoningSplitter) emit(r, a *strings.Builder, text string) {
+	if s.inside {
+		r.WriteString(text)
+		return
+	}
+
+	if !s.answered {
+		text = strings.TrimLeft(text, " \t\r\n")
+		if text == "" {
+			return
+		}
+		s.answered = true
+	}
+	a.WriteString(text)
+}
+
+// partialTagSuffix returns the length of the longest suffix of text that is a
+// proper prefix of tag
+func partialTagSuffix(text, tag string) int {
+	for n := len(tag) - 1; n > 0; n-- {
+		if strings.HasSuffix(text, tag[:n]) {
+			return n
+		}
+	}
+	return 0
+}
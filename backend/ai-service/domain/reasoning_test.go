@@ -0,0 +1,95 @@
+package domain_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/augment-local-manus-clone/backend/ai-service/domain"
+)
+
+func TestSplitReasoning(t *testing.T) {
+	tests := []struct {
+		name          string
+		text          string
+		wantReasoning string
+		wantAnswer    string
+	}{
+		{
+			name:       "No reasoning",
+			text:       "The answer is 4.",
+			wantAnswer: "The answer is 4.",
+		},
+		{
+			name:          "Think block before the answer",
+			text:          "<think>\n2 + 2 = 4\n</think>\n\nThe answer is 4.",
+			wantReasoning: "2 + 2 = 4",
+			wantAnswer:    "The answer is 4.",
+		},
+		{
+			name:          "Opening tag in the prompt",
+			text:          "2 + 2 = 4\n</think>\n\nThe answer is 4.",
+			wantReasoning: "2 + 2 = 4",
+			wantAnswer:    "The answer is 4.",
+		},
+		{
+			name:          "Unclosed block",
+			text:          "<think>Still thinking about",
+			wantReasoning: "Still thinking about",
+		},
+		{
+			name:          "Empty block",
+			text:          "<think>\n\n</think>\n\n{\"ok\": true}",
+			wantReasoning: "",
+			wantAnswer:    `{"ok": true}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reasoning, answer := domain.SplitReasoning(tt.text)
+			if reasoning != tt.wantReasoning || answer != tt.wantAnswer {
+				t.Errorf("SplitReasoning() = %q, %q, want %q, %q", reasoning, answer, tt.wantReasoning, tt.wantAnswer)
+			}
+		})
+	}
+}
+
+func TestReasoningSplitter(t *testing.T) {
+	tests := []struct {
+		name          string
+		chunks        []string
+		wantReasoning string
+		wantAnswer    string
+	}{
+		{
+			name:          "Tags split across chunks",
+			chunks:        []string{"<th", "ink>plan", " it</th", "ink>\n\nDo", "ne."},
+			wantReasoning: "plan it",
+			wantAnswer:    "Done.",
+		},
+		{
+			name:       "Angle bracket that is not a tag",
+			chunks:     []string{"a <", "b> c<"},
+			wantAnswer: "a <b> c<",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var splitter domain.ReasoningSplitter
+			var reasoning, answer strings.Builder
+			for _, chunk := range append(tt.chunks, "") {
+				r, a := splitter.Write(chunk)
+				reasoning.WriteString(r)
+				answer.WriteString(a)
+			}
+			r, a := splitter.Flush()
+			reasoning.WriteString(r)
+			answer.WriteString(a)
+
+			if reasoning.String() != tt.wantReasoning || answer.String() != tt.wantAnswer {
+				t.Errorf("split = %q, %q, want %q, %q", reasoning.String(), answer.String(), tt.wantReasoning, tt.wantAnswer)
+			}
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrUnknownRoute is returned when a request names a routing policy that is not configured
+var ErrUnknownRoute = errors.New("unknown route")
+
+// ErrNoModelAvailable is returned when every model a request could be routed
+// to is unsuitable or temporarily disabled by its circuit breaker
+var ErrNoModelAvailable = errors.New("no model available")
+
+// ModelTarget identifies a model on a provider that requests can be routed to
+type ModelTarget struct {
+	Provider string `json:"provider"`
+	Model    string `json:"model"`
+
+	// ContextLength is the model's context window in tokens; zero means unknown
+	ContextLength int `json:"context_length,omitempty"`
+}
+
+// Key returns the identifier used to track the target's health
+func (t ModelTarget) Key() string {
+	return t.Provider + "/" + t.Model
+}
+
+// CircuitState is the state of a model's circuit breaker
+type CircuitState string
+
+const (
+	// CircuitClosed lets requests through while the model is healthy
+	CircuitClosed CircuitState = "closed"
+
+	// CircuitOpen rejects requests until the cooldown has elapsed
+	CircuitOpen CircuitState = "open"
+
+	// CircuitHalfOpen lets a single probe request through to test recovery
+	CircuitHalfOpen CircuitState = "half_open"
+)
+
+// ModelHealth reports the circuit breaker state of a routed model
+type ModelHealth struct {
+	ModelTarget
+	State               CircuitState `json:"state"`
+	ConsecutiveFailures int          `json:"consecutive_failures"`
+	LastError           string       `json:"last_error,omitempty"`
+	OpenUntil           *time.Time   `json:"open_until,omitempty"`
+}
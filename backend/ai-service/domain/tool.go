@@ -0,0 +1,87 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+)
+
+// ToolMode controls how tool definitions are offered to the model
+type ToolMode string
+
+const (
+	// ToolModeAuto uses native tool calling and falls back to prompting when
+	// the model does not support it
+	ToolModeAuto ToolMode = "auto"
+
+	// ToolModeNative only uses the model's native tool calling
+	ToolModeNative ToolMode = "native"
+
+	// ToolModePrompt describes the tools in the prompt and parses a JSON reply
+	ToolModePrompt ToolMode = "prompt"
+)
+
+// ErrToolsNotSupported is returned by an LLMClient when the model cannot use native tool calling
+var ErrToolsNotSupported = errors.New("model does not support native tool calling")
+
+// toolNamePattern restricts tool names to what model APIs accept
+var toolNamePattern = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,64}$`)
+
+// ToolDefinition describes a tool the model may call
+type ToolDefinition struct {
+	Name        string     `json:"name"`
+	Description string     `json:"description"`
+	Parameters  JSONSchema `json:"parameters,omitempty"`
+}
+
+// ToolCall represents the model's request to invoke a tool
+type ToolCall struct {
+	ID        string                 `json:"id,omitempty"`
+	Name      string                 `json:"name"`
+	Arguments map[string]interface{} `json:"arguments"`
+}
+
+// Validate validates the tool definition
+func (t *ToolDefinition) Validate() error {
+	if !toolNamePattern.MatchString(t.Name) {
+		return fmt.Errorf("invalid tool name %q", t.Name)
+	}
+
+	if t.Description == "" {
+		return fmt.Errorf("tool %q: description cannot be empty", t.Name)
+	}
+
+	if t.Parameters != nil {
+		if schemaType, ok := t.Parameters["type"]; ok && schemaType != "object" {
+			return fmt.Errorf("tool %q: parameters must be an object schema", t.Name)
+		}
+	}
+
+	return nil
+}
+
+// ValidateToolCalls checks that each call names a defined tool and that its
+// arguments match the tool's parameter schema
+func ValidateToolCalls(tools []ToolDefinition, calls []ToolCall) error {
+	byName := make(map[string]*ToolDefinition, len(tools))
+	for i := range tools {
+		byName[tools[i].Name] = &tools[i]
+	}
+
+	for i, call := range calls {
+		tool, ok := byName[call.Name]
+		if !ok {
+			return fmt.Errorf("tool call %d: unknown tool %q", i, call.Name)
+		}
+
+		if tool.Parameters == nil {
+			continue
+		}
+
+		if err := tool.Parameters.Validate(call.Arguments); err != nil {
+			return fmt.Errorf("tool call %d (%s): %w", i, call.Name, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,133 @@
+package domain_test
+
+import (
+	"testing"
+
+	"github.com/augment-local-manus-clone/backend/ai-service/domain"
+)
+
+func TestToolDefinitionValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		tool    domain.ToolDefinition
+		wantErr bool
+	}{
+		{
+			name: "Valid tool",
+			tool: domain.ToolDefinition{
+				Name:        "browse",
+				Description: "Open a web page",
+				Parameters:  domain.JSONSchema{"type": "object"},
+			},
+			wantErr: false,
+		},
+		{
+			name:    "Tool without parameters",
+			tool:    domain.ToolDefinition{Name: "list_files", Description: "List workspace files"},
+			wantErr: false,
+		},
+		{
+			name:    "Invalid name",
+			tool:    domain.ToolDefinition{Name: "browse the web", Description: "Open a web page"},
+			wantErr: true,
+		},
+		{
+			name:    "Missing description",
+			tool:    domain.ToolDefinition{Name: "browse"},
+			wantErr: true,
+		},
+		{
+			name: "Non-object parameters",
+			tool: domain.ToolDefinition{
+				Name:        "browse",
+				Description: "Open a web page",
+				Parameters:  domain.JSONSchema{"type": "string"},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.tool.Validate()
+
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Validate() error = nil, wantErr %v", tt.wantErr)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateToolCalls(t *testing.T) {
+	tools := []domain.ToolDefinition{
+		{
+			Name:        "browse",
+			Description: "Open a web page",
+			Parameters: domain.JSONSchema{
+				"type":       "object",
+				"required":   []interface{}{"url"},
+				"properties": map[string]interface{}{"url": map[string]interface{}{"type": "string"}},
+			},
+		},
+		{Name: "list_files", Description: "List workspace files"},
+	}
+
+	tests := []struct {
+		name    string
+		calls   []domain.ToolCall
+		wantErr bool
+	}{
+		{
+			name: "Valid calls",
+			calls: []domain.ToolCall{
+				{Name: "browse", Arguments: map[string]interface{}{"url": "https://example.com"}},
+				{Name: "list_files", Arguments: map[string]interface{}{}},
+			},
+			wantErr: false,
+		},
+		{
+			name:    "No calls",
+			calls:   nil,
+			wantErr: false,
+		},
+		{
+			name:    "Unknown tool",
+			calls:   []domain.ToolCall{{Name: "delete_everything"}},
+			wantErr: true,
+		},
+		{
+			name:    "Missing required argument",
+			calls:   []domain.ToolCall{{Name: "browse", Arguments: map[string]interface{}{}}},
+			wantErr: true,
+		},
+		{
+			name:    "Wrong argument type",
+			calls:   []domain.ToolCall{{Name: "browse", Arguments: map[string]interface{}{"url": 42.0}}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := domain.ValidateToolCalls(tools, tt.calls)
+
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("ValidateToolCalls() error = nil, wantErr %v", tt.wantErr)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Errorf("ValidateToolCalls() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
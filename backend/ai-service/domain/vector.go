@@ -0,0 +1,71 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// ErrDimensionMismatch is returned when a vector's length differs from the
+// other vectors in its collection
+var ErrDimensionMismatch = errors.New("vector dimension mismatch")
+
+// VectorRecord is an embedded document stored in a collection of the vector index
+type VectorRecord struct {
+	ID         string                 `json:"id"`
+	Collection string                 `json:"collection"`
+	Vector     []float64              `json:"vector,omitempty"`
+	Text       string                 `json:"text,omitempty"`
+	Metadata   map[string]interface{} `json:"metadata,omitempty"`
+}
+
+// VectorMatch is a record returned by a similarity query with its cosine similarity
+type VectorMatch struct {
+	VectorRecord
+	Score float64 `json:"score"`
+}
+
+// Validate validates the vector record
+func (r *VectorRecord) Validate() error {
+	if r.ID == "" {
+		return errors.New("id cannot be empty")
+	}
+
+	if r.Collection == "" {
+		return errors.New("collection cannot be empty")
+	}
+
+	if len(r.Vector) == 0 {
+		return fmt.Errorf("record %q: vector cannot be empty", r.ID)
+	}
+
+	return nil
+}
+
+// CosineSimilarity returns the cosine of the angle between two vectors of
+// equal length, or 0 if either is a zero vector
+func CosineSimilarity(a, b []float64) float64 {
+	var dot, normA, normB float64
+	for i := range a {
+		dot += a[i] * b[i]
+		normA += a[i] * a[i]
+		normB += b[i] * b[i]
+	}
+
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
+
+// VectorStore defines the interface for storing and querying embeddings
+type VectorStore interface {
+	// Upsert inserts the records or replaces existing records with the same collection and ID
+	Upsert(records []VectorRecord) error
+
+	// Delete removes the records with the given IDs from a collection
+	Delete(collection string, ids []string) error
+
+	// Query returns the k records in the collection most similar to the vector, best match first
+	Query(collection string, vector []float64, k int) ([]VectorMatch, error)
+}
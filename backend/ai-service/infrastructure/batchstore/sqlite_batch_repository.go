@@ -0,0 +1,339 @@
+package batchstore
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/augment-local-manus-clone/backend/ai-service/domain"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// SQLiteBatchRepository implements the BatchRepository interface using SQLite.
+// Requests and responses are stored as JSON.
+type SQLiteBatchRepository struct {
+	db *sql.DB
+}
+
+// NewSQLiteBatchRepository creates a new SQLiteBatchRepository
+func NewSQLiteBatchRepository(dbPath string) (*SQLiteBatchRepository, error) {
+	db, err := sql.Open("sqlite3", dbPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+
+	// Create tables if they don't exist
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS batch_jobs (
+			id TEXT PRIMARY KEY,
+			status TEXT NOT NULL,
+			client TEXT NOT NULL DEFAULT '',
+			concurrency INTEGER NOT NULL,
+			total INTEGER NOT NULL,
+			succeeded INTEGER NOT NULL DEFAULT 0,
+			failed INTEGER NOT NULL DEFAULT 0,
+			cancelled INTEGER NOT NULL DEFAULT 0,
+			created_at TIMESTAMP NOT NULL,
+			updated_at TIMESTAMP NOT NULL,
+			finished_at TIMESTAMP
+		)
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create batch_jobs table: %w", err)
+	}
+
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS batch_items (
+			job_id TEXT NOT NULL,
+			idx INTEGER NOT NULL,
+			status TEXT NOT NULL,
+			request TEXT NOT NULL,
+			response TEXT,
+			error TEXT NOT NULL DEFAULT '',
+			started_at TIMESTAMP,
+			finished_at TIMESTAMP,
+			PRIMARY KEY (job_id, idx)
+		)
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create batch_items table: %w", err)
+	}
+
+	return &SQLiteBatchRepository{
+		db: db,
+	}, nil
+}
+
+// Create stores a new job and its items
+func (r *SQLiteBatchRepository) Create(job *domain.BatchJob) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(
+		`INSERT INTO batch_jobs (id, status, client, concurrency, total, succeeded, failed, cancelled, created_at, updated_at, finished_at)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		job.ID,
+		job.Status,
+		job.Client,
+		job.Concurrency,
+		job.Total,
+		job.Succeeded,
+		job.Failed,
+		job.Cancelled,
+		job.CreatedAt,
+		job.UpdatedAt,
+		job.FinishedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert batch job: %w", err)
+	}
+
+	for i := range job.Items {
+		item := &job.Items[i]
+		request, err := json.Marshal(item.Request)
+		if err != nil {
+			return fmt.Errorf("failed to encode request: %w", err)
+		}
+
+		_, err = tx.Exec(
+			`INSERT INTO batch_items (job_id, idx, status, request) VALUES (?, ?, ?, ?)`,
+			job.ID,
+			item.Index,
+			item.Status,
+			string(request),
+		)
+		if err != nil {
+			return fmt.Errorf("failed to insert batch item: %w", err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// Get returns a job with its items
+func (r *SQLiteBatchRepository) Get(id string) (*domain.BatchJob, error) {
+	job, err := scanJob(r.db.QueryRow(
+		`SELECT id, status, client, concurrency, total, succeeded, failed, cancelled, created_at, updated_at, finished_at
+		 FROM batch_jobs WHERE id = ?`,
+		id,
+	))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%w: %s", domain.ErrBatchNotFound, id)
+		}
+		return nil, fmt.Errorf("failed to read batch job: %w", err)
+	}
+
+	job.Items, err = r.items(id)
+	if err != nil {
+		return nil, err
+	}
+
+	return job, nil
+}
+
+// List returns every job without its items, newest first
+func (r *SQLiteBatchRepository) List() ([]*domain.BatchJob, error) {
+	rows, err := r.db.Query(
+		`SELECT id, status, client, concurrency, total, succeeded, failed, cancelled, created_at, updated_at, finished_at
+		 FROM batch_jobs ORDER BY created_at DESC`,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query batch jobs: %w", err)
+	}
+	defer rows.Close()
+
+	jobs := []*domain.BatchJob{}
+	for rows.Next() {
+		job, err := scanJob(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan batch job: %w", err)
+		}
+		jobs = append(jobs, job)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating batch jobs: %w", err)
+	}
+
+	return jobs, nil
+}
+
+// UpdateJob stores the job's status; its counts are kept by UpdateItem
+func (r *SQLiteBatchRepository) UpdateJob(job *domain.BatchJob) error {
+	result, err := r.db.Exec(
+		`UPDATE batch_jobs SET status = ?, updated_at = ?, finished_at = ?
+		 WHERE id = ?`,
+		job.Status,
+		job.UpdatedAt,
+		job.FinishedAt,
+		job.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update batch job: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to check affected rows: %w", err)
+	}
+	if affected == 0 {
+		return fmt.Errorf("%w: %s", domain.ErrBatchNotFound, job.ID)
+	}
+
+	return nil
+}
+
+// UpdateItem stores an item's status and outcome, and recounts the job's
+// items in the same transaction, so that items finishing at the same time
+// cannot store their job's counts out of order
+func (r *SQLiteBatchRepository) UpdateItem(jobID string, item *domain.BatchItem) error {
+	var response interface{}
+	if item.Response != nil {
+		data, err := json.Marshal(item.Response)
+		if err != nil {
+			return fmt.Errorf("failed to encode response: %w", err)
+		}
+		response = string(data)
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(
+		`UPDATE batch_items SET status = ?, response = ?, error = ?, started_at = ?, finished_at = ?
+		 WHERE job_id = ? AND idx = ?`,
+		item.Status,
+		response,
+		item.Error,
+		item.StartedAt,
+		item.FinishedAt,
+		jobID,
+		item.Index,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update batch item: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to check affected rows: %w", err)
+	}
+	if affected == 0 {
+		return fmt.Errorf("%w: %s item %d", domain.ErrBatchNotFound, jobID, item.Index)
+	}
+
+	updatedAt := item.FinishedAt
+	if updatedAt == nil {
+		updatedAt = item.StartedAt
+	}
+	_, err = tx.Exec(
+		`UPDATE batch_jobs SET
+			succeeded = (SELECT COUNT(*) FROM batch_items WHERE job_id = ? AND status = ?),
+			failed = (SELECT COUNT(*) FROM batch_items WHERE job_id = ? AND status = ?),
+			cancelled = (SELECT COUNT(*) FROM batch_items WHERE job_id = ? AND status = ?),
+			updated_at = COALESCE(?, updated_at)
+		 WHERE id = ?`,
+		jobID, domain.BatchItemSucceeded,
+		jobID, domain.BatchItemFailed,
+		jobID, domain.BatchItemCancelled,
+		updatedAt,
+		jobID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to count batch items: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// Close closes the database connection
+func (r *SQLiteBatchRepository) Close() error {
+	return r.db.Close()
+}
+
+// items returns the items of a job in order
+func (r *SQLiteBatchRepository) items(jobID string) ([]domain.BatchItem, error) {
+	rows, err := r.db.Query(
+		`SELECT idx, status, request, response, error, started_at, finished_at
+		 FROM batch_items WHERE job_id = ? ORDER BY idx`,
+		jobID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query batch items: %w", err)
+	}
+	defer rows.Close()
+
+	items := []domain.BatchItem{}
+	for rows.Next() {
+		var item domain.BatchItem
+		var request string
+		var response sql.NullString
+		var startedAt, finishedAt sql.NullTime
+		if err := rows.Scan(&item.Index, &item.Status, &request, &response, &item.Error, &startedAt, &finishedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan batch item: %w", err)
+		}
+
+		if err := json.Unmarshal([]byte(request), &item.Request); err != nil {
+			return nil, fmt.Errorf("failed to decode request: %w", err)
+		}
+		if response.Valid {
+			item.Response = &domain.AIResponse{}
+			if err := json.Unmarshal([]byte(response.String), item.Response); err != nil {
+				return nil, fmt.Errorf("failed to decode response: %w", err)
+			}
+		}
+		if startedAt.Valid {
+			item.StartedAt = &startedAt.Time
+		}
+		if finishedAt.Valid {
+			item.FinishedAt = &finishedAt.Time
+		}
+		items = append(items, item)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating batch items: %w", err)
+	}
+
+	return items, nil
+}
+
+// rowScanner is satisfied by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanJob reads a job row without its items
+func scanJob(row rowScanner) (*domain.BatchJob, error) {
+	var job domain.BatchJob
+	var finishedAt sql.NullTime
+	err := row.Scan(
+		&job.ID,
+		&job.Status,
+		&job.Client,
+		&job.Concurrency,
+		&job.Total,
+		&job.Succeeded,
+		&job.Failed,
+		&job.Cancelled,
+		&job.CreatedAt,
+		&job.UpdatedAt,
+		&finishedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if finishedAt.Valid {
+		job.FinishedAt = &finishedAt.Time
+	}
+	return &job, nil
+}
@@ -0,0 +1,110 @@
+package batchstore_test
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/augment-local-manus-clone/backend/ai-service/domain"
+	"github.com/augment-local-manus-clone/backend/ai-service/infrastructure/batchstore"
+)
+
+func TestSQLiteBatchRepository(t *testing.T) {
+	repo, err := batchstore.NewSQLiteBatchRepository(filepath.Join(t.TempDir(), "batch.db"))
+	if err != nil {
+		t.Fatalf("NewSQLiteBatchRepository() error = %v", err)
+	}
+	defer repo.Close()
+
+	if _, err := repo.Get("batch-1"); !errors.Is(err, domain.ErrBatchNotFound) {
+		t.Fatalf("Get() error = %v, want ErrBatchNotFound", err)
+	}
+
+	now := time.Now()
+	job := &domain.BatchJob{
+		ID:          "batch-1",
+		Status:      domain.BatchQueued,
+		Client:      "indexer",
+		Concurrency: 2,
+		Total:       2,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+		Items: []domain.BatchItem{
+			{Index: 0, Status: domain.BatchItemPending, Request: domain.AIRequest{Prompt: "Summarize a.go", Model: "llama3"}},
+			{Index: 1, Status: domain.BatchItemPending, Request: domain.AIRequest{Prompt: "Summarize b.go"}},
+		},
+	}
+	if err := repo.Create(job); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	// A snapshot of the job taken before its items finished
+	stale := *job
+	stale.Status = domain.BatchRunning
+
+	// Record one success and one failure
+	succeeded := job.Items[0]
+	succeeded.Status = domain.BatchItemSucceeded
+	succeeded.Response = &domain.AIResponse{Text: "a.go parses flags", Model: "llama3", TokensUsed: 12}
+	succeeded.StartedAt, succeeded.FinishedAt = &now, &now
+	if err := repo.UpdateItem(job.ID, &succeeded); err != nil {
+		t.Fatalf("UpdateItem() error = %v", err)
+	}
+
+	failed := job.Items[1]
+	failed.Status = domain.BatchItemFailed
+	failed.Error = "model not found"
+	if err := repo.UpdateItem(job.ID, &failed); err != nil {
+		t.Fatalf("UpdateItem() error = %v", err)
+	}
+
+	// The counts follow the items even when the stale snapshot lands last
+	if err := repo.UpdateJob(&stale); err != nil {
+		t.Fatalf("UpdateJob() error = %v", err)
+	}
+	got, err := repo.Get(job.ID)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if got.Status != domain.BatchRunning || got.Succeeded != 1 || got.Failed != 1 || got.Cancelled != 0 {
+		t.Errorf("Get() = %+v, want the counts of the stored items", got)
+	}
+
+	job.Status = domain.BatchCompleted
+	job.FinishedAt = &now
+	if err := repo.UpdateJob(job); err != nil {
+		t.Fatalf("UpdateJob() error = %v", err)
+	}
+
+	got, err = repo.Get(job.ID)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if got.Status != domain.BatchCompleted || got.Client != "indexer" || got.Succeeded != 1 || got.Failed != 1 || got.FinishedAt == nil {
+		t.Errorf("Get() = %+v, want the completed job", got)
+	}
+	if len(got.Items) != 2 {
+		t.Fatalf("Items = %+v, want 2 items", got.Items)
+	}
+	if item := got.Items[0]; item.Status != domain.BatchItemSucceeded || item.Response == nil || item.Response.Text != "a.go parses flags" || item.Request.Model != "llama3" || item.StartedAt == nil {
+		t.Errorf("Items[0] = %+v, want the stored response", item)
+	}
+	if item := got.Items[1]; item.Status != domain.BatchItemFailed || item.Error != "model not found" || item.Response != nil {
+		t.Errorf("Items[1] = %+v, want the stored error", item)
+	}
+
+	jobs, err := repo.List()
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(jobs) != 1 || jobs[0].ID != job.ID || jobs[0].Items != nil {
+		t.Errorf("List() = %+v, want the job without items", jobs)
+	}
+
+	missing := failed
+	missing.Index = 7
+	if err := repo.UpdateItem(job.ID, &missing); !errors.Is(err, domain.ErrBatchNotFound) {
+		t.Errorf("UpdateItem() error = %v, want ErrBatchNotFound", err)
+	}
+}
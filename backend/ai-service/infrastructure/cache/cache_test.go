@@ -0,0 +1,102 @@
+package cache_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/augment-local-manus-clone/backend/ai-service/domain"
+	"github.com/augment-local-manus-clone/backend/ai-service/infrastructure/cache"
+)
+
+func TestCacheStores(t *testing.T) {
+	memory, err := cache.NewLRUCache(2)
+	if err != nil {
+		t.Fatalf("NewLRUCache() error = %v", err)
+	}
+
+	disk, err := cache.NewDiskCache(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewDiskCache() error = %v", err)
+	}
+
+	stores := []struct {
+		name  string
+		store domain.CacheStore
+	}{
+		{name: "memory", store: memory},
+		{name: "disk", store: disk},
+	}
+
+	expiresAt := time.Now().Add(time.Hour).Truncate(time.Second)
+
+	for _, tt := range stores {
+		t.Run(tt.name, func(t *testing.T) {
+			store := tt.store
+
+			if err := store.Set("a", &domain.CacheEntry{Value: []byte(`"one"`), ExpiresAt: expiresAt}); err != nil {
+				t.Fatalf("Set() error = %v", err)
+			}
+			if err := store.Set("a", &domain.CacheEntry{Value: []byte(`"two"`), ExpiresAt: expiresAt}); err != nil {
+				t.Fatalf("Set() error = %v", err)
+			}
+
+			entry, err := store.Get("a")
+			if err != nil || entry == nil || string(entry.Value) != `"two"` || !entry.ExpiresAt.Equal(expiresAt) {
+				t.Fatalf("Get() = %+v, %v, want the replaced entry", entry, err)
+			}
+
+			if entry, err := store.Get("missing"); entry != nil || err != nil {
+				t.Errorf("Get(missing) = %+v, %v, want nil", entry, err)
+			}
+
+			store.Set("b", &domain.CacheEntry{Value: []byte(`1`)})
+			if store.Len() != 2 {
+				t.Errorf("Len() = %d, want 2", store.Len())
+			}
+
+			if err := store.Delete("a"); err != nil {
+				t.Fatalf("Delete() error = %v", err)
+			}
+			if entry, _ := store.Get("a"); entry != nil {
+				t.Errorf("Get() after Delete() = %+v, want nil", entry)
+			}
+
+			if err := store.Clear(); err != nil || store.Len() != 0 {
+				t.Errorf("Clear() error = %v, Len() = %d, want 0", err, store.Len())
+			}
+		})
+	}
+}
+
+func TestLRUCacheEviction(t *testing.T) {
+	store, _ := cache.NewLRUCache(2)
+	store.Set("a", &domain.CacheEntry{Value: []byte(`1`)})
+	store.Set("b", &domain.CacheEntry{Value: []byte(`2`)})
+
+	// Reading a makes b the least recently used entry
+	store.Get("a")
+	store.Set("c", &domain.CacheEntry{Value: []byte(`3`)})
+
+	tests := []struct {
+		key  string
+		want bool
+	}{
+		{key: "a", want: true},
+		{key: "b", want: false},
+		{key: "c", want: true},
+	}
+
+	for _, tt := range tests {
+		entry, _ := store.Get(tt.key)
+		if (entry != nil) != tt.want {
+			t.Errorf("Get(%q) present = %v, want %v", tt.key, entry != nil, tt.want)
+		}
+	}
+}
+
+func TestDiskCacheRejectsUnsafeKeys(t *testing.T) {
+	store, _ := cache.NewDiskCache(t.TempDir())
+	if err := store.Set("../escape", &domain.CacheEntry{}); err == nil {
+		t.Error("Set() error = nil, want an invalid key error")
+	}
+}
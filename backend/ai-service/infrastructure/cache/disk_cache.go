@@ -0,0 +1,157 @@
+package cache
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/augment-local-manus-clone/backend/ai-service/domain"
+)
+
+// entrySuffix is the file extension of cache entries
+const entrySuffix = ".json"
+
+// keyPattern restricts keys to names that are safe to use as file names
+var keyPattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// DiskCache implements the CacheStore interface with one JSON file per entry,
+// so cached responses survive restarts
+type DiskCache struct {
+	dir string
+}
+
+// NewDiskCache creates a new DiskCache storing entries in dir, creating it if needed
+func NewDiskCache(dir string) (*DiskCache, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create cache directory: %w", err)
+	}
+
+	return &DiskCache{
+		dir: dir,
+	}, nil
+}
+
+// Get returns the entry stored under key
+func (c *DiskCache) Get(key string) (*domain.CacheEntry, error) {
+	path, err := c.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read cache entry: %w", err)
+	}
+
+	var entry domain.CacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, fmt.Errorf("failed to decode cache entry: %w", err)
+	}
+
+	return &entry, nil
+}
+
+// Set stores an entry, writing it to a temporary file first so that readers
+// never see a partial entry
+func (c *DiskCache) Set(key string, entry *domain.CacheEntry) error {
+	path, err := c.path(key)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to encode cache entry: %w", err)
+	}
+
+	file, err := os.CreateTemp(c.dir, "tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create cache entry: %w", err)
+	}
+	defer os.Remove(file.Name())
+
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+
+	if err := os.Rename(file.Name(), path); err != nil {
+		return fmt.Errorf("failed to store cache entry: %w", err)
+	}
+
+	return nil
+}
+
+// Delete removes the entry stored under key
+func (c *DiskCache) Delete(key string) error {
+	path, err := c.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to delete cache entry: %w", err)
+	}
+
+	return nil
+}
+
+// Clear removes every entry
+func (c *DiskCache) Clear() error {
+	names, err := c.entries()
+	if err != nil {
+		return err
+	}
+
+	for _, name := range names {
+		if err := os.Remove(filepath.Join(c.dir, name)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to delete cache entry: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// Len returns the number of stored entries
+func (c *DiskCache) Len() int {
+	names, err := c.entries()
+	if err != nil {
+		return 0
+	}
+	return len(names)
+}
+
+// entries returns the file names of the stored entries
+func (c *DiskCache) entries() ([]string, error) {
+	dirEntries, err := os.ReadDir(c.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list cache directory: %w", err)
+	}
+
+	var names []string
+	for _, dirEntry := range dirEntries {
+		if !dirEntry.IsDir() && strings.HasSuffix(dirEntry.Name(), entrySuffix) {
+			names = append(names, dirEntry.Name())
+		}
+	}
+	return names, nil
+}
+
+// path returns the file holding the entry for key
+func (c *DiskCache) path(key string) (string, error) {
+	if !keyPattern.MatchString(key) {
+		return "", fmt.Errorf("invalid cache key %q", key)
+	}
+	return filepath.Join(c.dir, key+entrySuffix), nil
+}
@@ -0,0 +1,105 @@
+package cache
+
+import (
+	"container/list"
+	"errors"
+	"sync"
+
+	"github.com/augment-local-manus-clone/backend/ai-service/domain"
+)
+
+// LRUCache implements the CacheStore interface in memory, evicting the least
+// recently used entry once it holds capacity entries
+type LRUCache struct {
+	capacity int
+
+	mu      sync.Mutex
+	order   *list.List
+	entries map[string]*list.Element
+}
+
+// lruItem is the value held by each element of the recency list
+type lruItem struct {
+	key   string
+	entry *domain.CacheEntry
+}
+
+// NewLRUCache creates a new LRUCache holding at most capacity entries
+func NewLRUCache(capacity int) (*LRUCache, error) {
+	if capacity <= 0 {
+		return nil, errors.New("capacity must be positive")
+	}
+
+	return &LRUCache{
+		capacity: capacity,
+		order:    list.New(),
+		entries:  make(map[string]*list.Element),
+	}, nil
+}
+
+// Get returns the entry stored under key and marks it as recently used
+func (c *LRUCache) Get(key string) (*domain.CacheEntry, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.entries[key]
+	if !ok {
+		return nil, nil
+	}
+
+	c.order.MoveToFront(element)
+	return element.Value.(*lruItem).entry, nil
+}
+
+// Set stores an entry, evicting the least recently used entry when full
+func (c *LRUCache) Set(key string, entry *domain.CacheEntry) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if element, ok := c.entries[key]; ok {
+		element.Value.(*lruItem).entry = entry
+		c.order.MoveToFront(element)
+		return nil
+	}
+
+	c.entries[key] = c.order.PushFront(&lruItem{key: key, entry: entry})
+
+	if c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*lruItem).key)
+	}
+
+	return nil
+}
+
+// Delete removes the entry stored under key
+func (c *LRUCache) Delete(key string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if element, ok := c.entries[key]; ok {
+		c.order.Remove(element)
+		delete(c.entries, key)
+	}
+
+	return nil
+}
+
+// Clear removes every entry
+func (c *LRUCache) Clear() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.order.Init()
+	c.entries = make(map[string]*list.Element)
+	return nil
+}
+
+// Len returns the number of stored entries
+func (c *LRUCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.order.Len()
+}
@@ -0,0 +1,97 @@
+package cassette_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/augment-local-manus-clone/backend/ai-service/domain"
+	"github.com/augment-local-manus-clone/backend/ai-service/infrastructure/cassette"
+)
+
+func TestJSONLCassette(t *testing.T) {
+	tests := []struct {
+		name        string
+		contents    string
+		wantMethods []string
+		wantErr     string
+	}{
+		{
+			name:        "Skips blank lines",
+			contents:    `{"method":"process","request":{"prompt":"a"}}` + "\n\n" + `{"method":"chat","request":{"messages":[]}}` + "\n",
+			wantMethods: []string{"process", "chat"},
+		},
+		{
+			name:     "Reports the bad line",
+			contents: `{"method":"process","request":{}}` + "\n" + `{not json` + "\n",
+			wantErr:  "line 2",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "cassette.jsonl")
+			if err := os.WriteFile(path, []byte(tt.contents), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			store, err := cassette.NewJSONLCassette(path)
+			if err != nil {
+				t.Fatalf("NewJSONLCassette() error = %v", err)
+			}
+
+			interactions, err := store.Load()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Load() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+
+			var methods []string
+			for _, interaction := range interactions {
+				methods = append(methods, interaction.Method)
+			}
+			if strings.Join(methods, ",") != strings.Join(tt.wantMethods, ",") {
+				t.Errorf("methods = %v, want %v", methods, tt.wantMethods)
+			}
+		})
+	}
+}
+
+func TestJSONLCassetteAppend(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "recordings", "session.jsonl")
+	store, err := cassette.NewJSONLCassette(path)
+	if err != nil {
+		t.Fatalf("NewJSONLCassette() error = %v", err)
+	}
+
+	for _, prompt := range []string{"first", "second"} {
+		request, _ := json.Marshal(domain.AIRequest{Prompt: prompt})
+		if err := store.Append(&domain.Interaction{Method: domain.InteractionProcess, Request: request, Response: json.RawMessage(`{"text":"ok"}`)}); err != nil {
+			t.Fatalf("Append() error = %v", err)
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Count(string(data), "\n"); lines != 2 {
+		t.Errorf("cassette has %d lines, want 2", lines)
+	}
+
+	interactions, err := store.Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	var request domain.AIRequest
+	if len(interactions) != 2 || json.Unmarshal(interactions[1].Request, &request) != nil || request.Prompt != "second" {
+		t.Errorf("Load() = %+v", interactions)
+	}
+}
@@ -0,0 +1,93 @@
+package cassette
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/augment-local-manus-clone/backend/ai-service/domain"
+)
+
+// maxLineSize bounds the length of one recorded interaction
+const maxLineSize = 64 << 20
+
+// JSONLCassette implements the Cassette interface with a file holding one
+// JSON interaction per line, so that recordings can be diffed and edited by hand
+type JSONLCassette struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewJSONLCassette creates a new JSONLCassette at path, creating its directory if needed
+func NewJSONLCassette(path string) (*JSONLCassette, error) {
+	if path == "" {
+		return nil, errors.New("cassette path cannot be empty")
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create cassette directory: %w", err)
+	}
+
+	return &JSONLCassette{
+		path: path,
+	}, nil
+}
+
+// Append writes an interaction as a new line at the end of the file
+func (c *JSONLCassette) Append(interaction *domain.Interaction) error {
+	data, err := json.Marshal(interaction)
+	if err != nil {
+		return fmt.Errorf("failed to encode interaction: %w", err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	file, err := os.OpenFile(c.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open cassette: %w", err)
+	}
+	defer file.Close()
+
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write interaction: %w", err)
+	}
+
+	return nil
+}
+
+// Load reads every interaction in the file; blank lines are skipped
+func (c *JSONLCassette) Load() ([]domain.Interaction, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	file, err := os.Open(c.path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open cassette: %w", err)
+	}
+	defer file.Close()
+
+	var interactions []domain.Interaction
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		var interaction domain.Interaction
+		if err := json.Unmarshal(scanner.Bytes(), &interaction); err != nil {
+			return nil, fmt.Errorf("line %d: failed to decode interaction: %w", line, err)
+		}
+		interactions = append(interactions, interaction)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read cassette: %w", err)
+	}
+
+	return interactions, nil
+}
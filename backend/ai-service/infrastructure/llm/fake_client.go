@@ -0,0 +1,359 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/augment-local-manus-clone/backend/ai-service/domain"
+)
+
+// fakeModel is the model name reported when a request names none
+const fakeModel = "fake"
+
+// defaultFakeEmbeddingDimensions is the length of the fake's embedding vectors
+// when the script does not set one
+const defaultFakeEmbeddingDimensions = 8
+
+// ErrUnscriptedRequest is returned by FakeLLMClient when no rule matches a
+// request and the script has no default reply
+var ErrUnscriptedRequest = errors.New("no scripted response for request")
+
+// FakeReply is one scripted reply of the fake client
+type FakeReply struct {
+	// Text is the reply's content; a <think> block is split into reasoning
+	// just as for a real model
+	Text      string            `json:"text,omitempty"`
+	Reasoning string            `json:"reasoning,omitempty"`
+	ToolCalls []domain.ToolCall `json:"tool_calls,omitempty"`
+
+	// Error fails the call instead of replying. A message starting with that
+	// of a well-known error, such as "model does not support native tool
+	// calling", returns the matching domain error.
+	Error string `json:"error,omitempty"`
+
+	// LatencyMS delays the reply, or the first chunk of a stream
+	LatencyMS int `json:"latency_ms,omitempty"`
+}
+
+// FakeRule scripts the replies to requests whose prompt matches Pattern. The
+// prompt of a chat request is its last user message.
+type FakeRule struct {
+	// Pattern is a regular expression; an empty pattern matches every request
+	Pattern string `json:"pattern,omitempty"`
+
+	// FakeReply is the rule's reply when Script is empty
+	FakeReply
+
+	// Script lists replies that are played in order, one per matching call,
+	// such as a tool call followed by an answer; the last reply repeats
+	Script []FakeReply `json:"script,omitempty"`
+
+	// Times limits how many calls the rule answers before later rules are
+	// tried; zero means no limit
+	Times int `json:"times,omitempty"`
+}
+
+// FakeScript configures a FakeLLMClient. Rules are tried in order and the
+// first match answers; Default answers requests no rule matches.
+type FakeScript struct {
+	Rules   []FakeRule `json:"rules"`
+	Default *FakeReply `json:"default,omitempty"`
+
+	// EmbeddingDimensions is the length of the embedding vectors; defaults to 8
+	EmbeddingDimensions int `json:"embedding_dimensions,omitempty"`
+}
+
+// FakeCall records a call made to the fake client
+type FakeCall struct {
+	// Method is Process, Chat, ProcessStream or Embed
+	Method string
+	Prompt string
+	Model  string
+}
+
+// fakeRule is a compiled rule with its count of answered calls
+type fakeRule struct {
+	FakeRule
+	pattern *regexp.Regexp
+	calls   int
+}
+
+// FakeLLMClient implements the LLMClient interface with scripted replies, so
+// that the service and its tests can run without a model. It is safe for
+// concurrent use.
+type FakeLLMClient struct {
+	mu         sync.Mutex
+	rules      []*fakeRule
+	fallback   *FakeReply
+	dimensions int
+	calls      []FakeCall
+}
+
+// NewFakeLLMClient creates a FakeLLMClient that replies according to the script
+func NewFakeLLMClient(script FakeScript) (*FakeLLMClient, error) {
+	if script.EmbeddingDimensions < 0 {
+		return nil, errors.New("embedding_dimensions cannot be negative")
+	}
+
+	client := &FakeLLMClient{
+		fallback:   script.Default,
+		dimensions: script.EmbeddingDimensions,
+	}
+	if client.dimensions == 0 {
+		client.dimensions = defaultFakeEmbeddingDimensions
+	}
+
+	for i, rule := range script.Rules {
+		pattern, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("rule %d: invalid pattern: %w", i, err)
+		}
+		if rule.Times < 0 {
+			return nil, fmt.Errorf("rule %d: times cannot be negative", i)
+		}
+		client.rules = append(client.rules, &fakeRule{FakeRule: rule, pattern: pattern})
+	}
+
+	return client, nil
+}
+
+// LoadFakeLLMClient creates a FakeLLMClient from a JSON script fixture
+func LoadFakeLLMClient(path string) (*FakeLLMClient, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var script FakeScript
+	if err := json.Unmarshal(data, &script); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	return NewFakeLLMClient(script)
+}
+
+// Calls returns the calls made so far, in order
+func (c *FakeLLMClient) Calls() []FakeCall {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return append([]FakeCall(nil), c.calls...)
+}
+
+// Process replies to a single-prompt request as a one-message chat
+func (c *FakeLLMClient) Process(ctx context.Context, request *domain.AIRequest) (*domain.AIResponse, error) {
+	reply, err := c.reply(ctx, "Process", request.Prompt, request.Model)
+	if err != nil {
+		return nil, err
+	}
+
+	return fakeChatResponse(reply, request.Prompt, request.Model).ToAIResponse(), nil
+}
+
+// Chat replies to a conversation, matching rules against its last user message
+func (c *FakeLLMClient) Chat(ctx context.Context, request *domain.ChatRequest) (*domain.ChatResponse, error) {
+	prompt := lastUserMessage(request.Messages)
+
+	reply, err := c.reply(ctx, "Chat", prompt, request.Model)
+	if err != nil {
+		return nil, err
+	}
+
+	return fakeChatResponse(reply, prompt, request.Model), nil
+}
+
+// ProcessStream replies to a single-prompt request, passing the reasoning and
+// then each word of the text to the handler as separate chunks
+func (c *FakeLLMClient) ProcessStream(ctx context.Context, request *domain.AIRequest, handler domain.StreamHandler) (*domain.AIResponse, error) {
+	reply, err := c.reply(ctx, "ProcessStream", request.Prompt, request.Model)
+	if err != nil {
+		return nil, err
+	}
+
+	response := fakeChatResponse(reply, request.Prompt, request.Model).ToAIResponse()
+
+	if response.Reasoning != "" {
+		if err := handler(&domain.AIStreamChunk{Reasoning: response.Reasoning}); err != nil {
+			return nil, err
+		}
+	}
+	for _, word := range strings.SplitAfter(response.Text, " ") {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if word == "" {
+			continue
+		}
+		if err := handler(&domain.AIStreamChunk{Text: word}); err != nil {
+			return nil, err
+		}
+	}
+
+	return response, nil
+}
+
+// Embed returns a deterministic unit vector for each input, derived from a
+// hash of its words, so that texts sharing words are similar
+func (c *FakeLLMClient) Embed(ctx context.Context, request *domain.EmbedRequest) (*domain.EmbedResponse, error) {
+	c.record("Embed", strings.Join(request.Input, "\n"), request.Model)
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	embeddings := make([][]float64, len(request.Input))
+	tokens := 0
+	for i, text := range request.Input {
+		embeddings[i] = c.embed(text)
+		tokens += len(strings.Fields(text))
+	}
+
+	return &domain.EmbedResponse{
+		Embeddings: embeddings,
+		Model:      fakeModelFor(request.Model),
+		TokensUsed: tokens,
+	}, nil
+}
+
+// reply records the call, picks the reply for the prompt and waits out its latency
+func (c *FakeLLMClient) reply(ctx context.Context, method, prompt, model string) (*FakeReply, error) {
+	c.record(method, prompt, model)
+
+	reply := c.match(prompt)
+	if reply == nil {
+		return nil, fmt.Errorf("%w: %q", ErrUnscriptedRequest, prompt)
+	}
+
+	if reply.LatencyMS > 0 {
+		timer := time.NewTimer(time.Duration(reply.LatencyMS) * time.Millisecond)
+		defer timer.Stop()
+
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	} else if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if reply.Error != "" {
+		return nil, domain.RecordedError(reply.Error)
+	}
+
+	return reply, nil
+}
+
+// match returns the reply of the first rule that matches the prompt and has
+// calls left, advancing the rule's script, or the default reply
+func (c *FakeLLMClient) match(prompt string) *FakeReply {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, rule := range c.rules {
+		if rule.Times > 0 && rule.calls >= rule.Times {
+			continue
+		}
+		if !rule.pattern.MatchString(prompt) {
+			continue
+		}
+
+		rule.calls++
+		if len(rule.Script) == 0 {
+			return &rule.FakeReply
+		}
+		step := rule.calls - 1
+		if step >= len(rule.Script) {
+			step = len(rule.Script) - 1
+		}
+		return &rule.Script[step]
+	}
+
+	return c.fallback
+}
+
+// record appends a call to the call log
+func (c *FakeLLMClient) record(method, prompt, model string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.calls = append(c.calls, FakeCall{Method: method, Prompt: prompt, Model: model})
+}
+
+// embed hashes each word of the text into one of the vector's dimensions and normalises the result
+func (c *FakeLLMClient) embed(text string) []float64 {
+	vector := make([]float64, c.dimensions)
+	for _, word := range strings.Fields(strings.ToLower(text)) {
+		hash := fnv.New32a()
+		_, _ = hash.Write([]byte(word))
+		vector[hash.Sum32()%uint32(c.dimensions)]++
+	}
+
+	var norm float64
+	for _, v := range vector {
+		norm += v * v
+	}
+	if norm == 0 {
+		// Keep the vector usable for cosine similarity
+		vector[0] = 1
+		return vector
+	}
+
+	norm = math.Sqrt(norm)
+	for i := range vector {
+		vector[i] /= norm
+	}
+	return vector
+}
+
+// fakeChatResponse builds the chat response for a scripted reply, counting
+// words as tokens
+func fakeChatResponse(reply *FakeReply, prompt, model string) *domain.ChatResponse {
+	reasoning, content := splitReasoning(reply.Reasoning, reply.Text)
+
+	promptTokens := len(strings.Fields(prompt))
+	completionTokens := len(strings.Fields(reasoning)) + len(strings.Fields(content))
+
+	return &domain.ChatResponse{
+		Message: domain.ChatMessage{
+			Role:      domain.ChatRoleAssistant,
+			Content:   content,
+			ToolCalls: append([]domain.ToolCall(nil), reply.ToolCalls...),
+		},
+		Reasoning:  reasoning,
+		TokensUsed: promptTokens + completionTokens,
+		Usage: &domain.TokenUsage{
+			PromptTokens:     promptTokens,
+			CompletionTokens: completionTokens,
+			TotalTokens:      promptTokens + completionTokens,
+		},
+		Model:   fakeModelFor(model),
+		Elapsed: (time.Duration(reply.LatencyMS) * time.Millisecond).Seconds(),
+	}
+}
+
+// fakeModelFor returns the requested model, or the fake's model name when none was requested
+func fakeModelFor(requested string) string {
+	if requested != "" {
+		return requested
+	}
+	return fakeModel
+}
+
+// lastUserMessage returns the content of the conversation's last user message
+func lastUserMessage(messages []domain.ChatMessage) string {
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Role == domain.ChatRoleUser {
+			return messages[i].Content
+		}
+	}
+	return ""
+}
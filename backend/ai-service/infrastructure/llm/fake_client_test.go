@@ -0,0 +1,237 @@
+package llm_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/augment-local-manus-clone/backend/ai-service/domain"
+	"github.com/augment-local-manus-clone/backend/ai-service/infrastructure/llm"
+)
+
+func TestFakeLLMClientChat(t *testing.T) {
+	script := llm.FakeScript{
+		Rules: []llm.FakeRule{
+			{Pattern: `(?i)^hello`, FakeReply: llm.FakeReply{Text: "Hi there"}, Times: 1},
+			{Pattern: `(?i)list the files`, Script: []llm.FakeReply{
+				{ToolCalls: []domain.ToolCall{{Name: "list_files", Arguments: map[string]interface{}{"path": "."}}}},
+				{Text: "There are two files."},
+			}},
+			{Pattern: `tools please`, FakeReply: llm.FakeReply{Error: domain.ErrToolsNotSupported.Error()}},
+			{Pattern: `explode`, FakeReply: llm.FakeReply{Error: "boom"}},
+			{Pattern: `think`, FakeReply: llm.FakeReply{Text: "<think>Let me see.</think>42"}},
+		},
+	}
+
+	tests := []struct {
+		name          string
+		prompts       []string
+		wantContent   []string
+		wantReasoning string
+		wantToolCalls []string
+		wantErr       error
+		wantErrText   string
+	}{
+		{
+			name:        "Matches by regex",
+			prompts:     []string{"Hello model"},
+			wantContent: []string{"Hi there"},
+		},
+		{
+			name:        "Exhausted rule falls through",
+			prompts:     []string{"hello again"},
+			wantErr:     llm.ErrUnscriptedRequest,
+			wantContent: []string{},
+		},
+		{
+			name:          "Tool-call script plays in order",
+			prompts:       []string{"Please list the files", "Please list the files", "Please list the files"},
+			wantContent:   []string{"", "There are two files.", "There are two files."},
+			wantToolCalls: []string{"list_files", "", ""},
+		},
+		{
+			name:        "Known error returns the sentinel",
+			prompts:     []string{"tools please"},
+			wantErr:     domain.ErrToolsNotSupported,
+			wantContent: []string{},
+		},
+		{
+			name:        "Custom error",
+			prompts:     []string{"explode"},
+			wantErrText: "boom",
+			wantContent: []string{},
+		},
+		{
+			name:          "Splits reasoning",
+			prompts:       []string{"think hard"},
+			wantContent:   []string{"42"},
+			wantReasoning: "Let me see.",
+		},
+	}
+
+	client, err := llm.NewFakeLLMClient(script)
+	if err != nil {
+		t.Fatalf("NewFakeLLMClient() error = %v", err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			content := []string{}
+			var toolCalls []string
+			var reasoning string
+			for _, prompt := range tt.prompts {
+				resp, err := client.Chat(context.Background(), &domain.ChatRequest{
+					Messages: []domain.ChatMessage{
+						{Role: domain.ChatRoleSystem, Content: "You are helpful."},
+						{Role: domain.ChatRoleUser, Content: prompt},
+					},
+				})
+				if tt.wantErr != nil || tt.wantErrText != "" {
+					if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+						t.Fatalf("Chat() error = %v, want %v", err, tt.wantErr)
+					}
+					if tt.wantErrText != "" && (err == nil || err.Error() != tt.wantErrText) {
+						t.Fatalf("Chat() error = %v, want %q", err, tt.wantErrText)
+					}
+					continue
+				}
+				if err != nil {
+					t.Fatalf("Chat() error = %v", err)
+				}
+
+				content = append(content, resp.Message.Content)
+				reasoning = resp.Reasoning
+				if tt.wantToolCalls != nil {
+					name := ""
+					if len(resp.Message.ToolCalls) > 0 {
+						name = resp.Message.ToolCalls[0].Name
+					}
+					toolCalls = append(toolCalls, name)
+				}
+			}
+
+			if !reflect.DeepEqual(content, tt.wantContent) {
+				t.Errorf("content = %q, want %q", content, tt.wantContent)
+			}
+			if !reflect.DeepEqual(toolCalls, tt.wantToolCalls) {
+				t.Errorf("tool calls = %q, want %q", toolCalls, tt.wantToolCalls)
+			}
+			if reasoning != tt.wantReasoning {
+				t.Errorf("reasoning = %q, want %q", reasoning, tt.wantReasoning)
+			}
+		})
+	}
+}
+
+func TestFakeLLMClientLatency(t *testing.T) {
+	client, err := llm.NewFakeLLMClient(llm.FakeScript{
+		Default: &llm.FakeReply{Text: "slow", LatencyMS: 5000},
+	})
+	if err != nil {
+		t.Fatalf("NewFakeLLMClient() error = %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err = client.Process(ctx, &domain.AIRequest{Prompt: "anything"})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Process() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Process() took %v after its deadline", elapsed)
+	}
+}
+
+func TestFakeLLMClientProcessStream(t *testing.T) {
+	client, err := llm.NewFakeLLMClient(llm.FakeScript{
+		Default: &llm.FakeReply{Text: "one two three", Reasoning: "counting"},
+	})
+	if err != nil {
+		t.Fatalf("NewFakeLLMClient() error = %v", err)
+	}
+
+	var chunks []string
+	resp, err := client.ProcessStream(context.Background(), &domain.AIRequest{Prompt: "count", Model: "tiny"}, func(chunk *domain.AIStreamChunk) error {
+		chunks = append(chunks, chunk.Reasoning+"|"+chunk.Text)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ProcessStream() error = %v", err)
+	}
+
+	wantChunks := []string{"counting|", "|one ", "|two ", "|three"}
+	if !reflect.DeepEqual(chunks, wantChunks) {
+		t.Errorf("chunks = %q, want %q", chunks, wantChunks)
+	}
+	if resp.Text != "one two three" || resp.Model != "tiny" || resp.Usage.PromptTokens != 1 || resp.Usage.CompletionTokens != 4 {
+		t.Errorf("response = %+v, usage = %+v", resp, resp.Usage)
+	}
+
+	calls := client.Calls()
+	if len(calls) != 1 || calls[0].Method != "ProcessStream" || calls[0].Prompt != "count" {
+		t.Errorf("Calls() = %+v", calls)
+	}
+}
+
+func TestFakeLLMClientEmbed(t *testing.T) {
+	client, err := llm.NewFakeLLMClient(llm.FakeScript{EmbeddingDimensions: 16})
+	if err != nil {
+		t.Fatalf("NewFakeLLMClient() error = %v", err)
+	}
+
+	embed := func(text string) []float64 {
+		resp, err := client.Embed(context.Background(), &domain.EmbedRequest{Input: []string{text}})
+		if err != nil {
+			t.Fatalf("Embed() error = %v", err)
+		}
+		return resp.Embeddings[0]
+	}
+
+	first := embed("the quick brown fox")
+	if len(first) != 16 {
+		t.Fatalf("len(embedding) = %d, want 16", len(first))
+	}
+	if !reflect.DeepEqual(first, embed("The quick brown fox")) {
+		t.Error("embeddings of the same words differ")
+	}
+}
+
+func TestLoadFakeLLMClient(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "script.json")
+	fixture := `{
+		"rules": [{"pattern": "weather", "text": "It is sunny."}],
+		"default": {"text": "I don't know."}
+	}`
+	if err := os.WriteFile(path, []byte(fixture), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	client, err := llm.LoadFakeLLMClient(path)
+	if err != nil {
+		t.Fatalf("LoadFakeLLMClient() error = %v", err)
+	}
+
+	for prompt, want := range map[string]string{
+		"What is the weather?": "It is sunny.",
+		"Who are you?":         "I don't know.",
+	} {
+		resp, err := client.Process(context.Background(), &domain.AIRequest{Prompt: prompt})
+		if err != nil {
+			t.Fatalf("Process(%q) error = %v", prompt, err)
+		}
+		if resp.Text != want {
+			t.Errorf("Process(%q) = %q, want %q", prompt, resp.Text, want)
+		}
+	}
+
+	if _, err := llm.NewFakeLLMClient(llm.FakeScript{Rules: []llm.FakeRule{{Pattern: "("}}}); err == nil || !strings.Contains(err.Error(), "invalid pattern") {
+		t.Errorf("NewFakeLLMClient() with a bad pattern error = %v", err)
+	}
+}
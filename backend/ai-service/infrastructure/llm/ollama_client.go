@@ -2,9 +2,15 @@ package llm
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"reflect"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/augment-local-manus-clone/backend/ai-service/domain"
@@ -12,31 +18,134 @@ import (
 
 // OllamaClient implements the LLMClient interface using Ollama
 type OllamaClient struct {
-	baseURL string
-	model   string
-	client  *http.Client
+	baseURL    string
+	model      string
+	embedModel string
+
+	// client has no overall timeout since generation can legitimately run for
+	// a long time; requests are bounded by their context instead, so that
+	// cancelling a request stops the model
+	client *http.Client
+
+	// vision remembers whether each model reads images
+	mu     sync.Mutex
+	vision map[string]bool
 }
 
 // ollamaRequest represents a request to the Ollama API
 type ollamaRequest struct {
-	Model       string  `json:"model"`
-	Prompt      string  `json:"prompt"`
-	Stream      bool    `json:"stream"`
-	MaxTokens   int     `json:"max_tokens,omitempty"`
-	Temperature float64 `json:"temperature,omitempty"`
+	Model   string         `json:"model"`
+	Prompt  string         `json:"prompt"`
+	Images  []string       `json:"images,omitempty"`
+	Stream  bool           `json:"stream"`
+	Options *ollamaOptions `json:"options,omitempty"`
+}
+
+// ollamaOptions represents the model parameters of an Ollama request; Ollama
+// ignores sampling parameters outside this object
+type ollamaOptions struct {
+	NumPredict    *int     `json:"num_predict,omitempty"`
+	Temperature   *float64 `json:"temperature,omitempty"`
+	TopP          *float64 `json:"top_p,omitempty"`
+	TopK          *int     `json:"top_k,omitempty"`
+	Seed          *int     `json:"seed,omitempty"`
+	Stop          []string `json:"stop,omitempty"`
+	RepeatPenalty *float64 `json:"repeat_penalty,omitempty"`
+	NumCtx        *int     `json:"num_ctx,omitempty"`
 }
 
 // ollamaResponse represents a response from the Ollama API
 type ollamaResponse struct {
-	Model     string  `json:"model"`
-	Response  string  `json:"response"`
-	Done      bool    `json:"done"`
-	TokensUsed int     `json:"tokens_used,omitempty"`
-	Elapsed   float64 `json:"elapsed,omitempty"`
+	Model    string `json:"model"`
+	Response string `json:"response"`
+	Done     bool   `json:"done"`
+
+	// Thinking is set when Ollama parses the model's reasoning itself
+	Thinking string `json:"thinking,omitempty"`
+
+	// Populated on the final message of a stream
+	PromptEvalCount int   `json:"prompt_eval_count,omitempty"`
+	EvalCount       int   `json:"eval_count,omitempty"`
+	TotalDuration   int64 `json:"total_duration,omitempty"`
+
+	// Populated when Ollama reports an error mid-stream
+	Error string `json:"error,omitempty"`
+}
+
+// ollamaChatRequest represents a request to the Ollama chat API
+type ollamaChatRequest struct {
+	Model    string              `json:"model"`
+	Messages []ollamaChatMessage `json:"messages"`
+	Tools    []ollamaTool        `json:"tools,omitempty"`
+	Format   domain.JSONSchema   `json:"format,omitempty"`
+	Stream   bool                `json:"stream"`
+	Options  *ollamaOptions      `json:"options,omitempty"`
+}
+
+// ollamaChatMessage represents a message exchanged with the Ollama chat API
+type ollamaChatMessage struct {
+	Role      string           `json:"role"`
+	Content   string           `json:"content"`
+	Images    []string         `json:"images,omitempty"`
+	Thinking  string           `json:"thinking,omitempty"`
+	ToolCalls []ollamaToolCall `json:"tool_calls,omitempty"`
+	ToolName  string           `json:"tool_name,omitempty"`
+}
+
+// ollamaTool represents a tool definition in the Ollama chat API
+type ollamaTool struct {
+	Type     string             `json:"type"`
+	Function ollamaToolFunction `json:"function"`
+}
+
+// ollamaToolFunction describes a callable function in the Ollama chat API
+type ollamaToolFunction struct {
+	Name        string            `json:"name"`
+	Description string            `json:"description"`
+	Parameters  domain.JSONSchema `json:"parameters,omitempty"`
+}
+
+// ollamaToolCall represents a tool invocation in the Ollama chat API
+type ollamaToolCall struct {
+	Function struct {
+		Name string `json:"name"`
+
+		// Arguments is normally an object, but some models emit it as a JSON-encoded string
+		Arguments json.RawMessage `json:"arguments"`
+	} `json:"function"`
 }
 
-// NewOllamaClient creates a new OllamaClient
-func NewOllamaClient(baseURL, model string) (*OllamaClient, error) {
+// ollamaError represents an error body returned by the Ollama API
+type ollamaError struct {
+	Error string `json:"error"`
+}
+
+// ollamaChatResponse represents a response from the Ollama chat API
+type ollamaChatResponse struct {
+	Model           string            `json:"model"`
+	Message         ollamaChatMessage `json:"message"`
+	Done            bool              `json:"done"`
+	PromptEvalCount int               `json:"prompt_eval_count,omitempty"`
+	EvalCount       int               `json:"eval_count,omitempty"`
+	TotalDuration   int64             `json:"total_duration,omitempty"`
+}
+
+// ollamaEmbedRequest represents a request to the Ollama embed API
+type ollamaEmbedRequest struct {
+	Model string   `json:"model"`
+	Input []string `json:"input"`
+}
+
+// ollamaEmbedResponse represents a response from the Ollama embed API
+type ollamaEmbedResponse struct {
+	Model           string      `json:"model"`
+	Embeddings      [][]float64 `json:"embeddings"`
+	PromptEvalCount int         `json:"prompt_eval_count,omitempty"`
+}
+
+// NewOllamaClient creates a new OllamaClient. embedModel is the default
+// embedding model and may be empty if embed requests always name a model.
+func NewOllamaClient(baseURL, model, embedModel string) (*OllamaClient, error) {
 	if baseURL == "" {
 		return nil, fmt.Errorf("baseURL cannot be empty")
 	}
@@ -46,23 +155,55 @@ func NewOllamaClient(baseURL, model string) (*OllamaClient, error) {
 	}
 
 	return &OllamaClient{
-		baseURL: baseURL,
-		model:   model,
-		client: &http.Client{
-			Timeout: 60 * time.Second,
-		},
+		baseURL:    baseURL,
+		model:      model,
+		embedModel: embedModel,
+		client:     &http.Client{},
+		vision:     make(map[string]bool),
 	}, nil
 }
 
-// Process sends a request to the Ollama API and returns a response
-func (c *OllamaClient) Process(request *domain.AIRequest) (*domain.AIResponse, error) {
+// Process sends a single-prompt request to the Ollama API as a one-message chat
+func (c *OllamaClient) Process(ctx context.Context, request *domain.AIRequest) (*domain.AIResponse, error) {
+	chatResp, err := c.Chat(ctx, request.ToChatRequest())
+	if err != nil {
+		return nil, err
+	}
+
+	return chatResp.ToAIResponse(), nil
+}
+
+// Chat sends a conversation to the Ollama chat API and returns the model's reply
+func (c *OllamaClient) Chat(ctx context.Context, request *domain.ChatRequest) (*domain.ChatResponse, error) {
 	// Create Ollama request
-	ollamaReq := ollamaRequest{
-		Model:       c.model,
-		Prompt:      request.Prompt,
-		Stream:      false,
-		MaxTokens:   request.MaxTokens,
-		Temperature: request.Temperature,
+	ollamaReq := ollamaChatRequest{
+		Model:    c.modelFor(request.Model),
+		Messages: make([]ollamaChatMessage, 0, len(request.Messages)),
+		Format:   request.ResponseSchema,
+		Stream:   false,
+		Options:  newOllamaOptions(request.GenerationOptions()),
+	}
+	if request.HasImages() {
+		if err := c.checkImages(ctx, ollamaReq.Model); err != nil {
+			return nil, err
+		}
+	}
+	for _, message := range request.Messages {
+		ollamaMsg, err := toOllamaChatMessage(message)
+		if err != nil {
+			return nil, err
+		}
+		ollamaReq.Messages = append(ollamaReq.Messages, ollamaMsg)
+	}
+	for _, tool := range request.Tools {
+		ollamaReq.Tools = append(ollamaReq.Tools, ollamaTool{
+			Type: "function",
+			Function: ollamaToolFunction{
+				Name:        tool.Name,
+				Description: tool.Description,
+				Parameters:  tool.Parameters,
+			},
+		})
 	}
 
 	// Convert request to JSON
@@ -72,7 +213,77 @@ func (c *OllamaClient) Process(request *domain.AIRequest) (*domain.AIResponse, e
 	}
 
 	// Create HTTP request
-	req, err := http.NewRequest("POST", fmt.Sprintf("%s/api/generate", c.baseURL), bytes.NewBuffer(reqBody))
+	req, err := http.NewRequestWithContext(ctx, "POST", fmt.Sprintf("%s/api/chat", c.baseURL), bytes.NewBuffer(reqBody))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	// Send request
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// Check response status
+	if resp.StatusCode != http.StatusOK {
+		var ollamaErr ollamaError
+		_ = json.NewDecoder(resp.Body).Decode(&ollamaErr)
+		if len(request.Tools) > 0 && strings.Contains(ollamaErr.Error, "does not support tools") {
+			return nil, fmt.Errorf("%w: %s", domain.ErrToolsNotSupported, ollamaErr.Error)
+		}
+		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	// Decode response
+	var ollamaResp ollamaChatResponse
+	if err := json.NewDecoder(resp.Body).Decode(&ollamaResp); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	toolCalls, err := fromOllamaToolCalls(ollamaResp.Message.ToolCalls)
+	if err != nil {
+		return nil, err
+	}
+
+	reasoning, content := splitReasoning(ollamaResp.Message.Thinking, ollamaResp.Message.Content)
+
+	// Create chat response
+	chatResp := &domain.ChatResponse{
+		Message: domain.ChatMessage{
+			Role:      domain.ChatRole(ollamaResp.Message.Role),
+			Content:   content,
+			ToolCalls: toolCalls,
+		},
+		Reasoning:  reasoning,
+		TokensUsed: ollamaResp.PromptEvalCount + ollamaResp.EvalCount,
+		Usage:      ollamaUsage(ollamaResp.PromptEvalCount, ollamaResp.EvalCount),
+		Model:      ollamaResp.Model,
+		Elapsed:    time.Duration(ollamaResp.TotalDuration).Seconds(),
+	}
+
+	return chatResp, nil
+}
+
+// Embed sends texts to the Ollama embed API and returns their embeddings
+func (c *OllamaClient) Embed(ctx context.Context, request *domain.EmbedRequest) (*domain.EmbedResponse, error) {
+	model := request.Model
+	if model == "" {
+		model = c.embedModel
+	}
+	if model == "" {
+		return nil, errors.New("no embedding model configured")
+	}
+
+	// Convert request to JSON
+	reqBody, err := json.Marshal(ollamaEmbedRequest{Model: model, Input: request.Input})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	// Create HTTP request
+	req, err := http.NewRequestWithContext(ctx, "POST", fmt.Sprintf("%s/api/embed", c.baseURL), bytes.NewBuffer(reqBody))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -91,18 +302,187 @@ func (c *OllamaClient) Process(request *domain.AIRequest) (*domain.AIResponse, e
 	}
 
 	// Decode response
-	var ollamaResp ollamaResponse
+	var ollamaResp ollamaEmbedResponse
 	if err := json.NewDecoder(resp.Body).Decode(&ollamaResp); err != nil {
 		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
 
-	// Create AI response
-	aiResp := &domain.AIResponse{
-		Text:       ollamaResp.Response,
-		TokensUsed: ollamaResp.TokensUsed,
+	if len(ollamaResp.Embeddings) != len(request.Input) {
+		return nil, fmt.Errorf("expected %d embeddings, got %d", len(request.Input), len(ollamaResp.Embeddings))
+	}
+
+	return &domain.EmbedResponse{
+		Embeddings: ollamaResp.Embeddings,
 		Model:      ollamaResp.Model,
-		Elapsed:    ollamaResp.Elapsed,
+		TokensUsed: ollamaResp.PromptEvalCount,
+	}, nil
+}
+
+// newOllamaOptions converts generation options to Ollama's options object,
+// or nil when none are set
+func newOllamaOptions(options domain.GenerationOptions) *ollamaOptions {
+	converted := ollamaOptions(options)
+	if reflect.ValueOf(converted).IsZero() {
+		return nil
+	}
+	return &converted
+}
+
+// ollamaUsage converts Ollama's evaluation counts into token usage
+func ollamaUsage(promptEvalCount, evalCount int) *domain.TokenUsage {
+	return &domain.TokenUsage{
+		PromptTokens:     promptEvalCount,
+		CompletionTokens: evalCount,
+		TotalTokens:      promptEvalCount + evalCount,
+	}
+}
+
+// modelFor returns the requested model, or the client's default when none was requested
+func (c *OllamaClient) modelFor(requested string) string {
+	if requested != "" {
+		return requested
+	}
+	return c.model
+}
+
+// toOllamaChatMessage converts a domain chat message to the Ollama wire format
+func toOllamaChatMessage(message domain.ChatMessage) (ollamaChatMessage, error) {
+	ollamaMsg := ollamaChatMessage{
+		Role:     string(message.Role),
+		Content:  message.Content,
+		Images:   message.Images,
+		ToolName: message.ToolName,
+	}
+
+	for _, call := range message.ToolCalls {
+		arguments, err := json.Marshal(call.Arguments)
+		if err != nil {
+			return ollamaChatMessage{}, fmt.Errorf("failed to marshal tool arguments: %w", err)
+		}
+
+		var ollamaCall ollamaToolCall
+		ollamaCall.Function.Name = call.Name
+		ollamaCall.Function.Arguments = arguments
+		ollamaMsg.ToolCalls = append(ollamaMsg.ToolCalls, ollamaCall)
+	}
+
+	return ollamaMsg, nil
+}
+
+// fromOllamaToolCalls converts tool calls returned by Ollama to domain tool calls
+func fromOllamaToolCalls(calls []ollamaToolCall) ([]domain.ToolCall, error) {
+	var toolCalls []domain.ToolCall
+
+	for _, call := range calls {
+		arguments := map[string]interface{}{}
+
+		raw := bytes.TrimSpace(call.Function.Arguments)
+		if len(raw) > 0 && raw[0] == '"' {
+			var encoded string
+			if err := json.Unmarshal(raw, &encoded); err != nil {
+				return nil, fmt.Errorf("failed to decode tool arguments: %w", err)
+			}
+			raw = []byte(encoded)
+		}
+		if len(raw) > 0 && !bytes.Equal(raw, []byte("null")) {
+			if err := json.Unmarshal(raw, &arguments); err != nil {
+				return nil, fmt.Errorf("failed to decode tool arguments: %w", err)
+			}
+		}
+
+		toolCalls = append(toolCalls, domain.ToolCall{
+			Name:      call.Function.Name,
+			Arguments: arguments,
+		})
+	}
+
+	return toolCalls, nil
+}
+
+// ProcessStream sends a streaming request to the Ollama API, relaying each
+// NDJSON chunk to the handler, and returns the assembled response
+func (c *OllamaClient) ProcessStream(ctx context.Context, request *domain.AIRequest, handler domain.StreamHandler) (*domain.AIResponse, error) {
+	// Create Ollama request
+	ollamaReq := ollamaRequest{
+		Model:   c.modelFor(request.Model),
+		Prompt:  request.Prompt,
+		Images:  request.Images,
+		Stream:  true,
+		Options: newOllamaOptions(request.GenerationOptions()),
+	}
+
+	if len(request.Images) > 0 {
+		if err := c.checkImages(ctx, ollamaReq.Model); err != nil {
+			return nil, err
+		}
+	}
+
+	// Convert request to JSON
+	reqBody, err := json.Marshal(ollamaReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	return aiResp, nil
+	// Create HTTP request bound to the caller's context
+	req, err := http.NewRequestWithContext(ctx, "POST", fmt.Sprintf("%s/api/generate", c.baseURL), bytes.NewBuffer(reqBody))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	// Send request
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// Check response status
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	// Ollama streams one JSON object per line until a message with done set
+	stream := newReasoningStream(handler)
+	decoder := json.NewDecoder(resp.Body)
+	for {
+		var chunk ollamaResponse
+		if err := decoder.Decode(&chunk); err != nil {
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return nil, ctxErr
+			}
+			if errors.Is(err, io.EOF) {
+				return nil, errors.New("stream ended before completion")
+			}
+			return nil, fmt.Errorf("failed to decode stream chunk: %w", err)
+		}
+
+		if chunk.Error != "" {
+			return nil, fmt.Errorf("ollama error: %s", chunk.Error)
+		}
+
+		if err := stream.native(chunk.Thinking); err != nil {
+			return nil, err
+		}
+
+		if err := stream.content(chunk.Response); err != nil {
+			return nil, err
+		}
+
+		if chunk.Done {
+			if err := stream.finish(); err != nil {
+				return nil, err
+			}
+
+			reasoning, text := stream.result()
+			return &domain.AIResponse{
+				Text:       text,
+				Reasoning:  reasoning,
+				TokensUsed: chunk.PromptEvalCount + chunk.EvalCount,
+				Usage:      ollamaUsage(chunk.PromptEvalCount, chunk.EvalCount),
+				Model:      chunk.Model,
+				Elapsed:    time.Duration(chunk.TotalDuration).Seconds(),
+			}, nil
+		}
+	}
 }
@@ -0,0 +1,596 @@
+package llm_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/augment-local-manus-clone/backend/ai-service/domain"
+	"github.com/augment-local-manus-clone/backend/ai-service/infrastructure/llm"
+)
+
+func TestOllamaClientProcessStream(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     int
+		body       string
+		wantChunks []string
+		wantText   string
+		wantTokens int
+		wantErr    bool
+	}{
+		{
+			name:   "Complete stream",
+			status: http.StatusOK,
+			body: `{"model":"m","response":"Hel","done":false}
+{"model":"m","response":"lo","done":false}
+{"model":"m","response":"","done":true,"prompt_eval_count":3,"eval_count":2,"total_duration":1500000000}
+`,
+			wantChunks: []string{"Hel", "lo"},
+			wantText:   "Hello",
+			wantTokens: 5,
+			wantErr:    false,
+		},
+		{
+			name:   "Error mid-stream",
+			status: http.StatusOK,
+			body: `{"model":"m","response":"Hel","done":false}
+{"error":"model crashed"}
+`,
+			wantChunks: []string{"Hel"},
+			wantErr:    true,
+		},
+		{
+			name:       "Stream ends early",
+			status:     http.StatusOK,
+			body:       `{"model":"m","response":"Hel","done":false}` + "\n",
+			wantChunks: []string{"Hel"},
+			wantErr:    true,
+		},
+		{
+			name:    "Unexpected status",
+			status:  http.StatusNotFound,
+			body:    `{"error":"model not found"}`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var req map[string]interface{}
+				if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+					t.Errorf("failed to decode request: %v", err)
+				}
+				if req["stream"] != true {
+					t.Errorf("request stream = %v, want true", req["stream"])
+				}
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			client, err := llm.NewOllamaClient(server.URL, "m", "")
+			if err != nil {
+				t.Fatalf("NewOllamaClient() error = %v", err)
+			}
+
+			var chunks []string
+			resp, err := client.ProcessStream(context.Background(), &domain.AIRequest{Prompt: "hi"}, func(chunk *domain.AIStreamChunk) error {
+				chunks = append(chunks, chunk.Text)
+				return nil
+			})
+
+			if strings.Join(chunks, "|") != strings.Join(tt.wantChunks, "|") {
+				t.Errorf("chunks = %v, want %v", chunks, tt.wantChunks)
+			}
+
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("ProcessStream() error = nil, wantErr %v", tt.wantErr)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("ProcessStream() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if resp.Text != tt.wantText {
+				t.Errorf("Text = %q, want %q", resp.Text, tt.wantText)
+			}
+
+			if resp.TokensUsed != tt.wantTokens {
+				t.Errorf("TokensUsed = %v, want %v", resp.TokensUsed, tt.wantTokens)
+			}
+		})
+	}
+}
+
+func TestOllamaClientChat(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/chat" {
+			t.Errorf("path = %s, want /api/chat", r.URL.Path)
+		}
+
+		var req struct {
+			Messages []struct {
+				Role    string `json:"role"`
+				Content string `json:"content"`
+			} `json:"messages"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("failed to decode request: %v", err)
+		}
+
+		// Echo the number of messages and the last message back
+		last := req.Messages[len(req.Messages)-1]
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"model":             "m",
+			"message":           map[string]string{"role": "assistant", "content": last.Role + ":" + last.Content},
+			"done":              true,
+			"prompt_eval_count": len(req.Messages),
+			"eval_count":        1,
+		})
+	}))
+	defer server.Close()
+
+	client, err := llm.NewOllamaClient(server.URL, "m", "")
+	if err != nil {
+		t.Fatalf("NewOllamaClient() error = %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		call       func() (string, int, error)
+		wantText   string
+		wantTokens int
+	}{
+		{
+			name: "Chat",
+			call: func() (string, int, error) {
+				resp, err := client.Chat(context.Background(), &domain.ChatRequest{
+					Messages: []domain.ChatMessage{
+						{Role: domain.ChatRoleSystem, Content: "Be brief."},
+						{Role: domain.ChatRoleUser, Content: "Hello"},
+					},
+				})
+				if err != nil {
+					return "", 0, err
+				}
+				return resp.Message.Content, resp.TokensUsed, nil
+			},
+			wantText:   "user:Hello",
+			wantTokens: 3,
+		},
+		{
+			name: "Process wraps chat",
+			call: func() (string, int, error) {
+				resp, err := client.Process(context.Background(), &domain.AIRequest{Prompt: "Hi"})
+				if err != nil {
+					return "", 0, err
+				}
+				return resp.Text, resp.TokensUsed, nil
+			},
+			wantText:   "user:Hi",
+			wantTokens: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			text, tokens, err := tt.call()
+			if err != nil {
+				t.Fatalf("error = %v", err)
+			}
+
+			if text != tt.wantText {
+				t.Errorf("text = %q, want %q", text, tt.wantText)
+			}
+
+			if tokens != tt.wantTokens {
+				t.Errorf("tokens = %v, want %v", tokens, tt.wantTokens)
+			}
+		})
+	}
+}
+
+func TestOllamaClientChatTools(t *testing.T) {
+	tools := []domain.ToolDefinition{{Name: "browse", Description: "Open a web page"}}
+
+	tests := []struct {
+		name            string
+		status          int
+		body            string
+		wantArguments   map[string]interface{}
+		wantUnsupported bool
+	}{
+		{
+			name:          "Object arguments",
+			status:        http.StatusOK,
+			body:          `{"model":"m","message":{"role":"assistant","content":"","tool_calls":[{"function":{"name":"browse","arguments":{"url":"https://example.com"}}}]},"done":true}`,
+			wantArguments: map[string]interface{}{"url": "https://example.com"},
+		},
+		{
+			name:          "String-encoded arguments",
+			status:        http.StatusOK,
+			body:          `{"model":"m","message":{"role":"assistant","content":"","tool_calls":[{"function":{"name":"browse","arguments":"{\"url\":\"https://example.com\"}"}}]},"done":true}`,
+			wantArguments: map[string]interface{}{"url": "https://example.com"},
+		},
+		{
+			name:            "Model without tool support",
+			status:          http.StatusBadRequest,
+			body:            `{"error":"registry.ollama.ai/library/deepseek-r1:latest does not support tools"}`,
+			wantUnsupported: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var req struct {
+					Tools []struct {
+						Type     string `json:"type"`
+						Function struct {
+							Name string `json:"name"`
+						} `json:"function"`
+					} `json:"tools"`
+				}
+				_ = json.NewDecoder(r.Body).Decode(&req)
+				if len(req.Tools) != 1 || req.Tools[0].Type != "function" || req.Tools[0].Function.Name != "browse" {
+					t.Errorf("tools = %+v, want one browse function", req.Tools)
+				}
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			client, _ := llm.NewOllamaClient(server.URL, "m", "")
+			resp, err := client.Chat(context.Background(), &domain.ChatRequest{
+				Messages: []domain.ChatMessage{{Role: domain.ChatRoleUser, Content: "Open example.com"}},
+				Tools:    tools,
+			})
+
+			if tt.wantUnsupported {
+				if !errors.Is(err, domain.ErrToolsNotSupported) {
+					t.Errorf("Chat() error = %v, want ErrToolsNotSupported", err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Chat() error = %v", err)
+			}
+
+			if len(resp.Message.ToolCalls) != 1 {
+				t.Fatalf("ToolCalls = %v, want 1 call", resp.Message.ToolCalls)
+			}
+
+			if !reflect.DeepEqual(resp.Message.ToolCalls[0].Arguments, tt.wantArguments) {
+				t.Errorf("Arguments = %v, want %v", resp.Message.ToolCalls[0].Arguments, tt.wantArguments)
+			}
+		})
+	}
+}
+
+func TestOllamaClientEmbed(t *testing.T) {
+	var got map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/embed" {
+			t.Errorf("path = %s, want /api/embed", r.URL.Path)
+		}
+		_ = json.NewDecoder(r.Body).Decode(&got)
+		_, _ = w.Write([]byte(`{"model":"nomic-embed-text","embeddings":[[0.1,0.2],[0.3,0.4]],"prompt_eval_count":6}`))
+	}))
+	defer server.Close()
+
+	client, err := llm.NewOllamaClient(server.URL, "m", "nomic-embed-text")
+	if err != nil {
+		t.Fatalf("NewOllamaClient() error = %v", err)
+	}
+
+	resp, err := client.Embed(context.Background(), &domain.EmbedRequest{Input: []string{"a", "b"}})
+	if err != nil {
+		t.Fatalf("Embed() error = %v", err)
+	}
+
+	if got["model"] != "nomic-embed-text" || !reflect.DeepEqual(got["input"], []interface{}{"a", "b"}) {
+		t.Errorf("request = %v", got)
+	}
+	want := [][]float64{{0.1, 0.2}, {0.3, 0.4}}
+	if !reflect.DeepEqual(resp.Embeddings, want) || resp.TokensUsed != 6 {
+		t.Errorf("Embed() = %+v, want embeddings %v", resp, want)
+	}
+
+	// A mismatched number of embeddings is rejected
+	if _, err := client.Embed(context.Background(), &domain.EmbedRequest{Input: []string{"a"}}); err == nil {
+		t.Error("Embed() with mismatched embeddings succeeded, want error")
+	}
+}
+
+func TestOllamaClientGenerationOptions(t *testing.T) {
+	seed := 42
+	topK := 20
+	topP := 0.9
+	greedy := 0.0
+	warm := 0.7
+	numCtx := 8192
+
+	tests := []struct {
+		name        string
+		request     domain.ChatRequest
+		wantOptions map[string]interface{}
+	}{
+		{
+			name:        "No options",
+			request:     domain.ChatRequest{},
+			wantOptions: nil,
+		},
+		{
+			name:    "Top-level fields map to options",
+			request: domain.ChatRequest{MaxTokens: 100, Temperature: &warm, Seed: &seed},
+			wantOptions: map[string]interface{}{
+				"num_predict": float64(100),
+				"temperature": 0.7,
+				"seed":        float64(42),
+			},
+		},
+		{
+			name: "Options take precedence",
+			request: domain.ChatRequest{
+				MaxTokens:   100,
+				Temperature: &warm,
+				Options: &domain.GenerationOptions{
+					Temperature: &greedy,
+					TopK:        &topK,
+					TopP:        &topP,
+					Stop:        []string{"\n\n"},
+					NumCtx:      &numCtx,
+				},
+			},
+			wantOptions: map[string]interface{}{
+				"num_predict": float64(100),
+				"temperature": float64(0),
+				"top_k":       float64(20),
+				"top_p":       0.9,
+				"stop":        []interface{}{"\n\n"},
+				"num_ctx":     float64(8192),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var req map[string]interface{}
+				if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+					t.Errorf("failed to decode request: %v", err)
+				}
+
+				if _, ok := req["max_tokens"]; ok {
+					t.Error("request has top-level max_tokens, which Ollama ignores")
+				}
+
+				options, _ := req["options"].(map[string]interface{})
+				if !reflect.DeepEqual(options, tt.wantOptions) {
+					t.Errorf("options = %v, want %v", options, tt.wantOptions)
+				}
+
+				_, _ = w.Write([]byte(`{"model":"m","message":{"role":"assistant","content":"ok"},"done":true,"prompt_eval_count":12,"eval_count":3}`))
+			}))
+			defer server.Close()
+
+			client, err := llm.NewOllamaClient(server.URL, "m", "")
+			if err != nil {
+				t.Fatalf("NewOllamaClient() error = %v", err)
+			}
+
+			request := tt.request
+			request.Messages = []domain.ChatMessage{{Role: domain.ChatRoleUser, Content: "hi"}}
+			response, err := client.Chat(context.Background(), &request)
+			if err != nil {
+				t.Fatalf("Chat() error = %v", err)
+			}
+
+			wantUsage := &domain.TokenUsage{PromptTokens: 12, CompletionTokens: 3, TotalTokens: 15}
+			if !reflect.DeepEqual(response.Usage, wantUsage) || response.TokensUsed != 15 {
+				t.Errorf("Usage = %+v, TokensUsed = %d, want %+v", response.Usage, response.TokensUsed, wantUsage)
+			}
+		})
+	}
+}
+
+func TestOllamaClientReasoning(t *testing.T) {
+	tests := []struct {
+		name          string
+		body          string
+		wantChunks    []string
+		wantReasoning string
+		wantText      string
+	}{
+		{
+			name: "Think tags split across chunks",
+			body: `{"model":"m","response":"<thi","done":false}
+{"model":"m","response":"nk>2 + 2","done":false}
+{"model":"m","response":" = 4</think>\n\n","done":false}
+{"model":"m","response":"4","done":false}
+{"model":"m","response":"","done":true}
+`,
+			wantChunks:    []string{"reasoning:2 + 2", "reasoning: = 4", "text:4"},
+			wantReasoning: "2 + 2 = 4",
+			wantText:      "4",
+		},
+		{
+			name: "Native thinking",
+			body: `{"model":"m","thinking":"2 + 2 = 4","response":"","done":false}
+{"model":"m","response":"4","done":false}
+{"model":"m","response":"","done":true}
+`,
+			wantChunks:    []string{"reasoning:2 + 2 = 4", "text:4"},
+			wantReasoning: "2 + 2 = 4",
+			wantText:      "4",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			client, err := llm.NewOllamaClient(server.URL, "m", "")
+			if err != nil {
+				t.Fatalf("NewOllamaClient() error = %v", err)
+			}
+
+			var chunks []string
+			resp, err := client.ProcessStream(context.Background(), &domain.AIRequest{Prompt: "hi"}, func(chunk *domain.AIStreamChunk) error {
+				if chunk.Reasoning != "" {
+					chunks = append(chunks, "reasoning:"+chunk.Reasoning)
+				}
+				if chunk.Text != "" {
+					chunks = append(chunks, "text:"+chunk.Text)
+				}
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("ProcessStream() error = %v", err)
+			}
+
+			if !reflect.DeepEqual(chunks, tt.wantChunks) {
+				t.Errorf("chunks = %q, want %q", chunks, tt.wantChunks)
+			}
+
+			if resp.Reasoning != tt.wantReasoning || resp.Text != tt.wantText {
+				t.Errorf("response = %q, %q, want %q, %q", resp.Reasoning, resp.Text, tt.wantReasoning, tt.wantText)
+			}
+		})
+	}
+}
+
+func TestOllamaClientChatCancel(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Hold the request until the client gives up or the test ends
+		select {
+		case <-r.Context().Done():
+		case <-release:
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	client, err := llm.NewOllamaClient(server.URL, "m", "")
+	if err != nil {
+		t.Fatalf("NewOllamaClient() error = %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	_, err = client.Chat(ctx, &domain.ChatRequest{Messages: []domain.ChatMessage{{Role: domain.ChatRoleUser, Content: "hi"}}})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Chat() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestOllamaClientImages(t *testing.T) {
+	const image = "iVBORw0KGgo="
+
+	var mu sync.Mutex
+	shows := map[string]int{}
+	var chatImages, generateImages []string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Model    string   `json:"model"`
+			Images   []string `json:"images"`
+			Messages []struct {
+				Images []string `json:"images"`
+			} `json:"messages"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("failed to decode request: %v", err)
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		switch r.URL.Path {
+		case "/api/show":
+			shows[req.Model]++
+			capabilities := []string{"completion"}
+			if req.Model == "llava" {
+				capabilities = append(capabilities, "vision")
+			}
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"capabilities": capabilities})
+		case "/api/chat":
+			chatImages = req.Messages[len(req.Messages)-1].Images
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"model":   req.Model,
+				"message": map[string]string{"role": "assistant", "content": "A login form."},
+				"done":    true,
+			})
+		case "/api/generate":
+			generateImages = req.Images
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"model": req.Model, "response": "A login form.", "done": true})
+		default:
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	client, err := llm.NewOllamaClient(server.URL, "llama3", "")
+	if err != nil {
+		t.Fatalf("NewOllamaClient() error = %v", err)
+	}
+	ctx := context.Background()
+
+	// Vision models receive the images, and their capabilities are looked up once
+	for i := 0; i < 2; i++ {
+		if _, err := client.Process(ctx, &domain.AIRequest{Model: "llava", Prompt: "Describe the page", Images: []string{image}}); err != nil {
+			t.Fatalf("Process() error = %v", err)
+		}
+	}
+	mu.Lock()
+	if !reflect.DeepEqual(chatImages, []string{image}) {
+		t.Errorf("chat images = %v, want %v", chatImages, []string{image})
+	}
+	mu.Unlock()
+
+	if _, err := client.ProcessStream(ctx, &domain.AIRequest{Model: "llava", Prompt: "Describe the page", Images: []string{image}}, func(chunk *domain.AIStreamChunk) error { return nil }); err != nil {
+		t.Fatalf("ProcessStream() error = %v", err)
+	}
+
+	// Text-only models, including the default, are rejected before generating
+	if _, err := client.Chat(ctx, &domain.ChatRequest{Messages: []domain.ChatMessage{
+		{Role: domain.ChatRoleUser, Content: "Describe the page", Images: []string{image}},
+	}}); !errors.Is(err, domain.ErrImagesNotSupported) {
+		t.Errorf("Chat() error = %v, want ErrImagesNotSupported", err)
+	}
+
+	// Requests without images are not checked
+	if _, err := client.Process(ctx, &domain.AIRequest{Prompt: "Hello"}); err != nil {
+		t.Fatalf("Process() error = %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(chatImages) != 0 {
+		t.Errorf("last chat images = %v, want none", chatImages)
+	}
+	if !reflect.DeepEqual(generateImages, []string{image}) {
+		t.Errorf("generate images = %v, want %v", generateImages, []string{image})
+	}
+	if !reflect.DeepEqual(shows, map[string]int{"llava": 1, "llama3": 1}) {
+		t.Errorf("shows = %v, want one lookup per model", shows)
+	}
+}
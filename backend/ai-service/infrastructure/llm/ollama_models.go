@@ -0,0 +1,323 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/augment-local-manus-clone/backend/ai-service/domain"
+)
+
+// ollamaModelDetails represents the details block of an Ollama model
+type ollamaModelDetails struct {
+	Format            string `json:"format"`
+	Family            string `json:"family"`
+	ParameterSize     string `json:"parameter_size"`
+	QuantizationLevel string `json:"quantization_level"`
+}
+
+// ollamaModel represents an installed model in the Ollama tags API
+type ollamaModel struct {
+	Name       string             `json:"name"`
+	Size       int64              `json:"size"`
+	Digest     string             `json:"digest"`
+	ModifiedAt time.Time          `json:"modified_at"`
+	Details    ollamaModelDetails `json:"details"`
+}
+
+// ollamaTagsResponse represents a response from the Ollama tags API
+type ollamaTagsResponse struct {
+	Models []ollamaModel `json:"models"`
+}
+
+// ollamaModelRequest represents a request naming a model, as sent to the show, pull and delete APIs
+type ollamaModelRequest struct {
+	Model  string `json:"model"`
+	Stream *bool  `json:"stream,omitempty"`
+}
+
+// ollamaShowResponse represents a response from the Ollama show API
+type ollamaShowResponse struct {
+	Parameters    string                 `json:"parameters"`
+	Template      string                 `json:"template"`
+	License       string                 `json:"license"`
+	Details       ollamaModelDetails     `json:"details"`
+	ModelInfo     map[string]interface{} `json:"model_info"`
+	ProjectorInfo map[string]interface{} `json:"projector_info"`
+	ModifiedAt    time.Time              `json:"modified_at"`
+
+	// Capabilities is only reported by recent Ollama versions
+	Capabilities []string `json:"capabilities"`
+}
+
+// ollamaPullResponse represents a progress message from the Ollama pull API
+type ollamaPullResponse struct {
+	Status    string `json:"status"`
+	Digest    string `json:"digest,omitempty"`
+	Total     int64  `json:"total,omitempty"`
+	Completed int64  `json:"completed,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// ListModels returns the models installed on the Ollama server
+func (c *OllamaClient) ListModels(ctx context.Context) ([]domain.ModelInfo, error) {
+	resp, err := c.send(ctx, "GET", "/api/tags", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	// Check response status
+	if resp.StatusCode != http.StatusOK {
+		return nil, modelStatusError(resp, "")
+	}
+
+	// Decode response
+	var tags ollamaTagsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&tags); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	models := make([]domain.ModelInfo, 0, len(tags.Models))
+	for _, model := range tags.Models {
+		models = append(models, domain.ModelInfo{
+			Name:              model.Name,
+			Size:              model.Size,
+			Digest:            model.Digest,
+			ModifiedAt:        model.ModifiedAt,
+			Format:            model.Details.Format,
+			Family:            model.Details.Family,
+			ParameterSize:     model.Details.ParameterSize,
+			QuantizationLevel: model.Details.QuantizationLevel,
+		})
+	}
+
+	return models, nil
+}
+
+// ShowModel returns the details of a model installed on the Ollama server
+func (c *OllamaClient) ShowModel(ctx context.Context, name string) (*domain.ModelDetails, error) {
+	resp, err := c.send(ctx, "POST", "/api/show", ollamaModelRequest{Model: name})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	// Check response status
+	if resp.StatusCode != http.StatusOK {
+		return nil, modelStatusError(resp, name)
+	}
+
+	// Decode response
+	var show ollamaShowResponse
+	if err := json.NewDecoder(resp.Body).Decode(&show); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	architecture, _ := show.ModelInfo["general.architecture"].(string)
+
+	details := &domain.ModelDetails{
+		ModelInfo: domain.ModelInfo{
+			Name:              name,
+			ModifiedAt:        show.ModifiedAt,
+			Format:            show.Details.Format,
+			Family:            show.Details.Family,
+			ParameterSize:     show.Details.ParameterSize,
+			QuantizationLevel: show.Details.QuantizationLevel,
+			Capabilities:      show.Capabilities,
+		},
+		Architecture: architecture,
+		Parameters:   show.Parameters,
+		Template:     show.Template,
+		License:      show.License,
+		Metadata:     show.ModelInfo,
+	}
+
+	// GGUF metadata keys are prefixed with the architecture, e.g. llama.context_length
+	if length, ok := show.ModelInfo[architecture+".context_length"].(float64); ok {
+		details.ContextLength = int(length)
+	}
+
+	if details.Capabilities == nil {
+		details.Capabilities = inferCapabilities(&show, architecture)
+	}
+
+	return details, nil
+}
+
+// PullModel downloads a model to the Ollama server, relaying each NDJSON
+// progress message to the handler
+func (c *OllamaClient) PullModel(ctx context.Context, name string, handler domain.PullProgressHandler) error {
+	stream := true
+	resp, err := c.send(ctx, "POST", "/api/pull", ollamaModelRequest{Model: name, Stream: &stream})
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// Check response status
+	if resp.StatusCode != http.StatusOK {
+		return modelStatusError(resp, name)
+	}
+
+	// Ollama streams one JSON object per line until a message with status success
+	decoder := json.NewDecoder(resp.Body)
+	for {
+		var progress ollamaPullResponse
+		if err := decoder.Decode(&progress); err != nil {
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return ctxErr
+			}
+			if errors.Is(err, io.EOF) {
+				return errors.New("pull ended before completion")
+			}
+			return fmt.Errorf("failed to decode pull progress: %w", err)
+		}
+
+		if progress.Error != "" {
+			if strings.Contains(progress.Error, "file does not exist") {
+				return fmt.Errorf("%w: %s", domain.ErrModelNotFound, name)
+			}
+			return fmt.Errorf("ollama error: %s", progress.Error)
+		}
+
+		if err := handler(&domain.PullProgress{
+			Status:    progress.Status,
+			Digest:    progress.Digest,
+			Total:     progress.Total,
+			Completed: progress.Completed,
+		}); err != nil {
+			return err
+		}
+
+		if progress.Status == "success" {
+			c.forgetCapabilities(name)
+			return nil
+		}
+	}
+}
+
+// DeleteModel removes a model from the Ollama server
+func (c *OllamaClient) DeleteModel(ctx context.Context, name string) error {
+	resp, err := c.send(ctx, "DELETE", "/api/delete", ollamaModelRequest{Model: name})
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// Check response status
+	if resp.StatusCode != http.StatusOK {
+		return modelStatusError(resp, name)
+	}
+
+	c.forgetCapabilities(name)
+	return nil
+}
+
+// checkImages rejects a request carrying images for a model that does not
+// report the vision capability. Capabilities are looked up with ShowModel
+// once per model and remembered until the model is pulled or deleted.
+func (c *OllamaClient) checkImages(ctx context.Context, model string) error {
+	c.mu.Lock()
+	vision, known := c.vision[model]
+	c.mu.Unlock()
+
+	if !known {
+		details, err := c.ShowModel(ctx, model)
+		if err != nil {
+			return err
+		}
+		vision = details.HasCapability(domain.CapabilityVision)
+
+		c.mu.Lock()
+		c.vision[model] = vision
+		c.mu.Unlock()
+	}
+
+	if !vision {
+		return fmt.Errorf("%w: %s", domain.ErrImagesNotSupported, model)
+	}
+	return nil
+}
+
+// forgetCapabilities drops the remembered capabilities of a model that changed
+func (c *OllamaClient) forgetCapabilities(name string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.vision, name)
+}
+
+// send sends a request with an optional JSON body to an Ollama API path
+func (c *OllamaClient) send(ctx context.Context, method, path string, body interface{}) (*http.Response, error) {
+	var reader io.Reader
+	if body != nil {
+		// Convert request to JSON
+		reqBody, err := json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal request: %w", err)
+		}
+		reader = bytes.NewReader(reqBody)
+	}
+
+	// Create HTTP request
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	// Send request
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+
+	return resp, nil
+}
+
+// modelStatusError converts a non-200 response from a model API into an
+// error, recognising models that are not installed
+func modelStatusError(resp *http.Response, name string) error {
+	var ollamaErr ollamaError
+	_ = json.NewDecoder(resp.Body).Decode(&ollamaErr)
+
+	if resp.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("%w: %s", domain.ErrModelNotFound, name)
+	}
+
+	if ollamaErr.Error != "" {
+		return fmt.Errorf("unexpected status code: %d: %s", resp.StatusCode, ollamaErr.Error)
+	}
+	return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+}
+
+// inferCapabilities works out a model's capabilities for Ollama versions that
+// do not report them: embedding models have a pooling type, vision models
+// have a projector, and templates mention tools and thinking when the model
+// supports them
+func inferCapabilities(show *ollamaShowResponse, architecture string) []string {
+	if _, ok := show.ModelInfo[architecture+".pooling_type"]; ok {
+		return []string{domain.CapabilityEmbedding}
+	}
+
+	capabilities := []string{domain.CapabilityCompletion}
+	if strings.Contains(show.Template, ".Tools") {
+		capabilities = append(capabilities, domain.CapabilityTools)
+	}
+	if strings.Contains(show.Template, ".Thinking") {
+		capabilities = append(capabilities, domain.CapabilityThinking)
+	}
+	if len(show.ProjectorInfo) > 0 {
+		capabilities = append(capabilities, domain.CapabilityVision)
+	}
+	return capabilities
+}
@@ -0,0 +1,219 @@
+package llm_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/augment-local-manus-clone/backend/ai-service/domain"
+	"github.com/augment-local-manus-clone/backend/ai-service/infrastructure/llm"
+)
+
+// newOllamaModelServer starts a stand-in for the Ollama model APIs with a
+// single installed model, llama3.2:3b
+func newOllamaModelServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/tags", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"models":[{"name":"llama3.2:3b","size":2019393189,"digest":"a80c4f17acd5","modified_at":"2025-01-02T03:04:05Z","details":{"format":"gguf","family":"llama","parameter_size":"3.2B","quantization_level":"Q4_K_M"}}]}`))
+	})
+	mux.HandleFunc("/api/show", func(w http.ResponseWriter, r *http.Request) {
+		var req map[string]interface{}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+
+		switch req["model"] {
+		case "llama3.2:3b":
+			_, _ = w.Write([]byte(`{"template":"{{ if .Tools }}tools{{ end }}","details":{"family":"llama"},"model_info":{"general.architecture":"llama","llama.context_length":131072},"capabilities":["completion","tools"]}`))
+		case "old-vision":
+			_, _ = w.Write([]byte(`{"template":"{{ .Prompt }}","model_info":{"general.architecture":"mllama","mllama.context_length":8192},"projector_info":{"clip.has_vision_encoder":true}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"error":"model not found"}`))
+		}
+	})
+	mux.HandleFunc("/api/pull", func(w http.ResponseWriter, r *http.Request) {
+		var req map[string]interface{}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		if req["stream"] != true {
+			t.Errorf("pull stream = %v, want true", req["stream"])
+		}
+
+		if req["model"] != "qwen3" {
+			_, _ = w.Write([]byte(`{"status":"pulling manifest"}` + "\n" + `{"error":"pull model manifest: file does not exist"}` + "\n"))
+			return
+		}
+		_, _ = w.Write([]byte(`{"status":"pulling manifest"}
+{"status":"pulling 3f8eb4da87fa","digest":"sha256:3f8eb4da87fa","total":100,"completed":40}
+{"status":"pulling 3f8eb4da87fa","digest":"sha256:3f8eb4da87fa","total":100,"completed":100}
+{"status":"success"}
+`))
+	})
+	mux.HandleFunc("/api/delete", func(w http.ResponseWriter, r *http.Request) {
+		var req map[string]interface{}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		if r.Method != http.MethodDelete || req["model"] != "llama3.2:3b" {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"error":"model not found"}`))
+		}
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestOllamaClientListModels(t *testing.T) {
+	server := newOllamaModelServer(t)
+	client, err := llm.NewOllamaClient(server.URL, "m", "")
+	if err != nil {
+		t.Fatalf("NewOllamaClient() error = %v", err)
+	}
+
+	models, err := client.ListModels(context.Background())
+	if err != nil {
+		t.Fatalf("ListModels() error = %v", err)
+	}
+
+	if len(models) != 1 {
+		t.Fatalf("ListModels() returned %d models, want 1", len(models))
+	}
+	model := models[0]
+	if model.Name != "llama3.2:3b" || model.Size != 2019393189 || model.Family != "llama" || model.QuantizationLevel != "Q4_K_M" {
+		t.Errorf("model = %+v", model)
+	}
+}
+
+func TestOllamaClientShowModel(t *testing.T) {
+	tests := []struct {
+		name              string
+		model             string
+		wantContextLength int
+		wantCapabilities  []string
+		wantErr           error
+	}{
+		{
+			name:              "Reported capabilities",
+			model:             "llama3.2:3b",
+			wantContextLength: 131072,
+			wantCapabilities:  []string{"completion", "tools"},
+		},
+		{
+			name:              "Inferred capabilities",
+			model:             "old-vision",
+			wantContextLength: 8192,
+			wantCapabilities:  []string{"completion", "vision"},
+		},
+		{
+			name:    "Not installed",
+			model:   "missing",
+			wantErr: domain.ErrModelNotFound,
+		},
+	}
+
+	server := newOllamaModelServer(t)
+	client, err := llm.NewOllamaClient(server.URL, "m", "")
+	if err != nil {
+		t.Fatalf("NewOllamaClient() error = %v", err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			details, err := client.ShowModel(context.Background(), tt.model)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("ShowModel() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ShowModel() error = %v", err)
+			}
+
+			if details.ContextLength != tt.wantContextLength {
+				t.Errorf("ContextLength = %d, want %d", details.ContextLength, tt.wantContextLength)
+			}
+			if !reflect.DeepEqual(details.Capabilities, tt.wantCapabilities) {
+				t.Errorf("Capabilities = %v, want %v", details.Capabilities, tt.wantCapabilities)
+			}
+		})
+	}
+}
+
+func TestOllamaClientPullModel(t *testing.T) {
+	tests := []struct {
+		name         string
+		model        string
+		wantStatuses []string
+		wantErr      error
+	}{
+		{
+			name:         "Streams progress until success",
+			model:        "qwen3",
+			wantStatuses: []string{"pulling manifest", "pulling 3f8eb4da87fa 40/100", "pulling 3f8eb4da87fa 100/100", "success"},
+		},
+		{
+			name:         "Unknown model",
+			model:        "nonexistent",
+			wantStatuses: []string{"pulling manifest"},
+			wantErr:      domain.ErrModelNotFound,
+		},
+	}
+
+	server := newOllamaModelServer(t)
+	client, err := llm.NewOllamaClient(server.URL, "m", "")
+	if err != nil {
+		t.Fatalf("NewOllamaClient() error = %v", err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var statuses []string
+			err := client.PullModel(context.Background(), tt.model, func(progress *domain.PullProgress) error {
+				status := progress.Status
+				if progress.Total > 0 {
+					status += " " + strconv.FormatInt(progress.Completed, 10) + "/" + strconv.FormatInt(progress.Total, 10)
+				}
+				statuses = append(statuses, status)
+				return nil
+			})
+
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("PullModel() error = %v, want %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(statuses, tt.wantStatuses) {
+				t.Errorf("statuses = %q, want %q", statuses, tt.wantStatuses)
+			}
+		})
+	}
+}
+
+func TestOllamaClientDeleteModel(t *testing.T) {
+	tests := []struct {
+		name    string
+		model   string
+		wantErr error
+	}{
+		{name: "Installed model", model: "llama3.2:3b"},
+		{name: "Not installed", model: "missing", wantErr: domain.ErrModelNotFound},
+	}
+
+	server := newOllamaModelServer(t)
+	client, err := llm.NewOllamaClient(server.URL, "m", "")
+	if err != nil {
+		t.Fatalf("NewOllamaClient() error = %v", err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := client.DeleteModel(context.Background(), tt.model); !errors.Is(err, tt.wantErr) {
+				t.Errorf("DeleteModel() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,536 @@
+package llm
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/augment-local-manus-clone/backend/ai-service/domain"
+)
+
+// OpenAIClient implements the LLMClient interface for servers exposing the
+// OpenAI chat completions API, such as llama.cpp server, vLLM and LM Studio
+type OpenAIClient struct {
+	baseURL    string
+	model      string
+	embedModel string
+	apiKey     string
+
+	// client has no overall timeout since generation can legitimately run for
+	// a long time; requests are bounded by their context instead, so that
+	// cancelling a request stops the model
+	client *http.Client
+}
+
+// openAIChatRequest represents a request to the chat completions API
+type openAIChatRequest struct {
+	Model          string                `json:"model"`
+	Messages       []openAIChatMessage   `json:"messages"`
+	Tools          []openAITool          `json:"tools,omitempty"`
+	ResponseFormat *openAIResponseFormat `json:"response_format,omitempty"`
+	MaxTokens      *int                  `json:"max_tokens,omitempty"`
+	Temperature    *float64              `json:"temperature,omitempty"`
+	TopP           *float64              `json:"top_p,omitempty"`
+	Seed           *int                  `json:"seed,omitempty"`
+	Stop           []string              `json:"stop,omitempty"`
+
+	// TopK and RepeatPenalty are extensions accepted by llama.cpp and vLLM
+	TopK          *int     `json:"top_k,omitempty"`
+	RepeatPenalty *float64 `json:"repeat_penalty,omitempty"`
+
+	Stream        bool                 `json:"stream"`
+	StreamOptions *openAIStreamOptions `json:"stream_options,omitempty"`
+}
+
+// openAIChatMessage represents a message exchanged with the chat completions API
+type openAIChatMessage struct {
+	Role       string           `json:"role"`
+	Content    string           `json:"content"`
+	ToolCalls  []openAIToolCall `json:"tool_calls,omitempty"`
+	ToolCallID string           `json:"tool_call_id,omitempty"`
+	Name       string           `json:"name,omitempty"`
+
+	// ReasoningContent is set by servers that parse reasoning themselves, such as vLLM and llama.cpp
+	ReasoningContent string `json:"reasoning_content,omitempty"`
+}
+
+// openAITool represents a tool definition in the chat completions API
+type openAITool struct {
+	Type     string             `json:"type"`
+	Function openAIToolFunction `json:"function"`
+}
+
+// openAIToolFunction describes a callable function in the chat completions API
+type openAIToolFunction struct {
+	Name        string            `json:"name"`
+	Description string            `json:"description"`
+	Parameters  domain.JSONSchema `json:"parameters,omitempty"`
+}
+
+// openAIToolCall represents a tool invocation; arguments are a JSON-encoded string
+type openAIToolCall struct {
+	ID       string `json:"id,omitempty"`
+	Type     string `json:"type"`
+	Function struct {
+		Name      string `json:"name"`
+		Arguments string `json:"arguments"`
+	} `json:"function"`
+}
+
+// openAIResponseFormat constrains the output to a JSON schema
+type openAIResponseFormat struct {
+	Type       string `json:"type"`
+	JSONSchema struct {
+		Name   string            `json:"name"`
+		Schema domain.JSONSchema `json:"schema"`
+	} `json:"json_schema"`
+}
+
+// openAIStreamOptions requests a final usage chunk on streamed responses
+type openAIStreamOptions struct {
+	IncludeUsage bool `json:"include_usage"`
+}
+
+// openAIChatResponse represents a response, or a streamed chunk, from the chat completions API
+type openAIChatResponse struct {
+	Model   string `json:"model"`
+	Choices []struct {
+		Message openAIChatMessage `json:"message"`
+		Delta   struct {
+			Content          string `json:"content"`
+			ReasoningContent string `json:"reasoning_content"`
+		} `json:"delta"`
+	} `json:"choices"`
+	Usage *domain.TokenUsage `json:"usage,omitempty"`
+}
+
+// openAIEmbeddingRequest represents a request to the embeddings API
+type openAIEmbeddingRequest struct {
+	Model string   `json:"model"`
+	Input []string `json:"input"`
+}
+
+// openAIEmbeddingResponse represents a response from the embeddings API
+type openAIEmbeddingResponse struct {
+	Model string `json:"model"`
+	Data  []struct {
+		Index     int       `json:"index"`
+		Embedding []float64 `json:"embedding"`
+	} `json:"data"`
+	Usage *struct {
+		PromptTokens int `json:"prompt_tokens"`
+	} `json:"usage,omitempty"`
+}
+
+// NewOpenAIClient creates a new OpenAIClient. baseURL is the API root
+// including the version prefix, e.g. http://localhost:8080/v1. embedModel is
+// the default embedding model and may be empty. apiKey is optional since
+// local servers usually do not check it.
+func NewOpenAIClient(baseURL, model, embedModel, apiKey string) (*OpenAIClient, error) {
+	if baseURL == "" {
+		return nil, fmt.Errorf("baseURL cannot be empty")
+	}
+
+	if model == "" {
+		return nil, fmt.Errorf("model cannot be empty")
+	}
+
+	return &OpenAIClient{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		model:      model,
+		embedModel: embedModel,
+		apiKey:     apiKey,
+		client:     &http.Client{},
+	}, nil
+}
+
+// Process sends a single-prompt request as a one-message chat
+func (c *OpenAIClient) Process(ctx context.Context, request *domain.AIRequest) (*domain.AIResponse, error) {
+	chatResp, err := c.Chat(ctx, request.ToChatRequest())
+	if err != nil {
+		return nil, err
+	}
+
+	return chatResp.ToAIResponse(), nil
+}
+
+// Chat sends a conversation to the chat completions API and returns the model's reply
+func (c *OpenAIClient) Chat(ctx context.Context, request *domain.ChatRequest) (*domain.ChatResponse, error) {
+	openAIReq, err := c.newChatRequest(request, false)
+	if err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+
+	resp, err := c.send(ctx, "/chat/completions", openAIReq)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	// Check response status
+	if resp.StatusCode != http.StatusOK {
+		return nil, c.statusError(resp, len(request.Tools) > 0)
+	}
+
+	// Decode response
+	var openAIResp openAIChatResponse
+	if err := json.NewDecoder(resp.Body).Decode(&openAIResp); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	if len(openAIResp.Choices) == 0 {
+		return nil, errors.New("response contains no choices")
+	}
+	message := openAIResp.Choices[0].Message
+
+	toolCalls, err := fromOpenAIToolCalls(message.ToolCalls)
+	if err != nil {
+		return nil, err
+	}
+
+	role := domain.ChatRole(message.Role)
+	if role == "" {
+		role = domain.ChatRoleAssistant
+	}
+
+	reasoning, content := splitReasoning(message.ReasoningContent, message.Content)
+
+	// Create chat response
+	chatResp := &domain.ChatResponse{
+		Message: domain.ChatMessage{
+			Role:      role,
+			Content:   content,
+			ToolCalls: toolCalls,
+		},
+		Reasoning: reasoning,
+		Model:     openAIResp.Model,
+		Elapsed:   time.Since(start).Seconds(),
+	}
+	if openAIResp.Usage != nil {
+		chatResp.TokensUsed = openAIResp.Usage.TotalTokens
+		chatResp.Usage = openAIResp.Usage
+	}
+
+	return chatResp, nil
+}
+
+// ProcessStream sends a streaming request to the chat completions API,
+// relaying each server-sent delta to the handler, and returns the assembled response
+func (c *OpenAIClient) ProcessStream(ctx context.Context, request *domain.AIRequest, handler domain.StreamHandler) (*domain.AIResponse, error) {
+	openAIReq, err := c.newChatRequest(request.ToChatRequest(), true)
+	if err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+
+	resp, err := c.send(ctx, "/chat/completions", openAIReq)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	// Check response status
+	if resp.StatusCode != http.StatusOK {
+		return nil, c.statusError(resp, false)
+	}
+
+	aiResp := &domain.AIResponse{}
+	stream := newReasoningStream(handler)
+
+	// Each event is a "data: {json}" line; the stream ends with "data: [DONE]"
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if !strings.HasPrefix(line, "data:") {
+			continue
+		}
+
+		data := strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+		if data == "[DONE]" {
+			if err := stream.finish(); err != nil {
+				return nil, err
+			}
+			aiResp.Reasoning, aiResp.Text = stream.result()
+			aiResp.Elapsed = time.Since(start).Seconds()
+			return aiResp, nil
+		}
+
+		var chunk openAIChatResponse
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return nil, fmt.Errorf("failed to decode stream chunk: %w", err)
+		}
+
+		if chunk.Model != "" {
+			aiResp.Model = chunk.Model
+		}
+		if chunk.Usage != nil {
+			aiResp.TokensUsed = chunk.Usage.TotalTokens
+			aiResp.Usage = chunk.Usage
+		}
+
+		for _, choice := range chunk.Choices {
+			if err := stream.native(choice.Delta.ReasoningContent); err != nil {
+				return nil, err
+			}
+			if err := stream.content(choice.Delta.Content); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return nil, ctxErr
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read stream: %w", err)
+	}
+	return nil, errors.New("stream ended before completion")
+}
+
+// Embed sends texts to the embeddings API and returns their embeddings
+func (c *OpenAIClient) Embed(ctx context.Context, request *domain.EmbedRequest) (*domain.EmbedResponse, error) {
+	model := request.Model
+	if model == "" {
+		model = c.embedModel
+	}
+	if model == "" {
+		return nil, errors.New("no embedding model configured")
+	}
+
+	resp, err := c.send(ctx, "/embeddings", openAIEmbeddingRequest{Model: model, Input: request.Input})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	// Check response status
+	if resp.StatusCode != http.StatusOK {
+		return nil, c.statusError(resp, false)
+	}
+
+	// Decode response
+	var openAIResp openAIEmbeddingResponse
+	if err := json.NewDecoder(resp.Body).Decode(&openAIResp); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	// Embeddings are matched to inputs by index rather than by position in the list
+	embeddings := make([][]float64, len(request.Input))
+	for _, data := range openAIResp.Data {
+		if data.Index < 0 || data.Index >= len(embeddings) {
+			return nil, fmt.Errorf("embedding index %d out of range", data.Index)
+		}
+		embeddings[data.Index] = data.Embedding
+	}
+	for i, embedding := range embeddings {
+		if embedding == nil {
+			return nil, fmt.Errorf("missing embedding for input %d", i)
+		}
+	}
+
+	embedResp := &domain.EmbedResponse{
+		Embeddings: embeddings,
+		Model:      openAIResp.Model,
+	}
+	if openAIResp.Usage != nil {
+		embedResp.TokensUsed = openAIResp.Usage.PromptTokens
+	}
+
+	return embedResp, nil
+}
+
+// newChatRequest converts a domain chat request to the chat completions wire format
+func (c *OpenAIClient) newChatRequest(request *domain.ChatRequest, stream bool) (*openAIChatRequest, error) {
+	model := request.Model
+	if model == "" {
+		model = c.model
+	}
+
+	// num_ctx has no equivalent; these servers fix the context size at load time
+	options := request.GenerationOptions()
+
+	// Ollama's -1 (unlimited) and -2 (fill context) mean no limit here
+	if options.NumPredict != nil && *options.NumPredict < 0 {
+		options.NumPredict = nil
+	}
+
+	openAIReq := &openAIChatRequest{
+		Model:         model,
+		Messages:      make([]openAIChatMessage, 0, len(request.Messages)),
+		MaxTokens:     options.NumPredict,
+		Temperature:   options.Temperature,
+		TopP:          options.TopP,
+		Seed:          options.Seed,
+		Stop:          options.Stop,
+		TopK:          options.TopK,
+		RepeatPenalty: options.RepeatPenalty,
+		Stream:        stream,
+	}
+
+	if stream {
+		openAIReq.StreamOptions = &openAIStreamOptions{IncludeUsage: true}
+	}
+
+	// Images are only passed to Ollama's vision models
+	if request.HasImages() {
+		return nil, fmt.Errorf("%w: images are only supported by the ollama provider", domain.ErrImagesNotSupported)
+	}
+
+	// unanswered holds the calls of the latest assistant message that no tool
+	// message has answered yet, so that answers without a call ID can be linked
+	var unanswered []openAIToolCall
+
+	for m, message := range request.Messages {
+		openAIMsg := openAIChatMessage{
+			Role:       string(message.Role),
+			Content:    message.Content,
+			ToolCallID: message.ToolCallID,
+			Name:       message.ToolName,
+		}
+
+		for i, call := range message.ToolCalls {
+			arguments, err := json.Marshal(call.Arguments)
+			if err != nil {
+				return nil, fmt.Errorf("failed to marshal tool arguments: %w", err)
+			}
+
+			// Calls made by other providers, or through prompting, have no ID
+			openAICall := openAIToolCall{ID: call.ID, Type: "function"}
+			if openAICall.ID == "" {
+				openAICall.ID = fmt.Sprintf("call_%d_%d", m, i)
+			}
+			openAICall.Function.Name = call.Name
+			openAICall.Function.Arguments = string(arguments)
+			openAIMsg.ToolCalls = append(openAIMsg.ToolCalls, openAICall)
+		}
+
+		switch message.Role {
+		case domain.ChatRoleAssistant:
+			unanswered = append([]openAIToolCall(nil), openAIMsg.ToolCalls...)
+		case domain.ChatRoleTool:
+			openAIMsg.ToolCallID, unanswered = answerToolCall(unanswered, message)
+		}
+
+		openAIReq.Messages = append(openAIReq.Messages, openAIMsg)
+	}
+
+	for _, tool := range request.Tools {
+		openAIReq.Tools = append(openAIReq.Tools, openAITool{
+			Type: "function",
+			Function: openAIToolFunction{
+				Name:        tool.Name,
+				Description: tool.Description,
+				Parameters:  tool.Parameters,
+			},
+		})
+	}
+
+	if request.ResponseSchema != nil {
+		format := &openAIResponseFormat{Type: "json_schema"}
+		format.JSONSchema.Name = "response"
+		format.JSONSchema.Schema = request.ResponseSchema
+		openAIReq.ResponseFormat = format
+	}
+
+	return openAIReq, nil
+}
+
+// answerToolCall returns the ID of the call a tool message answers and the
+// calls still unanswered. A message without a call ID answers the first
+// unanswered call of the tool it names, or the first one if it names none.
+func answerToolCall(unanswered []openAIToolCall, message domain.ChatMessage) (string, []openAIToolCall) {
+	for i, call := range unanswered {
+		matches := call.ID == message.ToolCallID
+		if message.ToolCallID == "" {
+			matches = message.ToolName == "" || call.Function.Name == message.ToolName
+		}
+		if matches {
+			return call.ID, append(unanswered[:i], unanswered[i+1:]...)
+		}
+	}
+	return message.ToolCallID, unanswered
+}
+
+// send posts a JSON request to an API path
+func (c *OpenAIClient) send(ctx context.Context, path string, body interface{}) (*http.Response, error) {
+	// Convert request to JSON
+	reqBody, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	// Create HTTP request
+	req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+path, bytes.NewBuffer(reqBody))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+
+	// Send request
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+
+	return resp, nil
+}
+
+// statusError converts a non-200 response into an error, recognising servers
+// that reject tool definitions
+func (c *OpenAIClient) statusError(resp *http.Response, hadTools bool) error {
+	var body struct {
+		Error json.RawMessage `json:"error"`
+	}
+	_ = json.NewDecoder(resp.Body).Decode(&body)
+
+	// The error is either a string or an object with a message
+	var message string
+	if err := json.Unmarshal(body.Error, &message); err != nil {
+		var detail struct {
+			Message string `json:"message"`
+		}
+		_ = json.Unmarshal(body.Error, &detail)
+		message = detail.Message
+	}
+
+	if hadTools && strings.Contains(strings.ToLower(message), "tool") {
+		return fmt.Errorf("%w: %s", domain.ErrToolsNotSupported, message)
+	}
+
+	return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+}
+
+// fromOpenAIToolCalls converts tool calls returned by the API to domain tool calls
+func fromOpenAIToolCalls(calls []openAIToolCall) ([]domain.ToolCall, error) {
+	var toolCalls []domain.ToolCall
+
+	for _, call := range calls {
+		arguments := map[string]interface{}{}
+		if strings.TrimSpace(call.Function.Arguments) != "" {
+			if err := json.Unmarshal([]byte(call.Function.Arguments), &arguments); err != nil {
+				return nil, fmt.Errorf("failed to decode tool arguments: %w", err)
+			}
+		}
+
+		toolCalls = append(toolCalls, domain.ToolCall{
+			ID:        call.ID,
+			Name:      call.Function.Name,
+			Arguments: arguments,
+		})
+	}
+
+	return toolCalls, nil
+}
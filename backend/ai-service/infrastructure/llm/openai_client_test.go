@@ -0,0 +1,216 @@
+package llm_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/augment-local-manus-clone/backend/ai-service/domain"
+	"github.com/augment-local-manus-clone/backend/ai-service/infrastructure/llm"
+)
+
+func TestOpenAIClientChat(t *testing.T) {
+	var got map[string]interface{}
+	var gotAuth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/chat/completions" {
+			t.Errorf("path = %s, want /v1/chat/completions", r.URL.Path)
+		}
+		gotAuth = r.Header.Get("Authorization")
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("failed to decode request: %v", err)
+		}
+		_, _ = w.Write([]byte(`{
+			"model": "qwen",
+			"choices": [{"message": {"role": "assistant", "content": "", "tool_calls": [
+				{"id": "call_1", "type": "function", "function": {"name": "get_weather", "arguments": "{\"city\":\"Paris\"}"}}
+			]}}],
+			"usage": {"prompt_tokens": 7, "completion_tokens": 4, "total_tokens": 11}
+		}`))
+	}))
+	defer server.Close()
+
+	client, err := llm.NewOpenAIClient(server.URL+"/v1", "qwen", "", "secret")
+	if err != nil {
+		t.Fatalf("NewOpenAIClient() error = %v", err)
+	}
+
+	resp, err := client.Chat(context.Background(), &domain.ChatRequest{
+		Messages: []domain.ChatMessage{
+			{Role: domain.ChatRoleUser, Content: "Weather?"},
+			{Role: domain.ChatRoleAssistant, ToolCalls: []domain.ToolCall{{ID: "call_0", Name: "get_weather", Arguments: map[string]interface{}{"city": "Rome"}}}},
+			{Role: domain.ChatRoleTool, Content: "sunny", ToolName: "get_weather", ToolCallID: "call_0"},
+		},
+		Tools:     []domain.ToolDefinition{{Name: "get_weather", Description: "Get the weather"}},
+		MaxTokens: 100,
+	})
+	if err != nil {
+		t.Fatalf("Chat() error = %v", err)
+	}
+
+	if gotAuth != "Bearer secret" {
+		t.Errorf("Authorization = %q, want %q", gotAuth, "Bearer secret")
+	}
+	if got["model"] != "qwen" || got["stream"] != false || got["max_tokens"] != float64(100) {
+		t.Errorf("request = %v", got)
+	}
+
+	messages := got["messages"].([]interface{})
+	call := messages[1].(map[string]interface{})["tool_calls"].([]interface{})[0].(map[string]interface{})
+	if call["id"] != "call_0" || call["function"].(map[string]interface{})["arguments"] != `{"city":"Rome"}` {
+		t.Errorf("assistant tool call = %v", call)
+	}
+	if messages[2].(map[string]interface{})["tool_call_id"] != "call_0" {
+		t.Errorf("tool message = %v", messages[2])
+	}
+	if len(got["tools"].([]interface{})) != 1 {
+		t.Errorf("tools = %v", got["tools"])
+	}
+
+	wantCalls := []domain.ToolCall{{ID: "call_1", Name: "get_weather", Arguments: map[string]interface{}{"city": "Paris"}}}
+	if !reflect.DeepEqual(resp.Message.ToolCalls, wantCalls) {
+		t.Errorf("ToolCalls = %v, want %v", resp.Message.ToolCalls, wantCalls)
+	}
+	if resp.TokensUsed != 11 || resp.Model != "qwen" {
+		t.Errorf("TokensUsed = %d, Model = %q", resp.TokensUsed, resp.Model)
+	}
+}
+
+func TestOpenAIClientChatLinksToolCallIDs(t *testing.T) {
+	var got struct {
+		Messages []struct {
+			ToolCalls []struct {
+				ID string `json:"id"`
+			} `json:"tool_calls"`
+			ToolCallID string `json:"tool_call_id"`
+		} `json:"messages"`
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&got)
+		_, _ = w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"done"}}]}`))
+	}))
+	defer server.Close()
+
+	client, _ := llm.NewOpenAIClient(server.URL, "m", "", "")
+
+	// Calls from a model without call IDs, answered out of order by tool name
+	call := func(name string) domain.ToolCall {
+		return domain.ToolCall{Name: name, Arguments: map[string]interface{}{}}
+	}
+	_, err := client.Chat(context.Background(), &domain.ChatRequest{
+		Messages: []domain.ChatMessage{
+			{Role: domain.ChatRoleUser, Content: "Weather and time?"},
+			{Role: domain.ChatRoleAssistant, ToolCalls: []domain.ToolCall{call("get_weather"), call("get_time")}},
+			{Role: domain.ChatRoleTool, Content: "noon", ToolName: "get_time"},
+			{Role: domain.ChatRoleTool, Content: "sunny", ToolName: "get_weather"},
+			{Role: domain.ChatRoleAssistant, ToolCalls: []domain.ToolCall{call("get_time")}},
+			{Role: domain.ChatRoleTool, Content: "one"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Chat() error = %v", err)
+	}
+
+	var calls, answers []string
+	for _, message := range got.Messages {
+		for _, call := range message.ToolCalls {
+			calls = append(calls, call.ID)
+		}
+		if message.ToolCallID != "" {
+			answers = append(answers, message.ToolCallID)
+		}
+	}
+	if want := []string{"call_1_0", "call_1_1", "call_4_0"}; !reflect.DeepEqual(calls, want) {
+		t.Errorf("call IDs = %v, want %v", calls, want)
+	}
+	if want := []string{"call_1_1", "call_1_0", "call_4_0"}; !reflect.DeepEqual(answers, want) {
+		t.Errorf("tool_call_id = %v, want %v", answers, want)
+	}
+}
+
+func TestOpenAIClientChatResponseSchema(t *testing.T) {
+	var got map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&got)
+		_, _ = w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"{}"}}]}`))
+	}))
+	defer server.Close()
+
+	client, _ := llm.NewOpenAIClient(server.URL, "m", "", "")
+	_, err := client.Chat(context.Background(), &domain.ChatRequest{
+		Model:          "other",
+		Messages:       []domain.ChatMessage{{Role: domain.ChatRoleUser, Content: "hi"}},
+		ResponseSchema: domain.JSONSchema{"type": "object"},
+	})
+	if err != nil {
+		t.Fatalf("Chat() error = %v", err)
+	}
+
+	if got["model"] != "other" {
+		t.Errorf("model = %v, want other", got["model"])
+	}
+	format := got["response_format"].(map[string]interface{})
+	if format["type"] != "json_schema" {
+		t.Errorf("response_format = %v", format)
+	}
+}
+
+func TestOpenAIClientChatToolsNotSupported(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"error":{"message":"tools param requires --jinja flag"}}`))
+	}))
+	defer server.Close()
+
+	client, _ := llm.NewOpenAIClient(server.URL, "m", "", "")
+	_, err := client.Chat(context.Background(), &domain.ChatRequest{
+		Messages: []domain.ChatMessage{{Role: domain.ChatRoleUser, Content: "hi"}},
+		Tools:    []domain.ToolDefinition{{Name: "t", Description: "d"}},
+	})
+	if !errors.Is(err, domain.ErrToolsNotSupported) {
+		t.Errorf("Chat() error = %v, want ErrToolsNotSupported", err)
+	}
+}
+
+func TestOpenAIClientProcessStream(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req map[string]interface{}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		if req["stream"] != true {
+			t.Errorf("request stream = %v, want true", req["stream"])
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = w.Write([]byte(`data: {"model":"m","choices":[{"delta":{"content":"Hel"}}]}
+
+data: {"model":"m","choices":[{"delta":{"content":"lo"}}]}
+
+data: {"model":"m","choices":[],"usage":{"total_tokens":9}}
+
+data: [DONE]
+
+`))
+	}))
+	defer server.Close()
+
+	client, _ := llm.NewOpenAIClient(server.URL, "m", "", "")
+
+	var chunks []string
+	resp, err := client.ProcessStream(context.Background(), &domain.AIRequest{Prompt: "hi"}, func(chunk *domain.AIStreamChunk) error {
+		chunks = append(chunks, chunk.Text)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ProcessStream() error = %v", err)
+	}
+
+	if !reflect.DeepEqual(chunks, []string{"Hel", "lo"}) {
+		t.Errorf("chunks = %v", chunks)
+	}
+	if resp.Text != "Hello" || resp.TokensUsed != 9 {
+		t.Errorf("response = %+v", resp)
+	}
+}
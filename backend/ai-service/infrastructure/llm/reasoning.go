@@ -0,0 +1,63 @@
+package llm
+
+import (
+	"strings"
+
+	"github.com/augment-local-manus-clone/backend/ai-service/domain"
+)
+
+// splitReasoning separates a reply into reasoning and answer. native is the
+// reasoning a server returned in its own field, if it parses it already;
+// <think> blocks left in the content are split out as well.
+func splitReasoning(native, content string) (reasoning, answer string) {
+	reasoning, answer = domain.SplitReasoning(content)
+	if native = strings.TrimSpace(native); native != "" {
+		reasoning = strings.TrimSpace(native + "\n\n" + reasoning)
+	}
+	return reasoning, answer
+}
+
+// reasoningStream separates reasoning from the answer as a stream arrives,
+// relaying both to the handler and accumulating them for the final response
+type reasoningStream struct {
+	handler   domain.StreamHandler
+	splitter  domain.ReasoningSplitter
+	reasoning strings.Builder
+	answer    strings.Builder
+}
+
+// newReasoningStream creates a reasoningStream relaying to handler
+func newReasoningStream(handler domain.StreamHandler) *reasoningStream {
+	return &reasoningStream{handler: handler}
+}
+
+// native relays reasoning that the server streamed in its own field
+func (s *reasoningStream) native(text string) error {
+	return s.emit(text, "")
+}
+
+// content relays a piece of the streamed content, splitting out <think> blocks
+func (s *reasoningStream) content(text string) error {
+	return s.emit(s.splitter.Write(text))
+}
+
+// finish relays any text held back at the end of the stream
+func (s *reasoningStream) finish() error {
+	return s.emit(s.splitter.Flush())
+}
+
+// result returns the accumulated reasoning and answer
+func (s *reasoningStream) result() (reasoning, answer string) {
+	return strings.TrimSpace(s.reasoning.String()), s.answer.String()
+}
+
+// emit records and relays a chunk unless it is empty
+func (s *reasoningStream) emit(reasoning, answer string) error {
+	if reasoning == "" && answer == "" {
+		return nil
+	}
+
+	s.reasoning.WriteString(reasoning)
+	s.answer.WriteString(answer)
+	return s.handler(&domain.AIStreamChunk{Text: answer, Reasoning: reasoning})
+}
@@ -0,0 +1,102 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/augment-local-manus-clone/backend/ai-service/domain"
+)
+
+// Registry implements the LLMClient interface by dispatching each request to
+// the client registered for the request's provider, or to the default provider
+type Registry struct {
+	clients         map[string]domain.LLMClient
+	defaultProvider string
+}
+
+// NewRegistry creates an empty Registry that routes requests without a provider to defaultProvider
+func NewRegistry(defaultProvider string) *Registry {
+	return &Registry{
+		clients:         make(map[string]domain.LLMClient),
+		defaultProvider: defaultProvider,
+	}
+}
+
+// Register adds a client under the given provider name
+func (r *Registry) Register(name string, client domain.LLMClient) error {
+	if name == "" {
+		return fmt.Errorf("provider name cannot be empty")
+	}
+
+	if client == nil {
+		return fmt.Errorf("client for provider %q cannot be nil", name)
+	}
+
+	if _, exists := r.clients[name]; exists {
+		return fmt.Errorf("provider %q is already registered", name)
+	}
+
+	r.clients[name] = client
+	return nil
+}
+
+// Client returns the client for a provider; an empty name selects the default provider
+func (r *Registry) Client(name string) (domain.LLMClient, error) {
+	if name == "" {
+		name = r.defaultProvider
+	}
+
+	client, ok := r.clients[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", domain.ErrUnknownProvider, name)
+	}
+
+	return client, nil
+}
+
+// Providers returns the registered provider names in sorted order
+func (r *Registry) Providers() []string {
+	names := make([]string, 0, len(r.clients))
+	for name := range r.clients {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Process sends the request to the client of the requested provider
+func (r *Registry) Process(ctx context.Context, request *domain.AIRequest) (*domain.AIResponse, error) {
+	client, err := r.Client(request.Provider)
+	if err != nil {
+		return nil, err
+	}
+	return client.Process(ctx, request)
+}
+
+// ProcessStream streams the request from the client of the requested provider
+func (r *Registry) ProcessStream(ctx context.Context, request *domain.AIRequest, handler domain.StreamHandler) (*domain.AIResponse, error) {
+	client, err := r.Client(request.Provider)
+	if err != nil {
+		return nil, err
+	}
+	return client.ProcessStream(ctx, request, handler)
+}
+
+// Chat sends the conversation to the client of the requested provider
+func (r *Registry) Chat(ctx context.Context, request *domain.ChatRequest) (*domain.ChatResponse, error) {
+	client, err := r.Client(request.Provider)
+	if err != nil {
+		return nil, err
+	}
+	return client.Chat(ctx, request)
+}
+
+// Embed sends the texts to the client of the requested provider
+func (r *Registry) Embed(ctx context.Context, request *domain.EmbedRequest) (*domain.EmbedResponse, error) {
+	client, err := r.Client(request.Provider)
+	if err != nil {
+		return nil, err
+	}
+	return client.Embed(ctx, request)
+}
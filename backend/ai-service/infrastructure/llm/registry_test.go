@@ -0,0 +1,82 @@
+package llm_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/augment-local-manus-clone/backend/ai-service/domain"
+	"github.com/augment-local-manus-clone/backend/ai-service/infrastructure/llm"
+)
+
+// namedClient is an LLMClient that reports its own name as the model
+type namedClient struct {
+	name string
+}
+
+func (c *namedClient) Process(ctx context.Context, request *domain.AIRequest) (*domain.AIResponse, error) {
+	return &domain.AIResponse{Model: c.name}, nil
+}
+
+func (c *namedClient) ProcessStream(ctx context.Context, request *domain.AIRequest, handler domain.StreamHandler) (*domain.AIResponse, error) {
+	return &domain.AIResponse{Model: c.name}, nil
+}
+
+func (c *namedClient) Chat(ctx context.Context, request *domain.ChatRequest) (*domain.ChatResponse, error) {
+	return &domain.ChatResponse{Model: c.name}, nil
+}
+
+func (c *namedClient) Embed(ctx context.Context, request *domain.EmbedRequest) (*domain.EmbedResponse, error) {
+	return &domain.EmbedResponse{Model: c.name}, nil
+}
+
+func TestRegistry(t *testing.T) {
+	registry := llm.NewRegistry("ollama")
+	if err := registry.Register("ollama", &namedClient{name: "ollama"}); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	if err := registry.Register("vllm", &namedClient{name: "vllm"}); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	if err := registry.Register("vllm", &namedClient{name: "vllm"}); err == nil {
+		t.Error("Register() duplicate provider succeeded, want error")
+	}
+
+	tests := []struct {
+		name      string
+		provider  string
+		wantModel string
+		wantErr   error
+	}{
+		{name: "Default provider", provider: "", wantModel: "ollama"},
+		{name: "Named provider", provider: "vllm", wantModel: "vllm"},
+		{name: "Unknown provider", provider: "missing", wantErr: domain.ErrUnknownProvider},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := registry.Process(context.Background(), &domain.AIRequest{Provider: tt.provider, Prompt: "hi"})
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("Process() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Process() error = %v", err)
+			}
+			if resp.Model != tt.wantModel {
+				t.Errorf("Process() model = %q, want %q", resp.Model, tt.wantModel)
+			}
+
+			chatResp, err := registry.Chat(context.Background(), &domain.ChatRequest{Provider: tt.provider})
+			if err != nil || chatResp.Model != tt.wantModel {
+				t.Errorf("Chat() = %v, %v, want model %q", chatResp, err, tt.wantModel)
+			}
+		})
+	}
+
+	if got := registry.Providers(); len(got) != 2 || got[0] != "ollama" || got[1] != "vllm" {
+		t.Errorf("Providers() = %v", got)
+	}
+}
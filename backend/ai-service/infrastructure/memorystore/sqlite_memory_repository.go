@@ -0,0 +1,285 @@
+package memorystore
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/augment-local-manus-clone/backend/ai-service/domain"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// SQLiteMemoryRepository implements the MemoryRepository interface using SQLite
+type SQLiteMemoryRepository struct {
+	db *sql.DB
+}
+
+// NewSQLiteMemoryRepository creates a new SQLiteMemoryRepository
+func NewSQLiteMemoryRepository(dbPath string) (*SQLiteMemoryRepository, error) {
+	db, err := sql.Open("sqlite3", dbPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+
+	// Create tables if they don't exist
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS memories (
+			conversation_id TEXT PRIMARY KEY,
+			summary TEXT NOT NULL DEFAULT '',
+			summarized_turns INTEGER NOT NULL DEFAULT 0,
+			updated_at TIMESTAMP NOT NULL
+		)
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create memories table: %w", err)
+	}
+
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS memory_turns (
+			conversation_id TEXT NOT NULL,
+			seq INTEGER NOT NULL,
+			role TEXT NOT NULL,
+			content TEXT NOT NULL,
+			created_at TIMESTAMP NOT NULL,
+			PRIMARY KEY (conversation_id, seq)
+		)
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create memory_turns table: %w", err)
+	}
+
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS memory_facts (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			conversation_id TEXT NOT NULL,
+			content TEXT NOT NULL,
+			created_at TIMESTAMP NOT NULL
+		)
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create memory_facts table: %w", err)
+	}
+
+	return &SQLiteMemoryRepository{
+		db: db,
+	}, nil
+}
+
+// Get returns the memory of a conversation
+func (r *SQLiteMemoryRepository) Get(conversationID string) (*domain.Memory, error) {
+	memory := &domain.Memory{ConversationID: conversationID}
+	err := r.db.QueryRow(
+		`SELECT summary, summarized_turns, updated_at FROM memories WHERE conversation_id = ?`,
+		conversationID,
+	).Scan(&memory.Summary, &memory.SummarizedTurns, &memory.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%w: %s", domain.ErrMemoryNotFound, conversationID)
+		}
+		return nil, fmt.Errorf("failed to read memory: %w", err)
+	}
+
+	memory.Turns, err = r.turns(conversationID)
+	if err != nil {
+		return nil, err
+	}
+
+	memory.Facts, err = r.facts(conversationID)
+	if err != nil {
+		return nil, err
+	}
+
+	return memory, nil
+}
+
+// List returns the memory of every conversation without its turns and facts,
+// most recently updated first
+func (r *SQLiteMemoryRepository) List() ([]*domain.Memory, error) {
+	rows, err := r.db.Query(
+		`SELECT conversation_id, summary, summarized_turns, updated_at FROM memories ORDER BY updated_at DESC`,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query memories: %w", err)
+	}
+	defer rows.Close()
+
+	memories := []*domain.Memory{}
+	for rows.Next() {
+		var memory domain.Memory
+		if err := rows.Scan(&memory.ConversationID, &memory.Summary, &memory.SummarizedTurns, &memory.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan memory: %w", err)
+		}
+		memories = append(memories, &memory)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating memories: %w", err)
+	}
+
+	return memories, nil
+}
+
+// Save replaces the memory of a conversation, assigning IDs to new facts
+func (r *SQLiteMemoryRepository) Save(memory *domain.Memory) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	memory.UpdatedAt = time.Now()
+	_, err = tx.Exec(
+		`INSERT INTO memories (conversation_id, summary, summarized_turns, updated_at) VALUES (?, ?, ?, ?)
+		 ON CONFLICT (conversation_id) DO UPDATE SET
+		   summary = excluded.summary,
+		   summarized_turns = excluded.summarized_turns,
+		   updated_at = excluded.updated_at`,
+		memory.ConversationID,
+		memory.Summary,
+		memory.SummarizedTurns,
+		memory.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save memory: %w", err)
+	}
+
+	if _, err := tx.Exec(`DELETE FROM memory_turns WHERE conversation_id = ?`, memory.ConversationID); err != nil {
+		return fmt.Errorf("failed to replace turns: %w", err)
+	}
+	for i, turn := range memory.Turns {
+		_, err = tx.Exec(
+			`INSERT INTO memory_turns (conversation_id, seq, role, content, created_at) VALUES (?, ?, ?, ?, ?)`,
+			memory.ConversationID,
+			i,
+			turn.Role,
+			turn.Content,
+			turn.CreatedAt,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to insert turn: %w", err)
+		}
+	}
+
+	// Facts keep their IDs; facts without one are new
+	if _, err := tx.Exec(`DELETE FROM memory_facts WHERE conversation_id = ?`, memory.ConversationID); err != nil {
+		return fmt.Errorf("failed to replace facts: %w", err)
+	}
+	for i := range memory.Facts {
+		fact := &memory.Facts[i]
+		var id interface{}
+		if fact.ID != 0 {
+			id = fact.ID
+		}
+
+		result, err := tx.Exec(
+			`INSERT INTO memory_facts (id, conversation_id, content, created_at) VALUES (?, ?, ?, ?)`,
+			id,
+			memory.ConversationID,
+			fact.Content,
+			fact.CreatedAt,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to insert fact: %w", err)
+		}
+
+		if fact.ID == 0 {
+			fact.ID, err = result.LastInsertId()
+			if err != nil {
+				return fmt.Errorf("failed to read fact id: %w", err)
+			}
+		}
+	}
+
+	return tx.Commit()
+}
+
+// Delete removes the memory of a conversation
+func (r *SQLiteMemoryRepository) Delete(conversationID string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`DELETE FROM memories WHERE conversation_id = ?`, conversationID)
+	if err != nil {
+		return fmt.Errorf("failed to delete memory: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to check affected rows: %w", err)
+	}
+	if affected == 0 {
+		return fmt.Errorf("%w: %s", domain.ErrMemoryNotFound, conversationID)
+	}
+
+	if _, err := tx.Exec(`DELETE FROM memory_turns WHERE conversation_id = ?`, conversationID); err != nil {
+		return fmt.Errorf("failed to delete turns: %w", err)
+	}
+	if _, err := tx.Exec(`DELETE FROM memory_facts WHERE conversation_id = ?`, conversationID); err != nil {
+		return fmt.Errorf("failed to delete facts: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// Close closes the database connection
+func (r *SQLiteMemoryRepository) Close() error {
+	return r.db.Close()
+}
+
+// turns returns the turns of a conversation in order
+func (r *SQLiteMemoryRepository) turns(conversationID string) ([]domain.MemoryTurn, error) {
+	rows, err := r.db.Query(
+		`SELECT role, content, created_at FROM memory_turns WHERE conversation_id = ? ORDER BY seq`,
+		conversationID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query turns: %w", err)
+	}
+	defer rows.Close()
+
+	turns := []domain.MemoryTurn{}
+	for rows.Next() {
+		var turn domain.MemoryTurn
+		if err := rows.Scan(&turn.Role, &turn.Content, &turn.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan turn: %w", err)
+		}
+		turns = append(turns, turn)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating turns: %w", err)
+	}
+
+	return turns, nil
+}
+
+// facts returns the facts of a conversation, oldest first
+func (r *SQLiteMemoryRepository) facts(conversationID string) ([]domain.MemoryFact, error) {
+	rows, err := r.db.Query(
+		`SELECT id, content, created_at FROM memory_facts WHERE conversation_id = ? ORDER BY id`,
+		conversationID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query facts: %w", err)
+	}
+	defer rows.Close()
+
+	facts := []domain.MemoryFact{}
+	for rows.Next() {
+		var fact domain.MemoryFact
+		if err := rows.Scan(&fact.ID, &fact.Content, &fact.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan fact: %w", err)
+		}
+		facts = append(facts, fact)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating facts: %w", err)
+	}
+
+	return facts, nil
+}
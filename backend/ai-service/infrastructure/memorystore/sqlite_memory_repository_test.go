@@ -0,0 +1,72 @@
+package memorystore_test
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/augment-local-manus-clone/backend/ai-service/domain"
+	"github.com/augment-local-manus-clone/backend/ai-service/infrastructure/memorystore"
+)
+
+func TestSQLiteMemoryRepository(t *testing.T) {
+	repo, err := memorystore.NewSQLiteMemoryRepository(filepath.Join(t.TempDir(), "memory.db"))
+	if err != nil {
+		t.Fatalf("NewSQLiteMemoryRepository() error = %v", err)
+	}
+	defer repo.Close()
+
+	if _, err := repo.Get("task-1"); !errors.Is(err, domain.ErrMemoryNotFound) {
+		t.Fatalf("Get() error = %v, want ErrMemoryNotFound", err)
+	}
+
+	now := time.Now()
+	memory := &domain.Memory{
+		ConversationID:  "task-1",
+		Summary:         "The user asked for a plan.",
+		SummarizedTurns: 4,
+		Turns: []domain.MemoryTurn{
+			{Role: domain.ChatRoleUser, Content: "Start step one", CreatedAt: now},
+			{Role: domain.ChatRoleAssistant, Content: "Done", CreatedAt: now},
+		},
+		Facts: []domain.MemoryFact{{Content: "The user prefers Go.", CreatedAt: now}},
+	}
+	if err := repo.Save(memory); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	if memory.Facts[0].ID == 0 {
+		t.Fatal("Save() did not assign a fact ID")
+	}
+	factID := memory.Facts[0].ID
+
+	// Saving again replaces the turns and keeps the IDs of existing facts
+	memory.Turns = memory.Turns[1:]
+	memory.Facts = append(memory.Facts, domain.MemoryFact{Content: "The deadline is Friday.", CreatedAt: now})
+	if err := repo.Save(memory); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	got, err := repo.Get("task-1")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if got.Summary != memory.Summary || got.SummarizedTurns != 4 || len(got.Turns) != 1 || got.Turns[0].Content != "Done" {
+		t.Errorf("Get() = %+v, want the saved memory", got)
+	}
+	if len(got.Facts) != 2 || got.Facts[0].ID != factID || got.Facts[1].ID <= factID {
+		t.Errorf("Facts = %+v, want the first fact's ID kept", got.Facts)
+	}
+
+	list, err := repo.List()
+	if err != nil || len(list) != 1 || list[0].ConversationID != "task-1" || len(list[0].Turns) != 0 {
+		t.Errorf("List() = %+v, %v, want task-1 without turns", list, err)
+	}
+
+	if err := repo.Delete("task-1"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if err := repo.Delete("task-1"); !errors.Is(err, domain.ErrMemoryNotFound) {
+		t.Errorf("Delete() error = %v, want ErrMemoryNotFound", err)
+	}
+}
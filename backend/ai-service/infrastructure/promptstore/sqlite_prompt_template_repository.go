@@ -0,0 +1,282 @@
+package promptstore
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/augment-local-manus-clone/backend/ai-service/domain"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// SQLitePromptTemplateRepository implements the PromptTemplateRepository interface using SQLite
+type SQLitePromptTemplateRepository struct {
+	db *sql.DB
+}
+
+// NewSQLitePromptTemplateRepository creates a new SQLitePromptTemplateRepository
+func NewSQLitePromptTemplateRepository(dbPath string) (*SQLitePromptTemplateRepository, error) {
+	db, err := sql.Open("sqlite3", dbPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+
+	// Create prompt_templates table if it doesn't exist
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS prompt_templates (
+			name TEXT NOT NULL,
+			version INTEGER NOT NULL,
+			description TEXT,
+			template TEXT NOT NULL,
+			weight INTEGER NOT NULL DEFAULT 0,
+			created_at TIMESTAMP NOT NULL,
+			PRIMARY KEY (name, version)
+		)
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create prompt_templates table: %w", err)
+	}
+
+	// Highest version ever created per name, so deleted versions are not reused
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS retired_template_versions (
+			name TEXT PRIMARY KEY,
+			version INTEGER NOT NULL
+		)
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create retired_template_versions table: %w", err)
+	}
+
+	return &SQLitePromptTemplateRepository{
+		db: db,
+	}, nil
+}
+
+// Create stores the template as the next version of its name
+func (r *SQLitePromptTemplateRepository) Create(template *domain.PromptTemplate) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Version numbers are never reused, even after the latest version is deleted,
+	// so a version number always refers to the same text
+	var latest int
+	err = tx.QueryRow(
+		`SELECT COALESCE(MAX(version), 0) FROM prompt_templates WHERE name = ?`,
+		template.Name,
+	).Scan(&latest)
+	if err != nil {
+		return fmt.Errorf("failed to read latest version: %w", err)
+	}
+
+	var retired int
+	err = tx.QueryRow(
+		`SELECT COALESCE(MAX(version), 0) FROM retired_template_versions WHERE name = ?`,
+		template.Name,
+	).Scan(&retired)
+	if err != nil {
+		return fmt.Errorf("failed to read retired versions: %w", err)
+	}
+	if retired > latest {
+		latest = retired
+	}
+
+	template.Version = latest + 1
+	template.CreatedAt = time.Now()
+
+	_, err = tx.Exec(
+		`INSERT INTO prompt_templates (name, version, description, template, weight, created_at)
+		 VALUES (?, ?, ?, ?, ?, ?)`,
+		template.Name,
+		template.Version,
+		template.Description,
+		template.Template,
+		template.Weight,
+		template.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert template: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// Get returns a version of a template
+func (r *SQLitePromptTemplateRepository) Get(name string, version int) (*domain.PromptTemplate, error) {
+	row := r.db.QueryRow(
+		`SELECT name, version, description, template, weight, created_at
+		 FROM prompt_templates WHERE name = ? AND version = ?`,
+		name,
+		version,
+	)
+
+	template, err := scanTemplate(row)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%w: %s version %d", domain.ErrTemplateNotFound, name, version)
+		}
+		return nil, err
+	}
+
+	return template, nil
+}
+
+// ListVersions returns every version of a template, oldest first
+func (r *SQLitePromptTemplateRepository) ListVersions(name string) ([]*domain.PromptTemplate, error) {
+	templates, err := r.query(
+		`SELECT name, version, description, template, weight, created_at
+		 FROM prompt_templates WHERE name = ? ORDER BY version`,
+		name,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(templates) == 0 {
+		return nil, fmt.Errorf("%w: %s", domain.ErrTemplateNotFound, name)
+	}
+
+	return templates, nil
+}
+
+// List returns the latest version of every template, sorted by name
+func (r *SQLitePromptTemplateRepository) List() ([]*domain.PromptTemplate, error) {
+	return r.query(
+		`SELECT t.name, t.version, t.description, t.template, t.weight, t.created_at
+		 FROM prompt_templates t
+		 JOIN (SELECT name, MAX(version) AS version FROM prompt_templates GROUP BY name) latest
+		   ON latest.name = t.name AND latest.version = t.version
+		 ORDER BY t.name`,
+	)
+}
+
+// Update changes the description and weight of a version
+func (r *SQLitePromptTemplateRepository) Update(template *domain.PromptTemplate) error {
+	result, err := r.db.Exec(
+		`UPDATE prompt_templates SET description = ?, weight = ? WHERE name = ? AND version = ?`,
+		template.Description,
+		template.Weight,
+		template.Name,
+		template.Version,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update template: %w", err)
+	}
+
+	return requireRow(result, template.Name, template.Version)
+}
+
+// Delete removes a version of a template, or every version when version is 0
+func (r *SQLitePromptTemplateRepository) Delete(name string, version int) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Remember the highest version so that it is not reused
+	_, err = tx.Exec(
+		`INSERT INTO retired_template_versions (name, version)
+		 SELECT name, MAX(version) FROM prompt_templates WHERE name = ? GROUP BY name
+		 ON CONFLICT (name) DO UPDATE SET version = MAX(version, excluded.version)`,
+		name,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to retire template versions: %w", err)
+	}
+
+	var result sql.Result
+	if version == 0 {
+		result, err = tx.Exec(`DELETE FROM prompt_templates WHERE name = ?`, name)
+	} else {
+		result, err = tx.Exec(`DELETE FROM prompt_templates WHERE name = ? AND version = ?`, name, version)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete template: %w", err)
+	}
+
+	if err := requireRow(result, name, version); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Close closes the database connection
+func (r *SQLitePromptTemplateRepository) Close() error {
+	return r.db.Close()
+}
+
+// query runs a query returning templates
+func (r *SQLitePromptTemplateRepository) query(query string, args ...interface{}) ([]*domain.PromptTemplate, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query templates: %w", err)
+	}
+	defer rows.Close()
+
+	templates := []*domain.PromptTemplate{}
+	for rows.Next() {
+		template, err := scanTemplate(rows)
+		if err != nil {
+			return nil, err
+		}
+		templates = append(templates, template)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating templates: %w", err)
+	}
+
+	return templates, nil
+}
+
+// scanner is implemented by *sql.Row and *sql.Rows
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanTemplate reads a template from a row
+func scanTemplate(row scanner) (*domain.PromptTemplate, error) {
+	var template domain.PromptTemplate
+	var description sql.NullString
+
+	err := row.Scan(
+		&template.Name,
+		&template.Version,
+		&description,
+		&template.Template,
+		&template.Weight,
+		&template.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to scan template: %w", err)
+	}
+
+	template.Description = description.String
+	return &template, nil
+}
+
+// requireRow returns ErrTemplateNotFound when a statement affected no rows
+func requireRow(result sql.Result, name string, version int) error {
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if affected == 0 {
+		if version == 0 {
+			return fmt.Errorf("%w: %s", domain.ErrTemplateNotFound, name)
+		}
+		return fmt.Errorf("%w: %s version %d", domain.ErrTemplateNotFound, name, version)
+	}
+
+	return nil
+}
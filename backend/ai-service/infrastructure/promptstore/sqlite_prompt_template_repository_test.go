@@ -0,0 +1,117 @@
+package promptstore_test
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/augment-local-manus-clone/backend/ai-service/domain"
+	"github.com/augment-local-manus-clone/backend/ai-service/infrastructure/promptstore"
+)
+
+func TestSQLitePromptTemplateRepository(t *testing.T) {
+	repo, err := promptstore.NewSQLitePromptTemplateRepository(filepath.Join(t.TempDir(), "prompts.db"))
+	if err != nil {
+		t.Fatalf("NewSQLitePromptTemplateRepository() error = %v", err)
+	}
+	defer repo.Close()
+
+	for _, template := range []*domain.PromptTemplate{
+		{Name: "plan", Template: "Plan: {{.goal}}"},
+		{Name: "plan", Template: "Make a plan to {{.goal}}", Weight: 3},
+		{Name: "summarize", Template: "Summarize {{.text}}"},
+	} {
+		if err := repo.Create(template); err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+	}
+
+	latest, err := repo.List()
+	if err != nil || len(latest) != 2 || latest[0].Name != "plan" || latest[0].Version != 2 || latest[1].Name != "summarize" {
+		t.Errorf("List() = %+v, %v, want plan v2 and summarize v1", latest, err)
+	}
+
+	tests := []struct {
+		name         string
+		action       func() error
+		wantVersions []int
+		wantErr      error
+	}{
+		{
+			name:         "Versions are numbered in order",
+			action:       func() error { return nil },
+			wantVersions: []int{1, 2},
+		},
+		{
+			name: "Update changes the weight",
+			action: func() error {
+				return repo.Update(&domain.PromptTemplate{Name: "plan", Version: 1, Weight: 1, Description: "terse"})
+			},
+			wantVersions: []int{1, 2},
+		},
+		{
+			name: "Update of a missing version",
+			action: func() error {
+				return repo.Update(&domain.PromptTemplate{Name: "plan", Version: 9})
+			},
+			wantErr: domain.ErrTemplateNotFound,
+		},
+		{
+			name:         "Delete removes a version",
+			action:       func() error { return repo.Delete("plan", 2) },
+			wantVersions: []int{1},
+		},
+		{
+			name: "Deleted version numbers are not reused",
+			action: func() error {
+				return repo.Create(&domain.PromptTemplate{Name: "plan", Template: "Plan carefully: {{.goal}}"})
+			},
+			wantVersions: []int{1, 3},
+		},
+		{
+			name:    "Delete of a missing version",
+			action:  func() error { return repo.Delete("plan", 2) },
+			wantErr: domain.ErrTemplateNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.action()
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("error = %v", err)
+			}
+
+			versions, err := repo.ListVersions("plan")
+			if err != nil {
+				t.Fatalf("ListVersions() error = %v", err)
+			}
+			if len(versions) != len(tt.wantVersions) {
+				t.Fatalf("ListVersions() returned %d versions, want %v", len(versions), tt.wantVersions)
+			}
+			for i, version := range versions {
+				if version.Version != tt.wantVersions[i] {
+					t.Errorf("versions[%d] = %d, want %d", i, version.Version, tt.wantVersions[i])
+				}
+			}
+		})
+	}
+
+	template, err := repo.Get("plan", 1)
+	if err != nil || template.Weight != 1 || template.Description != "terse" || template.Template != "Plan: {{.goal}}" {
+		t.Errorf("Get() = %+v, %v, want the updated version 1", template, err)
+	}
+
+	if err := repo.Delete("plan", 0); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, err := repo.ListVersions("plan"); !errors.Is(err, domain.ErrTemplateNotFound) {
+		t.Errorf("ListVersions() error = %v, want %v", err, domain.ErrTemplateNotFound)
+	}
+}
@@ -0,0 +1,57 @@
+package tokenizer
+
+import (
+	"unicode"
+)
+
+// charsPerToken is the average number of letters or digits per token within
+// a word for the BPE vocabularies used by Llama, Qwen and DeepSeek models;
+// common short words are a single token
+const charsPerToken = 6
+
+// HeuristicTokenizer implements the Tokenizer interface by estimating token
+// counts without a model vocabulary. Runs of letters and digits count as one
+// token per charsPerToken characters; each CJK character, punctuation mark and
+// symbol counts as a token; whitespace is free. Estimates tend to err high
+// for markup, which keeps budgets conservative.
+type HeuristicTokenizer struct{}
+
+// NewHeuristicTokenizer creates a new HeuristicTokenizer
+func NewHeuristicTokenizer() *HeuristicTokenizer {
+	return &HeuristicTokenizer{}
+}
+
+// CountTokens estimates the number of tokens in the text
+func (t *HeuristicTokenizer) CountTokens(text string) int {
+	tokens := 0
+	run := 0
+
+	flush := func() {
+		tokens += (run + charsPerToken - 1) / charsPerToken
+		run = 0
+	}
+
+	for _, r := range text {
+		switch {
+		case isCJK(r):
+			flush()
+			tokens++
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsMark(r):
+			run++
+		case unicode.IsSpace(r):
+			flush()
+		default:
+			flush()
+			tokens++
+		}
+	}
+	flush()
+
+	return tokens
+}
+
+// isCJK reports whether r is a Chinese, Japanese or Korean character, which
+// tokenizers typically encode as at least one token each
+func isCJK(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul)
+}
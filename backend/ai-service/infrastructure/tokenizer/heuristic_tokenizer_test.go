@@ -0,0 +1,32 @@
+package tokenizer_test
+
+import (
+	"testing"
+
+	"github.com/augment-local-manus-clone/backend/ai-service/infrastructure/tokenizer"
+)
+
+func TestHeuristicTokenizerCountTokens(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want int
+	}{
+		{name: "Empty", text: "", want: 0},
+		{name: "Short words", text: "the cat sat", want: 3},
+		{name: "Long word", text: "internationalization", want: 4},
+		{name: "Punctuation", text: "Hello, world!", want: 4},
+		{name: "Markup", text: "<div class=\"a\">", want: 8},
+		{name: "CJK characters", text: "你好世界", want: 4},
+		{name: "Whitespace only", text: " \n\t ", want: 0},
+	}
+
+	tok := tokenizer.NewHeuristicTokenizer()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tok.CountTokens(tt.text); got != tt.want {
+				t.Errorf("CountTokens(%q) = %d, want %d", tt.text, got, tt.want)
+			}
+		})
+	}
+}
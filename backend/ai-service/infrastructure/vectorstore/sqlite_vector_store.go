@@ -0,0 +1,324 @@
+package vectorstore
+
+import (
+	"database/sql"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/augment-local-manus-clone/backend/ai-service/domain"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// SQLiteVectorStore implements the VectorStore interface with vectors stored
+// in SQLite and queried by brute-force cosine similarity. It also implements
+// DocumentIndexRepository to track which file versions have been indexed.
+type SQLiteVectorStore struct {
+	db *sql.DB
+}
+
+// NewSQLiteVectorStore creates a new SQLiteVectorStore
+func NewSQLiteVectorStore(dbPath string) (*SQLiteVectorStore, error) {
+	db, err := sql.Open("sqlite3", dbPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+
+	// Create vectors table if it doesn't exist
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS vectors (
+			collection TEXT NOT NULL,
+			id TEXT NOT NULL,
+			dimension INTEGER NOT NULL,
+			vector BLOB NOT NULL,
+			text TEXT,
+			metadata TEXT,
+			updated_at TIMESTAMP NOT NULL,
+			PRIMARY KEY (collection, id)
+		)
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create vectors table: %w", err)
+	}
+
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS indexed_documents (
+			collection TEXT NOT NULL,
+			path TEXT NOT NULL,
+			size INTEGER NOT NULL,
+			modified_time TIMESTAMP NOT NULL,
+			content_hash TEXT NOT NULL,
+			chunk_count INTEGER NOT NULL,
+			indexed_at TIMESTAMP NOT NULL,
+			PRIMARY KEY (collection, path)
+		)
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create indexed_documents table: %w", err)
+	}
+
+	return &SQLiteVectorStore{
+		db: db,
+	}, nil
+}
+
+// Upsert inserts the records or replaces existing records with the same collection and ID
+func (s *SQLiteVectorStore) Upsert(records []domain.VectorRecord) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Dimensions already used by each collection, including records earlier in this batch
+	dimensions := make(map[string]int)
+
+	for _, record := range records {
+		if err := record.Validate(); err != nil {
+			return err
+		}
+
+		dimension, ok := dimensions[record.Collection]
+		if !ok {
+			dimension, err = collectionDimension(tx, record.Collection, record.ID)
+			if err != nil {
+				return err
+			}
+		}
+		if dimension != 0 && dimension != len(record.Vector) {
+			return fmt.Errorf("%w: record %q has %d dimensions, collection %q has %d",
+				domain.ErrDimensionMismatch, record.ID, len(record.Vector), record.Collection, dimension)
+		}
+		dimensions[record.Collection] = len(record.Vector)
+
+		metadata, err := json.Marshal(record.Metadata)
+		if err != nil {
+			return fmt.Errorf("failed to marshal metadata: %w", err)
+		}
+
+		_, err = tx.Exec(
+			`INSERT INTO vectors (collection, id, dimension, vector, text, metadata, updated_at)
+			 VALUES (?, ?, ?, ?, ?, ?, ?)
+			 ON CONFLICT (collection, id) DO UPDATE SET
+				dimension = excluded.dimension,
+				vector = excluded.vector,
+				text = excluded.text,
+				metadata = excluded.metadata,
+				updated_at = excluded.updated_at`,
+			record.Collection,
+			record.ID,
+			len(record.Vector),
+			encodeVector(record.Vector),
+			record.Text,
+			string(metadata),
+			time.Now(),
+		)
+		if err != nil {
+			return fmt.Errorf("failed to upsert vector: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// Delete removes the records with the given IDs from a collection
+func (s *SQLiteVectorStore) Delete(collection string, ids []string) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, id := range ids {
+		if _, err := tx.Exec(`DELETE FROM vectors WHERE collection = ? AND id = ?`, collection, id); err != nil {
+			return fmt.Errorf("failed to delete vector: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// Query returns the k records in the collection most similar to the vector, best match first
+func (s *SQLiteVectorStore) Query(collection string, vector []float64, k int) ([]domain.VectorMatch, error) {
+	if k <= 0 {
+		return nil, errors.New("k must be positive")
+	}
+
+	if len(vector) == 0 {
+		return nil, errors.New("query vector cannot be empty")
+	}
+
+	rows, err := s.db.Query(
+		`SELECT id, dimension, vector, text, metadata FROM vectors WHERE collection = ?`,
+		collection,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query vectors: %w", err)
+	}
+	defer rows.Close()
+
+	var matches []domain.VectorMatch
+	for rows.Next() {
+		var (
+			match     domain.VectorMatch
+			dimension int
+			blob      []byte
+			text      sql.NullString
+			metadata  sql.NullString
+		)
+		if err := rows.Scan(&match.ID, &dimension, &blob, &text, &metadata); err != nil {
+			return nil, fmt.Errorf("failed to scan vector: %w", err)
+		}
+
+		if dimension != len(vector) {
+			return nil, fmt.Errorf("%w: query has %d dimensions, collection %q has %d",
+				domain.ErrDimensionMismatch, len(vector), collection, dimension)
+		}
+
+		match.Collection = collection
+		match.Text = text.String
+		if metadata.Valid && metadata.String != "" {
+			if err := json.Unmarshal([]byte(metadata.String), &match.Metadata); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal metadata: %w", err)
+			}
+		}
+		match.Score = domain.CosineSimilarity(vector, decodeVector(blob))
+
+		matches = append(matches, match)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read vectors: %w", err)
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].Score > matches[j].Score
+	})
+	if len(matches) > k {
+		matches = matches[:k]
+	}
+
+	return matches, nil
+}
+
+// ListDocuments returns every document indexed in a collection
+func (s *SQLiteVectorStore) ListDocuments(collection string) ([]*domain.IndexedDocument, error) {
+	rows, err := s.db.Query(
+		`SELECT path, size, modified_time, content_hash, chunk_count, indexed_at
+		 FROM indexed_documents WHERE collection = ? ORDER BY path`,
+		collection,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query documents: %w", err)
+	}
+	defer rows.Close()
+
+	var documents []*domain.IndexedDocument
+	for rows.Next() {
+		document := &domain.IndexedDocument{Collection: collection}
+		err := rows.Scan(
+			&document.Path,
+			&document.Size,
+			&document.ModifiedTime,
+			&document.ContentHash,
+			&document.ChunkCount,
+			&document.IndexedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan document: %w", err)
+		}
+		documents = append(documents, document)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read documents: %w", err)
+	}
+
+	return documents, nil
+}
+
+// SaveDocument inserts or replaces a document
+func (s *SQLiteVectorStore) SaveDocument(document *domain.IndexedDocument) error {
+	_, err := s.db.Exec(
+		`INSERT INTO indexed_documents (collection, path, size, modified_time, content_hash, chunk_count, indexed_at)
+		 VALUES (?, ?, ?, ?, ?, ?, ?)
+		 ON CONFLICT (collection, path) DO UPDATE SET
+			size = excluded.size,
+			modified_time = excluded.modified_time,
+			content_hash = excluded.content_hash,
+			chunk_count = excluded.chunk_count,
+			indexed_at = excluded.indexed_at`,
+		document.Collection,
+		document.Path,
+		document.Size,
+		document.ModifiedTime,
+		document.ContentHash,
+		document.ChunkCount,
+		document.IndexedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save document: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteDocument removes a document
+func (s *SQLiteVectorStore) DeleteDocument(collection, path string) error {
+	_, err := s.db.Exec(`DELETE FROM indexed_documents WHERE collection = ? AND path = ?`, collection, path)
+	if err != nil {
+		return fmt.Errorf("failed to delete document: %w", err)
+	}
+
+	return nil
+}
+
+// Close closes the database connection
+func (s *SQLiteVectorStore) Close() error {
+	return s.db.Close()
+}
+
+// collectionDimension returns the dimension of the collection's vectors,
+// ignoring the record being replaced, or 0 if there are none
+func collectionDimension(tx *sql.Tx, collection, replacedID string) (int, error) {
+	var dimension int
+	err := tx.QueryRow(
+		`SELECT dimension FROM vectors WHERE collection = ? AND id != ? LIMIT 1`,
+		collection, replacedID,
+	).Scan(&dimension)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to read collection dimension: %w", err)
+	}
+	return dimension, nil
+}
+
+// encodeVector packs a vector as little-endian float32 values
+func encodeVector(vector []float64) []byte {
+	blob := make([]byte, 4*len(vector))
+	for i, v := range vector {
+		binary.LittleEndian.PutUint32(blob[4*i:], math.Float32bits(float32(v)))
+	}
+	return blob
+}
+
+// decodeVector unpacks a vector encoded by encodeVector
+func decodeVector(blob []byte) []float64 {
+	vector := make([]float64, len(blob)/4)
+	for i := range vector {
+		vector[i] = float64(math.Float32frombits(binary.LittleEndian.Uint32(blob[4*i:])))
+	}
+	return vector
+}
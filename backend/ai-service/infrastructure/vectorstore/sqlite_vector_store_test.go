@@ -0,0 +1,133 @@
+package vectorstore_test
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/augment-local-manus-clone/backend/ai-service/domain"
+	"github.com/augment-local-manus-clone/backend/ai-service/infrastructure/vectorstore"
+)
+
+func TestSQLiteVectorStore(t *testing.T) {
+	store, err := vectorstore.NewSQLiteVectorStore(filepath.Join(t.TempDir(), "vectors.db"))
+	if err != nil {
+		t.Fatalf("NewSQLiteVectorStore() error = %v", err)
+	}
+	defer store.Close()
+
+	err = store.Upsert([]domain.VectorRecord{
+		{ID: "x", Collection: "files", Vector: []float64{1, 0}, Text: "east", Metadata: map[string]interface{}{"path": "a.txt"}},
+		{ID: "y", Collection: "files", Vector: []float64{0, 1}, Text: "north"},
+		{ID: "xy", Collection: "files", Vector: []float64{1, 1}, Text: "north-east"},
+		{ID: "other", Collection: "pages", Vector: []float64{1, 0, 0}},
+	})
+	if err != nil {
+		t.Fatalf("Upsert() error = %v", err)
+	}
+
+	matches, err := store.Query("files", []float64{1, 0}, 1)
+	if err != nil || len(matches) != 1 || matches[0].Text != "east" || matches[0].Metadata["path"] != "a.txt" {
+		t.Errorf("Query() = %+v, %v, want record x with its text and metadata", matches, err)
+	}
+
+	tests := []struct {
+		name       string
+		before     func() error
+		collection string
+		vector     []float64
+		k          int
+		wantIDs    []string
+		wantErr    error
+	}{
+		{
+			name:    "Top-k by cosine similarity",
+			vector:  []float64{1, 0.1},
+			k:       2,
+			wantIDs: []string{"x", "xy"},
+		},
+		{
+			name: "Upsert replaces existing record",
+			before: func() error {
+				return store.Upsert([]domain.VectorRecord{{ID: "y", Collection: "files", Vector: []float64{1, 0.2}}})
+			},
+			vector:  []float64{1, 0.1},
+			k:       1,
+			wantIDs: []string{"y"},
+		},
+		{
+			name:    "Delete removes records",
+			before:  func() error { return store.Delete("files", []string{"y", "x"}) },
+			vector:  []float64{1, 0.1},
+			k:       5,
+			wantIDs: []string{"xy"},
+		},
+		{
+			name:    "Dimension mismatch on query",
+			vector:  []float64{1, 0, 0},
+			k:       1,
+			wantErr: domain.ErrDimensionMismatch,
+		},
+		{
+			name: "Dimension mismatch on upsert",
+			before: func() error {
+				return store.Upsert([]domain.VectorRecord{{ID: "z", Collection: "files", Vector: []float64{1}}})
+			},
+			wantErr: domain.ErrDimensionMismatch,
+		},
+		{
+			name:       "Empty collection",
+			collection: "empty",
+			vector:     []float64{1, 0},
+			k:          1,
+			wantIDs:    nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.before != nil {
+				if err := tt.before(); err != nil {
+					if !errors.Is(err, tt.wantErr) {
+						t.Fatalf("setup error = %v, want %v", err, tt.wantErr)
+					}
+					return
+				}
+			}
+
+			collection := tt.collection
+			if collection == "" {
+				collection = "files"
+			}
+
+			matches, err := store.Query(collection, tt.vector, tt.k)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Query() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Query() error = %v", err)
+			}
+
+			var ids []string
+			for _, match := range matches {
+				ids = append(ids, match.ID)
+			}
+			if len(ids) != len(tt.wantIDs) {
+				t.Fatalf("Query() ids = %v, want %v", ids, tt.wantIDs)
+			}
+			for i := range ids {
+				if ids[i] != tt.wantIDs[i] {
+					t.Errorf("Query() ids = %v, want %v", ids, tt.wantIDs)
+				}
+			}
+		})
+	}
+
+	matches, err = store.Query("pages", []float64{1, 0, 0}, 1)
+	if err != nil || len(matches) != 1 || matches[0].Score < 0.999 {
+		t.Errorf("Query(pages) = %+v, %v", matches, err)
+	}
+}
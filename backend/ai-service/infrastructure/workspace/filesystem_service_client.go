@@ -0,0 +1,137 @@
+package workspace
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/augment-local-manus-clone/backend/ai-service/domain"
+)
+
+// maxListDepth bounds how deep ListFiles descends into nested directories
+const maxListDepth = 32
+
+// FilesystemServiceClient implements the Workspace interface using the filesystem-service API
+type FilesystemServiceClient struct {
+	baseURL string
+	client  *http.Client
+}
+
+// fileInfo represents a directory entry returned by the filesystem-service
+type fileInfo struct {
+	Path         string    `json:"path"`
+	Type         string    `json:"type"`
+	Size         int64     `json:"size"`
+	ModifiedTime time.Time `json:"modified_time"`
+	IsHidden     bool      `json:"is_hidden"`
+}
+
+// fileContent represents a file returned by the filesystem-service
+type fileContent struct {
+	Path    string `json:"path"`
+	Content string `json:"content"`
+}
+
+// NewFilesystemServiceClient creates a new FilesystemServiceClient
+func NewFilesystemServiceClient(baseURL string) (*FilesystemServiceClient, error) {
+	if baseURL == "" {
+		return nil, fmt.Errorf("baseURL cannot be empty")
+	}
+
+	return &FilesystemServiceClient{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		client: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+	}, nil
+}
+
+// ListFiles returns every non-hidden file in the workspace, descending into
+// non-hidden directories
+func (c *FilesystemServiceClient) ListFiles(ctx context.Context) ([]domain.WorkspaceFile, error) {
+	var files []domain.WorkspaceFile
+	if err := c.walk(ctx, ".", 0, &files); err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+// walk lists a directory and recurses into its subdirectories
+func (c *FilesystemServiceClient) walk(ctx context.Context, dir string, depth int, files *[]domain.WorkspaceFile) error {
+	if depth > maxListDepth {
+		return nil
+	}
+
+	var entries []fileInfo
+	if err := c.get(ctx, "/files?path="+url.QueryEscape(dir), &entries); err != nil {
+		return fmt.Errorf("failed to list %s: %w", dir, err)
+	}
+
+	for _, entry := range entries {
+		if entry.IsHidden {
+			continue
+		}
+
+		entryPath := path.Clean(strings.TrimPrefix(entry.Path, "./"))
+		if entry.Type == "directory" {
+			if err := c.walk(ctx, entryPath, depth+1, files); err != nil {
+				return err
+			}
+			continue
+		}
+
+		*files = append(*files, domain.WorkspaceFile{
+			Path:         entryPath,
+			Size:         entry.Size,
+			ModifiedTime: entry.ModifiedTime,
+		})
+	}
+
+	return nil
+}
+
+// ReadFile returns the content of a file
+func (c *FilesystemServiceClient) ReadFile(ctx context.Context, filePath string) (string, error) {
+	segments := strings.Split(strings.TrimPrefix(filePath, "/"), "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+
+	var content fileContent
+	if err := c.get(ctx, "/files/"+strings.Join(segments, "/"), &content); err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", filePath, err)
+	}
+
+	return content.Content, nil
+}
+
+// get sends a GET request and decodes the JSON response
+func (c *FilesystemServiceClient) get(ctx context.Context, requestPath string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+requestPath, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// Check response status
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	// Decode response
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return nil
+}
@@ -1,29 +1,242 @@
 package main
 
 import (
+	"encoding/json"
+	"fmt"
 	"log"
+	"net"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/augment-local-manus-clone/backend/ai-service/delivery/http"
+	"github.com/augment-local-manus-clone/backend/ai-service/domain"
+	"github.com/augment-local-manus-clone/backend/ai-service/infrastructure/batchstore"
+	"github.com/augment-local-manus-clone/backend/ai-service/infrastructure/cache"
+	"github.com/augment-local-manus-clone/backend/ai-service/infrastructure/cassette"
 	"github.com/augment-local-manus-clone/backend/ai-service/infrastructure/llm"
+	"github.com/augment-local-manus-clone/backend/ai-service/infrastructure/memorystore"
+	"github.com/augment-local-manus-clone/backend/ai-service/infrastructure/promptstore"
+	"github.com/augment-local-manus-clone/backend/ai-service/infrastructure/tokenizer"
+	"github.com/augment-local-manus-clone/backend/ai-service/infrastructure/vectorstore"
+	"github.com/augment-local-manus-clone/backend/ai-service/infrastructure/workspace"
 	"github.com/augment-local-manus-clone/backend/ai-service/usecase"
 	"github.com/gin-gonic/gin"
 )
 
+// openAICompatibleProviders maps provider names to the environment variable
+// prefix used to configure them; a provider is registered when <PREFIX>_URL is set
+var openAICompatibleProviders = map[string]string{
+	"llamacpp": "LLAMACPP",
+	"vllm":     "VLLM",
+	"lmstudio": "LMSTUDIO",
+}
+
 func main() {
-	// Initialize Ollama client
-	ollamaClient, err := llm.NewOllamaClient("http://localhost:11434", "deepseek-r1")
+	// Initialize LLM providers
+	registry := llm.NewRegistry(getEnv("LLM_PROVIDER", "ollama"))
+
+	ollamaClient, err := llm.NewOllamaClient(
+		ollamaHost(getEnv("OLLAMA_HOST", "localhost:11434")),
+		getEnv("OLLAMA_MODEL", "deepseek-r1"),
+		getEnv("OLLAMA_EMBED_MODEL", "nomic-embed-text"),
+	)
 	if err != nil {
 		log.Fatalf("Failed to initialize Ollama client: %v", err)
 	}
+	if err := registry.Register("ollama", ollamaClient); err != nil {
+		log.Fatalf("Failed to register Ollama client: %v", err)
+	}
+
+	fakeClient, err := loadFakeClient()
+	if err != nil {
+		log.Fatalf("Failed to initialize fake LLM client: %v", err)
+	}
+	if fakeClient != nil {
+		if err := registry.Register("fake", fakeClient); err != nil {
+			log.Fatalf("Failed to register fake LLM client: %v", err)
+		}
+	}
+
+	for name, prefix := range openAICompatibleProviders {
+		baseURL := os.Getenv(prefix + "_URL")
+		if baseURL == "" {
+			continue
+		}
+
+		client, err := llm.NewOpenAIClient(baseURL, os.Getenv(prefix+"_MODEL"), os.Getenv(prefix+"_EMBED_MODEL"), os.Getenv(prefix+"_API_KEY"))
+		if err != nil {
+			log.Fatalf("Failed to initialize %s client: %v", name, err)
+		}
+		if err := registry.Register(name, client); err != nil {
+			log.Fatalf("Failed to register %s client: %v", name, err)
+		}
+	}
+
+	if _, err := registry.Client(""); err != nil {
+		log.Fatalf("Default LLM provider is not configured: %v", err)
+	}
+	log.Printf("LLM providers: %s", strings.Join(registry.Providers(), ", "))
+
+	// Initialize recording or replay of the providers' interactions
+	var llmClient domain.LLMClient = registry
+	recorder, replayer, err := loadCassette(registry)
+	if err != nil {
+		log.Fatalf("Failed to initialize cassette: %v", err)
+	}
+	if recorder != nil {
+		llmClient = recorder
+	}
+	if replayer != nil {
+		llmClient = replayer
+	}
+
+	// Initialize admission control in front of the providers
+	admissionController, err := loadAdmissionController(llmClient)
+	if err != nil {
+		log.Fatalf("Failed to initialize admission control: %v", err)
+	}
+	if admissionController != nil {
+		llmClient = admissionController
+	}
+
+	// Initialize response cache in front of admission control, so that
+	// cached responses do not wait for a slot
+	responseCache, err := loadResponseCache(llmClient)
+	if err != nil {
+		log.Fatalf("Failed to initialize response cache: %v", err)
+	}
+	if responseCache != nil {
+		llmClient = responseCache
+	}
+
+	// Initialize model routing
+	modelRouter, err := loadModelRouter(os.Getenv("MODEL_ROUTES_FILE"))
+	if err != nil {
+		log.Fatalf("Failed to initialize model routing: %v", err)
+	}
+
+	// Initialize guardrails
+	guardrails, err := loadGuardrails(os.Getenv("GUARDRAILS_FILE"))
+	if err != nil {
+		log.Fatalf("Failed to initialize guardrails: %v", err)
+	}
+
+	// Initialize SQLite vector store
+	vectorStore, err := vectorstore.NewSQLiteVectorStore(getEnv("VECTOR_DB_PATH", "./vectors.db"))
+	if err != nil {
+		log.Fatalf("Failed to initialize vector store: %v", err)
+	}
+
+	// Initialize SQLite prompt template repository
+	promptTemplateRepo, err := promptstore.NewSQLitePromptTemplateRepository(getEnv("PROMPT_DB_PATH", "./prompts.db"))
+	if err != nil {
+		log.Fatalf("Failed to initialize prompt template repository: %v", err)
+	}
+
+	// Initialize SQLite memory repository
+	memoryRepo, err := memorystore.NewSQLiteMemoryRepository(getEnv("MEMORY_DB_PATH", "./memory.db"))
+	if err != nil {
+		log.Fatalf("Failed to initialize memory repository: %v", err)
+	}
+
+	memoryTurnBudget, err := strconv.Atoi(getEnv("MEMORY_TURN_BUDGET", "2000"))
+	if err != nil {
+		log.Fatalf("Invalid MEMORY_TURN_BUDGET: %v", err)
+	}
+
+	memoryMaxFacts, err := strconv.Atoi(getEnv("MEMORY_MAX_FACTS", "50"))
+	if err != nil {
+		log.Fatalf("Invalid MEMORY_MAX_FACTS: %v", err)
+	}
+
+	// Initialize SQLite batch repository
+	batchRepo, err := batchstore.NewSQLiteBatchRepository(getEnv("BATCH_DB_PATH", "./batch.db"))
+	if err != nil {
+		log.Fatalf("Failed to initialize batch repository: %v", err)
+	}
+
+	batchWorkers, err := strconv.Atoi(getEnv("BATCH_WORKERS", "4"))
+	if err != nil {
+		log.Fatalf("Invalid BATCH_WORKERS: %v", err)
+	}
+
+	// Initialize workspace client
+	workspaceClient, err := workspace.NewFilesystemServiceClient(getEnv("FILESYSTEM_SERVICE_URL", "http://localhost:8085"))
+	if err != nil {
+		log.Fatalf("Failed to initialize workspace client: %v", err)
+	}
+
+	ragSyncSeconds, err := strconv.Atoi(getEnv("RAG_SYNC_INTERVAL_SECONDS", "30"))
+	if err != nil {
+		log.Fatalf("Invalid RAG_SYNC_INTERVAL_SECONDS: %v", err)
+	}
+
+	// Initialize context window management
+	contextSizes, err := parseContextSizes(os.Getenv("MODEL_CONTEXT_LENGTHS"))
+	if err != nil {
+		log.Fatalf("Invalid MODEL_CONTEXT_LENGTHS: %v", err)
+	}
+
+	defaultContextLength, err := strconv.Atoi(getEnv("DEFAULT_CONTEXT_LENGTH", "4096"))
+	if err != nil {
+		log.Fatalf("Invalid DEFAULT_CONTEXT_LENGTH: %v", err)
+	}
+
+	heuristicTokenizer := tokenizer.NewHeuristicTokenizer()
+	contextWindow := usecase.NewContextWindowManager(heuristicTokenizer, contextSizes, defaultContextLength)
 
 	// Initialize use cases
-	processAIRequestUseCase := usecase.NewProcessAIRequestUseCase(ollamaClient)
+	promptTemplateUseCase := usecase.NewPromptTemplateUseCase(promptTemplateRepo)
+	memoryUseCase := usecase.NewMemoryUseCase(llmClient, memoryRepo, heuristicTokenizer, usecase.MemoryConfig{
+		TurnBudget: memoryTurnBudget,
+		MaxFacts:   memoryMaxFacts,
+	})
+	processAIRequestUseCase := usecase.NewProcessAIRequestUseCase(llmClient, modelRouter, contextWindow, promptTemplateUseCase, guardrails, memoryUseCase)
+	chatUseCase := usecase.NewChatUseCase(llmClient, contextWindow, guardrails, memoryUseCase)
+	embedUseCase := usecase.NewEmbedUseCase(llmClient)
+	vectorIndexUseCase := usecase.NewVectorIndexUseCase(llmClient, vectorStore)
+	modelManagementUseCase := usecase.NewModelManagementUseCase(ollamaClient, modelRouter)
+	plannerUseCase := usecase.NewPlannerUseCase(processAIRequestUseCase)
+	batchUseCase := usecase.NewBatchUseCase(processAIRequestUseCase, batchRepo, batchWorkers)
+	ragUseCase := usecase.NewRAGUseCase(
+		llmClient,
+		vectorStore,
+		vectorStore,
+		workspaceClient,
+		processAIRequestUseCase,
+		time.Duration(ragSyncSeconds)*time.Second,
+	)
+
+	// Resume the batch jobs left unfinished by a previous run
+	if err := batchUseCase.Resume(); err != nil {
+		log.Fatalf("Failed to resume batch jobs: %v", err)
+	}
 
 	// Initialize Gin router
 	router := gin.Default()
+	router.Use(http.Admission())
 
 	// Register HTTP handlers
-	http.NewAIHandler(router, processAIRequestUseCase)
+	http.NewAIHandler(router, processAIRequestUseCase, chatUseCase)
+	http.NewEmbeddingHandler(router, embedUseCase, vectorIndexUseCase)
+	http.NewRAGHandler(router, ragUseCase)
+	http.NewPromptTemplateHandler(router, promptTemplateUseCase)
+	http.NewModelHandler(router, modelManagementUseCase)
+	http.NewMemoryHandler(router, memoryUseCase)
+	http.NewPlanHandler(router, plannerUseCase)
+	http.NewBatchHandler(router, batchUseCase)
+	if responseCache != nil {
+		http.NewCacheHandler(router, responseCache)
+	}
+	if admissionController != nil {
+		http.NewAdmissionHandler(router, admissionController)
+	}
+	if recorder != nil || replayer != nil {
+		http.NewCassetteHandler(router, recorder, replayer)
+	}
 
 	// Start server
 	log.Println("Starting AI Service on :8082")
@@ -31,3 +244,206 @@ func main() {
 		log.Fatalf("Failed to start server: %v", err)
 	}
 }
+
+// getEnv returns the value of an environment variable, or fallback when it is unset
+func getEnv(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
+
+// loadModelRouter reads the routing configuration from a JSON file; routing
+// is disabled when no file is configured
+func loadModelRouter(path string) (*usecase.ModelRouter, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var config usecase.RouterConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, err
+	}
+
+	return usecase.NewModelRouter(config)
+}
+
+// loadGuardrails reads the guardrail configuration from a JSON file; without
+// a file every injection detector runs and there are no deny patterns
+func loadGuardrails(path string) (*usecase.Guardrails, error) {
+	var config usecase.GuardrailConfig
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := json.Unmarshal(data, &config); err != nil {
+			return nil, err
+		}
+	}
+
+	return usecase.NewGuardrails(config)
+}
+
+// loadFakeClient builds the scripted fake client, which is registered as the
+// fake provider when FAKE_LLM_SCRIPT is set or fake is the default provider.
+// Without a script, every request gets the same canned reply.
+func loadFakeClient() (*llm.FakeLLMClient, error) {
+	if path := os.Getenv("FAKE_LLM_SCRIPT"); path != "" {
+		return llm.LoadFakeLLMClient(path)
+	}
+
+	if getEnv("LLM_PROVIDER", "ollama") != "fake" {
+		return nil, nil
+	}
+
+	return llm.NewFakeLLMClient(llm.FakeScript{
+		Default: &llm.FakeReply{Text: "This is a fake response."},
+	})
+}
+
+// loadCassette builds the recorder or replayer selected by LLM_CASSETTE_MODE
+// for the cassette at LLM_CASSETTE; both are nil when no cassette is configured
+func loadCassette(next domain.LLMClient) (*usecase.CassetteRecorder, *usecase.CassetteReplayer, error) {
+	path := os.Getenv("LLM_CASSETTE")
+	if path == "" {
+		return nil, nil, nil
+	}
+
+	jsonlCassette, err := cassette.NewJSONLCassette(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	switch mode := getEnv("LLM_CASSETTE_MODE", "record"); mode {
+	case "record":
+		log.Printf("Recording LLM interactions to %s", path)
+		return usecase.NewCassetteRecorder(next, jsonlCassette), nil, nil
+	case "replay":
+		replayer, err := usecase.NewCassetteReplayer(jsonlCassette, domain.CassetteMatch(getEnv("LLM_CASSETTE_MATCH", "strict")))
+		if err != nil {
+			return nil, nil, err
+		}
+		log.Printf("Replaying LLM interactions from %s", path)
+		return nil, replayer, nil
+	default:
+		return nil, nil, fmt.Errorf("invalid LLM_CASSETTE_MODE %q", mode)
+	}
+}
+
+// loadAdmissionController builds the admission controller; admission control
+// is disabled when LLM_MAX_CONCURRENT is 0
+func loadAdmissionController(next domain.LLMClient) (*usecase.AdmissionController, error) {
+	maxConcurrent, err := strconv.Atoi(getEnv("LLM_MAX_CONCURRENT", "1"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid LLM_MAX_CONCURRENT: %w", err)
+	}
+
+	maxQueue, err := strconv.Atoi(getEnv("LLM_MAX_QUEUE", "32"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid LLM_MAX_QUEUE: %w", err)
+	}
+
+	preempt, err := strconv.ParseBool(getEnv("LLM_PREEMPT_BATCH", "true"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid LLM_PREEMPT_BATCH: %w", err)
+	}
+
+	if maxConcurrent == 0 {
+		return nil, nil
+	}
+
+	return usecase.NewAdmissionController(next, usecase.AdmissionConfig{
+		MaxConcurrent: maxConcurrent,
+		MaxQueue:      maxQueue,
+		Preempt:       preempt,
+	})
+}
+
+// loadResponseCache builds the response cache from its in-memory and on-disk
+// tiers; caching is disabled when neither tier is configured
+func loadResponseCache(next domain.LLMClient) (*usecase.ResponseCache, error) {
+	entries, err := strconv.Atoi(getEnv("RESPONSE_CACHE_ENTRIES", "256"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid RESPONSE_CACHE_ENTRIES: %w", err)
+	}
+
+	ttlSeconds, err := strconv.Atoi(getEnv("RESPONSE_CACHE_TTL_SECONDS", "86400"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid RESPONSE_CACHE_TTL_SECONDS: %w", err)
+	}
+
+	var tiers []usecase.CacheTier
+	if entries > 0 {
+		memory, err := cache.NewLRUCache(entries)
+		if err != nil {
+			return nil, err
+		}
+		tiers = append(tiers, usecase.CacheTier{Name: "memory", Store: memory})
+	}
+
+	if dir := os.Getenv("RESPONSE_CACHE_DIR"); dir != "" {
+		disk, err := cache.NewDiskCache(dir)
+		if err != nil {
+			return nil, err
+		}
+		tiers = append(tiers, usecase.CacheTier{Name: "disk", Store: disk})
+	}
+
+	if len(tiers) == 0 {
+		return nil, nil
+	}
+
+	return usecase.NewResponseCache(next, time.Duration(ttlSeconds)*time.Second, tiers...)
+}
+
+// parseContextSizes parses a comma-separated list of model=length pairs,
+// e.g. "deepseek-r1=8192,qwen3:14b=32768"
+func parseContextSizes(value string) (map[string]int, error) {
+	sizes := make(map[string]int)
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		model, length, found := strings.Cut(entry, "=")
+		if !found {
+			return nil, fmt.Errorf("expected model=length, got %q", entry)
+		}
+
+		size, err := strconv.Atoi(strings.TrimSpace(length))
+		if err != nil || size <= 0 {
+			return nil, fmt.Errorf("invalid context length for %q", model)
+		}
+		sizes[strings.TrimSpace(model)] = size
+	}
+	return sizes, nil
+}
+
+// ollamaHost normalises an OLLAMA_HOST value, which like the Ollama CLI may
+// omit the scheme and port, into a base URL
+func ollamaHost(host string) string {
+	if !strings.Contains(host, "://") {
+		host = "http://" + host
+	}
+
+	u, err := url.Parse(host)
+	if err != nil {
+		return strings.TrimRight(host, "/")
+	}
+
+	// Add the default port when the host part has none
+	if u.Port() == "" {
+		u.Host = net.JoinHostPort(u.Hostname(), "11434")
+	}
+	u.Path = strings.TrimRight(u.Path, "/")
+
+	return u.String()
+}
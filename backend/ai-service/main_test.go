@@ -0,0 +1,26 @@
+package main
+
+import "testing"
+
+func TestOllamaHost(t *testing.T) {
+	tests := []struct {
+		host string
+		want string
+	}{
+		{"localhost:11434", "http://localhost:11434"},
+		{"ollama.local", "http://ollama.local:11434"},
+		{"https://ollama.local/", "https://ollama.local:11434"},
+		{"http://ollama.local/api", "http://ollama.local:11434/api"},
+		{"http://ollama.local:8080/api/", "http://ollama.local:8080/api"},
+		{"[::1]", "http://[::1]:11434"},
+		{"http://[::1]:8080", "http://[::1]:8080"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.host, func(t *testing.T) {
+			if got := ollamaHost(tt.host); got != tt.want {
+				t.Errorf("ollamaHost(%q) = %q, want %q", tt.host, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,435 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/augment-local-manus-clone/backend/ai-service/domain"
+)
+
+// defaultCallDuration is the assumed length of a model call before any has completed
+const defaultCallDuration = 10 * time.Second
+
+// AdmissionConfig configures admission control
+type AdmissionConfig struct {
+	// MaxConcurrent is the number of model calls that may run at once
+	MaxConcurrent int
+
+	// MaxQueue is the number of calls that may wait for a slot; further calls are rejected
+	MaxQueue int
+
+	// Preempt lets a queued interactive call cancel a running batch call,
+	// which is queued again and retried from the start
+	Preempt bool
+}
+
+// AdmissionController implements the LLMClient interface by limiting how many
+// model calls run at once. Calls that cannot run wait in a bounded queue with
+// one lane per priority: interactive calls are served before batch calls, and
+// within a lane clients take turns so that one client cannot starve the others.
+type AdmissionController struct {
+	next          domain.LLMClient
+	maxConcurrent int
+	maxQueue      int
+	preempt       bool
+
+	mu      sync.Mutex
+	running []*admissionTicket
+	lanes   map[domain.Priority]*admissionLane
+	queued  int
+
+	// avgDuration is a moving average of call durations, used to estimate Retry-After
+	avgDuration time.Duration
+
+	admitted  int64
+	rejected  int64
+	preempted int64
+}
+
+// admissionTicket is a single call waiting for, or holding, a slot
+type admissionTicket struct {
+	admission   domain.Admission
+	preemptible bool
+	cancel      context.CancelFunc
+
+	// ready is closed when the ticket is granted a slot
+	ready   chan struct{}
+	granted bool
+	started time.Time
+
+	// preempted is set when an interactive call cancelled this one
+	preempted bool
+}
+
+// NewAdmissionController creates a new AdmissionController in front of next
+func NewAdmissionController(next domain.LLMClient, config AdmissionConfig) (*AdmissionController, error) {
+	if config.MaxConcurrent <= 0 {
+		return nil, errors.New("max concurrent calls must be positive")
+	}
+
+	if config.MaxQueue < 0 {
+		return nil, errors.New("max queue length cannot be negative")
+	}
+
+	lanes := make(map[domain.Priority]*admissionLane, len(domain.Priorities))
+	for _, priority := range domain.Priorities {
+		lanes[priority] = newAdmissionLane()
+	}
+
+	return &AdmissionController{
+		next:          next,
+		maxConcurrent: config.MaxConcurrent,
+		maxQueue:      config.MaxQueue,
+		preempt:       config.Preempt,
+		lanes:         lanes,
+	}, nil
+}
+
+// Process processes the request once it is admitted
+func (a *AdmissionController) Process(ctx context.Context, request *domain.AIRequest) (*domain.AIResponse, error) {
+	var response *domain.AIResponse
+	err := a.run(ctx, true, func(ctx context.Context) (err error) {
+		response, err = a.next.Process(ctx, request)
+		return err
+	})
+	return response, err
+}
+
+// ProcessStream streams the request once it is admitted. Streams are never
+// pre-empted since their output cannot be taken back.
+func (a *AdmissionController) ProcessStream(ctx context.Context, request *domain.AIRequest, handler domain.StreamHandler) (*domain.AIResponse, error) {
+	var response *domain.AIResponse
+	err := a.run(ctx, false, func(ctx context.Context) (err error) {
+		response, err = a.next.ProcessStream(ctx, request, handler)
+		return err
+	})
+	return response, err
+}
+
+// Chat sends the conversation once it is admitted
+func (a *AdmissionController) Chat(ctx context.Context, request *domain.ChatRequest) (*domain.ChatResponse, error) {
+	var response *domain.ChatResponse
+	err := a.run(ctx, true, func(ctx context.Context) (err error) {
+		response, err = a.next.Chat(ctx, request)
+		return err
+	})
+	return response, err
+}
+
+// Embed embeds the texts once the request is admitted
+func (a *AdmissionController) Embed(ctx context.Context, request *domain.EmbedRequest) (*domain.EmbedResponse, error) {
+	var response *domain.EmbedResponse
+	err := a.run(ctx, true, func(ctx context.Context) (err error) {
+		response, err = a.next.Embed(ctx, request)
+		return err
+	})
+	return response, err
+}
+
+// Stats returns the number of running and queued calls
+func (a *AdmissionController) Stats() domain.AdmissionStats {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	stats := domain.AdmissionStats{
+		MaxConcurrent:  a.maxConcurrent,
+		MaxQueue:       a.maxQueue,
+		Running:        make(map[domain.Priority]int, len(domain.Priorities)),
+		Queued:         make(map[domain.Priority]int, len(domain.Priorities)),
+		QueueDepth:     a.queued,
+		QueuedByClient: make(map[string]int),
+		Admitted:       a.admitted,
+		Rejected:       a.rejected,
+		Preempted:      a.preempted,
+	}
+
+	for _, priority := range domain.Priorities {
+		stats.Running[priority] = 0
+		stats.Queued[priority] = 0
+	}
+	for _, ticket := range a.running {
+		stats.Running[ticket.admission.Priority]++
+	}
+	for priority, lane := range a.lanes {
+		for client, tickets := range lane.queues {
+			stats.Queued[priority] += len(tickets)
+			stats.QueuedByClient[client] += len(tickets)
+		}
+	}
+
+	return stats
+}
+
+// run admits a call, runs it and releases its slot. A pre-empted call that
+// failed is queued again ahead of the other calls of its client and retried.
+func (a *AdmissionController) run(ctx context.Context, preemptible bool, call func(ctx context.Context) error) error {
+	admission := domain.AdmissionFrom(ctx)
+	requeue := false
+
+	for {
+		callCtx, cancel := context.WithCancel(ctx)
+		ticket := &admissionTicket{
+			admission:   admission,
+			preemptible: preemptible && admission.Priority == domain.PriorityBatch,
+			cancel:      cancel,
+			ready:       make(chan struct{}),
+		}
+
+		if err := a.acquire(ctx, ticket, requeue); err != nil {
+			cancel()
+			return err
+		}
+
+		err := call(callCtx)
+		cancel()
+
+		// A call that finished before it was pre-empted keeps its result
+		if a.release(ticket) && err != nil && ctx.Err() == nil {
+			requeue = true
+			continue
+		}
+		return err
+	}
+}
+
+// acquire waits until the ticket is granted a slot, the queue turns out to be
+// full or the context is done. A requeued ticket goes to the front of its
+// client's queue and is never rejected.
+func (a *AdmissionController) acquire(ctx context.Context, ticket *admissionTicket, requeue bool) error {
+	a.mu.Lock()
+
+	if len(a.running) < a.maxConcurrent && a.queued == 0 {
+		a.grant(ticket)
+		a.mu.Unlock()
+		return nil
+	}
+
+	if a.queued >= a.maxQueue && !requeue {
+		a.rejected++
+		retryAfter := a.retryAfter()
+		a.mu.Unlock()
+		return &domain.QueueFullError{RetryAfter: retryAfter}
+	}
+
+	a.lanes[ticket.admission.Priority].push(ticket, requeue)
+	a.queued++
+
+	if a.preempt && ticket.admission.Priority == domain.PriorityInteractive {
+		a.preemptBatch()
+	}
+	a.mu.Unlock()
+
+	// Time spent queued does not count against a routed attempt's timeout
+	clock, _ := ctx.Value(attemptClockKey{}).(*attemptClock)
+	if clock != nil {
+		clock.pause()
+	}
+
+	select {
+	case <-ticket.ready:
+		if clock != nil {
+			clock.resume()
+		}
+		return nil
+
+	case <-ctx.Done():
+		a.mu.Lock()
+		if ticket.granted {
+			a.mu.Unlock()
+			a.release(ticket)
+			return ctx.Err()
+		}
+		a.lanes[ticket.admission.Priority].remove(ticket)
+		a.queued--
+		a.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+// release frees the ticket's slot for the next queued call and reports
+// whether the ticket was pre-empted
+func (a *AdmissionController) release(ticket *admissionTicket) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for i, running := range a.running {
+		if running == ticket {
+			a.running = append(a.running[:i], a.running[i+1:]...)
+			break
+		}
+	}
+
+	// Pre-empted calls did not run to completion, so their duration says nothing
+	if !ticket.preempted {
+		duration := time.Since(ticket.started)
+		if a.avgDuration == 0 {
+			a.avgDuration = duration
+		} else {
+			a.avgDuration = (4*a.avgDuration + duration) / 5
+		}
+	}
+
+	a.dispatch()
+	return ticket.preempted
+}
+
+// dispatch grants free slots to queued tickets, highest priority first; a.mu must be held
+func (a *AdmissionController) dispatch() {
+	for len(a.running) < a.maxConcurrent {
+		var next *admissionTicket
+		for _, priority := range domain.Priorities {
+			if next = a.lanes[priority].pop(); next != nil {
+				break
+			}
+		}
+		if next == nil {
+			return
+		}
+
+		a.queued--
+		a.grant(next)
+	}
+}
+
+// grant gives the ticket a slot; a.mu must be held
+func (a *AdmissionController) grant(ticket *admissionTicket) {
+	ticket.granted = true
+	ticket.started = time.Now()
+	a.running = append(a.running, ticket)
+	a.admitted++
+	close(ticket.ready)
+}
+
+// preemptBatch cancels the most recently started pre-emptible batch call,
+// unless enough calls are already being pre-empted for the queued
+// interactive calls; a.mu must be held
+func (a *AdmissionController) preemptBatch() {
+	waiting := a.lanes[domain.PriorityInteractive].len()
+
+	var victim *admissionTicket
+	for _, ticket := range a.running {
+		if ticket.preempted {
+			waiting--
+			continue
+		}
+		if ticket.preemptible && (victim == nil || ticket.started.After(victim.started)) {
+			victim = ticket
+		}
+	}
+
+	if victim == nil || waiting <= 0 {
+		return
+	}
+
+	victim.preempted = true
+	a.preempted++
+	victim.cancel()
+}
+
+// retryAfter estimates when a slot will be free for a new call; a.mu must be held
+func (a *AdmissionController) retryAfter() time.Duration {
+	duration := a.avgDuration
+	if duration == 0 {
+		duration = defaultCallDuration
+	}
+
+	wait := duration * time.Duration(a.queued+1) / time.Duration(a.maxConcurrent)
+	return wait.Round(time.Second) + time.Second
+}
+
+// admissionLane is the queue of one priority. Each client has its own FIFO
+// queue, and clients with waiting tickets take turns.
+type admissionLane struct {
+	queues  map[string][]*admissionTicket
+	clients []string
+}
+
+// newAdmissionLane creates an empty lane
+func newAdmissionLane() *admissionLane {
+	return &admissionLane{queues: make(map[string][]*admissionTicket)}
+}
+
+// push adds a ticket to the back of its client's queue, or to the front with
+// the client's turn coming next
+func (l *admissionLane) push(ticket *admissionTicket, front bool) {
+	client := ticket.admission.Client
+	queue, waiting := l.queues[client]
+
+	if front {
+		l.queues[client] = append([]*admissionTicket{ticket}, queue...)
+		if waiting {
+			l.dropClient(client)
+		}
+		l.clients = append([]string{client}, l.clients...)
+		return
+	}
+
+	l.queues[client] = append(queue, ticket)
+	if !waiting {
+		l.clients = append(l.clients, client)
+	}
+}
+
+// pop removes the next ticket, taking it from the client whose turn it is,
+// or returns nil when the lane is empty
+func (l *admissionLane) pop() *admissionTicket {
+	if len(l.clients) == 0 {
+		return nil
+	}
+
+	client := l.clients[0]
+	l.clients = l.clients[1:]
+
+	queue := l.queues[client]
+	ticket := queue[0]
+	if len(queue) == 1 {
+		delete(l.queues, client)
+	} else {
+		l.queues[client] = queue[1:]
+		l.clients = append(l.clients, client)
+	}
+
+	return ticket
+}
+
+// remove takes a ticket out of the lane
+func (l *admissionLane) remove(ticket *admissionTicket) {
+	client := ticket.admission.Client
+	queue := l.queues[client]
+
+	for i, queued := range queue {
+		if queued != ticket {
+			continue
+		}
+
+		queue = append(queue[:i], queue[i+1:]...)
+		if len(queue) == 0 {
+			delete(l.queues, client)
+			l.dropClient(client)
+		} else {
+			l.queues[client] = queue
+		}
+		return
+	}
+}
+
+// len returns the number of queued tickets
+func (l *admissionLane) len() int {
+	n := 0
+	for _, queue := range l.queues {
+		n += len(queue)
+	}
+	return n
+}
+
+// dropClient removes a client from the turn order
+func (l *admissionLane) dropClient(client string) {
+	for i, c := range l.clients {
+		if c == client {
+			l.clients = append(l.clients[:i], l.clients[i+1:]...)
+			return
+		}
+	}
+}
@@ -0,0 +1,301 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/augment-local-manus-clone/backend/ai-service/domain"
+	"github.com/augment-local-manus-clone/backend/ai-service/usecase"
+)
+
+// gatedLLMClient holds every call until the test releases it, recording the
+// order in which calls started. A stubborn client ignores cancellation.
+type gatedLLMClient struct {
+	mu       sync.Mutex
+	started  []string
+	release  map[string]chan struct{}
+	stubborn bool
+}
+
+func newGatedLLMClient() *gatedLLMClient {
+	return &gatedLLMClient{release: make(map[string]chan struct{})}
+}
+
+// gate returns the channel that releases calls for a prompt
+func (c *gatedLLMClient) gate(prompt string) chan struct{} {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.release[prompt] == nil {
+		c.release[prompt] = make(chan struct{})
+	}
+	return c.release[prompt]
+}
+
+// order returns the prompts of the calls started so far
+func (c *gatedLLMClient) order() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]string{}, c.started...)
+}
+
+func (c *gatedLLMClient) Process(ctx context.Context, request *domain.AIRequest) (*domain.AIResponse, error) {
+	gate := c.gate(request.Prompt)
+
+	c.mu.Lock()
+	c.started = append(c.started, request.Prompt)
+	c.mu.Unlock()
+
+	done := ctx.Done()
+	if c.stubborn {
+		done = nil
+	}
+
+	select {
+	case <-gate:
+		return &domain.AIResponse{Text: request.Prompt}, nil
+	case <-done:
+		return nil, ctx.Err()
+	}
+}
+
+func (c *gatedLLMClient) ProcessStream(ctx context.Context, request *domain.AIRequest, handler domain.StreamHandler) (*domain.AIResponse, error) {
+	return c.Process(ctx, request)
+}
+
+func (c *gatedLLMClient) Chat(ctx context.Context, request *domain.ChatRequest) (*domain.ChatResponse, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (c *gatedLLMClient) Embed(ctx context.Context, request *domain.EmbedRequest) (*domain.EmbedResponse, error) {
+	return nil, errors.New("not implemented")
+}
+
+// admissionCall is a call started in the background
+type admissionCall struct {
+	prompt string
+	done   chan error
+}
+
+// startCall processes a prompt in the background as the given client and priority
+func startCall(ctx context.Context, controller *usecase.AdmissionController, client string, priority domain.Priority, prompt string) *admissionCall {
+	call := &admissionCall{prompt: prompt, done: make(chan error, 1)}
+	ctx = domain.WithAdmission(ctx, domain.Admission{Client: client, Priority: priority})
+	go func() {
+		_, err := controller.Process(ctx, &domain.AIRequest{Prompt: prompt})
+		call.done <- err
+	}()
+	return call
+}
+
+// waitUntil polls until the condition holds or the test times out
+func waitUntil(t *testing.T, what string, condition func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting until %s", what)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestAdmissionControllerOrder(t *testing.T) {
+	type queued struct {
+		client   string
+		priority domain.Priority
+		prompt   string
+	}
+
+	tests := []struct {
+		name      string
+		queued    []queued
+		wantOrder []string
+	}{
+		{
+			name: "Interactive before batch",
+			queued: []queued{
+				{"a", domain.PriorityBatch, "summarize"},
+				{"b", domain.PriorityInteractive, "chat"},
+			},
+			wantOrder: []string{"first", "chat", "summarize"},
+		},
+		{
+			name: "Clients take turns",
+			queued: []queued{
+				{"a", domain.PriorityInteractive, "a1"},
+				{"a", domain.PriorityInteractive, "a2"},
+				{"b", domain.PriorityInteractive, "b1"},
+			},
+			wantOrder: []string{"first", "a1", "b1", "a2"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newGatedLLMClient()
+			controller, err := usecase.NewAdmissionController(client, usecase.AdmissionConfig{MaxConcurrent: 1, MaxQueue: 10})
+			if err != nil {
+				t.Fatalf("NewAdmissionController() error = %v", err)
+			}
+
+			first := startCall(context.Background(), controller, "a", domain.PriorityBatch, "first")
+			waitUntil(t, "the first call runs", func() bool { return len(client.order()) == 1 })
+
+			calls := []*admissionCall{first}
+			for i, q := range tt.queued {
+				calls = append(calls, startCall(context.Background(), controller, q.client, q.priority, q.prompt))
+				waitUntil(t, "the call is queued", func() bool { return controller.Stats().QueueDepth == i+1 })
+			}
+
+			// Release the calls one at a time in the order they start
+			for i := range calls {
+				waitUntil(t, "the next call runs", func() bool { return len(client.order()) == i+1 })
+				close(client.gate(client.order()[i]))
+			}
+			for _, call := range calls {
+				if err := <-call.done; err != nil {
+					t.Errorf("Process(%q) error = %v", call.prompt, err)
+				}
+			}
+
+			if got := client.order(); !reflect.DeepEqual(got, tt.wantOrder) {
+				t.Errorf("order = %v, want %v", got, tt.wantOrder)
+			}
+		})
+	}
+}
+
+func TestAdmissionControllerQueueFull(t *testing.T) {
+	client := newGatedLLMClient()
+	controller, err := usecase.NewAdmissionController(client, usecase.AdmissionConfig{MaxConcurrent: 1, MaxQueue: 1})
+	if err != nil {
+		t.Fatalf("NewAdmissionController() error = %v", err)
+	}
+
+	running := startCall(context.Background(), controller, "a", domain.PriorityInteractive, "running")
+	waitUntil(t, "the first call runs", func() bool { return len(client.order()) == 1 })
+	queued := startCall(context.Background(), controller, "a", domain.PriorityInteractive, "queued")
+	waitUntil(t, "the second call is queued", func() bool { return controller.Stats().QueueDepth == 1 })
+
+	_, err = controller.Process(context.Background(), &domain.AIRequest{Prompt: "rejected"})
+	var queueFull *domain.QueueFullError
+	if !errors.As(err, &queueFull) || !errors.Is(err, domain.ErrQueueFull) {
+		t.Fatalf("Process() error = %v, want %v", err, domain.ErrQueueFull)
+	}
+	if queueFull.RetryAfter < time.Second {
+		t.Errorf("RetryAfter = %s, want at least 1s", queueFull.RetryAfter)
+	}
+
+	close(client.gate("running"))
+	close(client.gate("queued"))
+	<-running.done
+	<-queued.done
+
+	stats := controller.Stats()
+	if stats.Admitted != 2 || stats.Rejected != 1 || stats.QueueDepth != 0 {
+		t.Errorf("stats = %+v, want 2 admitted, 1 rejected and an empty queue", stats)
+	}
+}
+
+func TestAdmissionControllerCancelWhileQueued(t *testing.T) {
+	client := newGatedLLMClient()
+	controller, err := usecase.NewAdmissionController(client, usecase.AdmissionConfig{MaxConcurrent: 1, MaxQueue: 10})
+	if err != nil {
+		t.Fatalf("NewAdmissionController() error = %v", err)
+	}
+
+	running := startCall(context.Background(), controller, "a", domain.PriorityInteractive, "running")
+	waitUntil(t, "the first call runs", func() bool { return len(client.order()) == 1 })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	queued := startCall(ctx, controller, "b", domain.PriorityInteractive, "queued")
+	waitUntil(t, "the second call is queued", func() bool { return controller.Stats().QueueDepth == 1 })
+
+	cancel()
+	if err := <-queued.done; !errors.Is(err, context.Canceled) {
+		t.Errorf("Process() error = %v, want %v", err, context.Canceled)
+	}
+	if depth := controller.Stats().QueueDepth; depth != 0 {
+		t.Errorf("QueueDepth = %d, want 0", depth)
+	}
+
+	close(client.gate("running"))
+	<-running.done
+	if got := client.order(); !reflect.DeepEqual(got, []string{"running"}) {
+		t.Errorf("order = %v, want the cancelled call never to run", got)
+	}
+}
+
+func TestAdmissionControllerPreemption(t *testing.T) {
+	client := newGatedLLMClient()
+	controller, err := usecase.NewAdmissionController(client, usecase.AdmissionConfig{MaxConcurrent: 1, MaxQueue: 10, Preempt: true})
+	if err != nil {
+		t.Fatalf("NewAdmissionController() error = %v", err)
+	}
+
+	batch := startCall(context.Background(), controller, "worker", domain.PriorityBatch, "summarize")
+	waitUntil(t, "the batch call runs", func() bool { return len(client.order()) == 1 })
+
+	// The interactive call cancels the batch call and runs in its place
+	chat := startCall(context.Background(), controller, "user", domain.PriorityInteractive, "chat")
+	waitUntil(t, "the chat call runs", func() bool { return len(client.order()) == 2 })
+	close(client.gate("chat"))
+	if err := <-chat.done; err != nil {
+		t.Fatalf("Process(chat) error = %v", err)
+	}
+
+	// The batch call is retried once the slot is free
+	waitUntil(t, "the batch call is retried", func() bool { return len(client.order()) == 3 })
+	close(client.gate("summarize"))
+	if err := <-batch.done; err != nil {
+		t.Fatalf("Process(summarize) error = %v", err)
+	}
+
+	want := []string{"summarize", "chat", "summarize"}
+	if got := client.order(); !reflect.DeepEqual(got, want) {
+		t.Errorf("order = %v, want %v", got, want)
+	}
+	if stats := controller.Stats(); stats.Preempted != 1 {
+		t.Errorf("Preempted = %d, want 1", stats.Preempted)
+	}
+}
+
+func TestAdmissionControllerPreemptedCallFinishes(t *testing.T) {
+	client := newGatedLLMClient()
+	client.stubborn = true
+	controller, err := usecase.NewAdmissionController(client, usecase.AdmissionConfig{MaxConcurrent: 1, MaxQueue: 10, Preempt: true})
+	if err != nil {
+		t.Fatalf("NewAdmissionController() error = %v", err)
+	}
+
+	batch := startCall(context.Background(), controller, "worker", domain.PriorityBatch, "summarize")
+	waitUntil(t, "the batch call runs", func() bool { return len(client.order()) == 1 })
+
+	// The batch call is pre-empted but finishes anyway, so it is not retried
+	chat := startCall(context.Background(), controller, "user", domain.PriorityInteractive, "chat")
+	waitUntil(t, "the batch call is pre-empted", func() bool { return controller.Stats().Preempted == 1 })
+	close(client.gate("summarize"))
+	select {
+	case err := <-batch.done:
+		if err != nil {
+			t.Fatalf("Process(summarize) error = %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Process(summarize) did not return the result of the finished call")
+	}
+
+	waitUntil(t, "the chat call runs", func() bool { return len(client.order()) == 2 })
+	close(client.gate("chat"))
+	if err := <-chat.done; err != nil {
+		t.Fatalf("Process(chat) error = %v", err)
+	}
+
+	want := []string{"summarize", "chat"}
+	if got := client.order(); !reflect.DeepEqual(got, want) {
+		t.Errorf("order = %v, want %v", got, want)
+	}
+}
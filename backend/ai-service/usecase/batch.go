@@ -0,0 +1,485 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/augment-local-manus-clone/backend/ai-service/domain"
+)
+
+const (
+	// defaultBatchWorkers is how many batch requests run at once by default
+	defaultBatchWorkers = 4
+
+	// defaultBatchRetryAfter is how long an item waits before retrying when
+	// the admission queue is full and does not say when to retry
+	defaultBatchRetryAfter = time.Second
+)
+
+// BatchStats reports the batch workers' load and failures
+type BatchStats struct {
+	Workers     int   `json:"workers"`
+	RunningJobs int   `json:"running_jobs"`
+	ActiveItems int   `json:"active_items"`
+	Retries     int64 `json:"retries"`
+
+	// PersistFailures counts progress that could not be stored; the job
+	// carries on and an item whose outcome was lost is run again on resume
+	PersistFailures int64 `json:"persist_failures"`
+}
+
+// BatchList lists the batch jobs
+type BatchList struct {
+	Jobs  []*domain.BatchJob `json:"jobs"`
+	Stats BatchStats         `json:"stats"`
+}
+
+// BatchUseCase runs batches of AI requests in the background. Items run
+// through ProcessAIRequestUseCase on a worker pool shared by every job, at
+// batch priority so that interactive requests are admitted first, and each
+// item's outcome is stored as soon as it is known so that a restarted
+// service resumes unfinished jobs.
+type BatchUseCase struct {
+	processAIRequestUseCase *ProcessAIRequestUseCase
+	repository              domain.BatchRepository
+	workers                 int
+
+	// slots bounds the items running across every job
+	slots chan struct{}
+
+	mu      sync.Mutex
+	running map[string]*batchRun
+	stats   BatchStats
+	wg      sync.WaitGroup
+}
+
+// batchRun is a job being run and the streams following it
+type batchRun struct {
+	job         *domain.BatchJob
+	cancel      context.CancelFunc
+	subscribers []chan domain.BatchItem
+}
+
+// NewBatchUseCase creates a new instance of BatchUseCase. Workers bounds how
+// many batch requests run at once; zero selects the default of 4.
+func NewBatchUseCase(processAIRequestUseCase *ProcessAIRequestUseCase, repository domain.BatchRepository, workers int) *BatchUseCase {
+	if workers <= 0 {
+		workers = defaultBatchWorkers
+	}
+
+	return &BatchUseCase{
+		processAIRequestUseCase: processAIRequestUseCase,
+		repository:              repository,
+		workers:                 workers,
+		slots:                   make(chan struct{}, workers),
+		running:                 make(map[string]*batchRun),
+	}
+}
+
+// Submit stores a batch as a new job and starts running it. The job belongs
+// to the client of the context's admission.
+func (uc *BatchUseCase) Submit(ctx context.Context, request *domain.BatchRequest) (*domain.BatchJob, error) {
+	// Validate the request
+	if err := request.Validate(); err != nil {
+		return nil, err
+	}
+
+	id, err := newBatchID()
+	if err != nil {
+		return nil, err
+	}
+
+	concurrency := request.Concurrency
+	if concurrency == 0 || concurrency > uc.workers {
+		concurrency = uc.workers
+	}
+
+	now := time.Now()
+	job := &domain.BatchJob{
+		ID:          id,
+		Status:      domain.BatchQueued,
+		Client:      domain.AdmissionFrom(ctx).Client,
+		Concurrency: concurrency,
+		Total:       len(request.Requests),
+		CreatedAt:   now,
+		UpdatedAt:   now,
+		Items:       make([]domain.BatchItem, len(request.Requests)),
+	}
+	for i, itemRequest := range request.Requests {
+		job.Items[i] = domain.BatchItem{Index: i, Status: domain.BatchItemPending, Request: itemRequest}
+	}
+
+	if err := uc.repository.Create(job); err != nil {
+		return nil, err
+	}
+
+	submitted := copyBatchJob(job)
+	uc.start(job)
+	return submitted, nil
+}
+
+// Get returns a job with the outcome of every item so far
+func (uc *BatchUseCase) Get(id string) (*domain.BatchJob, error) {
+	uc.mu.Lock()
+	if run, ok := uc.running[id]; ok {
+		job := copyBatchJob(run.job)
+		uc.mu.Unlock()
+		return job, nil
+	}
+	uc.mu.Unlock()
+
+	return uc.repository.Get(id)
+}
+
+// List returns every job without its items, newest first, and the worker stats
+func (uc *BatchUseCase) List() (*BatchList, error) {
+	jobs, err := uc.repository.List()
+	if err != nil {
+		return nil, err
+	}
+
+	// Running jobs report their live progress
+	uc.mu.Lock()
+	for i, job := range jobs {
+		if run, ok := uc.running[job.ID]; ok {
+			live := *run.job
+			live.Items = nil
+			jobs[i] = &live
+		}
+	}
+	uc.mu.Unlock()
+
+	return &BatchList{Jobs: jobs, Stats: uc.Stats()}, nil
+}
+
+// Stats returns a snapshot of the batch workers' load and failures
+func (uc *BatchUseCase) Stats() BatchStats {
+	uc.mu.Lock()
+	defer uc.mu.Unlock()
+
+	stats := uc.stats
+	stats.Workers = uc.workers
+	stats.RunningJobs = len(uc.running)
+	stats.ActiveItems = len(uc.slots)
+	return stats
+}
+
+// Cancel stops a job. Items already running are interrupted; items that have
+// not started are marked cancelled. Finished items keep their outcome.
+func (uc *BatchUseCase) Cancel(id string) error {
+	uc.mu.Lock()
+	if run, ok := uc.running[id]; ok {
+		run.cancel()
+		uc.mu.Unlock()
+		return nil
+	}
+	uc.mu.Unlock()
+
+	// A job that is not running has finished, or was left unfinished by a
+	// previous run of the service and not resumed
+	job, err := uc.repository.Get(id)
+	if err != nil {
+		return err
+	}
+	if job.Status.Finished() {
+		return fmt.Errorf("%w: %s", domain.ErrBatchFinished, id)
+	}
+
+	for i := range job.Items {
+		item := &job.Items[i]
+		if !item.Status.Finished() {
+			item.Status = domain.BatchItemCancelled
+			if err := uc.repository.UpdateItem(id, item); err != nil {
+				return err
+			}
+			job.Cancelled++
+		}
+	}
+	finishBatchJob(job, domain.BatchCancelled)
+	return uc.repository.UpdateJob(job)
+}
+
+// Subscribe follows a job's progress. It returns the job as it is now and a
+// channel receiving each item as it finishes, which is closed when the job
+// finishes. The channel is nil when the job has already finished. The
+// returned function stops following the job.
+func (uc *BatchUseCase) Subscribe(id string) (*domain.BatchJob, <-chan domain.BatchItem, func(), error) {
+	uc.mu.Lock()
+	if run, ok := uc.running[id]; ok {
+		// Every item finishes once, so the buffer never fills
+		events := make(chan domain.BatchItem, run.job.Total)
+		run.subscribers = append(run.subscribers, events)
+		job := copyBatchJob(run.job)
+		uc.mu.Unlock()
+
+		unsubscribe := func() {
+			uc.mu.Lock()
+			defer uc.mu.Unlock()
+			for i, subscriber := range run.subscribers {
+				if subscriber == events {
+					run.subscribers = append(run.subscribers[:i], run.subscribers[i+1:]...)
+					close(events)
+					return
+				}
+			}
+		}
+		return job, events, unsubscribe, nil
+	}
+	uc.mu.Unlock()
+
+	job, err := uc.repository.Get(id)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return job, nil, func() {}, nil
+}
+
+// Resume starts the jobs left unfinished by a previous run of the service.
+// Items that were running when it stopped are run again.
+func (uc *BatchUseCase) Resume() error {
+	jobs, err := uc.repository.List()
+	if err != nil {
+		return err
+	}
+
+	for _, summary := range jobs {
+		if summary.Status.Finished() {
+			continue
+		}
+
+		job, err := uc.repository.Get(summary.ID)
+		if err != nil {
+			return err
+		}
+		for i := range job.Items {
+			if job.Items[i].Status == domain.BatchItemRunning {
+				job.Items[i].Status = domain.BatchItemPending
+				job.Items[i].StartedAt = nil
+			}
+		}
+		uc.start(job)
+	}
+
+	return nil
+}
+
+// Wait blocks until every running job has finished
+func (uc *BatchUseCase) Wait() {
+	uc.wg.Wait()
+}
+
+// start runs a job in the background
+func (uc *BatchUseCase) start(job *domain.BatchJob) {
+	// Items of a batch are background work; jobs without a client share
+	// the workers fairly with each other
+	client := job.Client
+	if client == "" {
+		client = "batch:" + job.ID
+	}
+	ctx := domain.WithAdmission(context.Background(), domain.Admission{Client: client, Priority: domain.PriorityBatch})
+	ctx, cancel := context.WithCancel(ctx)
+
+	uc.mu.Lock()
+	uc.running[job.ID] = &batchRun{job: job, cancel: cancel}
+	uc.mu.Unlock()
+
+	uc.wg.Add(1)
+	go func() {
+		defer uc.wg.Done()
+		defer cancel()
+		uc.run(ctx, job)
+	}()
+}
+
+// run runs the unfinished items of a job, at most the job's concurrency at a
+// time, and records the job's outcome
+func (uc *BatchUseCase) run(ctx context.Context, job *domain.BatchJob) {
+	uc.mu.Lock()
+	job.Status = domain.BatchRunning
+	job.UpdatedAt = time.Now()
+	snapshot := *job
+	uc.mu.Unlock()
+	uc.persist(uc.repository.UpdateJob(&snapshot))
+
+	concurrency := make(chan struct{}, job.Concurrency)
+	var items sync.WaitGroup
+
+dispatch:
+	for i := range job.Items {
+		if job.Items[i].Status.Finished() {
+			continue
+		}
+
+		select {
+		case concurrency <- struct{}{}:
+		case <-ctx.Done():
+			break dispatch
+		}
+		select {
+		case uc.slots <- struct{}{}:
+		case <-ctx.Done():
+			<-concurrency
+			break dispatch
+		}
+
+		items.Add(1)
+		go func(i int) {
+			defer items.Done()
+			defer func() { <-uc.slots; <-concurrency }()
+			uc.runItem(ctx, job, i)
+		}(i)
+	}
+	items.Wait()
+
+	// Items that did not run were cancelled with the job
+	uc.mu.Lock()
+	var cancelled []domain.BatchItem
+	for i := range job.Items {
+		item := &job.Items[i]
+		if !item.Status.Finished() {
+			item.Status = domain.BatchItemCancelled
+			job.Cancelled++
+			cancelled = append(cancelled, *item)
+			uc.publishLocked(job.ID, *item)
+		}
+	}
+	status := domain.BatchCompleted
+	if ctx.Err() != nil {
+		status = domain.BatchCancelled
+	}
+	finishBatchJob(job, status)
+	snapshot = *job
+	uc.mu.Unlock()
+
+	for i := range cancelled {
+		uc.persist(uc.repository.UpdateItem(job.ID, &cancelled[i]))
+	}
+	uc.persist(uc.repository.UpdateJob(&snapshot))
+
+	uc.mu.Lock()
+	run := uc.running[job.ID]
+	delete(uc.running, job.ID)
+	for _, subscriber := range run.subscribers {
+		close(subscriber)
+	}
+	run.subscribers = nil
+	uc.mu.Unlock()
+}
+
+// runItem runs one item of a job and records its outcome. Items rejected by
+// a full admission queue wait and retry rather than fail.
+func (uc *BatchUseCase) runItem(ctx context.Context, job *domain.BatchJob, index int) {
+	uc.mu.Lock()
+	item := &job.Items[index]
+	started := time.Now()
+	item.Status = domain.BatchItemRunning
+	item.StartedAt = &started
+	request := item.Request
+	snapshot := *item
+	uc.mu.Unlock()
+	uc.persist(uc.repository.UpdateItem(job.ID, &snapshot))
+
+	var response *domain.AIResponse
+	var err error
+	for {
+		attempt := request.Clone()
+		response, err = uc.processAIRequestUseCase.Execute(ctx, &attempt)
+
+		var queueFull *domain.QueueFullError
+		if !errors.As(err, &queueFull) || ctx.Err() != nil {
+			break
+		}
+
+		retryAfter := queueFull.RetryAfter
+		if retryAfter <= 0 {
+			retryAfter = defaultBatchRetryAfter
+		}
+		uc.mu.Lock()
+		uc.stats.Retries++
+		uc.mu.Unlock()
+
+		timer := time.NewTimer(retryAfter)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+		}
+	}
+
+	uc.mu.Lock()
+	finished := time.Now()
+	item.FinishedAt = &finished
+	switch {
+	case err == nil:
+		item.Status = domain.BatchItemSucceeded
+		item.Response = response
+		job.Succeeded++
+	case ctx.Err() != nil:
+		// The job was cancelled while the item ran
+		item.Status = domain.BatchItemCancelled
+		item.Error = ctx.Err().Error()
+		job.Cancelled++
+	default:
+		item.Status = domain.BatchItemFailed
+		item.Error = err.Error()
+		job.Failed++
+	}
+	job.UpdatedAt = finished
+	snapshot = *item
+	uc.publishLocked(job.ID, snapshot)
+	uc.mu.Unlock()
+
+	// The repository recounts the job's items with each item, so the
+	// counts stored cannot go back when items finish at the same time
+	uc.persist(uc.repository.UpdateItem(job.ID, &snapshot))
+}
+
+// publishLocked sends a finished item to the job's subscribers. It is called
+// with the lock held, so that a subscriber sees each item either in its
+// snapshot of the job or as an event, never both.
+func (uc *BatchUseCase) publishLocked(jobID string, item domain.BatchItem) {
+	if run, ok := uc.running[jobID]; ok {
+		for _, subscriber := range run.subscribers {
+			subscriber <- item
+		}
+	}
+}
+
+// persist counts a failure to store progress
+func (uc *BatchUseCase) persist(err error) {
+	if err == nil {
+		return
+	}
+	uc.mu.Lock()
+	uc.stats.PersistFailures++
+	uc.mu.Unlock()
+}
+
+// finishBatchJob marks a job finished with the given status
+func finishBatchJob(job *domain.BatchJob, status domain.BatchStatus) {
+	now := time.Now()
+	job.Status = status
+	job.UpdatedAt = now
+	job.FinishedAt = &now
+}
+
+// copyBatchJob copies a job and its items, so that it can be read while the job runs
+func copyBatchJob(job *domain.BatchJob) *domain.BatchJob {
+	copied := *job
+	copied.Items = append([]domain.BatchItem(nil), job.Items...)
+	return &copied
+}
+
+// newBatchID returns a random job ID
+func newBatchID() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate batch id: %w", err)
+	}
+	return "batch-" + hex.EncodeToString(b), nil
+}
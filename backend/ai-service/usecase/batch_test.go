@@ -0,0 +1,214 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/augment-local-manus-clone/backend/ai-service/domain"
+	"github.com/augment-local-manus-clone/backend/ai-service/infrastructure/batchstore"
+	"github.com/augment-local-manus-clone/backend/ai-service/infrastructure/llm"
+	"github.com/augment-local-manus-clone/backend/ai-service/usecase"
+)
+
+// admissionRecorder records the admission of every request it passes on
+type admissionRecorder struct {
+	*llm.FakeLLMClient
+
+	mu         sync.Mutex
+	admissions []domain.Admission
+}
+
+func (r *admissionRecorder) Process(ctx context.Context, request *domain.AIRequest) (*domain.AIResponse, error) {
+	r.mu.Lock()
+	r.admissions = append(r.admissions, domain.AdmissionFrom(ctx))
+	r.mu.Unlock()
+	return r.FakeLLMClient.Process(ctx, request)
+}
+
+// newBatchUseCase creates a batch runner backed by a fake model that fails
+// prompts mentioning "broken" and is slow for prompts mentioning "slow"
+func newBatchUseCase(t *testing.T, workers int) (*usecase.BatchUseCase, *batchstore.SQLiteBatchRepository, *admissionRecorder) {
+	t.Helper()
+
+	fake, err := llm.NewFakeLLMClient(llm.FakeScript{
+		Rules: []llm.FakeRule{
+			{Pattern: "broken", FakeReply: llm.FakeReply{Error: "model crashed"}},
+			{Pattern: "slow", FakeReply: llm.FakeReply{Text: "Finally done.", LatencyMS: 5000}},
+		},
+		Default: &llm.FakeReply{Text: "Summary of the file."},
+	})
+	if err != nil {
+		t.Fatalf("NewFakeLLMClient() error = %v", err)
+	}
+	client := &admissionRecorder{FakeLLMClient: fake}
+
+	repo, err := batchstore.NewSQLiteBatchRepository(filepath.Join(t.TempDir(), "batch.db"))
+	if err != nil {
+		t.Fatalf("NewSQLiteBatchRepository() error = %v", err)
+	}
+	t.Cleanup(func() { repo.Close() })
+
+	process := usecase.NewProcessAIRequestUseCase(client, nil, nil, nil, nil, nil)
+	return usecase.NewBatchUseCase(process, repo, workers), repo, client
+}
+
+func TestBatchUseCaseSubmit(t *testing.T) {
+	uc, repo, client := newBatchUseCase(t, 2)
+
+	ctx := domain.WithAdmission(context.Background(), domain.Admission{Client: "indexer"})
+	job, err := uc.Submit(ctx, &domain.BatchRequest{Requests: []domain.AIRequest{
+		{Prompt: "Summarize main.go"},
+		{Prompt: "Summarize broken.go"},
+		{Prompt: "Summarize util.go"},
+	}, Concurrency: 8})
+	if err != nil {
+		t.Fatalf("Submit() error = %v", err)
+	}
+	if job.ID == "" || job.Total != 3 || job.Concurrency != 2 || job.Client != "indexer" {
+		t.Errorf("Submit() = %+v, want a job of 3 items capped at 2 workers", job)
+	}
+
+	// Follow the job until it finishes
+	_, events, unsubscribe, err := uc.Subscribe(job.ID)
+	if err != nil {
+		t.Fatalf("Subscribe() error = %v", err)
+	}
+	defer unsubscribe()
+	for range events {
+	}
+	uc.Wait()
+
+	// The stored job reports each item's outcome
+	stored, err := repo.Get(job.ID)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if stored.Status != domain.BatchCompleted || stored.Succeeded != 2 || stored.Failed != 1 || stored.FinishedAt == nil {
+		t.Errorf("job = %+v, want completed with one failure", stored)
+	}
+	for _, item := range stored.Items {
+		switch item.Index {
+		case 1:
+			if item.Status != domain.BatchItemFailed || item.Error == "" || item.Response != nil {
+				t.Errorf("item 1 = %+v, want the failure reported", item)
+			}
+		default:
+			if item.Status != domain.BatchItemSucceeded || item.Response == nil || item.Response.Text != "Summary of the file." {
+				t.Errorf("item %d = %+v, want a response", item.Index, item)
+			}
+		}
+	}
+
+	// Items run at batch priority on behalf of the submitting client
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	if len(client.admissions) != 3 {
+		t.Fatalf("admissions = %+v, want 3", client.admissions)
+	}
+	for _, admission := range client.admissions {
+		if admission != (domain.Admission{Client: "indexer", Priority: domain.PriorityBatch}) {
+			t.Errorf("admission = %+v, want the indexer at batch priority", admission)
+		}
+	}
+}
+
+func TestBatchUseCaseCancel(t *testing.T) {
+	uc, repo, _ := newBatchUseCase(t, 1)
+
+	job, err := uc.Submit(context.Background(), &domain.BatchRequest{Requests: []domain.AIRequest{
+		{Prompt: "A slow request"},
+		{Prompt: "Another request"},
+	}})
+	if err != nil {
+		t.Fatalf("Submit() error = %v", err)
+	}
+
+	if err := uc.Cancel(job.ID); err != nil {
+		t.Fatalf("Cancel() error = %v", err)
+	}
+	uc.Wait()
+
+	stored, err := repo.Get(job.ID)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if stored.Status != domain.BatchCancelled || stored.Cancelled != 2 {
+		t.Errorf("job = %+v, want both items cancelled", stored)
+	}
+	for _, item := range stored.Items {
+		if item.Status != domain.BatchItemCancelled {
+			t.Errorf("item %d = %+v, want cancelled", item.Index, item)
+		}
+	}
+
+	if err := uc.Cancel(job.ID); !errors.Is(err, domain.ErrBatchFinished) {
+		t.Errorf("Cancel() error = %v, want ErrBatchFinished", err)
+	}
+	if err := uc.Cancel("batch-missing"); !errors.Is(err, domain.ErrBatchNotFound) {
+		t.Errorf("Cancel() error = %v, want ErrBatchNotFound", err)
+	}
+}
+
+func TestBatchUseCaseResume(t *testing.T) {
+	uc, repo, client := newBatchUseCase(t, 2)
+
+	// A job left behind by a previous run: one item finished, one was running
+	now := time.Now()
+	job := &domain.BatchJob{
+		ID:          "batch-resume",
+		Status:      domain.BatchRunning,
+		Concurrency: 2,
+		Total:       3,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+		Items: []domain.BatchItem{
+			{Index: 0, Status: domain.BatchItemPending, Request: domain.AIRequest{Prompt: "Summarize a.go"}},
+			{Index: 1, Status: domain.BatchItemPending, Request: domain.AIRequest{Prompt: "Summarize b.go"}},
+			{Index: 2, Status: domain.BatchItemPending, Request: domain.AIRequest{Prompt: "Summarize c.go"}},
+		},
+	}
+	if err := repo.Create(job); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	done := job.Items[0]
+	done.Status = domain.BatchItemSucceeded
+	done.Response = &domain.AIResponse{Text: "Earlier summary."}
+	running := job.Items[1]
+	running.Status = domain.BatchItemRunning
+	for _, item := range []*domain.BatchItem{&done, &running} {
+		if err := repo.UpdateItem(job.ID, item); err != nil {
+			t.Fatalf("UpdateItem() error = %v", err)
+		}
+	}
+	job.Succeeded = 1
+	if err := repo.UpdateJob(job); err != nil {
+		t.Fatalf("UpdateJob() error = %v", err)
+	}
+
+	if err := uc.Resume(); err != nil {
+		t.Fatalf("Resume() error = %v", err)
+	}
+	uc.Wait()
+
+	stored, err := uc.Get(job.ID)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if stored.Status != domain.BatchCompleted || stored.Succeeded != 3 {
+		t.Errorf("job = %+v, want every item succeeded", stored)
+	}
+	if stored.Items[0].Response.Text != "Earlier summary." {
+		t.Errorf("item 0 = %+v, want the earlier outcome kept", stored.Items[0])
+	}
+
+	// Only the unfinished items were run; the job had no client
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	if len(client.admissions) != 2 || client.admissions[0].Client != "batch:batch-resume" {
+		t.Errorf("admissions = %+v, want the 2 unfinished items run for the job", client.admissions)
+	}
+}
@@ -0,0 +1,362 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/augment-local-manus-clone/backend/ai-service/domain"
+)
+
+// CassetteRecorder implements the LLMClient interface by passing each call to
+// another client and appending the request and its outcome to a cassette.
+// A failed write does not fail the call; it is counted in the stats.
+type CassetteRecorder struct {
+	next     domain.LLMClient
+	cassette domain.Cassette
+	now      func() time.Time
+
+	mu    sync.Mutex
+	stats domain.RecordStats
+}
+
+// NewCassetteRecorder creates a new CassetteRecorder in front of next
+func NewCassetteRecorder(next domain.LLMClient, cassette domain.Cassette) *CassetteRecorder {
+	return &CassetteRecorder{
+		next:     next,
+		cassette: cassette,
+		now:      time.Now,
+	}
+}
+
+// Process processes the request with the wrapped client and records it
+func (r *CassetteRecorder) Process(ctx context.Context, request *domain.AIRequest) (*domain.AIResponse, error) {
+	response, err := r.next.Process(ctx, request)
+	r.record(ctx, domain.InteractionProcess, request, response, nil, err)
+	return response, err
+}
+
+// Chat sends the conversation to the wrapped client and records it
+func (r *CassetteRecorder) Chat(ctx context.Context, request *domain.ChatRequest) (*domain.ChatResponse, error) {
+	response, err := r.next.Chat(ctx, request)
+	r.record(ctx, domain.InteractionChat, request, response, nil, err)
+	return response, err
+}
+
+// ProcessStream streams the request from the wrapped client and records it
+// with every chunk that was passed to the handler
+func (r *CassetteRecorder) ProcessStream(ctx context.Context, request *domain.AIRequest, handler domain.StreamHandler) (*domain.AIResponse, error) {
+	var chunks []domain.AIStreamChunk
+	response, err := r.next.ProcessStream(ctx, request, func(chunk *domain.AIStreamChunk) error {
+		chunks = append(chunks, *chunk)
+		return handler(chunk)
+	})
+	r.record(ctx, domain.InteractionStream, request, response, chunks, err)
+	return response, err
+}
+
+// Embed embeds the texts with the wrapped client and records the call
+func (r *CassetteRecorder) Embed(ctx context.Context, request *domain.EmbedRequest) (*domain.EmbedResponse, error) {
+	response, err := r.next.Embed(ctx, request)
+	r.record(ctx, domain.InteractionEmbed, request, response, nil, err)
+	return response, err
+}
+
+// Stats returns how many interactions have been recorded
+func (r *CassetteRecorder) Stats() domain.RecordStats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.stats
+}
+
+// record appends an interaction to the cassette; response is ignored when err
+// is set. A call that ended because its context was cancelled or timed out,
+// such as one pre-empted by admission control, is not recorded: it says
+// nothing about the model, and replaying it would fail the retry that
+// followed it.
+func (r *CassetteRecorder) record(ctx context.Context, method string, request, response interface{}, chunks []domain.AIStreamChunk, err error) {
+	if err != nil && ctx.Err() != nil {
+		r.mu.Lock()
+		r.stats.Skipped++
+		r.mu.Unlock()
+		return
+	}
+
+	interaction := &domain.Interaction{
+		Method:     method,
+		Chunks:     chunks,
+		RecordedAt: r.now(),
+	}
+
+	requestData, marshalErr := json.Marshal(request)
+	if marshalErr == nil {
+		interaction.Request = requestData
+		if err != nil {
+			interaction.Error = err.Error()
+		} else {
+			interaction.Response, marshalErr = json.Marshal(response)
+		}
+	}
+
+	if marshalErr == nil {
+		marshalErr = r.cassette.Append(interaction)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if marshalErr != nil {
+		r.stats.Errors++
+		return
+	}
+	r.stats.Recorded++
+}
+
+// replayEntry is a recorded interaction with its match key
+type replayEntry struct {
+	interaction domain.Interaction
+	key         string
+	replayed    bool
+}
+
+// CassetteReplayer implements the LLMClient interface by serving responses
+// from a cassette instead of a model. Each request is matched to the first
+// interaction with the same key that has not been replayed yet. Requests
+// without a match fail with ErrNoRecording and are reported in the stats.
+type CassetteReplayer struct {
+	match domain.CassetteMatch
+	now   func() time.Time
+
+	mu        sync.Mutex
+	entries   []*replayEntry
+	replayed  int64
+	unmatched []domain.UnmatchedRequest
+}
+
+// NewCassetteReplayer creates a new CassetteReplayer from the interactions in a cassette
+func NewCassetteReplayer(cassette domain.Cassette, match domain.CassetteMatch) (*CassetteReplayer, error) {
+	if err := match.Validate(); err != nil {
+		return nil, err
+	}
+
+	interactions, err := cassette.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	replayer := &CassetteReplayer{
+		match: match,
+		now:   time.Now,
+	}
+
+	for i, interaction := range interactions {
+		request, err := decodeRecordedRequest(interaction.Method, interaction.Request)
+		if err != nil {
+			return nil, fmt.Errorf("interaction %d: %w", i, err)
+		}
+
+		key, err := replayer.key(interaction.Method, request)
+		if err != nil {
+			return nil, fmt.Errorf("interaction %d: %w", i, err)
+		}
+
+		replayer.entries = append(replayer.entries, &replayEntry{interaction: interaction, key: key})
+	}
+
+	return replayer, nil
+}
+
+// Process returns the recorded response to the request
+func (r *CassetteReplayer) Process(ctx context.Context, request *domain.AIRequest) (*domain.AIResponse, error) {
+	interaction, err := r.replay(domain.InteractionProcess, request)
+	if err != nil {
+		return nil, err
+	}
+
+	var response domain.AIResponse
+	if err := json.Unmarshal(interaction.Response, &response); err != nil {
+		return nil, fmt.Errorf("failed to decode recorded response: %w", err)
+	}
+	return &response, nil
+}
+
+// Chat returns the recorded reply to the conversation
+func (r *CassetteReplayer) Chat(ctx context.Context, request *domain.ChatRequest) (*domain.ChatResponse, error) {
+	interaction, err := r.replay(domain.InteractionChat, request)
+	if err != nil {
+		return nil, err
+	}
+
+	var response domain.ChatResponse
+	if err := json.Unmarshal(interaction.Response, &response); err != nil {
+		return nil, fmt.Errorf("failed to decode recorded response: %w", err)
+	}
+	return &response, nil
+}
+
+// ProcessStream passes the recorded chunks to the handler and returns the
+// recorded response. A response recorded without streaming is replayed as a
+// single chunk.
+func (r *CassetteReplayer) ProcessStream(ctx context.Context, request *domain.AIRequest, handler domain.StreamHandler) (*domain.AIResponse, error) {
+	interaction, err := r.replay(domain.InteractionStream, request)
+	if err != nil {
+		return nil, err
+	}
+
+	var response domain.AIResponse
+	if err := json.Unmarshal(interaction.Response, &response); err != nil {
+		return nil, fmt.Errorf("failed to decode recorded response: %w", err)
+	}
+
+	chunks := interaction.Chunks
+	if chunks == nil {
+		chunks = []domain.AIStreamChunk{{Text: response.Text, Reasoning: response.Reasoning}}
+	}
+	for _, chunk := range chunks {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if err := handler(&chunk); err != nil {
+			return nil, err
+		}
+	}
+
+	return &response, nil
+}
+
+// Embed returns the recorded embeddings of the texts
+func (r *CassetteReplayer) Embed(ctx context.Context, request *domain.EmbedRequest) (*domain.EmbedResponse, error) {
+	interaction, err := r.replay(domain.InteractionEmbed, request)
+	if err != nil {
+		return nil, err
+	}
+
+	var response domain.EmbedResponse
+	if err := json.Unmarshal(interaction.Response, &response); err != nil {
+		return nil, fmt.Errorf("failed to decode recorded response: %w", err)
+	}
+	return &response, nil
+}
+
+// Stats returns how much of the cassette has been replayed and the requests that matched nothing
+func (r *CassetteReplayer) Stats() domain.ReplayStats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stats := domain.ReplayStats{
+		Match:        r.match,
+		Interactions: len(r.entries),
+		Replayed:     r.replayed,
+		Unmatched:    append([]domain.UnmatchedRequest{}, r.unmatched...),
+	}
+	for _, entry := range r.entries {
+		if !entry.replayed {
+			stats.Unused++
+		}
+	}
+	return stats
+}
+
+// replay finds the interaction matching a request, returning its recorded
+// error if the call failed
+func (r *CassetteReplayer) replay(method string, request interface{}) (*domain.Interaction, error) {
+	key, err := r.key(method, request)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var found *replayEntry
+	for _, entry := range r.entries {
+		if entry.key != key {
+			continue
+		}
+		if !entry.replayed {
+			found = entry
+			break
+		}
+		if r.match == domain.CassetteMatchLenient {
+			found = entry
+		}
+	}
+
+	if found == nil {
+		data, _ := json.Marshal(request)
+		r.unmatched = append(r.unmatched, domain.UnmatchedRequest{Method: method, Request: data, At: r.now()})
+		return nil, fmt.Errorf("%w: %s request", domain.ErrNoRecording, method)
+	}
+
+	found.replayed = true
+	r.replayed++
+
+	if found.interaction.Error != "" {
+		return nil, domain.RecordedError(found.interaction.Error)
+	}
+	return &found.interaction, nil
+}
+
+// key returns the match key of a request. Strict keys cover the whole
+// request apart from the fields that do not change the response, as cache
+// keys do; lenient keys only cover the text sent to the model, and treat
+// streamed and non-streamed requests alike.
+func (r *CassetteReplayer) key(method string, request interface{}) (string, error) {
+	var keyed interface{}
+
+	switch request := request.(type) {
+	case *domain.AIRequest:
+		if r.match == domain.CassetteMatchLenient {
+			method = domain.InteractionProcess
+			keyed = request.Prompt
+		} else {
+			keyed = keyedRequest(request)
+		}
+	case *domain.ChatRequest:
+		if r.match == domain.CassetteMatchLenient {
+			turns := make([]string, len(request.Messages))
+			for i, message := range request.Messages {
+				turns[i] = string(message.Role) + ": " + message.Content
+			}
+			keyed = turns
+		} else {
+			keyed = keyedRequest(request)
+		}
+	case *domain.EmbedRequest:
+		if r.match == domain.CassetteMatchLenient {
+			keyed = request.Input
+		} else {
+			keyed = request
+		}
+	default:
+		return "", fmt.Errorf("unsupported request type %T", request)
+	}
+
+	data, err := json.Marshal(keyed)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode request: %w", err)
+	}
+	return method + "\n" + string(data), nil
+}
+
+// decodeRecordedRequest decodes the request of a recorded interaction into its method's request type
+func decodeRecordedRequest(method string, data json.RawMessage) (interface{}, error) {
+	var request interface{}
+	switch method {
+	case domain.InteractionProcess, domain.InteractionStream:
+		request = &domain.AIRequest{}
+	case domain.InteractionChat:
+		request = &domain.ChatRequest{}
+	case domain.InteractionEmbed:
+		request = &domain.EmbedRequest{}
+	default:
+		return nil, fmt.Errorf("unknown method %q", method)
+	}
+
+	if err := json.Unmarshal(data, request); err != nil {
+		return nil, fmt.Errorf("failed to decode recorded request: %w", err)
+	}
+	return request, nil
+}
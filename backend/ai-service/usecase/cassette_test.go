@@ -0,0 +1,232 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/augment-local-manus-clone/backend/ai-service/domain"
+	"github.com/augment-local-manus-clone/backend/ai-service/infrastructure/cassette"
+	"github.com/augment-local-manus-clone/backend/ai-service/infrastructure/llm"
+	"github.com/augment-local-manus-clone/backend/ai-service/usecase"
+)
+
+// recordSession records a short session against a fake model: two answers to
+// the same question, a streamed answer, a chat turn, an embedding and a
+// failed call
+func recordSession(t *testing.T) domain.Cassette {
+	t.Helper()
+
+	fake, err := llm.NewFakeLLMClient(llm.FakeScript{
+		Rules: []llm.FakeRule{
+			{Pattern: "capital", Script: []llm.FakeReply{{Text: "Paris"}, {Text: "Paris, France"}}},
+			{Pattern: "count", FakeReply: llm.FakeReply{Text: "one two"}},
+			{Pattern: "tools", FakeReply: llm.FakeReply{Error: domain.ErrToolsNotSupported.Error() + ": tiny"}},
+		},
+		Default: &llm.FakeReply{Text: "Hello"},
+	})
+	if err != nil {
+		t.Fatalf("NewFakeLLMClient() error = %v", err)
+	}
+
+	store, err := cassette.NewJSONLCassette(filepath.Join(t.TempDir(), "session.jsonl"))
+	if err != nil {
+		t.Fatalf("NewJSONLCassette() error = %v", err)
+	}
+
+	recorder := usecase.NewCassetteRecorder(fake, store)
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		if _, err := recorder.Process(ctx, &domain.AIRequest{Prompt: "What is the capital of France?", Model: "m1"}); err != nil {
+			t.Fatalf("Process() error = %v", err)
+		}
+	}
+	if _, err := recorder.ProcessStream(ctx, &domain.AIRequest{Prompt: "count", Model: "m1"}, func(*domain.AIStreamChunk) error { return nil }); err != nil {
+		t.Fatalf("ProcessStream() error = %v", err)
+	}
+	if _, err := recorder.Chat(ctx, &domain.ChatRequest{Model: "m1", Messages: []domain.ChatMessage{{Role: domain.ChatRoleUser, Content: "Hi"}}}); err != nil {
+		t.Fatalf("Chat() error = %v", err)
+	}
+	if _, err := recorder.Embed(ctx, &domain.EmbedRequest{Input: []string{"hello world"}}); err != nil {
+		t.Fatalf("Embed() error = %v", err)
+	}
+	if _, err := recorder.Chat(ctx, &domain.ChatRequest{Messages: []domain.ChatMessage{{Role: domain.ChatRoleUser, Content: "use tools"}}}); err == nil {
+		t.Fatal("Chat() error = nil, want the scripted error")
+	}
+
+	if stats := recorder.Stats(); stats.Recorded != 6 || stats.Errors != 0 {
+		t.Fatalf("Stats() = %+v, want 6 recorded", stats)
+	}
+	return store
+}
+
+func TestCassetteReplayer(t *testing.T) {
+	store := recordSession(t)
+	ctx := context.Background()
+
+	capital := func(model string, timeout int) func(domain.LLMClient) (string, error) {
+		return func(client domain.LLMClient) (string, error) {
+			resp, err := client.Process(ctx, &domain.AIRequest{Prompt: "What is the capital of France?", Model: model, TimeoutSeconds: timeout})
+			if err != nil {
+				return "", err
+			}
+			return resp.Text, nil
+		}
+	}
+	stream := func(client domain.LLMClient) (string, error) {
+		text := ""
+		_, err := client.ProcessStream(ctx, &domain.AIRequest{Prompt: "count", Model: "m1"}, func(chunk *domain.AIStreamChunk) error {
+			text += "[" + chunk.Text + "]"
+			return nil
+		})
+		return text, err
+	}
+	chat := func(content string) func(domain.LLMClient) (string, error) {
+		return func(client domain.LLMClient) (string, error) {
+			resp, err := client.Chat(ctx, &domain.ChatRequest{Messages: []domain.ChatMessage{{Role: domain.ChatRoleUser, Content: content}}})
+			if err != nil {
+				return "", err
+			}
+			return resp.Message.Content, nil
+		}
+	}
+
+	tests := []struct {
+		name          string
+		match         domain.CassetteMatch
+		calls         []func(domain.LLMClient) (string, error)
+		want          []string
+		wantErrs      []error
+		wantUnmatched int
+		wantUnused    int
+	}{
+		{
+			name:          "Strict replays repeated requests in order, ignoring timeouts",
+			match:         domain.CassetteMatchStrict,
+			calls:         []func(domain.LLMClient) (string, error){capital("m1", 0), capital("m1", 30), capital("m1", 0)},
+			want:          []string{"Paris", "Paris, France", ""},
+			wantErrs:      []error{nil, nil, domain.ErrNoRecording},
+			wantUnmatched: 1,
+			wantUnused:    4,
+		},
+		{
+			name:          "Strict rejects a changed model",
+			match:         domain.CassetteMatchStrict,
+			calls:         []func(domain.LLMClient) (string, error){capital("m2", 0), chat("Hi")},
+			want:          []string{"", ""},
+			wantErrs:      []error{domain.ErrNoRecording, domain.ErrNoRecording},
+			wantUnmatched: 2,
+			wantUnused:    6,
+		},
+		{
+			name:       "Lenient ignores the model and repeats the last answer",
+			match:      domain.CassetteMatchLenient,
+			calls:      []func(domain.LLMClient) (string, error){capital("m2", 0), capital("m2", 0), capital("m2", 0), chat("Hi")},
+			want:       []string{"Paris", "Paris, France", "Paris, France", "Hello"},
+			wantErrs:   []error{nil, nil, nil, nil},
+			wantUnused: 3,
+		},
+		{
+			name:       "Replays stream chunks",
+			match:      domain.CassetteMatchStrict,
+			calls:      []func(domain.LLMClient) (string, error){stream},
+			want:       []string{"[one ][two]"},
+			wantErrs:   []error{nil},
+			wantUnused: 5,
+		},
+		{
+			name:       "Replays recorded errors",
+			match:      domain.CassetteMatchLenient,
+			calls:      []func(domain.LLMClient) (string, error){chat("use tools")},
+			want:       []string{""},
+			wantErrs:   []error{domain.ErrToolsNotSupported},
+			wantUnused: 5,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			replayer, err := usecase.NewCassetteReplayer(store, tt.match)
+			if err != nil {
+				t.Fatalf("NewCassetteReplayer() error = %v", err)
+			}
+
+			var got []string
+			for i, call := range tt.calls {
+				text, err := call(replayer)
+				if !errors.Is(err, tt.wantErrs[i]) {
+					t.Errorf("call %d error = %v, want %v", i, err, tt.wantErrs[i])
+				}
+				got = append(got, text)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("replies = %q, want %q", got, tt.want)
+			}
+
+			stats := replayer.Stats()
+			if len(stats.Unmatched) != tt.wantUnmatched {
+				t.Errorf("unmatched = %+v, want %d", stats.Unmatched, tt.wantUnmatched)
+			}
+			if stats.Interactions != 6 || stats.Unused != tt.wantUnused {
+				t.Errorf("Stats() = %+v, want %d unused", stats, tt.wantUnused)
+			}
+		})
+	}
+}
+
+func TestCassetteReplayerEmbed(t *testing.T) {
+	store := recordSession(t)
+
+	replayer, err := usecase.NewCassetteReplayer(store, domain.CassetteMatchStrict)
+	if err != nil {
+		t.Fatalf("NewCassetteReplayer() error = %v", err)
+	}
+
+	resp, err := replayer.Embed(context.Background(), &domain.EmbedRequest{Input: []string{"hello world"}})
+	if err != nil {
+		t.Fatalf("Embed() error = %v", err)
+	}
+	if len(resp.Embeddings) != 1 || len(resp.Embeddings[0]) != 8 {
+		t.Errorf("Embed() = %+v", resp)
+	}
+}
+
+func TestCassetteRecorderSkipsCancelledCalls(t *testing.T) {
+	fake, err := llm.NewFakeLLMClient(llm.FakeScript{Default: &llm.FakeReply{Text: "Paris", LatencyMS: 50}})
+	if err != nil {
+		t.Fatalf("NewFakeLLMClient() error = %v", err)
+	}
+	store, err := cassette.NewJSONLCassette(filepath.Join(t.TempDir(), "session.jsonl"))
+	if err != nil {
+		t.Fatalf("NewJSONLCassette() error = %v", err)
+	}
+	recorder := usecase.NewCassetteRecorder(fake, store)
+	request := &domain.AIRequest{Prompt: "What is the capital of France?", Model: "m1"}
+
+	// The first attempt is cancelled, as a pre-empted call is, and retried
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := recorder.Process(ctx, request); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Process() error = %v, want context.Canceled", err)
+	}
+	if _, err := recorder.Process(context.Background(), request); err != nil {
+		t.Fatalf("Process() error = %v", err)
+	}
+	if stats := recorder.Stats(); stats.Recorded != 1 || stats.Skipped != 1 {
+		t.Errorf("Stats() = %+v, want 1 recorded and 1 skipped", stats)
+	}
+
+	// Strict replay serves the retry that succeeded
+	replayer, err := usecase.NewCassetteReplayer(store, domain.CassetteMatchStrict)
+	if err != nil {
+		t.Fatalf("NewCassetteReplayer() error = %v", err)
+	}
+	response, err := replayer.Process(context.Background(), request)
+	if err != nil || response.Text != "Paris" {
+		t.Errorf("Process() = %+v, %v, want the recorded answer", response, err)
+	}
+}
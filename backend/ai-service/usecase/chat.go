@@ -0,0 +1,180 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+
+	"github.com/augment-local-manus-clone/backend/ai-service/domain"
+)
+
+// ChatUseCase handles multi-message chat requests
+type ChatUseCase struct {
+	llmClient     domain.LLMClient
+	contextWindow *ContextWindowManager
+	guardrails    *Guardrails
+	memory        *MemoryUseCase
+}
+
+// NewChatUseCase creates a new instance of ChatUseCase. contextWindow,
+// guardrails and memory are optional; without a context window manager
+// conversations are sent unchanged, without guardrails untrusted messages are
+// tagged but not checked, and without memory requests cannot set a
+// conversation ID.
+func NewChatUseCase(llmClient domain.LLMClient, contextWindow *ContextWindowManager, guardrails *Guardrails, memory *MemoryUseCase) *ChatUseCase {
+	return &ChatUseCase{
+		llmClient:     llmClient,
+		contextWindow: contextWindow,
+		guardrails:    guardrails,
+		memory:        memory,
+	}
+}
+
+// Execute processes a chat request. Cancelling the context, or exceeding the
+// request's timeout, aborts the request.
+func (uc *ChatUseCase) Execute(ctx context.Context, request *domain.ChatRequest) (*domain.ChatResponse, error) {
+	ctx, cancel := withTimeout(ctx, request.Timeout())
+	defer cancel()
+
+	// Validate the request
+	if err := request.Validate(); err != nil {
+		return nil, err
+	}
+
+	// Check untrusted messages before they are tagged
+	var findings []domain.GuardrailFinding
+	if uc.guardrails != nil {
+		var err error
+		findings, err = uc.guardrails.CheckChat(request)
+		if err != nil {
+			return nil, err
+		}
+	}
+	// The latest user message is what the memory remembers of this turn
+	prompt := ""
+	for i := len(request.Messages) - 1; i >= 0; i-- {
+		if request.Messages[i].Role == domain.ChatRoleUser && request.Messages[i].Trusted() {
+			prompt = request.Messages[i].Content
+			break
+		}
+	}
+
+	request.TagUntrusted()
+
+	// Add what is remembered of the conversation
+	var memory *domain.MemoryReport
+	if request.ConversationID != "" {
+		if uc.memory == nil {
+			return nil, errors.New("conversation memory is not configured")
+		}
+
+		var err error
+		memory, err = uc.memory.injectChat(request)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Set default values if not provided
+	if request.MaxTokens == 0 {
+		request.MaxTokens = 2048
+	}
+
+	if request.Temperature == nil {
+		temperature := 0.7
+		request.Temperature = &temperature
+	}
+
+	var report *domain.ContextReport
+	if uc.contextWindow != nil {
+		var err error
+		report, err = uc.contextWindow.FitChat(ctx, uc.llmClient, request)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	response, err := uc.chat(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+
+	if request.Reasoning == domain.ReasoningDrop {
+		response.Reasoning = ""
+	}
+
+	if uc.guardrails != nil {
+		response.Guardrails, err = uc.checkOutput(findings, response)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if memory != nil {
+		uc.memory.record(ctx, request.ConversationID, request.Provider, response.Model, prompt, response.Message.Content)
+	}
+
+	response.ContextWindow = report
+	response.Memory = memory
+	return response, nil
+}
+
+// checkOutput applies the output guardrails to the reply, its structured
+// output and the arguments of its tool calls
+func (uc *ChatUseCase) checkOutput(findings []domain.GuardrailFinding, response *domain.ChatResponse) (*domain.GuardrailReport, error) {
+	values := []*interface{}{&response.JSON}
+	arguments := make([]interface{}, len(response.Message.ToolCalls))
+	for i, call := range response.Message.ToolCalls {
+		arguments[i] = call.Arguments
+		values = append(values, &arguments[i])
+	}
+
+	report, err := uc.guardrails.CheckOutput(findings, []*string{&response.Message.Content, &response.Reasoning}, values...)
+	if err != nil {
+		return nil, err
+	}
+
+	// The checked arguments are copies, so the calls are copied too
+	calls := make([]domain.ToolCall, len(response.Message.ToolCalls))
+	for i, call := range response.Message.ToolCalls {
+		call.Arguments, _ = arguments[i].(map[string]interface{})
+		calls[i] = call
+	}
+	if len(calls) > 0 {
+		response.Message.ToolCalls = calls
+	}
+	return report, nil
+}
+
+// chat sends a validated conversation to the model, handling structured output and tools
+func (uc *ChatUseCase) chat(ctx context.Context, request *domain.ChatRequest) (*domain.ChatResponse, error) {
+	if request.ResponseSchema != nil {
+		return chatWithSchema(ctx, uc.llmClient, request)
+	}
+
+	if len(request.Tools) == 0 {
+		// Process the conversation using the LLM client
+		return uc.llmClient.Chat(ctx, request)
+	}
+
+	mode := request.ToolMode
+	if mode == "" {
+		mode = domain.ToolModeAuto
+	}
+
+	if mode != domain.ToolModePrompt {
+		response, err := uc.llmClient.Chat(ctx, request)
+		if err == nil {
+			if err := domain.ValidateToolCalls(request.Tools, response.Message.ToolCalls); err != nil {
+				return nil, err
+			}
+			return response, nil
+		}
+
+		// Only fall back when the model lacks native support and the caller allows it
+		if mode == domain.ToolModeNative || !errors.Is(err, domain.ErrToolsNotSupported) {
+			return nil, err
+		}
+	}
+
+	return uc.chatWithPromptedTools(ctx, request)
+}
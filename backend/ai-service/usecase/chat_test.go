@@ -0,0 +1,222 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/augment-local-manus-clone/backend/ai-service/domain"
+	"github.com/augment-local-manus-clone/backend/ai-service/usecase"
+)
+
+// scriptedLLMClient replies to chat requests from a fixed script
+type scriptedLLMClient struct {
+	replies  []scriptedReply
+	requests []*domain.ChatRequest
+}
+
+// scriptedReply is a single canned chat reply or error
+type scriptedReply struct {
+	message   domain.ChatMessage
+	reasoning string
+	err       error
+}
+
+func (c *scriptedLLMClient) Process(ctx context.Context, request *domain.AIRequest) (*domain.AIResponse, error) {
+	response, err := c.Chat(ctx, request.ToChatRequest())
+	if err != nil {
+		return nil, err
+	}
+	return response.ToAIResponse(), nil
+}
+
+func (c *scriptedLLMClient) ProcessStream(ctx context.Context, request *domain.AIRequest, handler domain.StreamHandler) (*domain.AIResponse, error) {
+	return c.Process(ctx, request)
+}
+
+func (c *scriptedLLMClient) Chat(ctx context.Context, request *domain.ChatRequest) (*domain.ChatResponse, error) {
+	copied := *request
+	c.requests = append(c.requests, &copied)
+
+	if len(c.replies) == 0 {
+		return nil, errors.New("script exhausted")
+	}
+	reply := c.replies[0]
+	c.replies = c.replies[1:]
+
+	if reply.err != nil {
+		return nil, reply.err
+	}
+	return &domain.ChatResponse{Message: reply.message, Reasoning: reply.reasoning, TokensUsed: 1}, nil
+}
+
+func (c *scriptedLLMClient) Embed(ctx context.Context, request *domain.EmbedRequest) (*domain.EmbedResponse, error) {
+	return nil, errors.New("not implemented")
+}
+
+func TestChatUseCaseTools(t *testing.T) {
+	tools := []domain.ToolDefinition{
+		{
+			Name:        "browse",
+			Description: "Open a web page",
+			Parameters: domain.JSONSchema{
+				"type":       "object",
+				"required":   []interface{}{"url"},
+				"properties": map[string]interface{}{"url": map[string]interface{}{"type": "string"}},
+			},
+		},
+	}
+	assistant := func(content string, calls ...domain.ToolCall) scriptedReply {
+		return scriptedReply{message: domain.ChatMessage{Role: domain.ChatRoleAssistant, Content: content, ToolCalls: calls}}
+	}
+	unsupported := scriptedReply{err: domain.ErrToolsNotSupported}
+
+	tests := []struct {
+		name         string
+		mode         domain.ToolMode
+		replies      []scriptedReply
+		wantErr      bool
+		wantCalls    int
+		wantContent  string
+		wantRequests int
+		wantPrompted bool
+	}{
+		{
+			name:         "Native tool call",
+			replies:      []scriptedReply{assistant("", domain.ToolCall{Name: "browse", Arguments: map[string]interface{}{"url": "https://example.com"}})},
+			wantCalls:    1,
+			wantRequests: 1,
+		},
+		{
+			name:         "Native call with invalid arguments",
+			replies:      []scriptedReply{assistant("", domain.ToolCall{Name: "browse", Arguments: map[string]interface{}{}})},
+			wantErr:      true,
+			wantRequests: 1,
+		},
+		{
+			name:         "Fallback after unsupported",
+			replies:      []scriptedReply{unsupported, assistant(`Sure: {"tool_calls": [{"name": "browse", "arguments": {"url": "https://example.com"}}]}`)},
+			wantCalls:    1,
+			wantRequests: 2,
+			wantPrompted: true,
+		},
+		{
+			name:         "Native mode does not fall back",
+			mode:         domain.ToolModeNative,
+			replies:      []scriptedReply{unsupported},
+			wantErr:      true,
+			wantRequests: 1,
+		},
+		{
+			name:         "Prompt mode answers directly",
+			mode:         domain.ToolModePrompt,
+			replies:      []scriptedReply{assistant("```json\n{\"content\": \"No tool needed\"}\n```")},
+			wantContent:  "No tool needed",
+			wantRequests: 1,
+			wantPrompted: true,
+		},
+		{
+			name: "Prompt mode corrects invalid reply",
+			mode: domain.ToolModePrompt,
+			replies: []scriptedReply{
+				assistant("I would browse the page"),
+				assistant(`{"tool_calls": [{"name": "browse", "arguments": {}}]}`),
+				assistant(`{"tool_calls": [{"name": "browse", "arguments": {"url": "https://example.com"}}]}`),
+			},
+			wantCalls:    1,
+			wantRequests: 3,
+			wantPrompted: true,
+		},
+		{
+			name: "Prompt mode gives up",
+			mode: domain.ToolModePrompt,
+			replies: []scriptedReply{
+				assistant("no"),
+				assistant("still no"),
+				assistant("never"),
+			},
+			wantErr:      true,
+			wantRequests: 3,
+			wantPrompted: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &scriptedLLMClient{replies: tt.replies}
+			uc := usecase.NewChatUseCase(client, nil, nil, nil)
+
+			response, err := uc.Execute(context.Background(), &domain.ChatRequest{
+				Messages: []domain.ChatMessage{{Role: domain.ChatRoleUser, Content: "Open example.com"}},
+				Tools:    tools,
+				ToolMode: tt.mode,
+			})
+
+			if len(client.requests) != tt.wantRequests {
+				t.Errorf("requests = %d, want %d", len(client.requests), tt.wantRequests)
+			}
+
+			if tt.wantPrompted {
+				last := client.requests[len(client.requests)-1]
+				if len(last.Tools) != 0 || last.Messages[0].Role != domain.ChatRoleSystem ||
+					!strings.Contains(last.Messages[0].Content, "browse") {
+					t.Errorf("prompted request does not describe the tools: %+v", last)
+				}
+			}
+
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Execute() error = nil, wantErr %v", tt.wantErr)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Execute() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if len(response.Message.ToolCalls) != tt.wantCalls {
+				t.Errorf("ToolCalls = %v, want %d calls", response.Message.ToolCalls, tt.wantCalls)
+			}
+
+			if response.Message.Content != tt.wantContent {
+				t.Errorf("Content = %q, want %q", response.Message.Content, tt.wantContent)
+			}
+		})
+	}
+}
+
+func TestChatUseCaseReasoning(t *testing.T) {
+	tests := []struct {
+		name          string
+		mode          domain.ReasoningMode
+		wantReasoning string
+	}{
+		{name: "Separate by default", mode: "", wantReasoning: "2 + 2 = 4"},
+		{name: "Separate", mode: domain.ReasoningSeparate, wantReasoning: "2 + 2 = 4"},
+		{name: "Drop", mode: domain.ReasoningDrop, wantReasoning: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &scriptedLLMClient{replies: []scriptedReply{{
+				message:   domain.ChatMessage{Role: domain.ChatRoleAssistant, Content: "4"},
+				reasoning: "2 + 2 = 4",
+			}}}
+			uc := usecase.NewChatUseCase(client, nil, nil, nil)
+
+			response, err := uc.Execute(context.Background(), &domain.ChatRequest{
+				Messages:  []domain.ChatMessage{{Role: domain.ChatRoleUser, Content: "2 + 2?"}},
+				Reasoning: tt.mode,
+			})
+			if err != nil {
+				t.Fatalf("Execute() error = %v", err)
+			}
+
+			if response.Reasoning != tt.wantReasoning || response.Message.Content != "4" {
+				t.Errorf("response = %q, %q, want %q, %q", response.Reasoning, response.Message.Content, tt.wantReasoning, "4")
+			}
+		})
+	}
+}
@@ -0,0 +1,143 @@
+package usecase
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/augment-local-manus-clone/backend/ai-service/domain"
+)
+
+// circuitBreaker tracks consecutive failures per model. A model's circuit
+// opens after failureThreshold consecutive failures, rejecting requests until
+// the cooldown has elapsed; a single probe request is then let through and
+// closes the circuit again if it succeeds.
+type circuitBreaker struct {
+	mu               sync.Mutex
+	failureThreshold int
+	cooldown         time.Duration
+	now              func() time.Time
+	circuits         map[string]*circuit
+}
+
+// circuit is the breaker state of a single model
+type circuit struct {
+	target    domain.ModelTarget
+	state     domain.CircuitState
+	failures  int
+	lastError string
+	openedAt  time.Time
+	probing   bool
+}
+
+// newCircuitBreaker creates a circuitBreaker with every circuit closed
+func newCircuitBreaker(failureThreshold int, cooldown time.Duration) *circuitBreaker {
+	return &circuitBreaker{
+		failureThreshold: failureThreshold,
+		cooldown:         cooldown,
+		now:              time.Now,
+		circuits:         make(map[string]*circuit),
+	}
+}
+
+// allow reports whether a request may be sent to the target, moving an open
+// circuit whose cooldown has elapsed to half-open
+func (b *circuitBreaker) allow(target domain.ModelTarget) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	c := b.circuit(target)
+	switch c.state {
+	case domain.CircuitOpen:
+		if b.now().Sub(c.openedAt) < b.cooldown {
+			return false
+		}
+		c.state = domain.CircuitHalfOpen
+		c.probing = true
+		return true
+	case domain.CircuitHalfOpen:
+		// Only one probe is in flight at a time
+		if c.probing {
+			return false
+		}
+		c.probing = true
+		return true
+	default:
+		return true
+	}
+}
+
+// success records a successful request, closing the target's circuit
+func (b *circuitBreaker) success(target domain.ModelTarget) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	c := b.circuit(target)
+	c.state = domain.CircuitClosed
+	c.failures = 0
+	c.lastError = ""
+	c.probing = false
+}
+
+// failure records a failed request, opening the target's circuit once the
+// threshold is reached or when a probe fails
+func (b *circuitBreaker) failure(target domain.ModelTarget, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	c := b.circuit(target)
+	c.failures++
+	c.lastError = err.Error()
+	c.probing = false
+
+	if c.state == domain.CircuitHalfOpen || c.failures >= b.failureThreshold {
+		c.state = domain.CircuitOpen
+		c.openedAt = b.now()
+	}
+}
+
+// release ends a request that says nothing about the target's health, such
+// as one cancelled by its caller, without recording a result. A half-open
+// circuit can then be probed again.
+func (b *circuitBreaker) release(target domain.ModelTarget) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.circuit(target).probing = false
+}
+
+// health returns the state of every model that has received requests, sorted by key
+func (b *circuitBreaker) health() []domain.ModelHealth {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	health := make([]domain.ModelHealth, 0, len(b.circuits))
+	for _, c := range b.circuits {
+		entry := domain.ModelHealth{
+			ModelTarget:         c.target,
+			State:               c.state,
+			ConsecutiveFailures: c.failures,
+			LastError:           c.lastError,
+		}
+		if c.state == domain.CircuitOpen {
+			openUntil := c.openedAt.Add(b.cooldown)
+			entry.OpenUntil = &openUntil
+		}
+		health = append(health, entry)
+	}
+
+	sort.Slice(health, func(i, j int) bool {
+		return health[i].Key() < health[j].Key()
+	})
+	return health
+}
+
+// circuit returns the target's circuit, creating a closed one if needed; b.mu must be held
+func (b *circuitBreaker) circuit(target domain.ModelTarget) *circuit {
+	c, ok := b.circuits[target.Key()]
+	if !ok {
+		c = &circuit{target: target, state: domain.CircuitClosed}
+		b.circuits[target.Key()] = c
+	}
+	return c
+}
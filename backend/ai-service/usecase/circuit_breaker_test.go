@@ -0,0 +1,50 @@
+package usecase
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/augment-local-manus-clone/backend/ai-service/domain"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	now := time.Unix(0, 0)
+	breaker := newCircuitBreaker(2, time.Minute)
+	breaker.now = func() time.Time { return now }
+	target := domain.ModelTarget{Provider: "ollama", Model: "m"}
+	failure := errors.New("boom")
+
+	steps := []struct {
+		name      string
+		advance   time.Duration
+		action    func()
+		wantAllow bool
+		wantState domain.CircuitState
+	}{
+		{name: "Closed allows", wantAllow: true, wantState: domain.CircuitClosed},
+		{name: "Below threshold stays closed", action: func() { breaker.failure(target, failure) }, wantAllow: true, wantState: domain.CircuitClosed},
+		{name: "Threshold opens", action: func() { breaker.failure(target, failure) }, wantAllow: false, wantState: domain.CircuitOpen},
+		{name: "Open during cooldown", advance: 30 * time.Second, wantAllow: false, wantState: domain.CircuitOpen},
+		{name: "Half-open after cooldown", advance: 30 * time.Second, wantAllow: true, wantState: domain.CircuitHalfOpen},
+		{name: "Single probe in flight", wantAllow: false, wantState: domain.CircuitHalfOpen},
+		{name: "Failed probe reopens", action: func() { breaker.failure(target, failure) }, wantAllow: false, wantState: domain.CircuitOpen},
+		{name: "Probe again after cooldown", advance: time.Minute, wantAllow: true, wantState: domain.CircuitHalfOpen},
+		{name: "Released probe can be retried", action: func() { breaker.release(target) }, wantAllow: true, wantState: domain.CircuitHalfOpen},
+		{name: "Successful probe closes", action: func() { breaker.success(target) }, wantAllow: true, wantState: domain.CircuitClosed},
+	}
+
+	for _, step := range steps {
+		now = now.Add(step.advance)
+		if step.action != nil {
+			step.action()
+		}
+
+		if got := breaker.allow(target); got != step.wantAllow {
+			t.Errorf("%s: allow() = %v, want %v", step.name, got, step.wantAllow)
+		}
+		if got := breaker.health()[0].State; got != step.wantState {
+			t.Errorf("%s: state = %s, want %s", step.name, got, step.wantState)
+		}
+	}
+}
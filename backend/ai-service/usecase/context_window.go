@@ -0,0 +1,465 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/augment-local-manus-clone/backend/ai-service/domain"
+)
+
+const (
+	// contextMarginPercent keeps part of the window free to absorb tokenizer estimation error
+	contextMarginPercent = 5
+
+	// messageOverheadTokens approximates the chat template tokens around each message
+	messageOverheadTokens = 4
+
+	// truncationMarker replaces the text cut out of an oversized input
+	truncationMarker = "\n\n[... %d tokens truncated ...]\n\n"
+
+	// maxSummaryRounds bounds how many times map-reduce summarization condenses its own output
+	maxSummaryRounds = 3
+
+	// minSummaryTokens is the smallest reply requested for a summary
+	minSummaryTokens = 64
+
+	// summaryTemperature keeps summaries close to the source text
+	summaryTemperature = 0.2
+)
+
+// ContextWindowManager fits requests into the context window of their model.
+// It knows each model's context length and estimates token counts with a
+// tokenizer; inputs that do not fit are truncated, summarized or rejected
+// according to the request's context strategy.
+type ContextWindowManager struct {
+	tokenizer     domain.Tokenizer
+	contextSizes  map[string]int
+	defaultLength int
+}
+
+// NewContextWindowManager creates a ContextWindowManager. contextSizes maps
+// model names, with or without a ":tag" suffix, to their context lengths;
+// other models use defaultLength.
+func NewContextWindowManager(tokenizer domain.Tokenizer, contextSizes map[string]int, defaultLength int) *ContextWindowManager {
+	return &ContextWindowManager{
+		tokenizer:     tokenizer,
+		contextSizes:  contextSizes,
+		defaultLength: defaultLength,
+	}
+}
+
+// ContextLength returns the context length of a model
+func (m *ContextWindowManager) ContextLength(model string) int {
+	if length, ok := m.contextSizes[model]; ok {
+		return length
+	}
+
+	// "llama3.2:3b" falls back to the entry for "llama3.2"
+	if base, _, found := strings.Cut(model, ":"); found {
+		if length, ok := m.contextSizes[base]; ok {
+			return length
+		}
+	}
+
+	return m.defaultLength
+}
+
+// FitPrompt fits a single-prompt request into its model's context window,
+// rewriting the prompt if needed, and reports what was changed
+func (m *ContextWindowManager) FitPrompt(ctx context.Context, client domain.LLMClient, request *domain.AIRequest) (*domain.ContextReport, error) {
+	options := request.GenerationOptions()
+	report, err := m.newReport(request.Model, options, request.ContextStrategy)
+	if err != nil {
+		return nil, err
+	}
+
+	fixed := m.schemaTokens(request.ResponseSchema)
+	promptTokens := m.count(request.Prompt)
+	report.InputTokens = fixed + promptTokens
+	report.OutputTokens = report.InputTokens
+
+	if report.InputTokens <= report.Budget || report.Strategy == domain.ContextStrategyOff {
+		return report, nil
+	}
+
+	if report.Strategy == domain.ContextStrategyReject {
+		return nil, fmt.Errorf("%w: prompt is about %d tokens, the budget is %d", domain.ErrContextOverflow, report.InputTokens, report.Budget)
+	}
+
+	available := report.Budget - fixed
+
+	if report.Strategy == domain.ContextStrategySummarize {
+		summary, used, err := m.summarize(ctx, client, summaryModel{request.Provider, request.Model, report.ContextLength, options.NumCtx}, request.Prompt, available)
+		if err != nil {
+			return nil, err
+		}
+		request.Prompt = summary
+		report.SummarizedTokens = promptTokens
+		report.SummaryTokensUsed = used
+		report.Actions = append(report.Actions, fmt.Sprintf("summarized the %d-token prompt to %d tokens", promptTokens, m.count(summary)))
+	}
+
+	if m.count(request.Prompt) > available {
+		truncated, dropped := m.truncate(request.Prompt, available)
+		request.Prompt = truncated
+		report.TruncatedTokens = dropped
+		report.Actions = append(report.Actions, fmt.Sprintf("truncated %d tokens from the middle of the prompt", dropped))
+	}
+
+	report.OutputTokens = fixed + m.count(request.Prompt)
+	return report, nil
+}
+
+// FitChat fits a conversation into its model's context window and reports
+// what was changed. Leading system messages and the final turn are kept;
+// the turns in between are dropped oldest first, or replaced by a summary.
+// If that is not enough the largest remaining messages are truncated.
+func (m *ContextWindowManager) FitChat(ctx context.Context, client domain.LLMClient, request *domain.ChatRequest) (*domain.ContextReport, error) {
+	options := request.GenerationOptions()
+	report, err := m.newReport(request.Model, options, request.ContextStrategy)
+	if err != nil {
+		return nil, err
+	}
+
+	fixed := m.schemaTokens(request.ResponseSchema) + m.toolsTokens(request.Tools)
+	report.InputTokens = fixed + m.messagesTokens(request.Messages)
+	report.OutputTokens = report.InputTokens
+
+	if report.InputTokens <= report.Budget || report.Strategy == domain.ContextStrategyOff {
+		return report, nil
+	}
+
+	if report.Strategy == domain.ContextStrategyReject {
+		return nil, fmt.Errorf("%w: conversation is about %d tokens, the budget is %d", domain.ErrContextOverflow, report.InputTokens, report.Budget)
+	}
+
+	lead := 0
+	for lead < len(request.Messages) && request.Messages[lead].Role == domain.ChatRoleSystem {
+		lead++
+	}
+	groups := turnGroups(request.Messages, lead)
+
+	// Leave room for the summary of the dropped turns
+	target := report.Budget
+	summaryBudget := 0
+	if report.Strategy == domain.ContextStrategySummarize {
+		summaryBudget = report.Budget / 4
+		target -= summaryBudget
+	}
+
+	total := report.InputTokens
+	dropGroups := 0
+	for dropGroups < len(groups)-1 && total > target {
+		total -= m.messagesTokens(groups[dropGroups])
+		dropGroups++
+	}
+
+	var dropped []domain.ChatMessage
+	for _, group := range groups[:dropGroups] {
+		dropped = append(dropped, group...)
+	}
+
+	messages := append([]domain.ChatMessage{}, request.Messages[:lead]...)
+
+	if len(dropped) > 0 {
+		if report.Strategy == domain.ContextStrategySummarize {
+			transcript := chatTranscript(dropped)
+			summary, used, err := m.summarize(ctx, client, summaryModel{request.Provider, request.Model, report.ContextLength, options.NumCtx}, transcript, summaryBudget)
+			if err != nil {
+				return nil, err
+			}
+			messages = append(messages, domain.ChatMessage{
+				Role:    domain.ChatRoleSystem,
+				Content: "Summary of the earlier conversation:\n" + summary,
+			})
+			report.SummarizedMessages = len(dropped)
+			report.SummarizedTokens = m.messagesTokens(dropped)
+			report.SummaryTokensUsed = used
+			report.Actions = append(report.Actions, fmt.Sprintf("summarized the %d oldest messages", len(dropped)))
+		} else {
+			report.DroppedMessages = len(dropped)
+			report.Actions = append(report.Actions, fmt.Sprintf("dropped the %d oldest messages (%d tokens)", len(dropped), m.messagesTokens(dropped)))
+		}
+	}
+
+	for _, group := range groups[dropGroups:] {
+		messages = append(messages, group...)
+	}
+
+	// Truncate the largest messages until the conversation fits
+	total = fixed + m.messagesTokens(messages)
+	for attempt := 0; attempt < len(messages) && total > report.Budget; attempt++ {
+		largest := -1
+		for i := range messages {
+			if largest < 0 || m.count(messages[i].Content) > m.count(messages[largest].Content) {
+				largest = i
+			}
+		}
+
+		tokens := m.count(messages[largest].Content)
+		allowed := tokens - (total - report.Budget)
+		if allowed <= 0 {
+			allowed = tokens / 2
+		}
+
+		truncated, cut := m.truncate(messages[largest].Content, allowed)
+		if cut == 0 {
+			break
+		}
+		messages[largest].Content = truncated
+		report.TruncatedTokens += cut
+		report.Actions = append(report.Actions, fmt.Sprintf("truncated %d tokens from the middle of message %d", cut, largest))
+		total = fixed + m.messagesTokens(messages)
+	}
+
+	if total > report.Budget {
+		return nil, fmt.Errorf("%w: conversation is still about %d tokens after compaction, the budget is %d", domain.ErrContextOverflow, total, report.Budget)
+	}
+
+	request.Messages = messages
+	report.OutputTokens = total
+	return report, nil
+}
+
+// newReport starts a report with the context length and input budget. A
+// request's num_ctx sets the context length the model is loaded with, and its
+// num_predict is reserved for the output.
+func (m *ContextWindowManager) newReport(model string, options domain.GenerationOptions, strategy domain.ContextStrategy) (*domain.ContextReport, error) {
+	if strategy == "" {
+		strategy = domain.ContextStrategyTruncate
+	}
+
+	contextLength := m.ContextLength(model)
+	if options.NumCtx != nil {
+		contextLength = *options.NumCtx
+	}
+
+	// Unlimited output (a negative num_predict) reserves nothing
+	maxTokens := 0
+	if options.NumPredict != nil && *options.NumPredict > 0 {
+		maxTokens = *options.NumPredict
+	}
+
+	budget := contextLength*(100-contextMarginPercent)/100 - maxTokens
+	if budget <= 0 && strategy != domain.ContextStrategyOff {
+		return nil, fmt.Errorf("%w: max_tokens %d leaves no room for input in the %d-token context", domain.ErrContextOverflow, maxTokens, contextLength)
+	}
+
+	return &domain.ContextReport{
+		Strategy:      strategy,
+		ContextLength: contextLength,
+		Budget:        budget,
+	}, nil
+}
+
+// summaryModel identifies the model that summarizes and its context window
+type summaryModel struct {
+	provider      string
+	model         string
+	contextLength int
+
+	// numCtx is passed on so that the model is not reloaded with another context size
+	numCtx *int
+}
+
+// summarize condenses text to about target tokens with map-reduce
+// summarization: the text is split into pieces that fit the model, each piece
+// is summarized, and the joined summaries are condensed again while they are
+// still too long. It returns the summary and the tokens spent.
+func (m *ContextWindowManager) summarize(ctx context.Context, client domain.LLMClient, target summaryModel, text string, targetTokens int) (string, int, error) {
+	contextLength := target.contextLength
+	temperature := summaryTemperature
+	used := 0
+
+	for round := 0; round < maxSummaryRounds && (round == 0 || m.count(text) > targetTokens); round++ {
+		// Each piece and its summary must fit the model's context together
+		pieces := m.split(text, contextLength/2)
+
+		summaryTokens := targetTokens / len(pieces)
+		if summaryTokens < minSummaryTokens {
+			summaryTokens = minSummaryTokens
+		}
+		if summaryTokens > contextLength/4 {
+			summaryTokens = contextLength / 4
+		}
+
+		summaries := make([]string, 0, len(pieces))
+		for i, piece := range pieces {
+			response, err := client.Process(ctx, &domain.AIRequest{
+				Provider:    target.provider,
+				Model:       target.model,
+				Prompt:      summaryPrompt(piece, i+1, len(pieces), summaryTokens),
+				MaxTokens:   summaryTokens,
+				Temperature: &temperature,
+				Options:     &domain.GenerationOptions{NumCtx: target.numCtx},
+			})
+			if err != nil {
+				return "", used, fmt.Errorf("failed to summarize input: %w", err)
+			}
+			used += response.TokensUsed
+			summaries = append(summaries, strings.TrimSpace(response.Text))
+		}
+
+		text = strings.Join(summaries, "\n\n")
+	}
+
+	return text, used, nil
+}
+
+// summaryPrompt asks the model to condense one piece of a larger input
+func summaryPrompt(piece string, index, total, maxTokens int) string {
+	var b strings.Builder
+	if total > 1 {
+		fmt.Fprintf(&b, "The following is part %d of %d of a longer input. ", index, total)
+	}
+	fmt.Fprintf(&b, "Condense it to at most %d tokens. ", maxTokens)
+	b.WriteString("Keep facts, names, numbers, code and any instructions or questions; quote instructions and questions verbatim. ")
+	b.WriteString("Reply with the condensed text only.\n\n")
+	b.WriteString(piece)
+	return b.String()
+}
+
+// split divides text along line boundaries into pieces of at most maxTokens tokens
+func (m *ContextWindowManager) split(text string, maxTokens int) []string {
+	tokens := m.count(text)
+	if tokens <= maxTokens {
+		return []string{text}
+	}
+
+	// chunkText works in characters, so convert using the text's own density
+	maxChars := maxTokens * len(text) / tokens
+	if maxChars < 1 {
+		maxChars = 1
+	}
+
+	var pieces []string
+	for _, chunk := range chunkText(text, maxChars, 0) {
+		pieces = append(pieces, chunk.Text)
+	}
+	if len(pieces) == 0 {
+		return []string{text}
+	}
+	return pieces
+}
+
+// truncate cuts the middle out of text so that it fits in budget tokens,
+// keeping two thirds of the budget from the head and the rest from the tail,
+// and returns the result with the number of tokens removed
+func (m *ContextWindowManager) truncate(text string, budget int) (string, int) {
+	total := m.count(text)
+	if total <= budget {
+		return text, 0
+	}
+
+	keep := budget - m.count(fmt.Sprintf(truncationMarker, total))
+	if keep < 0 {
+		keep = 0
+	}
+
+	runes := []rune(text)
+	head := m.prefix(runes, keep*2/3)
+	tail := m.suffix(runes[len([]rune(head)):], keep-m.count(head))
+
+	dropped := total - m.count(head) - m.count(tail)
+	return head + fmt.Sprintf(truncationMarker, dropped) + tail, dropped
+}
+
+// prefix returns the longest prefix of runes within budget tokens
+func (m *ContextWindowManager) prefix(runes []rune, budget int) string {
+	lo, hi := 0, len(runes)
+	for lo < hi {
+		mid := (lo + hi + 1) / 2
+		if m.count(string(runes[:mid])) <= budget {
+			lo = mid
+		} else {
+			hi = mid - 1
+		}
+	}
+	return string(runes[:lo])
+}
+
+// suffix returns the longest suffix of runes within budget tokens
+func (m *ContextWindowManager) suffix(runes []rune, budget int) string {
+	lo, hi := 0, len(runes)
+	for lo < hi {
+		mid := (lo + hi + 1) / 2
+		if m.count(string(runes[len(runes)-mid:])) <= budget {
+			lo = mid
+		} else {
+			hi = mid - 1
+		}
+	}
+	return string(runes[len(runes)-lo:])
+}
+
+// count returns the estimated number of tokens in text
+func (m *ContextWindowManager) count(text string) int {
+	return m.tokenizer.CountTokens(text)
+}
+
+// messagesTokens estimates the tokens taken by chat messages, including tool calls
+func (m *ContextWindowManager) messagesTokens(messages []domain.ChatMessage) int {
+	tokens := 0
+	for _, message := range messages {
+		tokens += messageOverheadTokens + m.count(message.Content)
+		if len(message.ToolCalls) > 0 {
+			tokens += m.jsonTokens(message.ToolCalls)
+		}
+	}
+	return tokens
+}
+
+// toolsTokens estimates the tokens taken by tool definitions
+func (m *ContextWindowManager) toolsTokens(tools []domain.ToolDefinition) int {
+	if len(tools) == 0 {
+		return 0
+	}
+	return m.jsonTokens(tools)
+}
+
+// schemaTokens estimates the tokens taken by a response schema
+func (m *ContextWindowManager) schemaTokens(schema domain.JSONSchema) int {
+	if schema == nil {
+		return 0
+	}
+	return m.jsonTokens(schema)
+}
+
+// jsonTokens estimates the tokens of a value's JSON encoding
+func (m *ContextWindowManager) jsonTokens(value interface{}) int {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return 0
+	}
+	return m.count(string(data))
+}
+
+// turnGroups splits messages after the leading system messages into turns
+// that are kept or dropped together: tool results stay with the assistant
+// message that called the tools
+func turnGroups(messages []domain.ChatMessage, lead int) [][]domain.ChatMessage {
+	var groups [][]domain.ChatMessage
+	for _, message := range messages[lead:] {
+		if message.Role == domain.ChatRoleTool && len(groups) > 0 {
+			groups[len(groups)-1] = append(groups[len(groups)-1], message)
+			continue
+		}
+		groups = append(groups, []domain.ChatMessage{message})
+	}
+	return groups
+}
+
+// chatTranscript renders messages as plain text for summarization
+func chatTranscript(messages []domain.ChatMessage) string {
+	var b strings.Builder
+	for _, message := range messages {
+		fmt.Fprintf(&b, "%s: %s\n", message.Role, message.Content)
+		for _, call := range message.ToolCalls {
+			arguments, _ := json.Marshal(call.Arguments)
+			fmt.Fprintf(&b, "%s called %s(%s)\n", message.Role, call.Name, arguments)
+		}
+	}
+	return b.String()
+}
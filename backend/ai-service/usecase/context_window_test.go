@@ -0,0 +1,255 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/augment-local-manus-clone/backend/ai-service/domain"
+	"github.com/augment-local-manus-clone/backend/ai-service/usecase"
+)
+
+// wordTokenizer counts whitespace-separated words as tokens
+type wordTokenizer struct{}
+
+func (wordTokenizer) CountTokens(text string) int {
+	return len(strings.Fields(text))
+}
+
+// words returns n distinct words
+func words(prefix string, n int) string {
+	parts := make([]string, n)
+	for i := range parts {
+		parts[i] = prefix + strings.Repeat("x", i%3)
+	}
+	return strings.Join(parts, " ")
+}
+
+func TestContextWindowManagerContextLength(t *testing.T) {
+	manager := usecase.NewContextWindowManager(wordTokenizer{}, map[string]int{"llama3.2": 8192, "qwen3:14b": 32768}, 4096)
+
+	tests := []struct {
+		model string
+		want  int
+	}{
+		{model: "llama3.2", want: 8192},
+		{model: "llama3.2:3b", want: 8192},
+		{model: "qwen3:14b", want: 32768},
+		{model: "qwen3:8b", want: 4096},
+		{model: "", want: 4096},
+	}
+
+	for _, tt := range tests {
+		if got := manager.ContextLength(tt.model); got != tt.want {
+			t.Errorf("ContextLength(%q) = %d, want %d", tt.model, got, tt.want)
+		}
+	}
+}
+
+func TestProcessAIRequestContextWindow(t *testing.T) {
+	// 200-token context: 190 after the margin, 180 after reserving max_tokens
+	manager := usecase.NewContextWindowManager(wordTokenizer{}, nil, 200)
+	summary := scriptedReply{message: domain.ChatMessage{Role: domain.ChatRoleAssistant, Content: words("summary", 20)}}
+
+	tests := []struct {
+		name           string
+		prompt         string
+		strategy       domain.ContextStrategy
+		replies        []scriptedReply
+		wantErr        error
+		wantTokens     int
+		wantTruncated  bool
+		wantSummarized bool
+		wantRequests   int
+	}{
+		{
+			name:         "Fits",
+			prompt:       words("word", 100),
+			replies:      []scriptedReply{summary},
+			wantTokens:   100,
+			wantRequests: 1,
+		},
+		{
+			name:          "Truncates head and tail by default",
+			prompt:        "START " + words("word", 400) + " END",
+			replies:       []scriptedReply{summary},
+			wantTruncated: true,
+			wantRequests:  1,
+		},
+		{
+			name:     "Rejects",
+			prompt:   words("word", 400),
+			strategy: domain.ContextStrategyReject,
+			wantErr:  domain.ErrContextOverflow,
+		},
+		{
+			name:         "Off sends the prompt unchanged",
+			prompt:       words("word", 400),
+			strategy:     domain.ContextStrategyOff,
+			replies:      []scriptedReply{summary},
+			wantTokens:   400,
+			wantRequests: 1,
+		},
+		{
+			name:           "Summarizes with map-reduce",
+			prompt:         words("word", 250),
+			strategy:       domain.ContextStrategySummarize,
+			replies:        []scriptedReply{summary, summary, summary, summary},
+			wantSummarized: true,
+			wantRequests:   4,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &scriptedLLMClient{replies: tt.replies}
+			uc := usecase.NewProcessAIRequestUseCase(client, nil, manager, nil, nil, nil)
+
+			response, err := uc.Execute(context.Background(), &domain.AIRequest{Prompt: tt.prompt, MaxTokens: 10, ContextStrategy: tt.strategy})
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Execute() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Execute() error = %v", err)
+			}
+
+			if len(client.requests) != tt.wantRequests {
+				t.Fatalf("requests = %d, want %d", len(client.requests), tt.wantRequests)
+			}
+
+			report := response.ContextWindow
+			if report == nil || report.Budget != 180 || report.ContextLength != 200 {
+				t.Fatalf("ContextWindow = %+v, want budget 180 of 200", report)
+			}
+
+			sent := client.requests[len(client.requests)-1].Messages[0].Content
+			if tt.wantTokens > 0 && report.OutputTokens != tt.wantTokens {
+				t.Errorf("OutputTokens = %d, want %d", report.OutputTokens, tt.wantTokens)
+			}
+			if report.OutputTokens > report.Budget && tt.strategy != domain.ContextStrategyOff {
+				t.Errorf("OutputTokens = %d exceeds budget %d", report.OutputTokens, report.Budget)
+			}
+
+			if tt.wantTruncated {
+				if report.TruncatedTokens == 0 || len(report.Actions) == 0 {
+					t.Errorf("ContextWindow = %+v, want truncation reported", report)
+				}
+				if !strings.HasPrefix(sent, "START") || !strings.HasSuffix(sent, "END") || !strings.Contains(sent, "tokens truncated") {
+					t.Errorf("prompt = %q, want head and tail kept around a marker", sent)
+				}
+			}
+
+			if tt.wantSummarized {
+				if report.SummarizedTokens != 250 || report.SummaryTokensUsed == 0 {
+					t.Errorf("ContextWindow = %+v, want summarization reported", report)
+				}
+				if !strings.Contains(client.requests[0].Messages[0].Content, "part 1 of") {
+					t.Errorf("map request = %q, want a part of the input", client.requests[0].Messages[0].Content)
+				}
+				if !strings.HasPrefix(sent, "summary") {
+					t.Errorf("prompt = %q, want the summaries", sent)
+				}
+			}
+		})
+	}
+}
+
+func TestChatContextWindow(t *testing.T) {
+	manager := usecase.NewContextWindowManager(wordTokenizer{}, nil, 200)
+	reply := scriptedReply{message: domain.ChatMessage{Role: domain.ChatRoleAssistant, Content: "ok"}}
+	summary := scriptedReply{message: domain.ChatMessage{Role: domain.ChatRoleAssistant, Content: "they discussed the plan"}}
+
+	history := []domain.ChatMessage{
+		{Role: domain.ChatRoleSystem, Content: "Be helpful."},
+		{Role: domain.ChatRoleUser, Content: words("old", 20)},
+		{Role: domain.ChatRoleAssistant, ToolCalls: []domain.ToolCall{{Name: "browse", Arguments: map[string]interface{}{}}}},
+		{Role: domain.ChatRoleTool, ToolName: "browse", Content: words("page", 100)},
+		{Role: domain.ChatRoleAssistant, Content: words("reply", 60)},
+		{Role: domain.ChatRoleUser, Content: "What next?"},
+	}
+
+	tests := []struct {
+		name        string
+		strategy    domain.ContextStrategy
+		replies     []scriptedReply
+		wantRoles   []domain.ChatRole
+		wantDropped int
+		wantSummary bool
+	}{
+		{
+			name:        "Drops oldest turns with their tool results",
+			replies:     []scriptedReply{reply},
+			wantRoles:   []domain.ChatRole{domain.ChatRoleSystem, domain.ChatRoleAssistant, domain.ChatRoleUser},
+			wantDropped: 3,
+		},
+		{
+			name:        "Summarizes dropped turns",
+			strategy:    domain.ContextStrategySummarize,
+			replies:     []scriptedReply{summary, summary, summary, summary},
+			wantRoles:   []domain.ChatRole{domain.ChatRoleSystem, domain.ChatRoleSystem, domain.ChatRoleAssistant, domain.ChatRoleUser},
+			wantSummary: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &scriptedLLMClient{replies: tt.replies}
+			uc := usecase.NewChatUseCase(client, manager, nil, nil)
+
+			messages := append([]domain.ChatMessage{}, history...)
+			response, err := uc.Execute(context.Background(), &domain.ChatRequest{Messages: messages, MaxTokens: 10, ContextStrategy: tt.strategy})
+			if err != nil {
+				t.Fatalf("Execute() error = %v", err)
+			}
+
+			sent := client.requests[len(client.requests)-1].Messages
+			var roles []domain.ChatRole
+			for _, message := range sent {
+				roles = append(roles, message.Role)
+			}
+			if len(roles) != len(tt.wantRoles) {
+				t.Fatalf("roles = %v, want %v", roles, tt.wantRoles)
+			}
+			for i := range roles {
+				if roles[i] != tt.wantRoles[i] {
+					t.Fatalf("roles = %v, want %v", roles, tt.wantRoles)
+				}
+			}
+
+			report := response.ContextWindow
+			if report.OutputTokens > report.Budget {
+				t.Errorf("OutputTokens = %d exceeds budget %d", report.OutputTokens, report.Budget)
+			}
+			if report.DroppedMessages != tt.wantDropped {
+				t.Errorf("DroppedMessages = %d, want %d", report.DroppedMessages, tt.wantDropped)
+			}
+			if tt.wantSummary {
+				if report.SummarizedMessages != 3 || !strings.Contains(sent[1].Content, "they discussed the plan") {
+					t.Errorf("report = %+v, summary message = %q", report, sent[1].Content)
+				}
+			}
+		})
+	}
+}
+
+func TestContextWindowNumCtx(t *testing.T) {
+	manager := usecase.NewContextWindowManager(wordTokenizer{}, nil, 200)
+	numCtx := 1000
+	numPredict := 50
+
+	report, err := manager.FitPrompt(context.Background(), nil, &domain.AIRequest{
+		Prompt:  words("word", 400),
+		Options: &domain.GenerationOptions{NumCtx: &numCtx, NumPredict: &numPredict},
+	})
+	if err != nil {
+		t.Fatalf("FitPrompt() error = %v", err)
+	}
+
+	if report.ContextLength != 1000 || report.Budget != 900 || report.TruncatedTokens != 0 {
+		t.Errorf("FitPrompt() = %+v, want the num_ctx window with num_predict reserved", report)
+	}
+}
@@ -0,0 +1,29 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/augment-local-manus-clone/backend/ai-service/domain"
+)
+
+// EmbedUseCase handles embedding requests
+type EmbedUseCase struct {
+	llmClient domain.LLMClient
+}
+
+// NewEmbedUseCase creates a new instance of EmbedUseCase
+func NewEmbedUseCase(llmClient domain.LLMClient) *EmbedUseCase {
+	return &EmbedUseCase{
+		llmClient: llmClient,
+	}
+}
+
+// Execute returns an embedding for each input text
+func (uc *EmbedUseCase) Execute(ctx context.Context, request *domain.EmbedRequest) (*domain.EmbedResponse, error) {
+	// Validate the request
+	if err := request.Validate(); err != nil {
+		return nil, err
+	}
+
+	return uc.llmClient.Embed(ctx, request)
+}
@@ -0,0 +1,534 @@
+package usecase
+
+import (
+	"fmt"
+	"regexp"
+	"regexp/syntax"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/augment-local-manus-clone/backend/ai-service/domain"
+)
+
+// excerptContext is how many characters around a match are kept in a finding's excerpt
+const excerptContext = 40
+
+// redaction replaces output text matched by a redacting deny pattern
+const redaction = "[REDACTED]"
+
+// injectionDetector is a heuristic for instructions hidden in untrusted content
+type injectionDetector struct {
+	name    string
+	pattern *regexp.Regexp
+}
+
+// injectionDetectors are the heuristics run on untrusted content. They look
+// for the phrasing of known prompt-injection attacks, chat-template markup
+// and hidden text; a match is suspicious, not proof of an attack.
+var injectionDetectors = []injectionDetector{
+	{
+		name:    "ignore_instructions",
+		pattern: regexp.MustCompile(`(?i)\b(ignore|disregard|forget|override|bypass)\s+(all\s+|any\s+)?(of\s+)?(the\s+|your\s+|my\s+)?(previous|prior|above|earlier|preceding|original|system)\s+(instructions|directions|prompts?|rules|guidelines|context)`),
+	},
+	{
+		name:    "new_instructions",
+		pattern: regexp.MustCompile(`(?im)\b(new|updated|real|actual|important)\s+(system\s+)?instructions\s*:|^\s*(system|assistant)\s*:`),
+	},
+	{
+		name:    "role_override",
+		pattern: regexp.MustCompile(`(?i)\byou\s+are\s+(now|no\s+longer)\b|\bfrom\s+now\s+on,?\s+you\b|\b(developer|jailbreak|god)\s+mode\b|\bpretend\s+(to\s+be|you\s+are)\b`),
+	},
+	{
+		name:    "prompt_exfiltration",
+		pattern: regexp.MustCompile(`(?i)\b(reveal|print|show|repeat|output|leak)\s+(me\s+)?(your|the)\s+(system\s+prompt|hidden\s+instructions|initial\s+prompt|instructions)`),
+	},
+	{
+		name:    "chat_markup",
+		pattern: regexp.MustCompile(`<\|(im_start|im_end|system|user|assistant|start_header_id|end_header_id|eot_id)\|>|\[/?INST\]|<</?SYS>>`),
+	},
+	{
+		name:    "tag_escape",
+		pattern: regexp.MustCompile(`(?i)</?untrusted\b`),
+	},
+	{
+		name:    "exfiltration_link",
+		pattern: regexp.MustCompile(`!\[[^\]]*\]\(\s*https?://[^)\s]*\?[^)\s]*=[^)\s]*\)`),
+	},
+	{
+		name:    "hidden_text",
+		pattern: regexp.MustCompile(`[\x{200B}-\x{200F}\x{202A}-\x{202E}\x{2060}-\x{2064}\x{FEFF}]+`),
+	},
+}
+
+// DenyPattern is an output pattern the guardrails act on, such as a secret or
+// a link to an unexpected host
+type DenyPattern struct {
+	Name    string `json:"name"`
+	Pattern string `json:"pattern"`
+
+	// Action is flag, redact or block; defaults to redact
+	Action domain.GuardrailAction `json:"action,omitempty"`
+}
+
+// GuardrailConfig configures the guardrails
+type GuardrailConfig struct {
+	// InjectionAction is flag or block; defaults to flag, so suspicious
+	// untrusted content is tagged and reported but still sent
+	InjectionAction domain.GuardrailAction `json:"injection_action,omitempty"`
+
+	// DisabledDetectors names injection detectors that are not run
+	DisabledDetectors []string `json:"disabled_detectors,omitempty"`
+
+	// DenyPatterns are checked against every response
+	DenyPatterns []DenyPattern `json:"deny_patterns,omitempty"`
+}
+
+// denyRule is a compiled deny pattern
+type denyRule struct {
+	name    string
+	pattern *regexp.Regexp
+	action  domain.GuardrailAction
+
+	// maxLength is the longest match in bytes, or -1 when there is no limit
+	maxLength int
+}
+
+// Guardrails checks a request's untrusted content for prompt injection and
+// its response for denied output. Findings are reported on the response;
+// only those whose action is block fail the request.
+type Guardrails struct {
+	injectionAction domain.GuardrailAction
+	detectors       []injectionDetector
+	deny            []denyRule
+}
+
+// NewGuardrails creates guardrails from a configuration; the zero
+// configuration runs every injection detector and has no deny patterns
+func NewGuardrails(config GuardrailConfig) (*Guardrails, error) {
+	guardrails := &Guardrails{
+		injectionAction: config.InjectionAction,
+	}
+
+	if guardrails.injectionAction == "" {
+		guardrails.injectionAction = domain.GuardrailFlag
+	}
+	if guardrails.injectionAction != domain.GuardrailFlag && guardrails.injectionAction != domain.GuardrailBlock {
+		return nil, fmt.Errorf("injection_action must be %q or %q", domain.GuardrailFlag, domain.GuardrailBlock)
+	}
+
+	disabled := make(map[string]bool, len(config.DisabledDetectors))
+	for _, name := range config.DisabledDetectors {
+		if !knownDetector(name) {
+			return nil, fmt.Errorf("unknown detector %q", name)
+		}
+		disabled[name] = true
+	}
+	for _, detector := range injectionDetectors {
+		if !disabled[detector.name] {
+			guardrails.detectors = append(guardrails.detectors, detector)
+		}
+	}
+
+	for i, deny := range config.DenyPatterns {
+		if deny.Name == "" {
+			return nil, fmt.Errorf("deny pattern %d: name cannot be empty", i)
+		}
+
+		pattern, err := regexp.Compile(deny.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("deny pattern %q: %w", deny.Name, err)
+		}
+
+		action := deny.Action
+		if action == "" {
+			action = domain.GuardrailRedact
+		}
+		if err := action.Validate(); err != nil {
+			return nil, fmt.Errorf("deny pattern %q: %w", deny.Name, err)
+		}
+
+		parsed, err := syntax.Parse(deny.Pattern, syntax.Perl)
+		if err != nil {
+			return nil, fmt.Errorf("deny pattern %q: %w", deny.Name, err)
+		}
+
+		guardrails.deny = append(guardrails.deny, denyRule{
+			name:      deny.Name,
+			pattern:   pattern,
+			action:    action,
+			maxLength: maxMatchLength(parsed),
+		})
+	}
+
+	return guardrails, nil
+}
+
+// CheckPrompt runs the injection detectors on the request's untrusted content
+func (g *Guardrails) CheckPrompt(request *domain.AIRequest) ([]domain.GuardrailFinding, error) {
+	var findings []domain.GuardrailFinding
+	for i, untrusted := range request.Untrusted {
+		source := untrusted.Source
+		if source == "" {
+			source = "untrusted " + strconv.Itoa(i)
+		}
+		findings = append(findings, g.detect(source, untrusted.Content)...)
+	}
+
+	return findings, g.blocked(findings)
+}
+
+// CheckChat runs the injection detectors on the conversation's untrusted messages
+func (g *Guardrails) CheckChat(request *domain.ChatRequest) ([]domain.GuardrailFinding, error) {
+	var findings []domain.GuardrailFinding
+	for i := range request.Messages {
+		message := &request.Messages[i]
+		if message.Trusted() {
+			continue
+		}
+
+		source := "message " + strconv.Itoa(i)
+		if message.ToolName != "" {
+			source += " (" + message.ToolName + ")"
+		}
+		findings = append(findings, g.detect(source, message.Content)...)
+	}
+
+	return findings, g.blocked(findings)
+}
+
+// CheckOutput applies the deny patterns to the texts of a response, such as
+// its answer and reasoning, and to every string in its decoded JSON values,
+// such as structured output and tool call arguments. Matches are redacted in
+// place where configured. It returns a report of the earlier input findings
+// and the output findings, or nil when there are none.
+func (g *Guardrails) CheckOutput(findings []domain.GuardrailFinding, texts []*string, values ...*interface{}) (*domain.GuardrailReport, error) {
+	for _, text := range texts {
+		findings = g.checkText(findings, text)
+	}
+	for _, value := range values {
+		*value = g.checkValue(&findings, *value)
+	}
+
+	if len(findings) == 0 {
+		return nil, nil
+	}
+
+	return &domain.GuardrailReport{Findings: findings}, g.blocked(findings)
+}
+
+// checkText applies the deny patterns to a text and adds what they found
+func (g *Guardrails) checkText(findings []domain.GuardrailFinding, text *string) []domain.GuardrailFinding {
+	for _, rule := range g.deny {
+		for _, match := range rule.pattern.FindAllStringIndex(*text, -1) {
+			findings = append(findings, domain.GuardrailFinding{
+				Stage:    domain.GuardrailStageOutput,
+				Detector: rule.name,
+				Excerpt:  excerpt(*text, match, rule.action != domain.GuardrailFlag),
+				Action:   rule.action,
+			})
+		}
+
+		if rule.action == domain.GuardrailRedact {
+			*text = rule.pattern.ReplaceAllLiteralString(*text, redaction)
+		}
+	}
+	return findings
+}
+
+// checkValue applies the deny patterns to every string in a decoded JSON
+// value, in key order. Maps and slices are copied rather than changed, as
+// the value may be shared, such as by a cached response.
+func (g *Guardrails) checkValue(findings *[]domain.GuardrailFinding, value interface{}) interface{} {
+	switch v := value.(type) {
+	case string:
+		*findings = g.checkText(*findings, &v)
+		return v
+	case map[string]interface{}:
+		if v == nil {
+			return v
+		}
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		checked := make(map[string]interface{}, len(v))
+		for _, key := range keys {
+			checked[key] = g.checkValue(findings, v[key])
+		}
+		return checked
+	case []interface{}:
+		if v == nil {
+			return v
+		}
+		checked := make([]interface{}, len(v))
+		for i, item := range v {
+			checked[i] = g.checkValue(findings, item)
+		}
+		return checked
+	}
+	return value
+}
+
+// GuardStream wraps a stream handler so that the redact and block deny
+// patterns apply to chunks before they are passed on. Call flush on the
+// returned stream once the response is complete. It returns nil when no deny
+// pattern redacts or blocks, as chunks can then be passed on as they are.
+func (g *Guardrails) GuardStream(findings []domain.GuardrailFinding, handler domain.StreamHandler) *OutputStream {
+	stream := &OutputStream{findings: findings, handler: handler}
+	for _, rule := range g.deny {
+		if rule.action == domain.GuardrailFlag {
+			continue
+		}
+
+		stream.rules = append(stream.rules, rule)
+		if stream.hold >= 0 && (rule.maxLength < 0 || rule.maxLength > stream.hold) {
+			stream.hold = rule.maxLength
+		}
+	}
+
+	if len(stream.rules) == 0 {
+		return nil
+	}
+	return stream
+}
+
+// OutputStream applies the redact and block deny patterns to a streamed
+// response. The answer and the reasoning are each held back by the longest
+// match a pattern can have, so that a match split across chunks is redacted
+// whole; a pattern without a longest match holds them back until the end.
+type OutputStream struct {
+	rules    []denyRule
+	hold     int
+	findings []domain.GuardrailFinding
+	handler  domain.StreamHandler
+
+	text      string
+	reasoning string
+}
+
+// Send takes a chunk of the response and passes on what can no longer be
+// part of a match. It fails with a GuardrailError when a block pattern matches.
+func (s *OutputStream) Send(chunk *domain.AIStreamChunk) error {
+	s.text += chunk.Text
+	s.reasoning += chunk.Reasoning
+	return s.forward(false)
+}
+
+// Flush passes on the rest of the response once it is complete
+func (s *OutputStream) Flush() error {
+	return s.forward(true)
+}
+
+// forward passes on the released parts of the answer and the reasoning as one chunk
+func (s *OutputStream) forward(final bool) error {
+	text, err := s.release(&s.text, final)
+	if err != nil {
+		return err
+	}
+	reasoning, err := s.release(&s.reasoning, final)
+	if err != nil {
+		return err
+	}
+
+	if text == "" && reasoning == "" {
+		return nil
+	}
+	return s.handler(&domain.AIStreamChunk{Text: text, Reasoning: reasoning})
+}
+
+// release redacts and returns the start of the pending text that later
+// chunks can no longer change the matches of, keeping the rest pending
+func (s *OutputStream) release(pending *string, final bool) (string, error) {
+	text := *pending
+	for _, rule := range s.rules {
+		if rule.action != domain.GuardrailBlock {
+			continue
+		}
+		if match := rule.pattern.FindStringIndex(text); match != nil {
+			return "", &domain.GuardrailError{Findings: append(s.findings, domain.GuardrailFinding{
+				Stage:    domain.GuardrailStageOutput,
+				Detector: rule.name,
+				Excerpt:  excerpt(text, match, true),
+				Action:   rule.action,
+			})}
+		}
+	}
+
+	cut := len(text)
+	if !final {
+		if s.hold < 0 {
+			return "", nil
+		}
+		cut -= s.hold
+		if cut <= 0 {
+			return "", nil
+		}
+	}
+
+	// A match that starts before the cut ends within the text, as no match
+	// is longer than the hold; it is released whole
+	var matches [][]int
+	for _, rule := range s.rules {
+		if rule.action != domain.GuardrailRedact {
+			continue
+		}
+		for _, match := range rule.pattern.FindAllStringIndex(text, -1) {
+			if match[0] < cut {
+				matches = append(matches, match)
+				if match[1] > cut {
+					cut = match[1]
+				}
+			}
+		}
+	}
+	for cut < len(text) && !utf8.RuneStart(text[cut]) {
+		cut--
+	}
+	sort.Slice(matches, func(i, j int) bool { return matches[i][0] < matches[j][0] })
+
+	var b strings.Builder
+	end := 0
+	for _, match := range matches {
+		if match[0] >= end {
+			b.WriteString(text[end:match[0]])
+			b.WriteString(redaction)
+		}
+		if match[1] > end {
+			end = match[1]
+		}
+	}
+	b.WriteString(text[end:cut])
+
+	*pending = text[cut:]
+	return b.String(), nil
+}
+
+// maxMatchLength returns the longest match of a parsed pattern in bytes, or
+// -1 when it has none, such as for a pattern with a + or a *
+func maxMatchLength(re *syntax.Regexp) int {
+	switch re.Op {
+	case syntax.OpLiteral:
+		if re.Flags&syntax.FoldCase != 0 {
+			return len(re.Rune) * utf8.UTFMax
+		}
+		return len(string(re.Rune))
+	case syntax.OpCharClass:
+		if len(re.Rune) == 0 {
+			return 0
+		}
+		return utf8.RuneLen(re.Rune[len(re.Rune)-1])
+	case syntax.OpAnyChar, syntax.OpAnyCharNotNL:
+		return utf8.UTFMax
+	case syntax.OpCapture, syntax.OpQuest:
+		return maxMatchLength(re.Sub[0])
+	case syntax.OpStar, syntax.OpPlus, syntax.OpRepeat:
+		length := maxMatchLength(re.Sub[0])
+		if length <= 0 {
+			return length
+		}
+		if re.Op != syntax.OpRepeat || re.Max < 0 {
+			return -1
+		}
+		return re.Max * length
+	case syntax.OpConcat, syntax.OpAlternate:
+		total := 0
+		for _, sub := range re.Sub {
+			length := maxMatchLength(sub)
+			if length < 0 {
+				return -1
+			}
+			if re.Op == syntax.OpConcat {
+				total += length
+			} else if length > total {
+				total = length
+			}
+		}
+		return total
+	}
+
+	// Empty matches and assertions such as ^ and \b
+	return 0
+}
+
+// detect runs every enabled injection detector on a piece of untrusted content
+func (g *Guardrails) detect(source, content string) []domain.GuardrailFinding {
+	var findings []domain.GuardrailFinding
+	for _, detector := range g.detectors {
+		match := detector.pattern.FindStringIndex(content)
+		if match == nil {
+			continue
+		}
+
+		findings = append(findings, domain.GuardrailFinding{
+			Stage:    domain.GuardrailStageInput,
+			Detector: detector.name,
+			Source:   source,
+			Excerpt:  excerpt(content, match, false),
+			Action:   g.injectionAction,
+		})
+	}
+	return findings
+}
+
+// blocked returns a GuardrailError if any finding's action is block
+func (g *Guardrails) blocked(findings []domain.GuardrailFinding) error {
+	for _, finding := range findings {
+		if finding.Action == domain.GuardrailBlock {
+			return &domain.GuardrailError{Findings: findings}
+		}
+	}
+	return nil
+}
+
+// knownDetector reports whether an injection detector has the given name
+func knownDetector(name string) bool {
+	for _, detector := range injectionDetectors {
+		if detector.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// excerpt returns the matched text with some context on either side, on one
+// line and with invisible characters escaped so they show up in the report.
+// A masked excerpt replaces the match itself, so that a redacted or blocked
+// secret does not leak through the report.
+func excerpt(text string, match []int, mask bool) string {
+	start := match[0] - excerptContext
+	if start < 0 {
+		start = 0
+	}
+	end := match[1] + excerptContext
+	if end > len(text) {
+		end = len(text)
+	}
+
+	// Move inwards to rune boundaries
+	for start < match[0] && !utf8.RuneStart(text[start]) {
+		start++
+	}
+	for end > match[1] && end < len(text) && !utf8.RuneStart(text[end]) {
+		end--
+	}
+
+	matched := text[match[0]:match[1]]
+	if mask {
+		matched = redaction
+	}
+
+	var b strings.Builder
+	for _, r := range strings.Join(strings.Fields(text[start:match[0]]+matched+text[match[1]:end]), " ") {
+		if unicode.IsGraphic(r) {
+			b.WriteRune(r)
+		} else {
+			fmt.Fprintf(&b, "\\u%04X", r)
+		}
+	}
+	return b.String()
+}
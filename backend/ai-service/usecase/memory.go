@@ -0,0 +1,569 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/augment-local-manus-clone/backend/ai-service/domain"
+)
+
+const (
+	// defaultMemoryTurnBudget is how many tokens of turns are kept verbatim by default
+	defaultMemoryTurnBudget = 2000
+
+	// defaultMemoryMaxFacts is how many long-term facts are kept by default
+	defaultMemoryMaxFacts = 50
+
+	// minRecentTurns is how many of the latest turns are never summarized
+	minRecentTurns = 2
+
+	// memoryCompactionTimeout bounds a compaction run in the background
+	memoryCompactionTimeout = 2 * time.Minute
+)
+
+// memorySchema is the reply requested when older turns are compacted
+var memorySchema = domain.JSONSchema{
+	"type":     "object",
+	"required": []interface{}{"summary", "facts"},
+	"properties": map[string]interface{}{
+		"summary": map[string]interface{}{"type": "string", "minLength": 1},
+		"facts": map[string]interface{}{
+			"type":  "array",
+			"items": map[string]interface{}{"type": "string", "minLength": 1},
+		},
+	},
+}
+
+// MemoryConfig configures conversation memory
+type MemoryConfig struct {
+	// TurnBudget is how many tokens of turns are kept verbatim; older turns
+	// are summarized once it is exceeded. Defaults to 2000.
+	TurnBudget int
+
+	// MaxFacts is how many long-term facts are kept, dropping the oldest
+	// first. Defaults to 50.
+	MaxFacts int
+}
+
+// MemoryStats counts the work and failures of conversation memory
+type MemoryStats struct {
+	Compactions        int64 `json:"compactions"`
+	CompactionFailures int64 `json:"compaction_failures"`
+	RecordFailures     int64 `json:"record_failures"`
+	SummaryTokensUsed  int64 `json:"summary_tokens_used"`
+}
+
+// AppendTurnsInput represents turns added to a conversation's memory. Provider
+// and Model select the model that summarizes older turns; empty values use
+// the configured defaults.
+type AppendTurnsInput struct {
+	ConversationID string              `json:"-"`
+	Provider       string              `json:"provider,omitempty"`
+	Model          string              `json:"model,omitempty"`
+	Turns          []domain.MemoryTurn `json:"turns"`
+}
+
+// MemoryList lists the remembered conversations
+type MemoryList struct {
+	Conversations []*domain.Memory `json:"conversations"`
+	Stats         MemoryStats      `json:"stats"`
+}
+
+// MemoryUseCase remembers conversations and tasks across requests. Recent
+// turns are kept verbatim; once they exceed the turn budget the oldest are
+// condensed by the model into a running summary, and durable facts are
+// extracted from them into long-term memory.
+type MemoryUseCase struct {
+	llmClient  domain.LLMClient
+	repository domain.MemoryRepository
+	tokenizer  domain.Tokenizer
+	turnBudget int
+	maxFacts   int
+
+	mu         sync.Mutex
+	locks      map[string]*conversationLock
+	compacting map[string]bool
+	stats      MemoryStats
+	wg         sync.WaitGroup
+}
+
+// conversationLock serializes changes to a conversation's memory; refs
+// counts who holds or waits for it, so that it is dropped once unused
+type conversationLock struct {
+	sync.Mutex
+	refs int
+}
+
+// NewMemoryUseCase creates a new instance of MemoryUseCase
+func NewMemoryUseCase(llmClient domain.LLMClient, repository domain.MemoryRepository, tokenizer domain.Tokenizer, config MemoryConfig) *MemoryUseCase {
+	if config.TurnBudget <= 0 {
+		config.TurnBudget = defaultMemoryTurnBudget
+	}
+	if config.MaxFacts <= 0 {
+		config.MaxFacts = defaultMemoryMaxFacts
+	}
+
+	return &MemoryUseCase{
+		llmClient:  llmClient,
+		repository: repository,
+		tokenizer:  tokenizer,
+		turnBudget: config.TurnBudget,
+		maxFacts:   config.MaxFacts,
+		locks:      make(map[string]*conversationLock),
+		compacting: make(map[string]bool),
+	}
+}
+
+// Get returns the memory of a conversation
+func (uc *MemoryUseCase) Get(conversationID string) (*domain.Memory, error) {
+	if err := domain.ValidateConversationID(conversationID); err != nil {
+		return nil, err
+	}
+	return uc.repository.Get(conversationID)
+}
+
+// List returns every remembered conversation without its turns and facts
+func (uc *MemoryUseCase) List() (*MemoryList, error) {
+	conversations, err := uc.repository.List()
+	if err != nil {
+		return nil, err
+	}
+	return &MemoryList{Conversations: conversations, Stats: uc.Stats()}, nil
+}
+
+// Stats returns a snapshot of the memory counters
+func (uc *MemoryUseCase) Stats() MemoryStats {
+	uc.mu.Lock()
+	defer uc.mu.Unlock()
+	return uc.stats
+}
+
+// Delete forgets a conversation
+func (uc *MemoryUseCase) Delete(conversationID string) error {
+	if err := domain.ValidateConversationID(conversationID); err != nil {
+		return err
+	}
+
+	unlock := uc.lock(conversationID)
+	defer unlock()
+
+	return uc.repository.Delete(conversationID)
+}
+
+// AppendTurns adds turns to a conversation's memory, summarizing older turns
+// if the turn budget is exceeded
+func (uc *MemoryUseCase) AppendTurns(ctx context.Context, input AppendTurnsInput) (*domain.Memory, error) {
+	if err := domain.ValidateConversationID(input.ConversationID); err != nil {
+		return nil, err
+	}
+	if len(input.Turns) == 0 {
+		return nil, fmt.Errorf("%w: turns cannot be empty", domain.ErrInvalidMemory)
+	}
+	for i, turn := range input.Turns {
+		if turn.Role != domain.ChatRoleUser && turn.Role != domain.ChatRoleAssistant {
+			return nil, fmt.Errorf("%w: turn %d: role must be %q or %q", domain.ErrInvalidMemory, i, domain.ChatRoleUser, domain.ChatRoleAssistant)
+		}
+		if strings.TrimSpace(turn.Content) == "" {
+			return nil, fmt.Errorf("%w: turn %d: content cannot be empty", domain.ErrInvalidMemory, i)
+		}
+	}
+
+	memory, err := uc.append(input)
+	if err != nil {
+		return nil, err
+	}
+
+	// A failed compaction keeps the turns, so it is retried on the next append
+	compacted, err := uc.compact(ctx, input.ConversationID, input.Provider, input.Model)
+	if err != nil {
+		uc.countCompactionFailure()
+	}
+	if compacted != nil {
+		memory = compacted
+	}
+	return memory, nil
+}
+
+// Wait blocks until the compactions running in the background have finished
+func (uc *MemoryUseCase) Wait() {
+	uc.wg.Wait()
+}
+
+// AddFact adds a long-term fact to a conversation's memory
+func (uc *MemoryUseCase) AddFact(conversationID, content string) (*domain.MemoryFact, error) {
+	if err := domain.ValidateConversationID(conversationID); err != nil {
+		return nil, err
+	}
+	content = strings.TrimSpace(content)
+	if content == "" {
+		return nil, fmt.Errorf("%w: content cannot be empty", domain.ErrInvalidMemory)
+	}
+
+	unlock := uc.lock(conversationID)
+	defer unlock()
+
+	memory, err := uc.load(conversationID)
+	if err != nil {
+		return nil, err
+	}
+
+	uc.addFacts(memory, []string{content})
+	if err := uc.repository.Save(memory); err != nil {
+		return nil, err
+	}
+
+	for i := range memory.Facts {
+		if strings.EqualFold(memory.Facts[i].Content, content) {
+			return &memory.Facts[i], nil
+		}
+	}
+	return nil, fmt.Errorf("%w: fact was not kept", domain.ErrInvalidMemory)
+}
+
+// DeleteFact removes a long-term fact from a conversation's memory
+func (uc *MemoryUseCase) DeleteFact(conversationID string, factID int64) error {
+	if err := domain.ValidateConversationID(conversationID); err != nil {
+		return err
+	}
+
+	unlock := uc.lock(conversationID)
+	defer unlock()
+
+	memory, err := uc.repository.Get(conversationID)
+	if err != nil {
+		return err
+	}
+
+	for i, fact := range memory.Facts {
+		if fact.ID == factID {
+			memory.Facts = append(memory.Facts[:i], memory.Facts[i+1:]...)
+			return uc.repository.Save(memory)
+		}
+	}
+	return fmt.Errorf("%w: fact %d of %s", domain.ErrMemoryNotFound, factID, conversationID)
+}
+
+// injectPrompt adds a conversation's memory to a request's prompt
+func (uc *MemoryUseCase) injectPrompt(request *domain.AIRequest) (*domain.MemoryReport, error) {
+	memory, err := uc.load(request.ConversationID)
+	if err != nil {
+		return nil, err
+	}
+
+	request.InjectMemory(memory)
+	return memoryReport(memory), nil
+}
+
+// injectChat adds a conversation's memory to a chat request's messages
+func (uc *MemoryUseCase) injectChat(request *domain.ChatRequest) (*domain.MemoryReport, error) {
+	memory, err := uc.load(request.ConversationID)
+	if err != nil {
+		return nil, err
+	}
+
+	request.InjectMemory(memory)
+	return memoryReport(memory), nil
+}
+
+// record remembers an exchange once its response has been returned by the
+// model. The response is not failed if its exchange cannot be remembered;
+// failures are counted in the stats instead. Compaction calls the model, so
+// it runs in the background rather than adding to the response's latency.
+func (uc *MemoryUseCase) record(ctx context.Context, conversationID, provider, model, prompt, reply string) {
+	now := time.Now()
+	var turns []domain.MemoryTurn
+	if prompt != "" {
+		turns = append(turns, domain.MemoryTurn{Role: domain.ChatRoleUser, Content: prompt, CreatedAt: now})
+	}
+	if reply != "" {
+		turns = append(turns, domain.MemoryTurn{Role: domain.ChatRoleAssistant, Content: reply, CreatedAt: now})
+	}
+	if len(turns) == 0 {
+		return
+	}
+
+	memory, err := uc.append(AppendTurnsInput{ConversationID: conversationID, Provider: provider, Model: model, Turns: turns})
+	if err != nil {
+		uc.mu.Lock()
+		uc.stats.RecordFailures++
+		uc.mu.Unlock()
+		return
+	}
+
+	if uc.compactable(memory) > 0 {
+		uc.compactInBackground(ctx, conversationID, provider, model)
+	}
+}
+
+// append adds validated turns to a conversation's memory
+func (uc *MemoryUseCase) append(input AppendTurnsInput) (*domain.Memory, error) {
+	unlock := uc.lock(input.ConversationID)
+	defer unlock()
+
+	memory, err := uc.load(input.ConversationID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, turn := range input.Turns {
+		if turn.CreatedAt.IsZero() {
+			turn.CreatedAt = time.Now()
+		}
+		memory.Turns = append(memory.Turns, turn)
+	}
+
+	if err := uc.repository.Save(memory); err != nil {
+		return nil, err
+	}
+	return memory, nil
+}
+
+// compactInBackground compacts a conversation's memory once the request that
+// recorded its turns has returned. It keeps the request context's values but
+// is not cancelled with it. A conversation is compacted by one run at a time;
+// turns recorded meanwhile are compacted on a later turn.
+func (uc *MemoryUseCase) compactInBackground(ctx context.Context, conversationID, provider, model string) {
+	uc.mu.Lock()
+	if uc.compacting[conversationID] {
+		uc.mu.Unlock()
+		return
+	}
+	uc.compacting[conversationID] = true
+	uc.wg.Add(1)
+	uc.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), memoryCompactionTimeout)
+	go func() {
+		defer uc.wg.Done()
+		defer cancel()
+
+		if _, err := uc.compact(ctx, conversationID, provider, model); err != nil {
+			uc.countCompactionFailure()
+		}
+
+		uc.mu.Lock()
+		delete(uc.compacting, conversationID)
+		uc.mu.Unlock()
+	}()
+}
+
+// compact summarizes the oldest turns into the memory's summary once the
+// turns exceed the budget, leaving about half the budget of recent turns,
+// and adds the durable facts the model found in them. The conversation is
+// not locked while the model runs, so the summary is only stored if the
+// summarized turns are still the oldest. It returns the compacted memory,
+// or nil if there was nothing to compact.
+func (uc *MemoryUseCase) compact(ctx context.Context, conversationID, provider, model string) (*domain.Memory, error) {
+	memory, err := uc.repository.Get(conversationID)
+	if errors.Is(err, domain.ErrMemoryNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	old := uc.compactable(memory)
+	if old == 0 {
+		return nil, nil
+	}
+	summarized, before := memory.Turns[:old], memory.SummarizedTurns
+
+	temperature := summaryTemperature
+	response, err := chatWithSchema(ctx, uc.llmClient, &domain.ChatRequest{
+		Provider: provider,
+		Model:    model,
+		Messages: []domain.ChatMessage{
+			{Role: domain.ChatRoleSystem, Content: memoryCompactionInstructions},
+			{Role: domain.ChatRoleUser, Content: memoryCompactionPrompt(memory, summarized)},
+		},
+		Temperature:    &temperature,
+		ResponseSchema: memorySchema,
+	})
+	if response != nil {
+		uc.mu.Lock()
+		uc.stats.SummaryTokensUsed += int64(response.TokensUsed)
+		uc.mu.Unlock()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to summarize memory: %w", err)
+	}
+
+	value, ok := response.JSON.(map[string]interface{})
+	if !ok {
+		return nil, errors.New("failed to summarize memory: reply is not an object")
+	}
+
+	summary, _ := value["summary"].(string)
+	var facts []string
+	if items, ok := value["facts"].([]interface{}); ok {
+		for _, item := range items {
+			if fact, ok := item.(string); ok {
+				facts = append(facts, fact)
+			}
+		}
+	}
+
+	unlock := uc.lock(conversationID)
+	defer unlock()
+
+	// The conversation was deleted or compacted by another run meanwhile
+	memory, err = uc.repository.Get(conversationID)
+	if errors.Is(err, domain.ErrMemoryNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if memory.SummarizedTurns != before || !startsWith(memory.Turns, summarized) {
+		return nil, nil
+	}
+
+	memory.Summary = strings.TrimSpace(summary)
+	memory.SummarizedTurns += old
+	memory.Turns = append([]domain.MemoryTurn(nil), memory.Turns[old:]...)
+	uc.addFacts(memory, facts)
+
+	if err := uc.repository.Save(memory); err != nil {
+		return nil, err
+	}
+
+	uc.mu.Lock()
+	uc.stats.Compactions++
+	uc.mu.Unlock()
+	return memory, nil
+}
+
+// compactable returns how many of the oldest turns to summarize: none while
+// the turns fit the budget, else enough to leave about half of it
+func (uc *MemoryUseCase) compactable(memory *domain.Memory) int {
+	tokens := 0
+	for _, turn := range memory.Turns {
+		tokens += uc.tokenizer.CountTokens(turn.Content) + messageOverheadTokens
+	}
+	if tokens <= uc.turnBudget {
+		return 0
+	}
+
+	old := 0
+	for old < len(memory.Turns)-minRecentTurns && tokens > uc.turnBudget/2 {
+		tokens -= uc.tokenizer.CountTokens(memory.Turns[old].Content) + messageOverheadTokens
+		old++
+	}
+	return old
+}
+
+// countCompactionFailure counts a compaction that failed; its turns are kept
+func (uc *MemoryUseCase) countCompactionFailure() {
+	uc.mu.Lock()
+	uc.stats.CompactionFailures++
+	uc.mu.Unlock()
+}
+
+// addFacts adds the facts the memory does not know yet, dropping the oldest
+// facts beyond the limit
+func (uc *MemoryUseCase) addFacts(memory *domain.Memory, facts []string) {
+	known := make(map[string]bool, len(memory.Facts))
+	for _, fact := range memory.Facts {
+		known[strings.ToLower(fact.Content)] = true
+	}
+
+	for _, content := range facts {
+		content = strings.TrimSpace(content)
+		if content == "" || known[strings.ToLower(content)] {
+			continue
+		}
+		known[strings.ToLower(content)] = true
+		memory.Facts = append(memory.Facts, domain.MemoryFact{Content: content, CreatedAt: time.Now()})
+	}
+
+	if len(memory.Facts) > uc.maxFacts {
+		memory.Facts = append([]domain.MemoryFact(nil), memory.Facts[len(memory.Facts)-uc.maxFacts:]...)
+	}
+}
+
+// load returns the memory of a conversation, or an empty memory if there is none yet
+func (uc *MemoryUseCase) load(conversationID string) (*domain.Memory, error) {
+	memory, err := uc.repository.Get(conversationID)
+	if errors.Is(err, domain.ErrMemoryNotFound) {
+		return &domain.Memory{ConversationID: conversationID, Turns: []domain.MemoryTurn{}, Facts: []domain.MemoryFact{}}, nil
+	}
+	return memory, err
+}
+
+// lock serializes changes to a conversation's memory and returns the unlock
+// function. The lock is forgotten once it is unlocked and nobody waits for it.
+func (uc *MemoryUseCase) lock(conversationID string) func() {
+	uc.mu.Lock()
+	lock, ok := uc.locks[conversationID]
+	if !ok {
+		lock = &conversationLock{}
+		uc.locks[conversationID] = lock
+	}
+	lock.refs++
+	uc.mu.Unlock()
+
+	lock.Lock()
+	return func() {
+		lock.Unlock()
+
+		uc.mu.Lock()
+		defer uc.mu.Unlock()
+		if lock.refs--; lock.refs == 0 {
+			delete(uc.locks, conversationID)
+		}
+	}
+}
+
+// startsWith reports whether turns begin with the given turns
+func startsWith(turns, prefix []domain.MemoryTurn) bool {
+	if len(turns) < len(prefix) {
+		return false
+	}
+	for i := range prefix {
+		if turns[i].Role != prefix[i].Role || turns[i].Content != prefix[i].Content || !turns[i].CreatedAt.Equal(prefix[i].CreatedAt) {
+			return false
+		}
+	}
+	return true
+}
+
+// memoryReport describes what a memory contributed to a request
+func memoryReport(memory *domain.Memory) *domain.MemoryReport {
+	return &domain.MemoryReport{
+		ConversationID: memory.ConversationID,
+		InjectedTurns:  len(memory.Turns),
+		InjectedFacts:  len(memory.Facts),
+		Summary:        memory.Summary != "",
+	}
+}
+
+// memoryCompactionInstructions tells the model how to compact a conversation's memory
+const memoryCompactionInstructions = "You maintain the long-term memory of a conversation between a user and an assistant. " +
+	"Reply with a JSON object with two fields: \"summary\", an updated summary of the conversation so far, and " +
+	"\"facts\", a list of durable facts worth remembering that are not already known, such as the user's name, " +
+	"preferences, goals, decisions and constraints. Each fact is one short self-contained sentence. " +
+	"Leave out small talk and anything that only mattered for a single turn."
+
+// memoryCompactionPrompt presents the current memory and the turns to fold into it
+func memoryCompactionPrompt(memory *domain.Memory, turns []domain.MemoryTurn) string {
+	var b strings.Builder
+	if memory.Summary != "" {
+		b.WriteString("Current summary:\n")
+		b.WriteString(memory.Summary)
+		b.WriteString("\n\n")
+	}
+	if len(memory.Facts) > 0 {
+		b.WriteString("Known facts:\n")
+		for _, fact := range memory.Facts {
+			b.WriteString("- " + fact.Content + "\n")
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString("Turns to add to the summary:\n")
+	b.WriteString(domain.Transcript(turns))
+	return b.String()
+}
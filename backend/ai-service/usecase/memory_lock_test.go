@@ -0,0 +1,47 @@
+package usecase
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+)
+
+func TestMemoryUseCaseLocks(t *testing.T) {
+	uc := NewMemoryUseCase(nil, nil, nil, MemoryConfig{})
+
+	// Changes to one conversation are serialized
+	var wg sync.WaitGroup
+	holders, maxHolders := 0, 0
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			unlock := uc.lock("task-1")
+			defer unlock()
+
+			holders++
+			if holders > maxHolders {
+				maxHolders = holders
+			}
+			holders--
+		}()
+	}
+
+	// Every conversation gets a lock of its own
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			unlock := uc.lock("task-" + strconv.Itoa(i+2))
+			unlock()
+		}(i)
+	}
+	wg.Wait()
+
+	if maxHolders != 1 {
+		t.Errorf("holders = %d, want 1", maxHolders)
+	}
+	if len(uc.locks) != 0 {
+		t.Errorf("locks = %d, want every lock dropped once unlocked", len(uc.locks))
+	}
+}
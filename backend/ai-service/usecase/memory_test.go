@@ -0,0 +1,246 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/augment-local-manus-clone/backend/ai-service/domain"
+	"github.com/augment-local-manus-clone/backend/ai-service/infrastructure/llm"
+	"github.com/augment-local-manus-clone/backend/ai-service/infrastructure/memorystore"
+	"github.com/augment-local-manus-clone/backend/ai-service/infrastructure/tokenizer"
+	"github.com/augment-local-manus-clone/backend/ai-service/usecase"
+)
+
+// newMemoryUseCase creates a memory with a small turn budget, backed by a
+// fake model that compacts memory with a fixed summary and fact
+func newMemoryUseCase(t *testing.T, compaction llm.FakeReply) (*usecase.MemoryUseCase, *llm.FakeLLMClient) {
+	t.Helper()
+
+	fake, err := llm.NewFakeLLMClient(llm.FakeScript{
+		Rules:   []llm.FakeRule{{Pattern: "Turns to add to the summary", FakeReply: compaction}},
+		Default: &llm.FakeReply{Text: "Noted, I will keep that in mind for the rest of the task."},
+	})
+	if err != nil {
+		t.Fatalf("NewFakeLLMClient() error = %v", err)
+	}
+
+	repo, err := memorystore.NewSQLiteMemoryRepository(filepath.Join(t.TempDir(), "memory.db"))
+	if err != nil {
+		t.Fatalf("NewSQLiteMemoryRepository() error = %v", err)
+	}
+	t.Cleanup(func() { repo.Close() })
+
+	memory := usecase.NewMemoryUseCase(fake, repo, tokenizer.NewHeuristicTokenizer(), usecase.MemoryConfig{TurnBudget: 60})
+	return memory, fake
+}
+
+func TestProcessAIRequestUseCaseMemory(t *testing.T) {
+	memory, fake := newMemoryUseCase(t, llm.FakeReply{Text: `{"summary": "Ada is planning a trip to Lisbon.", "facts": ["The user's name is Ada.", "The user is vegetarian."]}`})
+	uc := usecase.NewProcessAIRequestUseCase(fake, nil, nil, nil, nil, memory)
+	ctx := context.Background()
+
+	prompts := []string{
+		"Hi, my name is Ada and I am planning a trip to Lisbon next spring.",
+		"I am vegetarian, so please keep that in mind when suggesting restaurants.",
+		"Which neighbourhoods should I stay in?",
+	}
+	for _, prompt := range prompts {
+		response, err := uc.Execute(ctx, &domain.AIRequest{Prompt: prompt, ConversationID: "trip-1"})
+		if err != nil {
+			t.Fatalf("Execute() error = %v", err)
+		}
+		if response.Memory == nil || response.Memory.ConversationID != "trip-1" {
+			t.Errorf("Memory = %+v, want a report for trip-1", response.Memory)
+		}
+	}
+
+	// Older turns are compacted in the background, after the responses
+	memory.Wait()
+	remembered, err := memory.Get("trip-1")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if remembered.Summary != "Ada is planning a trip to Lisbon." || remembered.SummarizedTurns == 0 {
+		t.Errorf("memory = %+v, want older turns summarized", remembered)
+	}
+	if len(remembered.Facts) != 2 || remembered.Facts[0].ID == 0 {
+		t.Errorf("Facts = %+v, want two stored facts", remembered.Facts)
+	}
+	if got := remembered.SummarizedTurns + len(remembered.Turns); got != 2*len(prompts) {
+		t.Errorf("turns = %d, want %d", got, 2*len(prompts))
+	}
+
+	// The next request sees the facts, the summary and the recent turns
+	if _, err := uc.Execute(ctx, &domain.AIRequest{Prompt: "Where should I eat tonight?", ConversationID: "trip-1"}); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	prompt := ""
+	for _, call := range fake.Calls() {
+		if call.Method == "Process" {
+			prompt = call.Prompt
+		}
+	}
+	for _, want := range []string{"- The user is vegetarian.", "Ada is planning a trip to Lisbon.", "Recent conversation:", "Current request:\nWhere should I eat tonight?"} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("prompt = %q, want it to contain %q", prompt, want)
+		}
+	}
+
+	// Other conversations are unaffected
+	response, err := uc.Execute(ctx, &domain.AIRequest{Prompt: "Hello", ConversationID: "trip-2"})
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if response.Memory.InjectedTurns != 0 || response.Memory.InjectedFacts != 0 {
+		t.Errorf("Memory = %+v, want nothing injected", response.Memory)
+	}
+}
+
+func TestMemoryUseCaseCompactionFailure(t *testing.T) {
+	memory, _ := newMemoryUseCase(t, llm.FakeReply{Text: "not json"})
+	ctx := context.Background()
+
+	turns := []domain.MemoryTurn{
+		{Role: domain.ChatRoleUser, Content: "Please remember that the deploy target is the staging cluster in eu-west."},
+		{Role: domain.ChatRoleAssistant, Content: "Understood, the deploy target is the staging cluster in eu-west."},
+		{Role: domain.ChatRoleUser, Content: "And the release branch is called release/2024-q3 for this task."},
+		{Role: domain.ChatRoleAssistant, Content: "Got it, release/2024-q3 is the branch to release from."},
+	}
+	remembered, err := memory.AppendTurns(ctx, usecase.AppendTurnsInput{ConversationID: "deploy", Turns: turns})
+	if err != nil {
+		t.Fatalf("AppendTurns() error = %v", err)
+	}
+
+	// The turns are kept so that compaction is retried later
+	if len(remembered.Turns) != len(turns) || remembered.Summary != "" {
+		t.Errorf("memory = %+v, want every turn kept", remembered)
+	}
+	if stats := memory.Stats(); stats.CompactionFailures != 1 || stats.Compactions != 0 {
+		t.Errorf("Stats() = %+v, want one compaction failure", stats)
+	}
+}
+
+func TestProcessAIRequestUseCaseMemoryCompactsInBackground(t *testing.T) {
+	const latency = 300 * time.Millisecond
+	memory, fake := newMemoryUseCase(t, llm.FakeReply{
+		Text:      `{"summary": "Ada is planning a trip to Lisbon.", "facts": []}`,
+		LatencyMS: int(latency / time.Millisecond),
+	})
+	uc := usecase.NewProcessAIRequestUseCase(fake, nil, nil, nil, nil, memory)
+
+	prompts := []string{
+		"Hi, my name is Ada and I am planning a trip to Lisbon next spring.",
+		"I am vegetarian, so please keep that in mind when suggesting restaurants.",
+		"Which neighbourhoods should I stay in?",
+	}
+	for _, prompt := range prompts {
+		// The client goes away as soon as its response has been returned
+		ctx, cancel := context.WithCancel(context.Background())
+		started := time.Now()
+		_, err := uc.Execute(ctx, &domain.AIRequest{Prompt: prompt, ConversationID: "trip-1"})
+		cancel()
+		if err != nil {
+			t.Fatalf("Execute() error = %v", err)
+		}
+		if elapsed := time.Since(started); elapsed >= latency {
+			t.Errorf("Execute() took %s, want it not to wait for compaction", elapsed)
+		}
+	}
+
+	memory.Wait()
+	remembered, err := memory.Get("trip-1")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if remembered.Summary != "Ada is planning a trip to Lisbon." {
+		t.Errorf("memory = %+v, want older turns summarized", remembered)
+	}
+	if got := remembered.SummarizedTurns + len(remembered.Turns); got != 2*len(prompts) {
+		t.Errorf("turns = %d, want %d", got, 2*len(prompts))
+	}
+	if stats := memory.Stats(); stats.Compactions == 0 || stats.CompactionFailures != 0 {
+		t.Errorf("Stats() = %+v, want compactions without failures", stats)
+	}
+}
+
+func TestMemoryUseCaseFacts(t *testing.T) {
+	memory, _ := newMemoryUseCase(t, llm.FakeReply{Text: "{}"})
+
+	tests := []struct {
+		name      string
+		action    func() error
+		wantFacts []string
+		wantErr   error
+	}{
+		{
+			name: "Add a fact to a new conversation",
+			action: func() error {
+				_, err := memory.AddFact("task-9", "The repository uses Go 1.24.")
+				return err
+			},
+			wantFacts: []string{"The repository uses Go 1.24."},
+		},
+		{
+			name: "Duplicate facts are kept once",
+			action: func() error {
+				_, err := memory.AddFact("task-9", "the repository uses go 1.24.")
+				return err
+			},
+			wantFacts: []string{"The repository uses Go 1.24."},
+		},
+		{
+			name: "Empty facts are rejected",
+			action: func() error {
+				_, err := memory.AddFact("task-9", "  ")
+				return err
+			},
+			wantFacts: []string{"The repository uses Go 1.24."},
+			wantErr:   domain.ErrInvalidMemory,
+		},
+		{
+			name:      "Unknown facts cannot be deleted",
+			action:    func() error { return memory.DeleteFact("task-9", 42) },
+			wantFacts: []string{"The repository uses Go 1.24."},
+			wantErr:   domain.ErrMemoryNotFound,
+		},
+		{
+			name: "Delete a fact",
+			action: func() error {
+				remembered, err := memory.Get("task-9")
+				if err != nil {
+					return err
+				}
+				return memory.DeleteFact("task-9", remembered.Facts[0].ID)
+			},
+			wantFacts: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.action(); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+
+			remembered, err := memory.Get("task-9")
+			if err != nil {
+				t.Fatalf("Get() error = %v", err)
+			}
+			facts := []string{}
+			for _, fact := range remembered.Facts {
+				facts = append(facts, fact.Content)
+			}
+			if strings.Join(facts, "|") != strings.Join(tt.wantFacts, "|") {
+				t.Errorf("facts = %q, want %q", facts, tt.wantFacts)
+			}
+		})
+	}
+
+	if _, err := memory.Get("bad id!"); !errors.Is(err, domain.ErrInvalidMemory) {
+		t.Errorf("Get() error = %v, want ErrInvalidMemory", err)
+	}
+}
@@ -0,0 +1,120 @@
+package usecase
+
+import (
+	"context"
+	"sync"
+
+	"github.com/augment-local-manus-clone/backend/ai-service/domain"
+)
+
+// maxConcurrentShows bounds how many models are described at once when listing
+const maxConcurrentShows = 4
+
+// ModelManagementUseCase handles listing, describing, pulling and deleting local models
+type ModelManagementUseCase struct {
+	manager domain.ModelManager
+	router  *ModelRouter
+}
+
+// NewModelManagementUseCase creates a new instance of ModelManagementUseCase.
+// router is optional; when set, what it remembers about a model's
+// capabilities is forgotten once the model is pulled or deleted.
+func NewModelManagementUseCase(manager domain.ModelManager, router *ModelRouter) *ModelManagementUseCase {
+	return &ModelManagementUseCase{
+		manager: manager,
+		router:  router,
+	}
+}
+
+// ListModels returns the installed models with their context length and
+// capabilities. A model that cannot be described is listed without them.
+func (uc *ModelManagementUseCase) ListModels(ctx context.Context) ([]domain.ModelInfo, error) {
+	models, err := uc.manager.ListModels(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, maxConcurrentShows)
+
+	for i := range models {
+		wg.Add(1)
+		go func(model *domain.ModelInfo) {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+
+			details, err := uc.manager.ShowModel(ctx, model.Name)
+			if err != nil {
+				return
+			}
+			model.ContextLength = details.ContextLength
+			model.Capabilities = details.Capabilities
+		}(&models[i])
+	}
+	wg.Wait()
+
+	return models, nil
+}
+
+// ShowModel returns the details of an installed model
+func (uc *ModelManagementUseCase) ShowModel(ctx context.Context, name string) (*domain.ModelDetails, error) {
+	if err := domain.ValidateModelName(name); err != nil {
+		return nil, err
+	}
+
+	details, err := uc.manager.ShowModel(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	// The show API does not report the size and digest, which the list does
+	models, err := uc.manager.ListModels(ctx)
+	if err == nil {
+		for _, model := range models {
+			if model.Name == details.Name {
+				details.Size = model.Size
+				details.Digest = model.Digest
+				details.ModifiedAt = model.ModifiedAt
+				break
+			}
+		}
+	}
+
+	return details, nil
+}
+
+// PullModel downloads a model, passing progress updates to the handler
+func (uc *ModelManagementUseCase) PullModel(ctx context.Context, name string, handler domain.PullProgressHandler) error {
+	if err := domain.ValidateModelName(name); err != nil {
+		return err
+	}
+
+	if err := uc.manager.PullModel(ctx, name, handler); err != nil {
+		return err
+	}
+
+	uc.forget(name)
+	return nil
+}
+
+// DeleteModel removes an installed model
+func (uc *ModelManagementUseCase) DeleteModel(ctx context.Context, name string) error {
+	if err := domain.ValidateModelName(name); err != nil {
+		return err
+	}
+
+	if err := uc.manager.DeleteModel(ctx, name); err != nil {
+		return err
+	}
+
+	uc.forget(name)
+	return nil
+}
+
+// forget clears what the router remembers about a model that changed
+func (uc *ModelManagementUseCase) forget(name string) {
+	if uc.router != nil {
+		uc.router.forgetModel(name)
+	}
+}
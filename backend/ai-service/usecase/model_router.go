@@ -0,0 +1,275 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/augment-local-manus-clone/backend/ai-service/domain"
+)
+
+// RouterConfig configures model routing. Each route is an ordered fallback
+// chain of models; requests name a route, or use DefaultRoute.
+type RouterConfig struct {
+	Routes       map[string][]domain.ModelTarget `json:"routes"`
+	DefaultRoute string                          `json:"default_route"`
+
+	// AttemptTimeoutSeconds bounds how long a model may take to respond, or
+	// for streams to produce its first chunk, before falling back; zero disables it
+	AttemptTimeoutSeconds int `json:"attempt_timeout_seconds"`
+
+	// FailureThreshold consecutive failures open a model's circuit for CooldownSeconds
+	FailureThreshold int `json:"failure_threshold"`
+	CooldownSeconds  int `json:"cooldown_seconds"`
+
+	// Now tells the time for circuit cooldowns; nil means time.Now
+	Now func() time.Time `json:"-"`
+}
+
+// ModelRouter picks models for requests from routing policies, falling back
+// down each route's chain on error or timeout and skipping unhealthy models
+type ModelRouter struct {
+	routes         map[string][]domain.ModelTarget
+	defaultRoute   string
+	attemptTimeout time.Duration
+	breaker        *circuitBreaker
+
+	// textOnly holds the keys of models that rejected images, so that later
+	// requests with images skip them without taking a probe slot. A model
+	// is tried again once it is pulled or deleted.
+	mu       sync.Mutex
+	textOnly map[string]bool
+}
+
+// routeAttempt sends a request to the model it names. progress is called
+// once output has been passed on to the caller, after which the attempt can
+// no longer time out or fall back.
+type routeAttempt func(ctx context.Context, request *domain.AIRequest, progress func()) (*domain.AIResponse, error)
+
+// NewModelRouter creates a ModelRouter from the configuration
+func NewModelRouter(config RouterConfig) (*ModelRouter, error) {
+	if len(config.Routes) == 0 {
+		return nil, fmt.Errorf("at least one route is required")
+	}
+
+	for name, chain := range config.Routes {
+		if len(chain) == 0 {
+			return nil, fmt.Errorf("route %q has no models", name)
+		}
+		for _, target := range chain {
+			if target.Model == "" {
+				return nil, fmt.Errorf("route %q: model cannot be empty", name)
+			}
+		}
+	}
+
+	if _, ok := config.Routes[config.DefaultRoute]; !ok {
+		return nil, fmt.Errorf("default route %q is not defined", config.DefaultRoute)
+	}
+
+	if config.FailureThreshold <= 0 {
+		config.FailureThreshold = 3
+	}
+
+	if config.CooldownSeconds <= 0 {
+		config.CooldownSeconds = 30
+	}
+
+	breaker := newCircuitBreaker(config.FailureThreshold, time.Duration(config.CooldownSeconds)*time.Second)
+	if config.Now != nil {
+		breaker.now = config.Now
+	}
+
+	return &ModelRouter{
+		routes:         config.Routes,
+		defaultRoute:   config.DefaultRoute,
+		attemptTimeout: time.Duration(config.AttemptTimeoutSeconds) * time.Second,
+		breaker:        breaker,
+		textOnly:       make(map[string]bool),
+	}, nil
+}
+
+// RoutingStatus describes the configured routes and the health of their models
+type RoutingStatus struct {
+	DefaultRoute string                          `json:"default_route"`
+	Routes       map[string][]domain.ModelTarget `json:"routes"`
+	Health       []domain.ModelHealth            `json:"health"`
+}
+
+// Status returns the configured routes and the circuit breaker state of every model that has been tried
+func (r *ModelRouter) Status() *RoutingStatus {
+	return &RoutingStatus{
+		DefaultRoute: r.defaultRoute,
+		Routes:       r.routes,
+		Health:       r.breaker.health(),
+	}
+}
+
+// execute tries the request against each eligible model of its route in
+// order until one succeeds. A request naming a model bypasses routing.
+func (r *ModelRouter) execute(ctx context.Context, request *domain.AIRequest, attempt routeAttempt) (*domain.AIResponse, error) {
+	if request.Model != "" {
+		return attempt(ctx, request, func() {})
+	}
+
+	route := request.Route
+	if route == "" {
+		route = r.defaultRoute
+	}
+
+	chain, ok := r.routes[route]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", domain.ErrUnknownRoute, route)
+	}
+
+	var lastErr error
+	for _, target := range chain {
+		// Skip models whose known context window is too small
+		if request.MinContextLength > 0 && target.ContextLength > 0 && target.ContextLength < request.MinContextLength {
+			continue
+		}
+
+		// Models known not to read images are skipped before the breaker is asked
+		if len(request.Images) > 0 && r.isTextOnly(target) {
+			lastErr = fmt.Errorf("%s: %w", target.Key(), domain.ErrImagesNotSupported)
+			continue
+		}
+
+		if !r.breaker.allow(target) {
+			continue
+		}
+
+		routed := *request
+		routed.Provider = target.Provider
+		routed.Model = target.Model
+
+		started := false
+		response, err := r.try(ctx, &routed, attempt, func() { started = true })
+		if err == nil {
+			r.breaker.success(target)
+			return response, nil
+		}
+
+		// The caller went away, the service is overloaded, or a guardrail
+		// stopped the stream; none says anything about the model's health
+		if ctx.Err() != nil || errors.Is(err, domain.ErrQueueFull) || errors.Is(err, domain.ErrGuardrailBlocked) {
+			r.breaker.release(target)
+			return nil, err
+		}
+
+		// A text-only model cannot serve a request with images, which says
+		// nothing about its health either; a later model may read them
+		lastErr = fmt.Errorf("%s: %w", target.Key(), err)
+		if errors.Is(err, domain.ErrImagesNotSupported) {
+			r.breaker.release(target)
+			r.markTextOnly(target)
+			continue
+		}
+		r.breaker.failure(target, err)
+
+		// Output already reached the caller, so another model cannot take over
+		if started {
+			return nil, lastErr
+		}
+	}
+
+	if lastErr == nil {
+		return nil, fmt.Errorf("%w for route %q", domain.ErrNoModelAvailable, route)
+	}
+	return nil, fmt.Errorf("all models for route %q failed: %w", route, lastErr)
+}
+
+// isTextOnly reports whether the target has rejected images before
+func (r *ModelRouter) isTextOnly(target domain.ModelTarget) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.textOnly[target.Key()]
+}
+
+// markTextOnly remembers that the target does not read images
+func (r *ModelRouter) markTextOnly(target domain.ModelTarget) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.textOnly[target.Key()] = true
+}
+
+// forgetModel clears the text-only mark of every target serving a model that
+// was pulled or deleted. Ollama names without a tag mean the latest tag.
+func (r *ModelRouter) forgetModel(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	name = strings.TrimSuffix(name, ":latest")
+	for _, chain := range r.routes {
+		for _, target := range chain {
+			if strings.TrimSuffix(target.Model, ":latest") == name {
+				delete(r.textOnly, target.Key())
+			}
+		}
+	}
+}
+
+// try runs a single attempt, cancelling it if the model does not respond, or
+// start streaming, within the attempt timeout. Time the attempt spends in the
+// admission queue does not count.
+func (r *ModelRouter) try(ctx context.Context, request *domain.AIRequest, attempt routeAttempt, progress func()) (*domain.AIResponse, error) {
+	if r.attemptTimeout <= 0 {
+		return attempt(ctx, request, progress)
+	}
+
+	attemptCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	clock := &attemptClock{timer: time.AfterFunc(r.attemptTimeout, cancel), timeout: r.attemptTimeout}
+	defer clock.stop()
+
+	response, err := attempt(context.WithValue(attemptCtx, attemptClockKey{}, clock), request, func() {
+		clock.stop()
+		progress()
+	})
+
+	// Only the timer cancels attemptCtx while the caller's context is live
+	if err != nil && ctx.Err() == nil && attemptCtx.Err() != nil {
+		return nil, fmt.Errorf("no response within %s: %w", r.attemptTimeout, err)
+	}
+	return response, err
+}
+
+// attemptClockKey is the context key under which a routed attempt's clock is stored
+type attemptClockKey struct{}
+
+// attemptClock times out a routed attempt. The admission controller pauses
+// it while the attempt waits for a slot, and restarts it once the attempt runs.
+type attemptClock struct {
+	mu      sync.Mutex
+	timer   *time.Timer
+	timeout time.Duration
+	stopped bool
+}
+
+// pause stops the clock while the attempt is queued
+func (c *attemptClock) pause() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.timer.Stop()
+}
+
+// resume gives the attempt its full timeout again once it runs
+func (c *attemptClock) resume() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.stopped {
+		c.timer.Reset(c.timeout)
+	}
+}
+
+// stop stops the clock for good, once output has reached the caller
+func (c *attemptClock) stop() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.stopped = true
+	c.timer.Stop()
+}
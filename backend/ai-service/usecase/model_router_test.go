@@ -0,0 +1,467 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/augment-local-manus-clone/backend/ai-service/domain"
+	"github.com/augment-local-manus-clone/backend/ai-service/usecase"
+)
+
+// modelLLMClient answers as the requested model, failing or stalling for
+// configured models; a stall ends early when the context is cancelled.
+// Text-only models reject requests with images.
+type modelLLMClient struct {
+	mu        sync.Mutex
+	failing   map[string]bool
+	slow      map[string]time.Duration
+	textOnly  map[string]bool
+	queueFull map[string]bool
+	requests  []string
+}
+
+func (c *modelLLMClient) Process(ctx context.Context, request *domain.AIRequest) (*domain.AIResponse, error) {
+	c.mu.Lock()
+	c.requests = append(c.requests, request.Model)
+	c.mu.Unlock()
+
+	if delay, ok := c.slow[request.Model]; ok {
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	if c.failing[request.Model] {
+		return nil, errors.New("unexpected status code: 404")
+	}
+	if c.queueFull[request.Model] {
+		return nil, &domain.QueueFullError{RetryAfter: time.Second}
+	}
+	if c.textOnly[request.Model] && len(request.Images) > 0 {
+		return nil, fmt.Errorf("%w: %s", domain.ErrImagesNotSupported, request.Model)
+	}
+	return &domain.AIResponse{Text: "ok", Model: request.Model}, nil
+}
+
+func (c *modelLLMClient) ProcessStream(ctx context.Context, request *domain.AIRequest, handler domain.StreamHandler) (*domain.AIResponse, error) {
+	return c.Process(ctx, request)
+}
+
+func (c *modelLLMClient) Chat(ctx context.Context, request *domain.ChatRequest) (*domain.ChatResponse, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (c *modelLLMClient) Embed(ctx context.Context, request *domain.EmbedRequest) (*domain.EmbedResponse, error) {
+	return nil, errors.New("not implemented")
+}
+
+// testClock is a clock that only moves when the test advances it
+type testClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *testClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *testClock) advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func TestProcessAIRequestRouting(t *testing.T) {
+	config := usecase.RouterConfig{
+		DefaultRoute: "smart",
+		Routes: map[string][]domain.ModelTarget{
+			"smart": {
+				{Provider: "ollama", Model: "deepseek-r1", ContextLength: 8192},
+				{Provider: "ollama", Model: "qwen3", ContextLength: 32768},
+			},
+			"fast": {{Provider: "ollama", Model: "llama3.2"}},
+		},
+		AttemptTimeoutSeconds: 1,
+	}
+
+	tests := []struct {
+		name         string
+		request      domain.AIRequest
+		failing      map[string]bool
+		slow         map[string]time.Duration
+		textOnly     map[string]bool
+		wantModel    string
+		wantRequests []string
+		wantErr      error
+	}{
+		{
+			name:         "Default route uses first model",
+			request:      domain.AIRequest{Prompt: "hi"},
+			wantModel:    "deepseek-r1",
+			wantRequests: []string{"deepseek-r1"},
+		},
+		{
+			name:         "Named route",
+			request:      domain.AIRequest{Prompt: "hi", Route: "fast"},
+			wantModel:    "llama3.2",
+			wantRequests: []string{"llama3.2"},
+		},
+		{
+			name:         "Falls back on error",
+			request:      domain.AIRequest{Prompt: "hi"},
+			failing:      map[string]bool{"deepseek-r1": true},
+			wantModel:    "qwen3",
+			wantRequests: []string{"deepseek-r1", "qwen3"},
+		},
+		{
+			name:         "Falls back on timeout",
+			request:      domain.AIRequest{Prompt: "hi"},
+			slow:         map[string]time.Duration{"deepseek-r1": 1500 * time.Millisecond},
+			wantModel:    "qwen3",
+			wantRequests: []string{"deepseek-r1", "qwen3"},
+		},
+		{
+			name:         "Skips models with too small a context window",
+			request:      domain.AIRequest{Prompt: "hi", MinContextLength: 16000},
+			wantModel:    "qwen3",
+			wantRequests: []string{"qwen3"},
+		},
+		{
+			name:         "Skips text-only models for images",
+			request:      domain.AIRequest{Prompt: "What is on this page?", Images: []string{"iVBORw0KGgo="}},
+			textOnly:     map[string]bool{"deepseek-r1": true},
+			wantModel:    "qwen3",
+			wantRequests: []string{"deepseek-r1", "qwen3"},
+		},
+		{
+			name:     "No model reads images",
+			request:  domain.AIRequest{Prompt: "What is on this page?", Images: []string{"iVBORw0KGgo="}},
+			textOnly: map[string]bool{"deepseek-r1": true, "qwen3": true},
+			wantErr:  domain.ErrImagesNotSupported,
+		},
+		{
+			name:         "Explicit model bypasses routing",
+			request:      domain.AIRequest{Prompt: "hi", Model: "mistral"},
+			wantModel:    "mistral",
+			wantRequests: []string{"mistral"},
+		},
+		{
+			name:    "Unknown route",
+			request: domain.AIRequest{Prompt: "hi", Route: "missing"},
+			wantErr: domain.ErrUnknownRoute,
+		},
+		{
+			name:    "No model large enough",
+			request: domain.AIRequest{Prompt: "hi", MinContextLength: 100000},
+			wantErr: domain.ErrNoModelAvailable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router, err := usecase.NewModelRouter(config)
+			if err != nil {
+				t.Fatalf("NewModelRouter() error = %v", err)
+			}
+			client := &modelLLMClient{failing: tt.failing, slow: tt.slow, textOnly: tt.textOnly}
+			uc := usecase.NewProcessAIRequestUseCase(client, router, nil, nil, nil, nil)
+
+			response, err := uc.Execute(context.Background(), &tt.request)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Execute() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Execute() error = %v", err)
+			}
+
+			if response.Model != tt.wantModel {
+				t.Errorf("Execute() model = %q, want %q", response.Model, tt.wantModel)
+			}
+
+			client.mu.Lock()
+			defer client.mu.Unlock()
+			if !reflect.DeepEqual(client.requests, tt.wantRequests) {
+				t.Errorf("requests = %v, want %v", client.requests, tt.wantRequests)
+			}
+		})
+	}
+}
+
+func TestProcessAIRequestCircuitBreaker(t *testing.T) {
+	router, err := usecase.NewModelRouter(usecase.RouterConfig{
+		DefaultRoute:     "smart",
+		Routes:           map[string][]domain.ModelTarget{"smart": {{Provider: "ollama", Model: "a"}, {Provider: "ollama", Model: "b"}}},
+		FailureThreshold: 2,
+		CooldownSeconds:  60,
+	})
+	if err != nil {
+		t.Fatalf("NewModelRouter() error = %v", err)
+	}
+	client := &modelLLMClient{failing: map[string]bool{"a": true}}
+	uc := usecase.NewProcessAIRequestUseCase(client, router, nil, nil, nil, nil)
+
+	for i := 0; i < 3; i++ {
+		if _, err := uc.Execute(context.Background(), &domain.AIRequest{Prompt: "hi"}); err != nil {
+			t.Fatalf("Execute() error = %v", err)
+		}
+	}
+
+	// Model a is tried until its circuit opens, then skipped
+	want := []string{"a", "b", "a", "b", "b"}
+	if !reflect.DeepEqual(client.requests, want) {
+		t.Errorf("requests = %v, want %v", client.requests, want)
+	}
+
+	health := uc.RoutingStatus().Health
+	if len(health) != 2 || health[0].State != domain.CircuitOpen || health[0].OpenUntil == nil || health[1].State != domain.CircuitClosed {
+		t.Errorf("health = %+v", health)
+	}
+}
+
+func TestProcessAIRequestTimeout(t *testing.T) {
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		request domain.AIRequest
+		wantErr error
+	}{
+		{
+			name:    "Responds within the timeout",
+			ctx:     context.Background(),
+			request: domain.AIRequest{Prompt: "hi", Model: "fast", TimeoutSeconds: 1},
+		},
+		{
+			name:    "Timeout exceeded",
+			ctx:     context.Background(),
+			request: domain.AIRequest{Prompt: "hi", Model: "slow", TimeoutSeconds: 1},
+			wantErr: context.DeadlineExceeded,
+		},
+		{
+			name:    "Caller cancelled",
+			ctx:     cancelled,
+			request: domain.AIRequest{Prompt: "hi", Model: "slow"},
+			wantErr: context.Canceled,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &modelLLMClient{slow: map[string]time.Duration{"slow": 5 * time.Second}}
+			uc := usecase.NewProcessAIRequestUseCase(client, nil, nil, nil, nil, nil)
+
+			started := time.Now()
+			_, err := uc.Execute(tt.ctx, &tt.request)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Execute() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if elapsed := time.Since(started); elapsed > 2*time.Second {
+				t.Errorf("Execute() took %s, want the request abandoned", elapsed)
+			}
+		})
+	}
+}
+
+func TestProcessAIRequestCancelledProbe(t *testing.T) {
+	clock := &testClock{now: time.Unix(0, 0)}
+	router, err := usecase.NewModelRouter(usecase.RouterConfig{
+		DefaultRoute:     "smart",
+		Routes:           map[string][]domain.ModelTarget{"smart": {{Provider: "ollama", Model: "a"}, {Provider: "ollama", Model: "b"}}},
+		FailureThreshold: 1,
+		CooldownSeconds:  30,
+		Now:              clock.Now,
+	})
+	if err != nil {
+		t.Fatalf("NewModelRouter() error = %v", err)
+	}
+	client := &modelLLMClient{failing: map[string]bool{"a": true}}
+	uc := usecase.NewProcessAIRequestUseCase(client, router, nil, nil, nil, nil)
+
+	// Model a fails and its circuit opens
+	if _, err := uc.Execute(context.Background(), &domain.AIRequest{Prompt: "hi"}); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	clock.advance(30 * time.Second)
+
+	// The caller goes away while model a is probed
+	client.failing = nil
+	client.slow = map[string]time.Duration{"a": 5 * time.Second}
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if _, err := uc.Execute(ctx, &domain.AIRequest{Prompt: "hi"}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Execute() error = %v, want DeadlineExceeded", err)
+	}
+
+	// Model a is probed again rather than left out of the route
+	client.slow = nil
+	response, err := uc.Execute(context.Background(), &domain.AIRequest{Prompt: "hi"})
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if response.Model != "a" {
+		t.Errorf("Execute() model = %q, want the probe to reach a", response.Model)
+	}
+}
+
+func TestProcessAIRequestProbeQueueFull(t *testing.T) {
+	clock := &testClock{now: time.Unix(0, 0)}
+	router, err := usecase.NewModelRouter(usecase.RouterConfig{
+		DefaultRoute:     "smart",
+		Routes:           map[string][]domain.ModelTarget{"smart": {{Provider: "ollama", Model: "a"}, {Provider: "ollama", Model: "b"}}},
+		FailureThreshold: 1,
+		CooldownSeconds:  30,
+		Now:              clock.Now,
+	})
+	if err != nil {
+		t.Fatalf("NewModelRouter() error = %v", err)
+	}
+	client := &modelLLMClient{failing: map[string]bool{"a": true}}
+	uc := usecase.NewProcessAIRequestUseCase(client, router, nil, nil, nil, nil)
+
+	// Model a fails and its circuit opens
+	if _, err := uc.Execute(context.Background(), &domain.AIRequest{Prompt: "hi"}); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	clock.advance(30 * time.Second)
+
+	// The probe of model a is turned away by a full admission queue
+	client.failing = nil
+	client.queueFull = map[string]bool{"a": true}
+	if _, err := uc.Execute(context.Background(), &domain.AIRequest{Prompt: "hi"}); !errors.Is(err, domain.ErrQueueFull) {
+		t.Fatalf("Execute() error = %v, want ErrQueueFull", err)
+	}
+
+	// Model a is probed again once the queue drains
+	client.queueFull = nil
+	response, err := uc.Execute(context.Background(), &domain.AIRequest{Prompt: "hi"})
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if response.Model != "a" {
+		t.Errorf("Execute() model = %q, want the probe to reach a", response.Model)
+	}
+}
+
+func TestProcessAIRequestHalfOpenTextOnlyModel(t *testing.T) {
+	clock := &testClock{now: time.Unix(0, 0)}
+	router, err := usecase.NewModelRouter(usecase.RouterConfig{
+		DefaultRoute:     "smart",
+		Routes:           map[string][]domain.ModelTarget{"smart": {{Provider: "ollama", Model: "a"}, {Provider: "ollama", Model: "b"}}},
+		FailureThreshold: 1,
+		CooldownSeconds:  30,
+		Now:              clock.Now,
+	})
+	if err != nil {
+		t.Fatalf("NewModelRouter() error = %v", err)
+	}
+	client := &modelLLMClient{failing: map[string]bool{"a": true}, textOnly: map[string]bool{"a": true}}
+	uc := usecase.NewProcessAIRequestUseCase(client, router, nil, nil, nil, nil)
+	image := domain.AIRequest{Prompt: "What is on this page?", Images: []string{"iVBORw0KGgo="}}
+
+	// Model a fails and its circuit opens
+	if _, err := uc.Execute(context.Background(), &domain.AIRequest{Prompt: "hi"}); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	clock.advance(30 * time.Second)
+
+	// The half-open probe of text-only model a is spent on a request with
+	// images, which falls back to model b
+	client.failing = nil
+	for i := 0; i < 2; i++ {
+		request := image
+		response, err := uc.Execute(context.Background(), &request)
+		if err != nil {
+			t.Fatalf("Execute() error = %v", err)
+		}
+		if response.Model != "b" {
+			t.Errorf("Execute() model = %q, want b", response.Model)
+		}
+	}
+
+	// Model a is still probed for text, and is not tried again for images
+	response, err := uc.Execute(context.Background(), &domain.AIRequest{Prompt: "hi"})
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if response.Model != "a" {
+		t.Errorf("Execute() model = %q, want the probe to reach a", response.Model)
+	}
+
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	want := []string{"a", "b", "a", "b", "b", "a"}
+	if !reflect.DeepEqual(client.requests, want) {
+		t.Errorf("requests = %v, want %v", client.requests, want)
+	}
+}
+
+// pulledModelManager installs every model it is asked to pull
+type pulledModelManager struct{}
+
+func (pulledModelManager) ListModels(ctx context.Context) ([]domain.ModelInfo, error) {
+	return nil, nil
+}
+
+func (pulledModelManager) ShowModel(ctx context.Context, name string) (*domain.ModelDetails, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (pulledModelManager) PullModel(ctx context.Context, name string, handler domain.PullProgressHandler) error {
+	return handler(&domain.PullProgress{Status: "success"})
+}
+
+func (pulledModelManager) DeleteModel(ctx context.Context, name string) error {
+	return nil
+}
+
+func TestProcessAIRequestTextOnlyModelPulled(t *testing.T) {
+	router, err := usecase.NewModelRouter(usecase.RouterConfig{
+		DefaultRoute: "smart",
+		Routes:       map[string][]domain.ModelTarget{"smart": {{Provider: "ollama", Model: "a"}, {Provider: "ollama", Model: "b"}}},
+	})
+	if err != nil {
+		t.Fatalf("NewModelRouter() error = %v", err)
+	}
+	client := &modelLLMClient{textOnly: map[string]bool{"a": true}}
+	uc := usecase.NewProcessAIRequestUseCase(client, router, nil, nil, nil, nil)
+	models := usecase.NewModelManagementUseCase(pulledModelManager{}, router)
+
+	execute := func() {
+		t.Helper()
+		if _, err := uc.Execute(context.Background(), &domain.AIRequest{Prompt: "What is on this page?", Images: []string{"iVBORw0KGgo="}}); err != nil {
+			t.Fatalf("Execute() error = %v", err)
+		}
+	}
+
+	// Model a rejects images and is then skipped for them
+	execute()
+	execute()
+
+	// Pulling a vision build of model a, as its latest tag, makes it worth trying again
+	if err := models.PullModel(context.Background(), "a:latest", func(*domain.PullProgress) error { return nil }); err != nil {
+		t.Fatalf("PullModel() error = %v", err)
+	}
+	client.textOnly = nil
+	execute()
+
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	want := []string{"a", "b", "b", "a"}
+	if !reflect.DeepEqual(client.requests, want) {
+		t.Errorf("requests = %v, want %v", client.requests, want)
+	}
+}
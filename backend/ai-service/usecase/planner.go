@@ -0,0 +1,244 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/augment-local-manus-clone/backend/ai-service/domain"
+)
+
+const (
+	// defaultPlanSteps bounds the steps of a plan when a request does not set max_steps
+	defaultPlanSteps = 10
+
+	// maxPlanRetries bounds how many times a model is re-prompted when its
+	// plan matches the schema but calls tools incorrectly
+	maxPlanRetries = 2
+)
+
+// PlannerUseCase turns a task description and a set of tools into an ordered
+// plan of tool calls. Plans are requested as schema-checked JSON through
+// ProcessAIRequestUseCase and then checked against the tools' parameter
+// schemas; a plan that is partly carried out can be replanned from what its
+// steps produced.
+type PlannerUseCase struct {
+	processAIRequestUseCase *ProcessAIRequestUseCase
+}
+
+// NewPlannerUseCase creates a new instance of PlannerUseCase
+func NewPlannerUseCase(processAIRequestUseCase *ProcessAIRequestUseCase) *PlannerUseCase {
+	return &PlannerUseCase{
+		processAIRequestUseCase: processAIRequestUseCase,
+	}
+}
+
+// Execute plans the task, or replans it when the request carries a plan and
+// observations of its steps. The request's timeout covers every attempt.
+func (uc *PlannerUseCase) Execute(ctx context.Context, request *domain.PlanRequest) (*domain.PlanResponse, error) {
+	ctx, cancel := withTimeout(ctx, request.Timeout())
+	defer cancel()
+
+	// Validate the request
+	if err := request.Validate(); err != nil {
+		return nil, err
+	}
+
+	if request.MaxSteps == 0 {
+		request.MaxSteps = defaultPlanSteps
+	}
+
+	// Steps that succeeded are kept; the model plans the rest of the task
+	var completed []domain.PlanStep
+	nextID := 1
+	if request.Plan != nil {
+		succeeded := make(map[int]bool, len(request.Observations))
+		for _, observation := range request.Observations {
+			if observation.Status == domain.StepSucceeded {
+				succeeded[observation.StepID] = true
+			}
+		}
+		for _, step := range request.Plan.Steps {
+			if succeeded[step.ID] {
+				completed = append(completed, step)
+			}
+			nextID = step.ID + 1
+		}
+	}
+
+	aiRequest := request.AIRequest
+	aiRequest.Prompt = buildPlanPrompt(request, nextID)
+	aiRequest.ResponseSchema = planSchema(request.Tools, request.MaxSteps, request.Plan != nil)
+
+	// Tool output is untrusted: it is checked by the guardrails and tagged as data
+	for _, observation := range request.Observations {
+		text := observation.Output
+		if observation.Error != "" {
+			text = strings.TrimSpace(text + "\n" + observation.Error)
+		}
+		if text != "" {
+			aiRequest.Untrusted = append(aiRequest.Untrusted, domain.UntrustedContent{
+				Source:  "step " + strconv.Itoa(observation.StepID),
+				Content: text,
+			})
+		}
+	}
+
+	prompt := aiRequest.Prompt
+	result := &domain.PlanResponse{}
+	var lastErr error
+
+	for attempt := 0; attempt <= maxPlanRetries; attempt++ {
+		attemptReq := aiRequest
+		attemptReq.Untrusted = append([]domain.UntrustedContent(nil), aiRequest.Untrusted...)
+		if lastErr != nil {
+			attemptReq.Prompt = prompt + "\n\n" + planCorrectionPrompt(lastErr)
+		}
+
+		response, err := uc.processAIRequestUseCase.Execute(ctx, &attemptReq)
+		if err != nil {
+			return nil, err
+		}
+		result.Attempts++
+
+		previous := result.AIResponse
+		result.AIResponse = *response
+		result.TokensUsed += previous.TokensUsed
+		result.Elapsed += previous.Elapsed
+		result.Usage = domain.AddUsage(previous.Usage, response.Usage)
+
+		var steps []domain.PlanStep
+		steps, lastErr = decodePlanSteps(response.JSON)
+		if lastErr != nil {
+			continue
+		}
+
+		plan := &domain.Plan{Steps: append(append([]domain.PlanStep(nil), completed...), steps...)}
+		lastErr = plan.Validate(request.Tools)
+		if lastErr == nil && len(steps) > 0 && steps[0].ID < nextID {
+			lastErr = fmt.Errorf("%w: new steps must be numbered from %d", domain.ErrInvalidPlan, nextID)
+		}
+		if lastErr != nil {
+			continue
+		}
+
+		// The plan replaces the raw JSON it was decoded from
+		result.JSON = nil
+		result.Plan = plan
+		for _, step := range completed {
+			result.Completed = append(result.Completed, step.ID)
+		}
+		return result, nil
+	}
+
+	return nil, fmt.Errorf("%w after %d attempts: %w", domain.ErrPlanFailed, maxPlanRetries+1, lastErr)
+}
+
+// decodePlanSteps converts the schema-checked JSON of a plan into its steps
+func decodePlanSteps(value interface{}) ([]domain.PlanStep, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrInvalidPlan, err)
+	}
+
+	var plan domain.Plan
+	if err := json.Unmarshal(data, &plan); err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrInvalidPlan, err)
+	}
+	return plan.Steps, nil
+}
+
+// planSchema describes the JSON plan the model must reply with. Tool names
+// are restricted to the available tools; arguments are checked against each
+// tool's own schema afterwards. A replan may have no steps left.
+func planSchema(tools []domain.ToolDefinition, maxSteps int, replan bool) domain.JSONSchema {
+	names := make([]interface{}, 0, len(tools))
+	for _, tool := range tools {
+		names = append(names, tool.Name)
+	}
+
+	minSteps := 1
+	if replan {
+		minSteps = 0
+	}
+
+	return domain.JSONSchema{
+		"type":     "object",
+		"required": []interface{}{"steps"},
+		"properties": map[string]interface{}{
+			"steps": map[string]interface{}{
+				"type":     "array",
+				"minItems": minSteps,
+				"maxItems": maxSteps,
+				"items": map[string]interface{}{
+					"type":     "object",
+					"required": []interface{}{"id", "description", "tool", "arguments", "expected_output"},
+					"properties": map[string]interface{}{
+						"id":              map[string]interface{}{"type": "integer", "minimum": 1},
+						"description":     map[string]interface{}{"type": "string", "minLength": 1},
+						"tool":            map[string]interface{}{"type": "string", "enum": names},
+						"arguments":       map[string]interface{}{"type": "object"},
+						"expected_output": map[string]interface{}{"type": "string", "minLength": 1},
+						"depends_on": map[string]interface{}{
+							"type":  "array",
+							"items": map[string]interface{}{"type": "integer", "minimum": 1},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// buildPlanPrompt asks the model to plan a task with the given tools, or to
+// plan the rest of a task from what its steps produced so far
+func buildPlanPrompt(request *domain.PlanRequest, nextID int) string {
+	var b strings.Builder
+	b.WriteString("Plan how to carry out the task below using only the available tools. ")
+	b.WriteString("Each step calls one tool with arguments that match its parameters.\n\n")
+	b.WriteString("Task:\n")
+	b.WriteString(request.Prompt)
+	b.WriteString("\n\nAvailable tools:\n")
+	for _, tool := range request.Tools {
+		fmt.Fprintf(&b, "- %s: %s", tool.Name, tool.Description)
+		if tool.Parameters != nil {
+			parameters, _ := json.Marshal(tool.Parameters)
+			fmt.Fprintf(&b, " Parameters: %s", parameters)
+		}
+		b.WriteString("\n")
+	}
+
+	if request.Plan != nil {
+		observations := make(map[int]domain.StepObservation, len(request.Observations))
+		for _, observation := range request.Observations {
+			observations[observation.StepID] = observation
+		}
+
+		b.WriteString("\nThe task is partly done. Current plan:\n")
+		for _, step := range request.Plan.Steps {
+			status := "not started"
+			if observation, ok := observations[step.ID]; ok {
+				status = string(observation.Status)
+			}
+			arguments, _ := json.Marshal(step.Arguments)
+			fmt.Fprintf(&b, "%d. [%s] %s: %s(%s), expected: %s\n", step.ID, status, step.Description, step.Tool, arguments, step.ExpectedOutput)
+		}
+		b.WriteString("\nWhat the steps produced is given below, labelled by step. ")
+		b.WriteString("Succeeded steps are done and must not be repeated; new steps may depend on them. ")
+		b.WriteString("Plan the remaining steps to finish the task, working around failed steps and using what was learned. ")
+		fmt.Fprintf(&b, "Number the new steps from %d. If the task is already complete, reply with no steps.\n", nextID)
+	}
+
+	fmt.Fprintf(&b, "\nReply with a JSON object with a \"steps\" array of at most %d steps. ", request.MaxSteps)
+	b.WriteString("Each step has an integer \"id\", a \"description\", the \"tool\" name, its \"arguments\" object, ")
+	b.WriteString("the \"expected_output\" of the step, and \"depends_on\", the ids of earlier steps whose output it needs. ")
+	b.WriteString("Step ids increase through the plan.")
+	return b.String()
+}
+
+// planCorrectionPrompt asks the model to fix a plan that was rejected
+func planCorrectionPrompt(err error) string {
+	return fmt.Sprintf("A previous reply was rejected: %v\nReply with a corrected plan.", err)
+}
@@ -0,0 +1,148 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/augment-local-manus-clone/backend/ai-service/domain"
+	"github.com/augment-local-manus-clone/backend/ai-service/infrastructure/llm"
+	"github.com/augment-local-manus-clone/backend/ai-service/usecase"
+)
+
+// planTools are the tools offered to the planner in tests
+var planTools = []domain.ToolDefinition{
+	{
+		Name:        "read_file",
+		Description: "Read a file from the workspace",
+		Parameters: domain.JSONSchema{
+			"type":       "object",
+			"required":   []interface{}{"path"},
+			"properties": map[string]interface{}{"path": map[string]interface{}{"type": "string"}},
+		},
+	},
+	{Name: "run_tests", Description: "Run the test suite"},
+}
+
+const (
+	validPlan = `{"steps": [
+		{"id": 1, "description": "Read the config", "tool": "read_file", "arguments": {"path": "config.yaml"}, "expected_output": "The config file"},
+		{"id": 2, "description": "Run the tests", "tool": "run_tests", "arguments": {}, "expected_output": "Passing tests", "depends_on": [1]}
+	]}`
+	missingArgumentPlan = `{"steps": [
+		{"id": 1, "description": "Read the config", "tool": "read_file", "arguments": {}, "expected_output": "The config file"}
+	]}`
+	forwardDependencyPlan = `{"steps": [
+		{"id": 1, "description": "Run the tests", "tool": "run_tests", "arguments": {}, "expected_output": "Passing tests", "depends_on": [2]}
+	]}`
+	replannedPlan = `{"steps": [
+		{"id": 4, "description": "Read the fixed config", "tool": "read_file", "arguments": {"path": "config.yml"}, "expected_output": "The config file", "depends_on": [1]}
+	]}`
+)
+
+func TestPlannerUseCase(t *testing.T) {
+	currentPlan := &domain.Plan{Steps: []domain.PlanStep{
+		{ID: 1, Description: "Run the tests", Tool: "run_tests", ExpectedOutput: "Test results"},
+		{ID: 2, Description: "Read the config", Tool: "read_file", Arguments: map[string]interface{}{"path": "config.yaml"}, ExpectedOutput: "The config file"},
+		{ID: 3, Description: "Run the tests again", Tool: "run_tests", ExpectedOutput: "Passing tests", DependsOn: []int{2}},
+	}}
+
+	tests := []struct {
+		name          string
+		replies       []string
+		request       domain.PlanRequest
+		wantIDs       []int
+		wantCompleted []int
+		wantAttempts  int
+		wantPrompt    string
+		wantErr       error
+	}{
+		{
+			name:         "Valid plan",
+			replies:      []string{validPlan},
+			request:      domain.PlanRequest{AIRequest: domain.AIRequest{Prompt: "Check the config works"}, Tools: planTools},
+			wantIDs:      []int{1, 2},
+			wantAttempts: 1,
+			wantPrompt:   "read_file: Read a file from the workspace Parameters:",
+		},
+		{
+			name:         "Invalid arguments are corrected",
+			replies:      []string{missingArgumentPlan, validPlan},
+			request:      domain.PlanRequest{AIRequest: domain.AIRequest{Prompt: "Check the config works"}, Tools: planTools},
+			wantIDs:      []int{1, 2},
+			wantAttempts: 2,
+			wantPrompt:   `missing required property "path"`,
+		},
+		{
+			name:    "Gives up on invalid plans",
+			replies: []string{forwardDependencyPlan, forwardDependencyPlan, forwardDependencyPlan},
+			request: domain.PlanRequest{AIRequest: domain.AIRequest{Prompt: "Check the config works"}, Tools: planTools},
+			wantErr: domain.ErrPlanFailed,
+		},
+		{
+			name:    "Replans from observations",
+			replies: []string{replannedPlan},
+			request: domain.PlanRequest{
+				AIRequest: domain.AIRequest{Prompt: "Check the config works"},
+				Tools:     planTools,
+				Plan:      currentPlan,
+				Observations: []domain.StepObservation{
+					{StepID: 1, Status: domain.StepSucceeded, Output: "3 passed"},
+					{StepID: 2, Status: domain.StepFailed, Error: "config.yaml not found; did you mean config.yml?"},
+				},
+			},
+			wantIDs:       []int{1, 4},
+			wantCompleted: []int{1},
+			wantAttempts:  1,
+			wantPrompt:    "<untrusted source=\"step 2\">\nconfig.yaml not found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			script := make([]llm.FakeReply, len(tt.replies))
+			for i, reply := range tt.replies {
+				script[i] = llm.FakeReply{Text: reply}
+			}
+			fake, err := llm.NewFakeLLMClient(llm.FakeScript{Rules: []llm.FakeRule{{Pattern: "Plan how", Script: script}}})
+			if err != nil {
+				t.Fatalf("NewFakeLLMClient() error = %v", err)
+			}
+
+			uc := usecase.NewPlannerUseCase(usecase.NewProcessAIRequestUseCase(fake, nil, nil, nil, nil, nil))
+			request := tt.request
+			response, err := uc.Execute(context.Background(), &request)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Execute() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				// The model's last mistake stays matchable
+				if !errors.Is(err, domain.ErrInvalidPlan) {
+					t.Errorf("Execute() error = %v, want it to wrap ErrInvalidPlan", err)
+				}
+				return
+			}
+
+			var ids []int
+			for _, step := range response.Plan.Steps {
+				ids = append(ids, step.ID)
+			}
+			if !reflect.DeepEqual(ids, tt.wantIDs) {
+				t.Errorf("step ids = %v, want %v", ids, tt.wantIDs)
+			}
+			if !reflect.DeepEqual(response.Completed, tt.wantCompleted) {
+				t.Errorf("Completed = %v, want %v", response.Completed, tt.wantCompleted)
+			}
+			if response.Attempts != tt.wantAttempts {
+				t.Errorf("Attempts = %d, want %d", response.Attempts, tt.wantAttempts)
+			}
+
+			calls := fake.Calls()
+			if prompt := calls[len(calls)-1].Prompt; !strings.Contains(prompt, tt.wantPrompt) {
+				t.Errorf("prompt = %q, want it to contain %q", prompt, tt.wantPrompt)
+			}
+		})
+	}
+}
@@ -1,37 +1,284 @@
 package usecase
 
 import (
+	"context"
+	"errors"
+	"time"
+
 	"github.com/augment-local-manus-clone/backend/ai-service/domain"
 )
 
 // ProcessAIRequestUseCase handles processing AI requests
 type ProcessAIRequestUseCase struct {
-	llmClient domain.LLMClient
+	llmClient     domain.LLMClient
+	router        *ModelRouter
+	contextWindow *ContextWindowManager
+	templates     *PromptTemplateUseCase
+	guardrails    *Guardrails
+	memory        *MemoryUseCase
 }
 
-// NewProcessAIRequestUseCase creates a new instance of ProcessAIRequestUseCase
-func NewProcessAIRequestUseCase(llmClient domain.LLMClient) *ProcessAIRequestUseCase {
+// preparedRequest holds what preparing a request produced for its response
+type preparedRequest struct {
+	report   *domain.ContextReport
+	template *domain.TemplateRef
+	findings []domain.GuardrailFinding
+	memory   *domain.MemoryReport
+
+	// prompt is the prompt as the user wrote it, before untrusted content
+	// and memory were added, which is what the memory remembers
+	prompt string
+}
+
+// NewProcessAIRequestUseCase creates a new instance of ProcessAIRequestUseCase.
+// router, contextWindow, templates, guardrails and memory are optional;
+// without a router requests go straight to the LLM client, without a context
+// window manager prompts are sent unchanged, without templates requests must
+// set a prompt, without guardrails untrusted content is tagged but not
+// checked, and without memory requests cannot set a conversation ID.
+func NewProcessAIRequestUseCase(
+	llmClient domain.LLMClient,
+	router *ModelRouter,
+	contextWindow *ContextWindowManager,
+	templates *PromptTemplateUseCase,
+	guardrails *Guardrails,
+	memory *MemoryUseCase,
+) *ProcessAIRequestUseCase {
 	return &ProcessAIRequestUseCase{
-		llmClient: llmClient,
+		llmClient:     llmClient,
+		router:        router,
+		contextWindow: contextWindow,
+		templates:     templates,
+		guardrails:    guardrails,
+		memory:        memory,
+	}
+}
+
+// Execute processes an AI request. Cancelling the context, or exceeding the
+// request's timeout, aborts the request.
+func (uc *ProcessAIRequestUseCase) Execute(ctx context.Context, request *domain.AIRequest) (*domain.AIResponse, error) {
+	ctx, cancel := withTimeout(ctx, request.Timeout())
+	defer cancel()
+
+	prepared, err := uc.prepare(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+
+	var response *domain.AIResponse
+	if uc.router == nil {
+		response, err = uc.process(ctx, request)
+	} else {
+		response, err = uc.router.execute(ctx, request, func(ctx context.Context, routed *domain.AIRequest, progress func()) (*domain.AIResponse, error) {
+			return uc.process(ctx, routed)
+		})
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if request.Reasoning == domain.ReasoningDrop {
+		response.Reasoning = ""
+	}
+
+	if err := uc.checkOutput(prepared.findings, response); err != nil {
+		return nil, err
+	}
+
+	uc.remember(ctx, request, prepared, response)
+
+	response.ContextWindow = prepared.report
+	response.Template = prepared.template
+	response.Memory = prepared.memory
+	return response, nil
+}
+
+// ExecuteStream processes an AI request, passing response chunks to the handler as they arrive.
+// The request's timeout covers the whole stream. Deny patterns that redact or
+// block apply to chunks before they reach the handler, so some output is held
+// back until no match can span it; the complete response is checked again
+// once the stream ends.
+func (uc *ProcessAIRequestUseCase) ExecuteStream(ctx context.Context, request *domain.AIRequest, handler domain.StreamHandler) (*domain.AIResponse, error) {
+	if request.ResponseSchema != nil {
+		return nil, errors.New("response_schema is not supported for streaming requests")
+	}
+
+	ctx, cancel := withTimeout(ctx, request.Timeout())
+	defer cancel()
+
+	prepared, err := uc.prepare(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+
+	var guard *OutputStream
+	if uc.guardrails != nil {
+		if guard = uc.guardrails.GuardStream(prepared.findings, handler); guard != nil {
+			handler = guard.Send
+		}
+	}
+	handler = reasoningHandler(request.Reasoning, handler)
+
+	var response *domain.AIResponse
+	if uc.router == nil {
+		// Stream the request using the LLM client
+		response, err = uc.llmClient.ProcessStream(ctx, request, handler)
+	} else {
+		response, err = uc.router.execute(ctx, request, func(ctx context.Context, routed *domain.AIRequest, progress func()) (*domain.AIResponse, error) {
+			return uc.llmClient.ProcessStream(ctx, routed, func(chunk *domain.AIStreamChunk) error {
+				progress()
+				return handler(chunk)
+			})
+		})
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if guard != nil {
+		if err := guard.Flush(); err != nil {
+			return nil, err
+		}
+	}
+
+	if request.Reasoning == domain.ReasoningDrop {
+		response.Reasoning = ""
+	}
+
+	if err := uc.checkOutput(prepared.findings, response); err != nil {
+		return nil, err
+	}
+
+	uc.remember(ctx, request, prepared, response)
+
+	response.ContextWindow = prepared.report
+	response.Template = prepared.template
+	response.Memory = prepared.memory
+	return response, nil
+}
+
+// RoutingStatus returns the model routes and their health, or nil when routing is not configured
+func (uc *ProcessAIRequestUseCase) RoutingStatus() *RoutingStatus {
+	if uc.router == nil {
+		return nil
+	}
+	return uc.router.Status()
+}
+
+// process sends a validated request to the LLM client
+func (uc *ProcessAIRequestUseCase) process(ctx context.Context, request *domain.AIRequest) (*domain.AIResponse, error) {
+	// Structured output needs a conversation so invalid replies can be corrected
+	if request.ResponseSchema != nil {
+		response, err := chatWithSchema(ctx, uc.llmClient, request.ToChatRequest())
+		if err != nil {
+			return nil, err
+		}
+		return response.ToAIResponse(), nil
+	}
+
+	// Process the request using the LLM client
+	return uc.llmClient.Process(ctx, request)
+}
+
+// withTimeout bounds the context by a request's timeout, if it sets one
+func withTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// checkOutput applies the output guardrails to a response and reports every finding on it
+func (uc *ProcessAIRequestUseCase) checkOutput(findings []domain.GuardrailFinding, response *domain.AIResponse) error {
+	if uc.guardrails == nil {
+		return nil
 	}
+
+	report, err := uc.guardrails.CheckOutput(findings, []*string{&response.Text, &response.Reasoning}, &response.JSON)
+	if err != nil {
+		return err
+	}
+
+	response.Guardrails = report
+	return nil
 }
 
-// Execute processes an AI request
-func (uc *ProcessAIRequestUseCase) Execute(request *domain.AIRequest) (*domain.AIResponse, error) {
+// remember records the prompt and the reply in the conversation's memory
+func (uc *ProcessAIRequestUseCase) remember(ctx context.Context, request *domain.AIRequest, prepared *preparedRequest, response *domain.AIResponse) {
+	if prepared.memory == nil {
+		return
+	}
+	uc.memory.record(ctx, request.ConversationID, request.Provider, response.Model, prepared.prompt, response.Text)
+}
+
+// prepare validates the request, renders its template, checks and tags its
+// untrusted content, adds the conversation's memory, applies default values
+// and fits the prompt into the model's context window
+func (uc *ProcessAIRequestUseCase) prepare(ctx context.Context, request *domain.AIRequest) (*preparedRequest, error) {
 	// Validate the request
 	if err := request.Validate(); err != nil {
 		return nil, err
 	}
 
+	prepared := &preparedRequest{}
+
+	// Render the prompt from its template
+	if request.Template != "" {
+		if uc.templates == nil {
+			return nil, errors.New("prompt templates are not configured")
+		}
+
+		var err error
+		prepared.template, err = uc.templates.Render(request)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	prepared.prompt = request.Prompt
+
+	// Check untrusted content before it is added to the prompt
+	if uc.guardrails != nil {
+		var err error
+		prepared.findings, err = uc.guardrails.CheckPrompt(request)
+		if err != nil {
+			return nil, err
+		}
+	}
+	request.TagUntrusted()
+
+	// Add what is remembered of the conversation
+	if request.ConversationID != "" {
+		if uc.memory == nil {
+			return nil, errors.New("conversation memory is not configured")
+		}
+
+		var err error
+		prepared.memory, err = uc.memory.injectPrompt(request)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	// Set default values if not provided
 	if request.MaxTokens == 0 {
 		request.MaxTokens = 2048
 	}
 
-	if request.Temperature == 0 {
-		request.Temperature = 0.7
+	if request.Temperature == nil {
+		temperature := 0.7
+		request.Temperature = &temperature
 	}
 
-	// Process the request using the LLM client
-	return uc.llmClient.Process(request)
+	if uc.contextWindow == nil {
+		return prepared, nil
+	}
+
+	var err error
+	prepared.report, err = uc.contextWindow.FitPrompt(ctx, uc.llmClient, request)
+	if err != nil {
+		return nil, err
+	}
+
+	return prepared, nil
 }
@@ -0,0 +1,169 @@
+package usecase
+
+import (
+	"math/rand"
+
+	"github.com/augment-local-manus-clone/backend/ai-service/domain"
+)
+
+// CreatePromptTemplateInput represents the input for creating a template version
+type CreatePromptTemplateInput struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Template    string `json:"template"`
+	Weight      int    `json:"weight,omitempty"`
+}
+
+// UpdatePromptTemplateInput represents the changes to a template version;
+// nil fields are left unchanged
+type UpdatePromptTemplateInput struct {
+	Name        string  `json:"-"`
+	Version     int     `json:"-"`
+	Description *string `json:"description,omitempty"`
+	Weight      *int    `json:"weight,omitempty"`
+}
+
+// PromptTemplateUseCase manages prompt templates and renders prompts from them
+type PromptTemplateUseCase struct {
+	repository domain.PromptTemplateRepository
+
+	// pick returns a random number in [0, n) for weighted version selection
+	pick func(n int) int
+}
+
+// NewPromptTemplateUseCase creates a new instance of PromptTemplateUseCase
+func NewPromptTemplateUseCase(repository domain.PromptTemplateRepository) *PromptTemplateUseCase {
+	return &PromptTemplateUseCase{
+		repository: repository,
+		pick:       rand.Intn,
+	}
+}
+
+// Create stores a new version of a template, creating the template if it does not exist
+func (uc *PromptTemplateUseCase) Create(input CreatePromptTemplateInput) (*domain.PromptTemplate, error) {
+	template := &domain.PromptTemplate{
+		Name:        input.Name,
+		Description: input.Description,
+		Template:    input.Template,
+		Weight:      input.Weight,
+	}
+
+	// Validate the template
+	if err := template.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := uc.repository.Create(template); err != nil {
+		return nil, err
+	}
+
+	return template, nil
+}
+
+// Get returns a version of a template
+func (uc *PromptTemplateUseCase) Get(name string, version int) (*domain.PromptTemplate, error) {
+	return uc.repository.Get(name, version)
+}
+
+// ListVersions returns every version of a template
+func (uc *PromptTemplateUseCase) ListVersions(name string) ([]*domain.PromptTemplate, error) {
+	return uc.repository.ListVersions(name)
+}
+
+// List returns the latest version of every template
+func (uc *PromptTemplateUseCase) List() ([]*domain.PromptTemplate, error) {
+	return uc.repository.List()
+}
+
+// Update changes the description or traffic weight of a template version
+func (uc *PromptTemplateUseCase) Update(input UpdatePromptTemplateInput) (*domain.PromptTemplate, error) {
+	template, err := uc.repository.Get(input.Name, input.Version)
+	if err != nil {
+		return nil, err
+	}
+
+	if input.Description != nil {
+		template.Description = *input.Description
+	}
+
+	if input.Weight != nil {
+		template.Weight = *input.Weight
+	}
+
+	// Validate the template
+	if err := template.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := uc.repository.Update(template); err != nil {
+		return nil, err
+	}
+
+	return template, nil
+}
+
+// Delete removes a version of a template, or the whole template when version is 0
+func (uc *PromptTemplateUseCase) Delete(name string, version int) error {
+	return uc.repository.Delete(name, version)
+}
+
+// Render sets the request's prompt from its template and returns the version
+// used. A pinned version is always used; otherwise a version is chosen at
+// random in proportion to the versions' weights, so prompt versions can be
+// A/B tested.
+func (uc *PromptTemplateUseCase) Render(request *domain.AIRequest) (*domain.TemplateRef, error) {
+	var template *domain.PromptTemplate
+	var err error
+	if request.TemplateVersion != 0 {
+		template, err = uc.repository.Get(request.Template, request.TemplateVersion)
+	} else {
+		template, err = uc.choose(request.Template)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Variables override context entries of the same name
+	data := make(map[string]interface{}, len(request.Context)+len(request.Variables))
+	for key, value := range request.Context {
+		data[key] = value
+	}
+	for key, value := range request.Variables {
+		data[key] = value
+	}
+
+	prompt, err := template.Render(data)
+	if err != nil {
+		return nil, err
+	}
+
+	request.Prompt = prompt
+	return &domain.TemplateRef{Name: template.Name, Version: template.Version}, nil
+}
+
+// choose picks a template version by weight, or the latest version when none has a weight
+func (uc *PromptTemplateUseCase) choose(name string) (*domain.PromptTemplate, error) {
+	versions, err := uc.repository.ListVersions(name)
+	if err != nil {
+		return nil, err
+	}
+
+	total := 0
+	for _, version := range versions {
+		total += version.Weight
+	}
+
+	if total == 0 {
+		return versions[len(versions)-1], nil
+	}
+
+	n := uc.pick(total)
+	for _, version := range versions {
+		if n < version.Weight {
+			return version, nil
+		}
+		n -= version.Weight
+	}
+
+	return versions[len(versions)-1], nil
+}
@@ -0,0 +1,218 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/augment-local-manus-clone/backend/ai-service/domain"
+	"github.com/augment-local-manus-clone/backend/ai-service/usecase"
+)
+
+// memoryTemplateRepository is an in-memory PromptTemplateRepository
+type memoryTemplateRepository struct {
+	templates []*domain.PromptTemplate
+}
+
+func (r *memoryTemplateRepository) Create(template *domain.PromptTemplate) error {
+	template.Version = 1
+	for _, existing := range r.templates {
+		if existing.Name == template.Name && existing.Version >= template.Version {
+			template.Version = existing.Version + 1
+		}
+	}
+	copied := *template
+	r.templates = append(r.templates, &copied)
+	return nil
+}
+
+func (r *memoryTemplateRepository) Get(name string, version int) (*domain.PromptTemplate, error) {
+	for _, template := range r.templates {
+		if template.Name == name && template.Version == version {
+			copied := *template
+			return &copied, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s version %d", domain.ErrTemplateNotFound, name, version)
+}
+
+func (r *memoryTemplateRepository) ListVersions(name string) ([]*domain.PromptTemplate, error) {
+	var versions []*domain.PromptTemplate
+	for _, template := range r.templates {
+		if template.Name == name {
+			copied := *template
+			versions = append(versions, &copied)
+		}
+	}
+	if len(versions) == 0 {
+		return nil, fmt.Errorf("%w: %s", domain.ErrTemplateNotFound, name)
+	}
+	return versions, nil
+}
+
+func (r *memoryTemplateRepository) List() ([]*domain.PromptTemplate, error) {
+	return r.templates, nil
+}
+
+func (r *memoryTemplateRepository) Update(template *domain.PromptTemplate) error {
+	for _, existing := range r.templates {
+		if existing.Name == template.Name && existing.Version == template.Version {
+			*existing = *template
+			return nil
+		}
+	}
+	return domain.ErrTemplateNotFound
+}
+
+func (r *memoryTemplateRepository) Delete(name string, version int) error {
+	return errors.New("not implemented")
+}
+
+func TestPromptTemplateRender(t *testing.T) {
+	repo := &memoryTemplateRepository{}
+	uc := usecase.NewPromptTemplateUseCase(repo)
+
+	for _, input := range []usecase.CreatePromptTemplateInput{
+		{Name: "plan", Template: "Plan how to {{.goal}} for {{.user}}."},
+		{Name: "plan", Template: "Steps to {{.goal}}:"},
+		{Name: "split", Template: "A {{.goal}}", Weight: 1},
+		{Name: "split", Template: "B {{.goal}}", Weight: 1},
+		{Name: "pinned", Template: "old", Weight: 0},
+		{Name: "pinned", Template: "new", Weight: 5},
+	} {
+		if _, err := uc.Create(input); err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+	}
+
+	tests := []struct {
+		name        string
+		request     domain.AIRequest
+		wantPrompt  string
+		wantVersion int
+		wantErr     error
+	}{
+		{
+			name: "Latest version when no version has a weight",
+			request: domain.AIRequest{
+				Template:  "plan",
+				Variables: map[string]interface{}{"goal": "ship"},
+			},
+			wantPrompt:  "Steps to ship:",
+			wantVersion: 2,
+		},
+		{
+			name: "Pinned version with context and variables",
+			request: domain.AIRequest{
+				Template:        "plan",
+				TemplateVersion: 1,
+				Context:         map[string]interface{}{"goal": "rest", "user": "ana"},
+				Variables:       map[string]interface{}{"goal": "ship"},
+			},
+			wantPrompt:  "Plan how to ship for ana.",
+			wantVersion: 1,
+		},
+		{
+			name:        "Only weighted versions are chosen",
+			request:     domain.AIRequest{Template: "pinned"},
+			wantPrompt:  "new",
+			wantVersion: 2,
+		},
+		{
+			name: "Missing variable",
+			request: domain.AIRequest{
+				Template:        "plan",
+				TemplateVersion: 1,
+				Variables:       map[string]interface{}{"goal": "ship"},
+			},
+			wantErr: domain.ErrTemplateRender,
+		},
+		{
+			name:    "Unknown template",
+			request: domain.AIRequest{Template: "missing"},
+			wantErr: domain.ErrTemplateNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := tt.request
+			ref, err := uc.Render(&request)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Render() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Render() error = %v", err)
+			}
+
+			if request.Prompt != tt.wantPrompt {
+				t.Errorf("Prompt = %q, want %q", request.Prompt, tt.wantPrompt)
+			}
+			if ref.Name != tt.request.Template || ref.Version != tt.wantVersion {
+				t.Errorf("Render() = %+v, want version %d", ref, tt.wantVersion)
+			}
+		})
+	}
+
+	// Equal weights split traffic between both versions
+	seen := make(map[int]bool)
+	for i := 0; i < 200; i++ {
+		request := domain.AIRequest{Template: "split", Variables: map[string]interface{}{"goal": "x"}}
+		ref, err := uc.Render(&request)
+		if err != nil {
+			t.Fatalf("Render() error = %v", err)
+		}
+		seen[ref.Version] = true
+	}
+	if !seen[1] || !seen[2] {
+		t.Errorf("versions used = %v, want both 1 and 2", seen)
+	}
+}
+
+func TestPromptTemplateCreateInvalid(t *testing.T) {
+	uc := usecase.NewPromptTemplateUseCase(&memoryTemplateRepository{})
+
+	tests := []struct {
+		name  string
+		input usecase.CreatePromptTemplateInput
+	}{
+		{name: "Invalid name", input: usecase.CreatePromptTemplateInput{Name: "Plan Steps", Template: "x"}},
+		{name: "Empty template", input: usecase.CreatePromptTemplateInput{Name: "plan", Template: " "}},
+		{name: "Syntax error", input: usecase.CreatePromptTemplateInput{Name: "plan", Template: "{{.goal"}},
+		{name: "Negative weight", input: usecase.CreatePromptTemplateInput{Name: "plan", Template: "x", Weight: -1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := uc.Create(tt.input); !errors.Is(err, domain.ErrInvalidTemplate) {
+				t.Errorf("Create() error = %v, want %v", err, domain.ErrInvalidTemplate)
+			}
+		})
+	}
+}
+
+func TestProcessAIRequestWithTemplate(t *testing.T) {
+	templates := usecase.NewPromptTemplateUseCase(&memoryTemplateRepository{})
+	if _, err := templates.Create(usecase.CreatePromptTemplateInput{Name: "greet", Template: "Say hi to {{.name}}"}); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	client := &scriptedLLMClient{replies: []scriptedReply{{message: domain.ChatMessage{Role: domain.ChatRoleAssistant, Content: "hi"}}}}
+	uc := usecase.NewProcessAIRequestUseCase(client, nil, nil, templates, nil, nil)
+
+	response, err := uc.Execute(context.Background(), &domain.AIRequest{Template: "greet", Variables: map[string]interface{}{"name": "Ada"}})
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+
+	if got := client.requests[0].Messages[0].Content; got != "Say hi to Ada" {
+		t.Errorf("prompt = %q, want the rendered template", got)
+	}
+	if response.Template == nil || response.Template.Name != "greet" || response.Template.Version != 1 {
+		t.Errorf("Template = %+v, want greet version 1", response.Template)
+	}
+}
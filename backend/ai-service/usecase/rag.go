@@ -0,0 +1,397 @@
+package usecase
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/augment-local-manus-clone/backend/ai-service/domain"
+)
+
+const (
+	// chunkSize is the maximum number of characters in an indexed chunk
+	chunkSize = 1000
+
+	// chunkOverlap is the number of characters repeated between consecutive chunks
+	chunkOverlap = 200
+
+	// maxIndexFileSize is the largest file that is indexed
+	maxIndexFileSize = 512 * 1024
+
+	// embedBatchSize is the number of chunks embedded per request
+	embedBatchSize = 32
+
+	// defaultRAGChunks is the number of chunks retrieved when a request does not set k
+	defaultRAGChunks = 5
+)
+
+// RAGUseCase answers questions from workspace files. Files are chunked and
+// embedded into the vector index, which is kept up to date incrementally:
+// only files whose size, modification time and content changed are re-embedded.
+type RAGUseCase struct {
+	llmClient               domain.LLMClient
+	store                   domain.VectorStore
+	documents               domain.DocumentIndexRepository
+	workspace               domain.Workspace
+	processAIRequestUseCase *ProcessAIRequestUseCase
+
+	// syncInterval is how long a sync stays fresh before a query triggers another
+	syncInterval time.Duration
+
+	mu       sync.Mutex
+	lastSync time.Time
+}
+
+// NewRAGUseCase creates a new instance of RAGUseCase
+func NewRAGUseCase(
+	llmClient domain.LLMClient,
+	store domain.VectorStore,
+	documents domain.DocumentIndexRepository,
+	workspace domain.Workspace,
+	processAIRequestUseCase *ProcessAIRequestUseCase,
+	syncInterval time.Duration,
+) *RAGUseCase {
+	return &RAGUseCase{
+		llmClient:               llmClient,
+		store:                   store,
+		documents:               documents,
+		workspace:               workspace,
+		processAIRequestUseCase: processAIRequestUseCase,
+		syncInterval:            syncInterval,
+	}
+}
+
+// Execute retrieves the workspace chunks most relevant to the prompt and asks
+// the model to answer from them, citing them by number. The request's timeout
+// covers indexing and retrieval as well as the answer.
+func (uc *RAGUseCase) Execute(ctx context.Context, request *domain.RAGRequest) (*domain.RAGResponse, error) {
+	ctx, cancel := withTimeout(ctx, request.Timeout())
+	defer cancel()
+
+	// Validate the request
+	if err := request.Validate(); err != nil {
+		return nil, err
+	}
+
+	if request.K == 0 {
+		request.K = defaultRAGChunks
+	}
+
+	if request.K > maxQueryLimit {
+		return nil, fmt.Errorf("k must be between 1 and %d", maxQueryLimit)
+	}
+
+	uc.mu.Lock()
+	if time.Since(uc.lastSync) >= uc.syncInterval {
+		if _, err := uc.reindex(ctx); err != nil {
+			uc.mu.Unlock()
+			return nil, fmt.Errorf("failed to index workspace: %w", err)
+		}
+	}
+	uc.mu.Unlock()
+
+	embedding, err := uc.llmClient.Embed(ctx, &domain.EmbedRequest{Input: []string{request.Prompt}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed prompt: %w", err)
+	}
+	if len(embedding.Embeddings) != 1 {
+		return nil, fmt.Errorf("expected 1 embedding, got %d", len(embedding.Embeddings))
+	}
+
+	matches, err := uc.store.Query(domain.WorkspaceCollection, embedding.Embeddings[0], request.K)
+	if err != nil {
+		return nil, err
+	}
+
+	citations := make([]domain.Citation, 0, len(matches))
+	for _, match := range matches {
+		if match.Score < request.MinScore {
+			continue
+		}
+		citations = append(citations, domain.Citation{
+			Index:     len(citations) + 1,
+			Path:      metadataString(match.Metadata, "path"),
+			StartLine: metadataInt(match.Metadata, "start_line"),
+			EndLine:   metadataInt(match.Metadata, "end_line"),
+			Score:     match.Score,
+			Excerpt:   match.Text,
+		})
+	}
+
+	aiRequest := request.AIRequest
+	aiRequest.Prompt = buildRAGPrompt(request.Prompt, citations)
+
+	response, err := uc.processAIRequestUseCase.Execute(ctx, &aiRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.RAGResponse{
+		AIResponse: *response,
+		Citations:  citations,
+	}, nil
+}
+
+// Reindex brings the vector index up to date with the workspace
+func (uc *RAGUseCase) Reindex(ctx context.Context) (*domain.IndexReport, error) {
+	uc.mu.Lock()
+	defer uc.mu.Unlock()
+
+	return uc.reindex(ctx)
+}
+
+// reindex embeds new and changed files and removes deleted ones; uc.mu must be held
+func (uc *RAGUseCase) reindex(ctx context.Context) (*domain.IndexReport, error) {
+	files, err := uc.workspace.ListFiles(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	indexed, err := uc.documents.ListDocuments(domain.WorkspaceCollection)
+	if err != nil {
+		return nil, err
+	}
+
+	known := make(map[string]*domain.IndexedDocument, len(indexed))
+	for _, document := range indexed {
+		known[document.Path] = document
+	}
+
+	report := &domain.IndexReport{}
+	seen := make(map[string]bool, len(files))
+
+	for _, file := range files {
+		// Stop rather than recording every remaining file as failed
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		seen[file.Path] = true
+		document := known[file.Path]
+
+		// Size and modification time are checked first to avoid reading unchanged files
+		if document != nil && document.Size == file.Size && document.ModifiedTime.Equal(file.ModifiedTime) {
+			report.Unchanged++
+			continue
+		}
+
+		status, chunks, err := uc.indexFile(ctx, file, document)
+		if err != nil {
+			report.Errors = append(report.Errors, domain.FileError{Path: file.Path, Error: err.Error()})
+			continue
+		}
+
+		switch status {
+		case indexAdded:
+			report.Added = append(report.Added, file.Path)
+		case indexUpdated:
+			report.Updated = append(report.Updated, file.Path)
+		case indexUnchanged:
+			report.Unchanged++
+		case indexSkipped:
+			report.Skipped = append(report.Skipped, file.Path)
+		}
+		report.Chunks += chunks
+	}
+
+	for _, document := range indexed {
+		if seen[document.Path] {
+			continue
+		}
+		if err := uc.removeDocument(document); err != nil {
+			report.Errors = append(report.Errors, domain.FileError{Path: document.Path, Error: err.Error()})
+			continue
+		}
+		report.Removed = append(report.Removed, document.Path)
+	}
+
+	uc.lastSync = time.Now()
+	return report, nil
+}
+
+// indexStatus is the outcome of indexing a single file
+type indexStatus int
+
+const (
+	indexAdded indexStatus = iota
+	indexUpdated
+	indexUnchanged
+	indexSkipped
+)
+
+// indexFile chunks and embeds a new or changed file, replacing its previous
+// chunks, and returns the outcome with the number of chunks written
+func (uc *RAGUseCase) indexFile(ctx context.Context, file domain.WorkspaceFile, document *domain.IndexedDocument) (indexStatus, int, error) {
+	if file.Size > maxIndexFileSize {
+		return uc.skipFile(document)
+	}
+
+	content, err := uc.workspace.ReadFile(ctx, file.Path)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	// Only text files are indexed
+	if !utf8.ValidString(content) || strings.ContainsRune(content, 0) {
+		return uc.skipFile(document)
+	}
+
+	sum := sha256.Sum256([]byte(content))
+	hash := hex.EncodeToString(sum[:])
+
+	updated := &domain.IndexedDocument{
+		Collection:   domain.WorkspaceCollection,
+		Path:         file.Path,
+		Size:         file.Size,
+		ModifiedTime: file.ModifiedTime,
+		ContentHash:  hash,
+		IndexedAt:    time.Now(),
+	}
+
+	// The file was touched but its content is the same
+	if document != nil && document.ContentHash == hash {
+		updated.ChunkCount = document.ChunkCount
+		if err := uc.documents.SaveDocument(updated); err != nil {
+			return 0, 0, err
+		}
+		return indexUnchanged, 0, nil
+	}
+
+	chunks := chunkText(content, chunkSize, chunkOverlap)
+
+	records := make([]domain.VectorRecord, 0, len(chunks))
+	for start := 0; start < len(chunks); start += embedBatchSize {
+		end := start + embedBatchSize
+		if end > len(chunks) {
+			end = len(chunks)
+		}
+
+		texts := make([]string, 0, end-start)
+		for _, chunk := range chunks[start:end] {
+			texts = append(texts, chunk.Text)
+		}
+
+		response, err := uc.llmClient.Embed(ctx, &domain.EmbedRequest{Input: texts})
+		if err != nil {
+			return 0, 0, fmt.Errorf("failed to embed chunks: %w", err)
+		}
+		if len(response.Embeddings) != len(texts) {
+			return 0, 0, fmt.Errorf("expected %d embeddings, got %d", len(texts), len(response.Embeddings))
+		}
+
+		for i, chunk := range chunks[start:end] {
+			records = append(records, domain.VectorRecord{
+				ID:         chunkID(file.Path, start+i),
+				Collection: domain.WorkspaceCollection,
+				Vector:     response.Embeddings[i],
+				Text:       chunk.Text,
+				Metadata: map[string]interface{}{
+					"path":       file.Path,
+					"start_line": chunk.StartLine,
+					"end_line":   chunk.EndLine,
+				},
+			})
+		}
+	}
+
+	// Remove chunks beyond the new chunk count; the rest are replaced by the upsert
+	if document != nil && document.ChunkCount > len(chunks) {
+		var stale []string
+		for i := len(chunks); i < document.ChunkCount; i++ {
+			stale = append(stale, chunkID(file.Path, i))
+		}
+		if err := uc.store.Delete(domain.WorkspaceCollection, stale); err != nil {
+			return 0, 0, err
+		}
+	}
+
+	if len(records) > 0 {
+		if err := uc.store.Upsert(records); err != nil {
+			return 0, 0, err
+		}
+	}
+
+	updated.ChunkCount = len(chunks)
+	if err := uc.documents.SaveDocument(updated); err != nil {
+		return 0, 0, err
+	}
+
+	if document == nil {
+		return indexAdded, len(chunks), nil
+	}
+	return indexUpdated, len(chunks), nil
+}
+
+// skipFile drops a file that can no longer be indexed from the index
+func (uc *RAGUseCase) skipFile(document *domain.IndexedDocument) (indexStatus, int, error) {
+	if document != nil {
+		if err := uc.removeDocument(document); err != nil {
+			return 0, 0, err
+		}
+	}
+	return indexSkipped, 0, nil
+}
+
+// removeDocument deletes a document's chunks and its index entry
+func (uc *RAGUseCase) removeDocument(document *domain.IndexedDocument) error {
+	if document.ChunkCount > 0 {
+		ids := make([]string, 0, document.ChunkCount)
+		for i := 0; i < document.ChunkCount; i++ {
+			ids = append(ids, chunkID(document.Path, i))
+		}
+		if err := uc.store.Delete(domain.WorkspaceCollection, ids); err != nil {
+			return err
+		}
+	}
+
+	return uc.documents.DeleteDocument(domain.WorkspaceCollection, document.Path)
+}
+
+// chunkID returns the vector record ID of a file's chunk
+func chunkID(path string, index int) string {
+	return fmt.Sprintf("%s#%d", path, index)
+}
+
+// buildRAGPrompt wraps the question with the numbered excerpts the model should answer from
+func buildRAGPrompt(question string, citations []domain.Citation) string {
+	var b strings.Builder
+
+	if len(citations) == 0 {
+		b.WriteString("No relevant workspace files were found. Answer the question, and say that the workspace did not contain supporting information.\n\n")
+	} else {
+		b.WriteString("Answer the question using the workspace file excerpts below. ")
+		b.WriteString("Cite the excerpts you rely on by their number in square brackets, e.g. [1]. ")
+		b.WriteString("If the excerpts do not contain the answer, say so rather than guessing.\n\n")
+
+		for _, citation := range citations {
+			fmt.Fprintf(&b, "[%d] %s (lines %d-%d)\n```\n%s\n```\n\n", citation.Index, citation.Path, citation.StartLine, citation.EndLine, citation.Excerpt)
+		}
+	}
+
+	b.WriteString("Question: ")
+	b.WriteString(question)
+	return b.String()
+}
+
+// metadataString reads a string metadata value
+func metadataString(metadata map[string]interface{}, key string) string {
+	value, _ := metadata[key].(string)
+	return value
+}
+
+// metadataInt reads a numeric metadata value, which is a float64 once decoded from JSON
+func metadataInt(metadata map[string]interface{}, key string) int {
+	switch value := metadata[key].(type) {
+	case float64:
+		return int(value)
+	case int:
+		return value
+	default:
+		return 0
+	}
+}
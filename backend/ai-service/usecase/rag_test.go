@@ -0,0 +1,229 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/augment-local-manus-clone/backend/ai-service/domain"
+	"github.com/augment-local-manus-clone/backend/ai-service/usecase"
+)
+
+// keywordLLMClient embeds texts as keyword counts and echoes prompts back
+type keywordLLMClient struct {
+	keywords []string
+	embedded []string
+	prompts  []string
+}
+
+func (c *keywordLLMClient) Process(ctx context.Context, request *domain.AIRequest) (*domain.AIResponse, error) {
+	c.prompts = append(c.prompts, request.Prompt)
+	return &domain.AIResponse{Text: "answer [1]"}, nil
+}
+
+func (c *keywordLLMClient) ProcessStream(ctx context.Context, request *domain.AIRequest, handler domain.StreamHandler) (*domain.AIResponse, error) {
+	return c.Process(ctx, request)
+}
+
+func (c *keywordLLMClient) Chat(ctx context.Context, request *domain.ChatRequest) (*domain.ChatResponse, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (c *keywordLLMClient) Embed(ctx context.Context, request *domain.EmbedRequest) (*domain.EmbedResponse, error) {
+	response := &domain.EmbedResponse{}
+	for _, text := range request.Input {
+		c.embedded = append(c.embedded, text)
+		vector := make([]float64, len(c.keywords)+1)
+		vector[len(c.keywords)] = 0.01
+		for i, keyword := range c.keywords {
+			vector[i] = float64(strings.Count(strings.ToLower(text), keyword))
+		}
+		response.Embeddings = append(response.Embeddings, vector)
+	}
+	return response, nil
+}
+
+// memoryWorkspace is a Workspace backed by a map of paths to contents
+type memoryWorkspace struct {
+	files    map[string]string
+	modified map[string]time.Time
+	reads    []string
+}
+
+func (w *memoryWorkspace) ListFiles(ctx context.Context) ([]domain.WorkspaceFile, error) {
+	var files []domain.WorkspaceFile
+	for path, content := range w.files {
+		files = append(files, domain.WorkspaceFile{Path: path, Size: int64(len(content)), ModifiedTime: w.modified[path]})
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+	return files, nil
+}
+
+func (w *memoryWorkspace) ReadFile(ctx context.Context, path string) (string, error) {
+	w.reads = append(w.reads, path)
+	return w.files[path], nil
+}
+
+// memoryVectorStore is an in-memory VectorStore and DocumentIndexRepository
+type memoryVectorStore struct {
+	records   map[string]domain.VectorRecord
+	documents map[string]*domain.IndexedDocument
+}
+
+func newMemoryVectorStore() *memoryVectorStore {
+	return &memoryVectorStore{
+		records:   make(map[string]domain.VectorRecord),
+		documents: make(map[string]*domain.IndexedDocument),
+	}
+}
+
+func (s *memoryVectorStore) Upsert(records []domain.VectorRecord) error {
+	for _, record := range records {
+		s.records[record.ID] = record
+	}
+	return nil
+}
+
+func (s *memoryVectorStore) Delete(collection string, ids []string) error {
+	for _, id := range ids {
+		delete(s.records, id)
+	}
+	return nil
+}
+
+func (s *memoryVectorStore) Query(collection string, vector []float64, k int) ([]domain.VectorMatch, error) {
+	var matches []domain.VectorMatch
+	for _, record := range s.records {
+		matches = append(matches, domain.VectorMatch{VectorRecord: record, Score: domain.CosineSimilarity(vector, record.Vector)})
+	}
+	sort.Slice(matches, func(i, j int) bool { return matches[i].Score > matches[j].Score })
+	if len(matches) > k {
+		matches = matches[:k]
+	}
+	return matches, nil
+}
+
+func (s *memoryVectorStore) ListDocuments(collection string) ([]*domain.IndexedDocument, error) {
+	var documents []*domain.IndexedDocument
+	for _, document := range s.documents {
+		copied := *document
+		documents = append(documents, &copied)
+	}
+	return documents, nil
+}
+
+func (s *memoryVectorStore) SaveDocument(document *domain.IndexedDocument) error {
+	copied := *document
+	s.documents[document.Path] = &copied
+	return nil
+}
+
+func (s *memoryVectorStore) DeleteDocument(collection, path string) error {
+	delete(s.documents, path)
+	return nil
+}
+
+func TestRAGUseCase(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	workspace := &memoryWorkspace{
+		files: map[string]string{
+			"notes/deploy.md": "Deploy with docker compose.\nThe gateway listens on port 8080.",
+			"todo.txt":        "Buy milk",
+		},
+		modified: map[string]time.Time{"notes/deploy.md": start, "todo.txt": start},
+	}
+	client := &keywordLLMClient{keywords: []string{"port", "gateway", "milk"}}
+	store := newMemoryVectorStore()
+	uc := usecase.NewRAGUseCase(client, store, store, workspace, usecase.NewProcessAIRequestUseCase(client, nil, nil, nil, nil, nil), time.Hour)
+
+	response, err := uc.Execute(context.Background(), &domain.RAGRequest{AIRequest: domain.AIRequest{Prompt: "Which port does the gateway use?"}, K: 1})
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+
+	wantCitations := []domain.Citation{{
+		Index:     1,
+		Path:      "notes/deploy.md",
+		StartLine: 1,
+		EndLine:   2,
+		Score:     response.Citations[0].Score,
+		Excerpt:   workspace.files["notes/deploy.md"],
+	}}
+	if !reflect.DeepEqual(response.Citations, wantCitations) {
+		t.Errorf("Citations = %+v, want %+v", response.Citations, wantCitations)
+	}
+	if response.Text != "answer [1]" {
+		t.Errorf("Text = %q", response.Text)
+	}
+	prompt := client.prompts[0]
+	if !strings.Contains(prompt, "[1] notes/deploy.md (lines 1-2)") || !strings.HasSuffix(prompt, "Question: Which port does the gateway use?") {
+		t.Errorf("prompt = %q", prompt)
+	}
+
+	tests := []struct {
+		name        string
+		change      func()
+		wantAdded   []string
+		wantUpdated []string
+		wantRemoved []string
+		wantReads   []string
+	}{
+		{
+			name:      "Nothing changed",
+			change:    func() {},
+			wantReads: nil,
+		},
+		{
+			name: "Touched without content change",
+			change: func() {
+				workspace.modified["todo.txt"] = start.Add(time.Minute)
+			},
+			wantReads: []string{"todo.txt"},
+		},
+		{
+			name: "Modified, added and deleted files",
+			change: func() {
+				workspace.files["todo.txt"] = "Buy oat milk"
+				workspace.modified["todo.txt"] = start.Add(2 * time.Minute)
+				workspace.files["new.txt"] = "The gateway retries twice."
+				delete(workspace.files, "notes/deploy.md")
+			},
+			wantAdded:   []string{"new.txt"},
+			wantUpdated: []string{"todo.txt"},
+			wantRemoved: []string{"notes/deploy.md"},
+			wantReads:   []string{"new.txt", "todo.txt"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.change()
+			workspace.reads = nil
+
+			report, err := uc.Reindex(context.Background())
+			if err != nil {
+				t.Fatalf("Reindex() error = %v", err)
+			}
+
+			if !reflect.DeepEqual(report.Added, tt.wantAdded) || !reflect.DeepEqual(report.Updated, tt.wantUpdated) || !reflect.DeepEqual(report.Removed, tt.wantRemoved) {
+				t.Errorf("Reindex() = %+v, want added %v, updated %v, removed %v", report, tt.wantAdded, tt.wantUpdated, tt.wantRemoved)
+			}
+			if !reflect.DeepEqual(workspace.reads, tt.wantReads) {
+				t.Errorf("reads = %v, want %v", workspace.reads, tt.wantReads)
+			}
+		})
+	}
+
+	var paths []string
+	for _, record := range store.records {
+		paths = append(paths, record.Metadata["path"].(string))
+	}
+	sort.Strings(paths)
+	if !reflect.DeepEqual(paths, []string{"new.txt", "todo.txt"}) {
+		t.Errorf("indexed chunks = %v, want chunks for new.txt and todo.txt", paths)
+	}
+}
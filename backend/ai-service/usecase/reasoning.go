@@ -0,0 +1,20 @@
+package usecase
+
+import (
+	"github.com/augment-local-manus-clone/backend/ai-service/domain"
+)
+
+// reasoningHandler wraps a stream handler so that reasoning is not relayed
+// when the request drops it; chunks carrying only reasoning are skipped
+func reasoningHandler(mode domain.ReasoningMode, handler domain.StreamHandler) domain.StreamHandler {
+	if mode != domain.ReasoningDrop {
+		return handler
+	}
+
+	return func(chunk *domain.AIStreamChunk) error {
+		if chunk.Text == "" {
+			return nil
+		}
+		return handler(&domain.AIStreamChunk{Text: chunk.Text})
+	}
+}
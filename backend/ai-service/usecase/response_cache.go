@@ -0,0 +1,281 @@
+package usecase
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/augment-local-manus-clone/backend/ai-service/domain"
+)
+
+// CacheTier is a named tier of the response cache
+type CacheTier struct {
+	Name  string
+	Store domain.CacheStore
+}
+
+// ResponseCache implements the LLMClient interface by serving deterministic
+// requests from a content-addressed cache in front of another client. Tiers
+// are checked in order, such as memory before disk, and a hit in a later tier
+// is copied into the earlier ones. Requests that are not deterministic go
+// straight to the wrapped client.
+type ResponseCache struct {
+	next  domain.LLMClient
+	tiers []CacheTier
+	ttl   time.Duration
+	now   func() time.Time
+
+	mu       sync.Mutex
+	stats    domain.CacheStats
+	tierHits []int64
+}
+
+// NewResponseCache creates a new ResponseCache in front of next. Entries
+// expire after ttl; a ttl of 0 keeps them until they are evicted.
+func NewResponseCache(next domain.LLMClient, ttl time.Duration, tiers ...CacheTier) (*ResponseCache, error) {
+	if len(tiers) == 0 {
+		return nil, errors.New("response cache needs at least one tier")
+	}
+
+	return &ResponseCache{
+		next:     next,
+		tiers:    tiers,
+		ttl:      ttl,
+		now:      time.Now,
+		tierHits: make([]int64, len(tiers)),
+	}, nil
+}
+
+// Process returns the cached response for a deterministic request, or
+// processes it with the wrapped client and caches the response
+func (c *ResponseCache) Process(ctx context.Context, request *domain.AIRequest) (*domain.AIResponse, error) {
+	key, ok := c.key("process", request, request.Deterministic(), request.NoCache)
+	if !ok {
+		return c.next.Process(ctx, request)
+	}
+
+	var cached domain.AIResponse
+	if c.lookup(key, request.NoCache, &cached) {
+		cached.Cached = true
+		return &cached, nil
+	}
+
+	response, err := c.next.Process(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+
+	c.store(key, response)
+	return response, nil
+}
+
+// ProcessStream replays a cached response as a single chunk, or streams the
+// request from the wrapped client and caches the complete response. Streamed
+// and non-streamed requests share cache entries.
+func (c *ResponseCache) ProcessStream(ctx context.Context, request *domain.AIRequest, handler domain.StreamHandler) (*domain.AIResponse, error) {
+	key, ok := c.key("process", request, request.Deterministic(), request.NoCache)
+	if !ok {
+		return c.next.ProcessStream(ctx, request, handler)
+	}
+
+	var cached domain.AIResponse
+	if c.lookup(key, request.NoCache, &cached) {
+		cached.Cached = true
+		if err := handler(&domain.AIStreamChunk{Text: cached.Text, Reasoning: cached.Reasoning}); err != nil {
+			return nil, err
+		}
+		return &cached, nil
+	}
+
+	response, err := c.next.ProcessStream(ctx, request, handler)
+	if err != nil {
+		return nil, err
+	}
+
+	c.store(key, response)
+	return response, nil
+}
+
+// Chat returns the cached reply for a deterministic conversation, or sends it
+// to the wrapped client and caches the reply
+func (c *ResponseCache) Chat(ctx context.Context, request *domain.ChatRequest) (*domain.ChatResponse, error) {
+	key, ok := c.key("chat", request, request.Deterministic(), request.NoCache)
+	if !ok {
+		return c.next.Chat(ctx, request)
+	}
+
+	var cached domain.ChatResponse
+	if c.lookup(key, request.NoCache, &cached) {
+		cached.Cached = true
+		return &cached, nil
+	}
+
+	response, err := c.next.Chat(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+
+	c.store(key, response)
+	return response, nil
+}
+
+// Embed passes the request to the wrapped client
+func (c *ResponseCache) Embed(ctx context.Context, request *domain.EmbedRequest) (*domain.EmbedResponse, error) {
+	return c.next.Embed(ctx, request)
+}
+
+// Stats returns the cache's hit rate and the size of each tier
+func (c *ResponseCache) Stats() domain.CacheStats {
+	c.mu.Lock()
+	stats := c.stats
+	tierHits := append([]int64{}, c.tierHits...)
+	c.mu.Unlock()
+
+	if stats.Lookups > 0 {
+		stats.HitRate = float64(stats.Hits) / float64(stats.Lookups)
+	}
+
+	stats.Tiers = make([]domain.CacheTierStats, len(c.tiers))
+	for i, tier := range c.tiers {
+		stats.Tiers[i] = domain.CacheTierStats{
+			Name:    tier.Name,
+			Entries: tier.Store.Len(),
+			Hits:    tierHits[i],
+		}
+	}
+
+	return stats
+}
+
+// Clear removes every cached response from every tier
+func (c *ResponseCache) Clear() error {
+	for _, tier := range c.tiers {
+		if err := tier.Store.Clear(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// key returns the cache key for a request, derived from every field that is
+// sent to the model, or false when the request must not be cached
+func (c *ResponseCache) key(kind string, request interface{}, deterministic, noCache bool) (string, bool) {
+	if !deterministic {
+		c.count(func(stats *domain.CacheStats) { stats.Uncacheable++ })
+		return "", false
+	}
+
+	// NoCache is not serialized, so bypassing requests share the key of the
+	// requests they refresh
+	data, err := json.Marshal(keyedRequest(request))
+	if err != nil {
+		c.count(func(stats *domain.CacheStats) { stats.Errors++ })
+		return "", false
+	}
+
+	if noCache {
+		c.count(func(stats *domain.CacheStats) { stats.Bypassed++ })
+	}
+
+	sum := sha256.Sum256(append([]byte(kind+"\n"), data...))
+	return hex.EncodeToString(sum[:]), true
+}
+
+// keyedRequest returns a copy of the request without the fields that do not
+// change the model's response: the timeout and the number of schema retries
+func keyedRequest(request interface{}) interface{} {
+	switch request := request.(type) {
+	case *domain.AIRequest:
+		keyed := *request
+		keyed.TimeoutSeconds = 0
+		keyed.SchemaRetries = nil
+		return &keyed
+	case *domain.ChatRequest:
+		keyed := *request
+		keyed.TimeoutSeconds = 0
+		keyed.SchemaRetries = nil
+		return &keyed
+	}
+	return request
+}
+
+// lookup decodes the first unexpired entry for key into out, copying it into
+// the tiers before the one it was found in
+func (c *ResponseCache) lookup(key string, bypass bool, out interface{}) bool {
+	if bypass {
+		return false
+	}
+
+	now := c.now()
+	for i, tier := range c.tiers {
+		entry, err := tier.Store.Get(key)
+		if err != nil {
+			c.count(func(stats *domain.CacheStats) { stats.Errors++ })
+			continue
+		}
+		if entry == nil {
+			continue
+		}
+		if entry.Expired(now) {
+			tier.Store.Delete(key)
+			continue
+		}
+
+		if err := json.Unmarshal(entry.Value, out); err != nil {
+			c.count(func(stats *domain.CacheStats) { stats.Errors++ })
+			continue
+		}
+
+		for _, earlier := range c.tiers[:i] {
+			if err := earlier.Store.Set(key, entry); err != nil {
+				c.count(func(stats *domain.CacheStats) { stats.Errors++ })
+			}
+		}
+
+		c.count(func(stats *domain.CacheStats) {
+			stats.Lookups++
+			stats.Hits++
+			c.tierHits[i]++
+		})
+		return true
+	}
+
+	c.count(func(stats *domain.CacheStats) {
+		stats.Lookups++
+		stats.Misses++
+	})
+	return false
+}
+
+// store writes a response to every tier
+func (c *ResponseCache) store(key string, response interface{}) {
+	data, err := json.Marshal(response)
+	if err != nil {
+		c.count(func(stats *domain.CacheStats) { stats.Errors++ })
+		return
+	}
+
+	entry := &domain.CacheEntry{Value: data}
+	if c.ttl > 0 {
+		entry.ExpiresAt = c.now().Add(c.ttl)
+	}
+
+	for _, tier := range c.tiers {
+		if err := tier.Store.Set(key, entry); err != nil {
+			c.count(func(stats *domain.CacheStats) { stats.Errors++ })
+		}
+	}
+
+	c.count(func(stats *domain.CacheStats) { stats.Stores++ })
+}
+
+// count updates the statistics under the lock
+func (c *ResponseCache) count(update func(stats *domain.CacheStats)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	update(&c.stats)
+}
@@ -0,0 +1,249 @@
+package usecase_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/augment-local-manus-clone/backend/ai-service/domain"
+	"github.com/augment-local-manus-clone/backend/ai-service/infrastructure/cache"
+	"github.com/augment-local-manus-clone/backend/ai-service/usecase"
+)
+
+// newTestCache builds a response cache with two in-memory tiers
+func newTestCache(t *testing.T, client domain.LLMClient, ttl time.Duration) (*usecase.ResponseCache, domain.CacheStore) {
+	t.Helper()
+
+	first, _ := cache.NewLRUCache(8)
+	second, _ := cache.NewLRUCache(8)
+	responseCache, err := usecase.NewResponseCache(client, ttl,
+		usecase.CacheTier{Name: "memory", Store: first},
+		usecase.CacheTier{Name: "disk", Store: second},
+	)
+	if err != nil {
+		t.Fatalf("NewResponseCache() error = %v", err)
+	}
+	return responseCache, first
+}
+
+func TestResponseCache(t *testing.T) {
+	seed := 42
+	warm := 0.7
+	retries := 4
+	reply := func(text string) scriptedReply {
+		return scriptedReply{message: domain.ChatMessage{Role: domain.ChatRoleAssistant, Content: text}}
+	}
+
+	tests := []struct {
+		name         string
+		ttl          time.Duration
+		requests     []domain.AIRequest
+		wantTexts    []string
+		wantCached   []bool
+		wantRequests int
+		wantStats    domain.CacheStats
+	}{
+		{
+			name: "Repeated deterministic request is served from the cache",
+			ttl:  time.Hour,
+			requests: []domain.AIRequest{
+				{Prompt: "plan", Seed: &seed},
+				{Prompt: "plan", Seed: &seed},
+			},
+			wantTexts:    []string{"first", "first"},
+			wantCached:   []bool{false, true},
+			wantRequests: 1,
+			wantStats:    domain.CacheStats{Lookups: 2, Hits: 1, Misses: 1, HitRate: 0.5, Stores: 1},
+		},
+		{
+			name: "Requests differing in options have different keys",
+			ttl:  time.Hour,
+			requests: []domain.AIRequest{
+				{Prompt: "plan", Seed: &seed},
+				{Prompt: "plan", Seed: &seed, MaxTokens: 10},
+			},
+			wantTexts:    []string{"first", "second"},
+			wantCached:   []bool{false, false},
+			wantRequests: 2,
+			wantStats:    domain.CacheStats{Lookups: 2, Misses: 2, Stores: 2},
+		},
+		{
+			name: "Timeout is not part of the key",
+			ttl:  time.Hour,
+			requests: []domain.AIRequest{
+				{Prompt: "plan", Seed: &seed, TimeoutSeconds: 30},
+				{Prompt: "plan", Seed: &seed},
+			},
+			wantTexts:    []string{"first", "first"},
+			wantCached:   []bool{false, true},
+			wantRequests: 1,
+			wantStats:    domain.CacheStats{Lookups: 2, Hits: 1, Misses: 1, HitRate: 0.5, Stores: 1},
+		},
+		{
+			name: "Schema retries are not part of the key",
+			ttl:  time.Hour,
+			requests: []domain.AIRequest{
+				{Prompt: "plan", Seed: &seed, SchemaRetries: &retries},
+				{Prompt: "plan", Seed: &seed},
+			},
+			wantTexts:    []string{"first", "first"},
+			wantCached:   []bool{false, true},
+			wantRequests: 1,
+			wantStats:    domain.CacheStats{Lookups: 2, Hits: 1, Misses: 1, HitRate: 0.5, Stores: 1},
+		},
+		{
+			name: "Sampled requests are not cached",
+			ttl:  time.Hour,
+			requests: []domain.AIRequest{
+				{Prompt: "plan", Temperature: &warm},
+				{Prompt: "plan", Temperature: &warm},
+			},
+			wantTexts:    []string{"first", "second"},
+			wantCached:   []bool{false, false},
+			wantRequests: 2,
+			wantStats:    domain.CacheStats{Uncacheable: 2},
+		},
+		{
+			name: "No-cache bypasses the lookup and refreshes the entry",
+			ttl:  time.Hour,
+			requests: []domain.AIRequest{
+				{Prompt: "plan", Seed: &seed},
+				{Prompt: "plan", Seed: &seed, NoCache: true},
+				{Prompt: "plan", Seed: &seed},
+			},
+			wantTexts:    []string{"first", "second", "second"},
+			wantCached:   []bool{false, false, true},
+			wantRequests: 2,
+			wantStats:    domain.CacheStats{Lookups: 2, Hits: 1, Misses: 1, HitRate: 0.5, Bypassed: 1, Stores: 2},
+		},
+		{
+			name: "Expired entries are not served",
+			ttl:  time.Nanosecond,
+			requests: []domain.AIRequest{
+				{Prompt: "plan", Seed: &seed},
+				{Prompt: "plan", Seed: &seed},
+			},
+			wantTexts:    []string{"first", "second"},
+			wantCached:   []bool{false, false},
+			wantRequests: 2,
+			wantStats:    domain.CacheStats{Lookups: 2, Misses: 2, Stores: 2},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &scriptedLLMClient{replies: []scriptedReply{reply("first"), reply("second"), reply("third")}}
+			responseCache, _ := newTestCache(t, client, tt.ttl)
+
+			for i := range tt.requests {
+				if tt.ttl < time.Millisecond {
+					time.Sleep(time.Millisecond)
+				}
+
+				response, err := responseCache.Process(context.Background(), &tt.requests[i])
+				if err != nil {
+					t.Fatalf("Process() error = %v", err)
+				}
+				if response.Text != tt.wantTexts[i] || response.Cached != tt.wantCached[i] {
+					t.Errorf("request %d: text = %q, cached = %v, want %q, %v", i, response.Text, response.Cached, tt.wantTexts[i], tt.wantCached[i])
+				}
+			}
+
+			if len(client.requests) != tt.wantRequests {
+				t.Errorf("upstream requests = %d, want %d", len(client.requests), tt.wantRequests)
+			}
+
+			stats := responseCache.Stats()
+			stats.Tiers = nil
+			if !reflect.DeepEqual(stats, tt.wantStats) {
+				t.Errorf("Stats() = %+v, want %+v", stats, tt.wantStats)
+			}
+		})
+	}
+}
+
+func TestResponseCacheTiers(t *testing.T) {
+	seed := 7
+	client := &scriptedLLMClient{replies: []scriptedReply{
+		{message: domain.ChatMessage{Role: domain.ChatRoleAssistant, Content: "answer"}},
+	}}
+	responseCache, first := newTestCache(t, client, time.Hour)
+
+	request := domain.AIRequest{Prompt: "plan", Seed: &seed}
+	if _, err := responseCache.Process(context.Background(), &request); err != nil {
+		t.Fatalf("Process() error = %v", err)
+	}
+
+	// Losing the memory tier falls back to the disk tier, which refills it
+	first.Clear()
+
+	var chunks []string
+	response, err := responseCache.ProcessStream(context.Background(), &request, func(chunk *domain.AIStreamChunk) error {
+		chunks = append(chunks, chunk.Text)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ProcessStream() error = %v", err)
+	}
+
+	if !response.Cached || len(chunks) != 1 || chunks[0] != "answer" {
+		t.Errorf("ProcessStream() = %+v with chunks %v, want the cached answer replayed", response, chunks)
+	}
+
+	stats := responseCache.Stats()
+	if stats.Tiers[0].Hits != 0 || stats.Tiers[1].Hits != 1 || stats.Tiers[0].Entries != 1 {
+		t.Errorf("Tiers = %+v, want a disk hit copied into memory", stats.Tiers)
+	}
+
+	if err := responseCache.Clear(); err != nil || responseCache.Stats().Tiers[1].Entries != 0 {
+		t.Errorf("Clear() error = %v, tiers = %+v", err, responseCache.Stats().Tiers)
+	}
+}
+
+func TestResponseCacheTopLevelTemperature(t *testing.T) {
+	greedy := 0.0
+	client := &scriptedLLMClient{replies: []scriptedReply{
+		{message: domain.ChatMessage{Role: domain.ChatRoleAssistant, Content: "first"}},
+		{message: domain.ChatMessage{Role: domain.ChatRoleAssistant, Content: "second"}},
+		{message: domain.ChatMessage{Role: domain.ChatRoleAssistant, Content: "third"}},
+	}}
+	responseCache, _ := newTestCache(t, client, time.Hour)
+
+	// An explicit temperature of 0 is kept rather than replaced by the default
+	process := usecase.NewProcessAIRequestUseCase(responseCache, nil, nil, nil, nil, nil)
+	for i, want := range []bool{false, true} {
+		response, err := process.Execute(context.Background(), &domain.AIRequest{Prompt: "plan", Temperature: &greedy})
+		if err != nil {
+			t.Fatalf("Execute() error = %v", err)
+		}
+		if response.Text != "first" || response.Cached != want {
+			t.Errorf("request %d: text = %q, cached = %v, want %q, %v", i, response.Text, response.Cached, "first", want)
+		}
+	}
+
+	chat := usecase.NewChatUseCase(responseCache, nil, nil, nil)
+	for i, want := range []bool{false, true} {
+		response, err := chat.Execute(context.Background(), &domain.ChatRequest{
+			Messages:    []domain.ChatMessage{{Role: domain.ChatRoleUser, Content: "plan"}},
+			Temperature: &greedy,
+		})
+		if err != nil {
+			t.Fatalf("Execute() error = %v", err)
+		}
+		if response.Message.Content != "second" || response.Cached != want {
+			t.Errorf("chat %d: content = %q, cached = %v, want %q, %v", i, response.Message.Content, response.Cached, "second", want)
+		}
+	}
+
+	// Leaving the temperature out still samples at the default
+	if _, err := process.Execute(context.Background(), &domain.AIRequest{Prompt: "plan"}); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if got := client.requests[len(client.requests)-1].Temperature; got == nil || *got != 0.7 {
+		t.Errorf("Temperature = %v, want the 0.7 default", got)
+	}
+	if len(client.requests) != 3 {
+		t.Errorf("upstream requests = %d, want 3", len(client.requests))
+	}
+}
@@ -0,0 +1,127 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/augment-local-manus-clone/backend/ai-service/domain"
+)
+
+// defaultSchemaRetries is how many times a model is re-prompted when its
+// reply does not match the requested response schema, unless the request
+// sets schema_retries
+const defaultSchemaRetries = 2
+
+// chatWithSchema sends a chat request that must produce JSON matching its
+// response schema, re-prompting the model with the validation errors until
+// the reply matches or the retries are exhausted
+func chatWithSchema(ctx context.Context, llmClient domain.LLMClient, request *domain.ChatRequest) (*domain.ChatResponse, error) {
+	attemptReq := *request
+	attemptReq.Messages = append([]domain.ChatMessage(nil), request.Messages...)
+
+	retries := defaultSchemaRetries
+	if request.SchemaRetries != nil {
+		retries = *request.SchemaRetries
+	}
+
+	result := &domain.ChatResponse{}
+	var lastErr error
+
+	for attempt := 0; attempt <= retries; attempt++ {
+		response, err := llmClient.Chat(ctx, &attemptReq)
+		if err != nil {
+			return nil, err
+		}
+
+		result.TokensUsed += response.TokensUsed
+		result.Usage = domain.AddUsage(result.Usage, response.Usage)
+		result.Elapsed += response.Elapsed
+		result.Model = response.Model
+		result.Reasoning = response.Reasoning
+
+		value, err := parseJSONReply(response.Message.Content)
+		if err == nil {
+			err = request.ResponseSchema.Validate(value)
+		}
+		if err == nil {
+			result.Message = response.Message
+			result.JSON = value
+			return result, nil
+		}
+
+		lastErr = err
+		attemptReq.Messages = append(attemptReq.Messages,
+			domain.ChatMessage{Role: domain.ChatRoleAssistant, Content: response.Message.Content},
+			domain.ChatMessage{Role: domain.ChatRoleUser, Content: schemaCorrectionPrompt(request.ResponseSchema, err)},
+		)
+	}
+
+	return nil, fmt.Errorf("model did not produce JSON matching the response schema after %d attempts: %w", retries+1, lastErr)
+}
+
+// schemaCorrectionPrompt asks the model to fix a reply that failed validation
+func schemaCorrectionPrompt(schema domain.JSONSchema, err error) string {
+	var b strings.Builder
+
+	b.WriteString("Your previous reply did not match the required JSON schema.\n")
+
+	var schemaErr *domain.SchemaError
+	if errors.As(err, &schemaErr) {
+		b.WriteString("Problems:\n")
+		for _, violation := range schemaErr.Violations {
+			fmt.Fprintf(&b, "- %s\n", violation)
+		}
+	} else {
+		fmt.Fprintf(&b, "Problem: %v\n", err)
+	}
+
+	encoded, _ := json.Marshal(schema)
+	fmt.Fprintf(&b, "Schema: %s\n", encoded)
+	b.WriteString("Reply again with only JSON that matches the schema.")
+
+	return b.String()
+}
+
+// parseJSONReply decodes the JSON value in a model reply
+func parseJSONReply(text string) (interface{}, error) {
+	raw, err := extractJSON(text)
+	if err != nil {
+		return nil, err
+	}
+
+	var value interface{}
+	if err := json.Unmarshal([]byte(raw), &value); err != nil {
+		return nil, fmt.Errorf("reply is not valid JSON: %w", err)
+	}
+
+	return value, nil
+}
+
+// extractJSON returns the JSON object or array in a model reply, tolerating
+// surrounding prose and code fences
+func extractJSON(text string) (string, error) {
+	trimmed := strings.TrimSpace(text)
+	if json.Valid([]byte(trimmed)) {
+		return trimmed, nil
+	}
+
+	start := strings.IndexAny(trimmed, "{[")
+	if start < 0 {
+		return "", errors.New("reply does not contain JSON")
+	}
+
+	closing := "}"
+	if trimmed[start] == '[' {
+		closing = "]"
+	}
+
+	end := strings.LastIndex(trimmed, closing)
+	if end < start {
+		return "", errors.New("reply does not contain JSON")
+	}
+
+	return trimmed[start : end+1], nil
+}
@@ -0,0 +1,127 @@
+package usecase_test
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/augment-local-manus-clone/backend/ai-service/domain"
+	"github.com/augment-local-manus-clone/backend/ai-service/usecase"
+)
+
+func TestProcessAIRequestResponseSchema(t *testing.T) {
+	schema := domain.JSONSchema{
+		"type":     "object",
+		"required": []interface{}{"steps"},
+		"properties": map[string]interface{}{
+			"steps": map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}},
+		},
+	}
+	reply := func(content string) scriptedReply {
+		return scriptedReply{message: domain.ChatMessage{Role: domain.ChatRoleAssistant, Content: content}}
+	}
+	retries := func(n int) *int { return &n }
+
+	tests := []struct {
+		name         string
+		retries      *int
+		replies      []scriptedReply
+		wantJSON     interface{}
+		wantErr      bool
+		wantRequests int
+	}{
+		{
+			name:         "Valid on first attempt",
+			replies:      []scriptedReply{reply(`{"steps": ["search", "summarize"]}`)},
+			wantJSON:     map[string]interface{}{"steps": []interface{}{"search", "summarize"}},
+			wantRequests: 1,
+		},
+		{
+			name: "Corrected after validation errors",
+			replies: []scriptedReply{
+				reply(`{"plan": "search"}`),
+				reply("Here it is:\n```json\n{\"steps\": [\"search\"]}\n```"),
+			},
+			wantJSON:     map[string]interface{}{"steps": []interface{}{"search"}},
+			wantRequests: 2,
+		},
+		{
+			name: "Retries exhausted",
+			replies: []scriptedReply{
+				reply(`not json`),
+				reply(`{"steps": "search"}`),
+				reply(`{"steps": [1]}`),
+			},
+			wantErr:      true,
+			wantRequests: 3,
+		},
+		{
+			name:    "More retries requested",
+			retries: retries(3),
+			replies: []scriptedReply{
+				reply(`not json`),
+				reply(`{"steps": "search"}`),
+				reply(`{"steps": [1]}`),
+				reply(`{"steps": ["search"]}`),
+			},
+			wantJSON:     map[string]interface{}{"steps": []interface{}{"search"}},
+			wantRequests: 4,
+		},
+		{
+			name:         "Retries disabled",
+			retries:      retries(0),
+			replies:      []scriptedReply{reply(`not json`), reply(`{"steps": ["search"]}`)},
+			wantErr:      true,
+			wantRequests: 1,
+		},
+		{
+			name:         "Too many retries",
+			retries:      retries(domain.MaxSchemaRetries + 1),
+			replies:      []scriptedReply{reply(`{"steps": ["search"]}`)},
+			wantErr:      true,
+			wantRequests: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &scriptedLLMClient{replies: tt.replies}
+			uc := usecase.NewProcessAIRequestUseCase(client, nil, nil, nil, nil, nil)
+
+			response, err := uc.Execute(context.Background(), &domain.AIRequest{Prompt: "Plan a web search", ResponseSchema: schema, SchemaRetries: tt.retries})
+
+			if len(client.requests) != tt.wantRequests {
+				t.Errorf("requests = %d, want %d", len(client.requests), tt.wantRequests)
+			}
+
+			for _, request := range client.requests {
+				if !reflect.DeepEqual(request.ResponseSchema, schema) {
+					t.Errorf("request ResponseSchema = %v, want %v", request.ResponseSchema, schema)
+				}
+			}
+
+			if len(client.requests) > 1 {
+				correction := client.requests[1].Messages[len(client.requests[1].Messages)-1]
+				if correction.Role != domain.ChatRoleUser || !strings.Contains(correction.Content, "did not match") {
+					t.Errorf("correction message = %+v, want validation feedback", correction)
+				}
+			}
+
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Execute() error = nil, wantErr %v", tt.wantErr)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Execute() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if !reflect.DeepEqual(response.JSON, tt.wantJSON) {
+				t.Errorf("JSON = %v, want %v", response.JSON, tt.wantJSON)
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package usecase
+
+import (
+	"strings"
+)
+
+// textChunk is a piece of a document with the line range it was taken from
+type textChunk struct {
+	Text      string
+	StartLine int
+	EndLine   int
+}
+
+// chunkLine is a line, or a piece of an overlong line, with its 1-based line number
+type chunkLine struct {
+	text string
+	line int
+}
+
+// chunkText splits text into chunks of whole lines of at most maxChars
+// characters, where consecutive chunks repeat up to overlapChars characters
+// of trailing lines for context. Lines longer than maxChars are split.
+// Chunks containing only whitespace are dropped.
+func chunkText(text string, maxChars, overlapChars int) []textChunk {
+	var lines []chunkLine
+	for i, line := range strings.Split(text, "\n") {
+		for len(line) > maxChars {
+			lines = append(lines, chunkLine{text: line[:maxChars], line: i + 1})
+			line = line[maxChars:]
+		}
+		lines = append(lines, chunkLine{text: line, line: i + 1})
+	}
+
+	var chunks []textChunk
+	for start := 0; start < len(lines); {
+		// Take lines until the next one would exceed the chunk size
+		end := start
+		size := 0
+		for end < len(lines) && (end == start || size+len(lines[end].text)+1 <= maxChars) {
+			size += len(lines[end].text) + 1
+			end++
+		}
+
+		parts := make([]string, 0, end-start)
+		for _, line := range lines[start:end] {
+			parts = append(parts, line.text)
+		}
+		chunk := strings.Join(parts, "\n")
+		if strings.TrimSpace(chunk) != "" {
+			chunks = append(chunks, textChunk{
+				Text:      chunk,
+				StartLine: lines[start].line,
+				EndLine:   lines[end-1].line,
+			})
+		}
+
+		if end == len(lines) {
+			break
+		}
+
+		// Start the next chunk far enough back to repeat the overlap, while
+		// always making progress
+		next := end
+		overlap := 0
+		for next-1 > start && overlap+len(lines[next-1].text)+1 <= overlapChars {
+			next--
+			overlap += len(lines[next].text) + 1
+		}
+		start = next
+	}
+
+	return chunks
+}
@@ -0,0 +1,73 @@
+package usecase
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestChunkText(t *testing.T) {
+	tests := []struct {
+		name       string
+		text       string
+		maxChars   int
+		overlap    int
+		wantChunks []textChunk
+	}{
+		{
+			name:       "Fits in one chunk",
+			text:       "one\ntwo",
+			maxChars:   100,
+			wantChunks: []textChunk{{Text: "one\ntwo", StartLine: 1, EndLine: 2}},
+		},
+		{
+			name:     "Splits on line boundaries",
+			text:     "aaaa\nbbbb\ncccc",
+			maxChars: 10,
+			wantChunks: []textChunk{
+				{Text: "aaaa\nbbbb", StartLine: 1, EndLine: 2},
+				{Text: "cccc", StartLine: 3, EndLine: 3},
+			},
+		},
+		{
+			name:     "Overlapping lines",
+			text:     "aaaa\nbbbb\ncccc\ndddd",
+			maxChars: 10,
+			overlap:  5,
+			wantChunks: []textChunk{
+				{Text: "aaaa\nbbbb", StartLine: 1, EndLine: 2},
+				{Text: "bbbb\ncccc", StartLine: 2, EndLine: 3},
+				{Text: "cccc\ndddd", StartLine: 3, EndLine: 4},
+			},
+		},
+		{
+			name:     "Splits overlong lines",
+			text:     strings.Repeat("x", 12),
+			maxChars: 5,
+			wantChunks: []textChunk{
+				{Text: "xxxxx", StartLine: 1, EndLine: 1},
+				{Text: "xxxxx", StartLine: 1, EndLine: 1},
+				{Text: "xx", StartLine: 1, EndLine: 1},
+			},
+		},
+		{
+			name:       "Drops blank chunks",
+			text:       "    \n    \nword",
+			maxChars:   5,
+			wantChunks: []textChunk{{Text: "word", StartLine: 3, EndLine: 3}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chunks := chunkText(tt.text, tt.maxChars, tt.overlap)
+			if len(chunks) != len(tt.wantChunks) {
+				t.Fatalf("chunkText() = %+v, want %+v", chunks, tt.wantChunks)
+			}
+			for i := range chunks {
+				if chunks[i] != tt.wantChunks[i] {
+					t.Errorf("chunk %d = %+v, want %+v", i, chunks[i], tt.wantChunks[i])
+				}
+			}
+		})
+	}
+}
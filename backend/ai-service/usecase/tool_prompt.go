@@ -0,0 +1,161 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/augment-local-manus-clone/backend/ai-service/domain"
+)
+
+// maxToolPromptAttempts bounds how many times a model is asked for a valid
+// reply when tools are offered through the prompt
+const maxToolPromptAttempts = 3
+
+// toolPromptReply is the JSON object models are asked to reply with when
+// tools are offered through the prompt
+type toolPromptReply struct {
+	ToolCalls []domain.ToolCall `json:"tool_calls,omitempty"`
+	Content   *string           `json:"content,omitempty"`
+}
+
+// chatWithPromptedTools offers the tools to the model through a system
+// prompt, then parses and validates the JSON reply, asking the model to
+// correct itself when the reply is invalid
+func (uc *ChatUseCase) chatWithPromptedTools(ctx context.Context, request *domain.ChatRequest) (*domain.ChatResponse, error) {
+	promptReq := *request
+	promptReq.Tools = nil
+	promptReq.ToolMode = ""
+	promptReq.Messages = append(
+		[]domain.ChatMessage{{Role: domain.ChatRoleSystem, Content: buildToolPrompt(request.Tools)}},
+		flattenToolMessages(request.Messages)...,
+	)
+
+	result := &domain.ChatResponse{}
+	var lastErr error
+
+	for attempt := 0; attempt < maxToolPromptAttempts; attempt++ {
+		response, err := uc.llmClient.Chat(ctx, &promptReq)
+		if err != nil {
+			return nil, err
+		}
+
+		result.TokensUsed += response.TokensUsed
+		result.Usage = domain.AddUsage(result.Usage, response.Usage)
+		result.Elapsed += response.Elapsed
+		result.Model = response.Model
+		result.Reasoning = response.Reasoning
+
+		reply, err := parseToolPromptReply(response.Message.Content)
+		if err == nil {
+			err = domain.ValidateToolCalls(request.Tools, reply.ToolCalls)
+		}
+		if err == nil {
+			result.Message = domain.ChatMessage{
+				Role:      domain.ChatRoleAssistant,
+				ToolCalls: reply.ToolCalls,
+			}
+			if reply.Content != nil {
+				result.Message.Content = *reply.Content
+			}
+			return result, nil
+		}
+
+		lastErr = err
+		promptReq.Messages = append(promptReq.Messages,
+			domain.ChatMessage{Role: domain.ChatRoleAssistant, Content: response.Message.Content},
+			domain.ChatMessage{
+				Role: domain.ChatRoleUser,
+				Content: fmt.Sprintf(
+					"Your previous reply was invalid: %v\nReply again with only the JSON object described in the instructions.",
+					err,
+				),
+			},
+		)
+	}
+
+	return nil, fmt.Errorf("model did not produce a valid tool reply after %d attempts: %w", maxToolPromptAttempts, lastErr)
+}
+
+// buildToolPrompt describes the tools and the expected reply format
+func buildToolPrompt(tools []domain.ToolDefinition) string {
+	var b strings.Builder
+
+	b.WriteString("You can call the following tools. Each tool's parameters are described by a JSON schema.\n\n")
+	for _, tool := range tools {
+		parameters := []byte("{}")
+		if tool.Parameters != nil {
+			parameters, _ = json.Marshal(tool.Parameters)
+		}
+		fmt.Fprintf(&b, "- %s: %s\n  parameters: %s\n", tool.Name, tool.Description, parameters)
+	}
+
+	b.WriteString("\nReply with a single JSON object and nothing else.\n")
+	b.WriteString("To call one or more tools, reply with:\n")
+	b.WriteString(`{"tool_calls": [{"name": "<tool name>", "arguments": {<arguments matching the tool's parameters>}}]}` + "\n")
+	b.WriteString("To answer without calling a tool, reply with:\n")
+	b.WriteString(`{"content": "<your answer>"}`)
+
+	return b.String()
+}
+
+// flattenToolMessages rewrites tool calls and tool results as plain messages
+// for models that do not understand them natively
+func flattenToolMessages(messages []domain.ChatMessage) []domain.ChatMessage {
+	flattened := make([]domain.ChatMessage, 0, len(messages))
+
+	for _, message := range messages {
+		switch {
+		case message.Role == domain.ChatRoleAssistant && len(message.ToolCalls) > 0:
+			reply := toolPromptReply{ToolCalls: message.ToolCalls}
+			if message.Content != "" {
+				reply.Content = &message.Content
+			}
+			content, _ := json.Marshal(reply)
+			flattened = append(flattened, domain.ChatMessage{Role: domain.ChatRoleAssistant, Content: string(content)})
+
+		case message.Role == domain.ChatRoleTool:
+			name := message.ToolName
+			if name == "" {
+				name = "tool"
+			}
+			flattened = append(flattened, domain.ChatMessage{
+				Role:    domain.ChatRoleUser,
+				Content: fmt.Sprintf("Result of %s:\n%s", name, message.Content),
+			})
+
+		default:
+			flattened = append(flattened, message)
+		}
+	}
+
+	return flattened
+}
+
+// parseToolPromptReply extracts the JSON reply object from the model's text,
+// tolerating surrounding prose and code fences
+func parseToolPromptReply(text string) (*toolPromptReply, error) {
+	raw, err := extractJSON(text)
+	if err != nil {
+		return nil, err
+	}
+
+	var reply toolPromptReply
+	if err := json.Unmarshal([]byte(raw), &reply); err != nil {
+		return nil, fmt.Errorf("reply is not valid JSON: %w", err)
+	}
+
+	if len(reply.ToolCalls) == 0 && reply.Content == nil {
+		return nil, errors.New(`reply must contain either "tool_calls" or "content"`)
+	}
+
+	for i := range reply.ToolCalls {
+		if reply.ToolCalls[i].Arguments == nil {
+			reply.ToolCalls[i].Arguments = map[string]interface{}{}
+		}
+	}
+
+	return &reply, nil
+}
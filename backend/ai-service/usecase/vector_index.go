@@ -0,0 +1,189 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/augment-local-manus-clone/backend/ai-service/domain"
+)
+
+const (
+	// defaultQueryLimit is the number of matches returned when a query does not set k
+	defaultQueryLimit = 5
+
+	// maxQueryLimit caps the number of matches a single query can return
+	maxQueryLimit = 100
+)
+
+// VectorDocument is a document to index; documents without a vector are
+// embedded from their text
+type VectorDocument struct {
+	ID       string                 `json:"id"`
+	Text     string                 `json:"text"`
+	Vector   []float64              `json:"vector,omitempty"`
+	Metadata map[string]interface{} `json:"metadata,omitempty"`
+}
+
+// UpsertVectorsInput represents the input for indexing documents into a collection
+type UpsertVectorsInput struct {
+	Collection string           `json:"-"`
+	Documents  []VectorDocument `json:"documents"`
+
+	// Provider and Model select the embedding model for documents without a vector
+	Provider string `json:"provider,omitempty"`
+	Model    string `json:"model,omitempty"`
+}
+
+// DeleteVectorsInput represents the input for removing documents from a collection
+type DeleteVectorsInput struct {
+	Collection string   `json:"-"`
+	IDs        []string `json:"ids"`
+}
+
+// QueryVectorsInput represents the input for a similarity query; either
+// Text, which is embedded, or Vector must be set
+type QueryVectorsInput struct {
+	Collection string    `json:"-"`
+	Text       string    `json:"text,omitempty"`
+	Vector     []float64 `json:"vector,omitempty"`
+	K          int       `json:"k,omitempty"`
+
+	// Provider and Model select the embedding model for Text; they must match
+	// the model the collection was indexed with
+	Provider string `json:"provider,omitempty"`
+	Model    string `json:"model,omitempty"`
+}
+
+// VectorIndexUseCase handles indexing and searching embedded documents
+type VectorIndexUseCase struct {
+	llmClient domain.LLMClient
+	store     domain.VectorStore
+}
+
+// NewVectorIndexUseCase creates a new instance of VectorIndexUseCase
+func NewVectorIndexUseCase(llmClient domain.LLMClient, store domain.VectorStore) *VectorIndexUseCase {
+	return &VectorIndexUseCase{
+		llmClient: llmClient,
+		store:     store,
+	}
+}
+
+// Upsert embeds documents that have no vector and stores them in the
+// collection, replacing documents with the same ID. It returns the number of
+// documents stored.
+func (uc *VectorIndexUseCase) Upsert(ctx context.Context, input UpsertVectorsInput) (int, error) {
+	if input.Collection == "" {
+		return 0, errors.New("collection cannot be empty")
+	}
+
+	if len(input.Documents) == 0 {
+		return 0, errors.New("documents cannot be empty")
+	}
+
+	// Embed all documents missing a vector in a single request
+	var texts []string
+	var pending []int
+	for i, document := range input.Documents {
+		if document.ID == "" {
+			return 0, fmt.Errorf("document %d: id cannot be empty", i)
+		}
+		if len(document.Vector) > 0 {
+			continue
+		}
+		if document.Text == "" {
+			return 0, fmt.Errorf("document %q: text or vector is required", document.ID)
+		}
+		texts = append(texts, document.Text)
+		pending = append(pending, i)
+	}
+
+	vectors := make([][]float64, len(input.Documents))
+	for i, document := range input.Documents {
+		vectors[i] = document.Vector
+	}
+
+	if len(texts) > 0 {
+		response, err := uc.llmClient.Embed(ctx, &domain.EmbedRequest{
+			Provider: input.Provider,
+			Model:    input.Model,
+			Input:    texts,
+		})
+		if err != nil {
+			return 0, fmt.Errorf("failed to embed documents: %w", err)
+		}
+		if len(response.Embeddings) != len(texts) {
+			return 0, fmt.Errorf("expected %d embeddings, got %d", len(texts), len(response.Embeddings))
+		}
+		for j, i := range pending {
+			vectors[i] = response.Embeddings[j]
+		}
+	}
+
+	records := make([]domain.VectorRecord, 0, len(input.Documents))
+	for i, document := range input.Documents {
+		records = append(records, domain.VectorRecord{
+			ID:         document.ID,
+			Collection: input.Collection,
+			Vector:     vectors[i],
+			Text:       document.Text,
+			Metadata:   document.Metadata,
+		})
+	}
+
+	if err := uc.store.Upsert(records); err != nil {
+		return 0, err
+	}
+
+	return len(records), nil
+}
+
+// Delete removes documents from a collection
+func (uc *VectorIndexUseCase) Delete(input DeleteVectorsInput) error {
+	if input.Collection == "" {
+		return errors.New("collection cannot be empty")
+	}
+
+	if len(input.IDs) == 0 {
+		return errors.New("ids cannot be empty")
+	}
+
+	return uc.store.Delete(input.Collection, input.IDs)
+}
+
+// Query returns the documents in a collection most similar to the query text or vector
+func (uc *VectorIndexUseCase) Query(ctx context.Context, input QueryVectorsInput) ([]domain.VectorMatch, error) {
+	if input.Collection == "" {
+		return nil, errors.New("collection cannot be empty")
+	}
+
+	if (input.Text == "") == (len(input.Vector) == 0) {
+		return nil, errors.New("exactly one of text or vector is required")
+	}
+
+	if input.K < 0 || input.K > maxQueryLimit {
+		return nil, fmt.Errorf("k must be between 1 and %d", maxQueryLimit)
+	}
+
+	if input.K == 0 {
+		input.K = defaultQueryLimit
+	}
+
+	vector := input.Vector
+	if input.Text != "" {
+		response, err := uc.llmClient.Embed(ctx, &domain.EmbedRequest{
+			Provider: input.Provider,
+			Model:    input.Model,
+			Input:    []string{input.Text},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to embed query: %w", err)
+		}
+		if len(response.Embeddings) != 1 {
+			return nil, fmt.Errorf("expected 1 embedding, got %d", len(response.Embeddings))
+		}
+		vector = response.Embeddings[0]
+	}
+
+	return uc.store.Query(input.Collection, vector, input.K)
+}
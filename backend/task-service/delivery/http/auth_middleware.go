@@ -0,0 +1,34 @@
+package http
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// UserIDHeader carries the authenticated user's ID, set by the gateway in front of the service
+	UserIDHeader = "X-User-ID"
+
+	userIDContextKey = "userID"
+)
+
+// RequireUser rejects requests that do not carry an authenticated user ID
+func RequireUser() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := strings.TrimSpace(c.GetHeader(UserIDHeader))
+		if userID == "" {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "missing " + UserIDHeader + " header"})
+			return
+		}
+
+		c.Set(userIDContextKey, userID)
+		c.Next()
+	}
+}
+
+// CurrentUserID returns the authenticated user ID set by RequireUser
+func CurrentUserID(c *gin.Context) string {
+	return c.GetString(userIDContextKey)
+}
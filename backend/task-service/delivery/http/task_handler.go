@@ -1,19 +1,25 @@
 package http
 
 import (
+	"errors"
 	"net/http"
 
+	"github.com/augment-local-manus-clone/backend/task-service/domain"
 	"github.com/augment-local-manus-clone/backend/task-service/usecase"
 	"github.com/gin-gonic/gin"
 )
 
 // TaskHandler handles HTTP requests for tasks
 type TaskHandler struct {
-	createTaskUseCase *usecase.CreateTaskUseCase
-	getTaskUseCase    *usecase.GetTaskUseCase
-	listTasksUseCase  *usecase.ListTasksUseCase
-	updateTaskUseCase *usecase.UpdateTaskUseCase
-	deleteTaskUseCase *usecase.DeleteTaskUseCase
+	createTaskUseCase  *usecase.CreateTaskUseCase
+	getTaskUseCase     *usecase.GetTaskUseCase
+	listTasksUseCase   *usecase.ListTasksUseCase
+	updateTaskUseCase  *usecase.UpdateTaskUseCase
+	deleteTaskUseCase  *usecase.DeleteTaskUseCase
+	shareTaskUseCase   *usecase.ShareTaskUseCase
+	unshareTaskUseCase *usecase.UnshareTaskUseCase
+	cloneTaskUseCase   *usecase.CloneTaskUseCase
+	getLineageUseCase  *usecase.GetTaskLineageUseCase
 }
 
 // NewTaskHandler creates a new TaskHandler
@@ -24,21 +30,34 @@ func NewTaskHandler(
 	listTasksUseCase *usecase.ListTasksUseCase,
 	updateTaskUseCase *usecase.UpdateTaskUseCase,
 	deleteTaskUseCase *usecase.DeleteTaskUseCase,
+	shareTaskUseCase *usecase.ShareTaskUseCase,
+	unshareTaskUseCase *usecase.UnshareTaskUseCase,
+	cloneTaskUseCase *usecase.CloneTaskUseCase,
+	getLineageUseCase *usecase.GetTaskLineageUseCase,
 ) *TaskHandler {
 	handler := &TaskHandler{
-		createTaskUseCase: createTaskUseCase,
-		getTaskUseCase:    getTaskUseCase,
-		listTasksUseCase:  listTasksUseCase,
-		updateTaskUseCase: updateTaskUseCase,
-		deleteTaskUseCase: deleteTaskUseCase,
+		createTaskUseCase:  createTaskUseCase,
+		getTaskUseCase:     getTaskUseCase,
+		listTasksUseCase:   listTasksUseCase,
+		updateTaskUseCase:  updateTaskUseCase,
+		deleteTaskUseCase:  deleteTaskUseCase,
+		shareTaskUseCase:   shareTaskUseCase,
+		unshareTaskUseCase: unshareTaskUseCase,
+		cloneTaskUseCase:   cloneTaskUseCase,
+		getLineageUseCase:  getLineageUseCase,
 	}
 
 	// Register routes
-	router.POST("/tasks", handler.CreateTask)
-	router.GET("/tasks/:id", handler.GetTask)
-	router.GET("/tasks", handler.ListTasks)
-	router.PUT("/tasks/:id", handler.UpdateTask)
-	router.DELETE("/tasks/:id", handler.DeleteTask)
+	tasks := router.Group("/tasks", RequireUser())
+	tasks.POST("", handler.CreateTask)
+	tasks.GET("/:id", handler.GetTask)
+	tasks.GET("", handler.ListTasks)
+	tasks.PUT("/:id", handler.UpdateTask)
+	tasks.DELETE("/:id", handler.DeleteTask)
+	tasks.POST("/:id/shares", handler.ShareTask)
+	tasks.DELETE("/:id/shares/:userId", handler.UnshareTask)
+	tasks.POST("/:id/clone", handler.CloneTask)
+	tasks.GET("/:id/lineage", handler.GetTaskLineage)
 
 	return handler
 }
@@ -51,6 +70,8 @@ func (h *TaskHandler) CreateTask(c *gin.Context) {
 		return
 	}
 
+	input.OwnerID = CurrentUserID(c)
+
 	task, err := h.createTaskUseCase.Execute(input)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -64,18 +85,18 @@ func (h *TaskHandler) CreateTask(c *gin.Context) {
 func (h *TaskHandler) GetTask(c *gin.Context) {
 	id := c.Param("id")
 
-	task, err := h.getTaskUseCase.Execute(id)
+	task, err := h.getTaskUseCase.Execute(id, CurrentUserID(c))
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		c.JSON(errorStatus(err, http.StatusNotFound), gin.H{"error": err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusOK, task)
 }
 
-// ListTasks handles retrieving all tasks
+// ListTasks handles retrieving all tasks visible to the caller
 func (h *TaskHandler) ListTasks(c *gin.Context) {
-	tasks, err := h.listTasksUseCase.Execute()
+	tasks, err := h.listTasksUseCase.Execute(CurrentUserID(c))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -95,10 +116,11 @@ func (h *TaskHandler) UpdateTask(c *gin.Context) {
 	}
 
 	input.ID = id
+	input.UserID = CurrentUserID(c)
 
 	task, err := h.updateTaskUseCase.Execute(input)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(errorStatus(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -109,11 +131,89 @@ func (h *TaskHandler) UpdateTask(c *gin.Context) {
 func (h *TaskHandler) DeleteTask(c *gin.Context) {
 	id := c.Param("id")
 
-	err := h.deleteTaskUseCase.Execute(id)
+	err := h.deleteTaskUseCase.Execute(id, CurrentUserID(c))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(errorStatus(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Task deleted successfully"})
 }
+
+// ShareTask handles granting another user access to a task
+func (h *TaskHandler) ShareTask(c *gin.Context) {
+	var input usecase.ShareTaskInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	input.ID = c.Param("id")
+	input.CallerID = CurrentUserID(c)
+
+	task, err := h.shareTaskUseCase.Execute(input)
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusBadRequest), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, task)
+}
+
+// UnshareTask handles revoking a user's access to a task
+func (h *TaskHandler) UnshareTask(c *gin.Context) {
+	task, err := h.unshareTaskUseCase.Execute(c.Param("id"), CurrentUserID(c), c.Param("userId"))
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusBadRequest), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, task)
+}
+
+// CloneTask handles creating a linked copy of a task with optional overrides
+func (h *TaskHandler) CloneTask(c *gin.Context) {
+	var input usecase.CloneTaskInput
+
+	// The body is optional; an empty body clones the task unchanged
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	input.ID = c.Param("id")
+	input.UserID = CurrentUserID(c)
+
+	task, err := h.cloneTaskUseCase.Execute(input)
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusBadRequest), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, task)
+}
+
+// GetTaskLineage handles retrieving the sources and clones of a task
+func (h *TaskHandler) GetTaskLineage(c *gin.Context) {
+	lineage, err := h.getLineageUseCase.Execute(c.Param("id"), CurrentUserID(c))
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, lineage)
+}
+
+// errorStatus maps access errors to their HTTP status, falling back to the given status
+func errorStatus(err error, fallback int) int {
+	switch {
+	case errors.Is(err, domain.ErrTaskNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, domain.ErrTaskForbidden):
+		return http.StatusForbidden
+	default:
+		return fallback
+	}
+}
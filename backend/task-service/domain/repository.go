@@ -8,10 +8,13 @@ type TaskRepository interface {
 	// GetByID retrieves a task by its ID
 	GetByID(id string) (*Task, error)
 
-	// List retrieves all tasks
-	List() ([]*Task, error)
+	// List retrieves all tasks owned by or shared with the given user
+	List(userID string) ([]*Task, error)
 
-	// Update updates an existing task
+	// ListBySource retrieves the clones of a task owned by or shared with the given user
+	ListBySource(sourceID, userID string) ([]*Task, error)
+
+	// Update updates an existing task, including its shares
 	Update(task *Task) error
 
 	// Delete removes a task by its ID
@@ -15,29 +15,60 @@ const (
 	TaskStatusFailed    TaskStatus = "failed"
 )
 
+// TaskRole represents the access level granted to a user a task is shared with
+type TaskRole string
+
+const (
+	TaskRoleRead  TaskRole = "read"
+	TaskRoleWrite TaskRole = "write"
+)
+
+var (
+	// ErrTaskNotFound is returned when a task does not exist or is not visible to the caller
+	ErrTaskNotFound = errors.New("task not found")
+
+	// ErrTaskForbidden is returned when the caller can see a task but may not perform the operation
+	ErrTaskForbidden = errors.New("operation not permitted on task")
+)
+
+// TaskShare grants a user access to a task they do not own
+type TaskShare struct {
+	UserID string   `json:"user_id"`
+	Role   TaskRole `json:"role"`
+}
+
 // Task represents a task in the system
 type Task struct {
-	ID          string     `json:"id"`
-	Title       string     `json:"title"`
-	Description string     `json:"description"`
-	Status      TaskStatus `json:"status"`
-	Input       string     `json:"input"`
-	Result      string     `json:"result"`
-	CreatedAt   time.Time  `json:"created_at"`
-	UpdatedAt   time.Time  `json:"updated_at"`
+	ID           string      `json:"id"`
+	OwnerID      string      `json:"owner_id"`
+	SourceTaskID string      `json:"source_task_id,omitempty"`
+	Title        string      `json:"title"`
+	Description  string      `json:"description"`
+	Status       TaskStatus  `json:"status"`
+	Input        string      `json:"input"`
+	Result       string      `json:"result"`
+	Shares       []TaskShare `json:"shares"`
+	CreatedAt    time.Time   `json:"created_at"`
+	UpdatedAt    time.Time   `json:"updated_at"`
 }
 
-// NewTask creates a new task with the given title and description
-func NewTask(title, description, input string) (*Task, error) {
+// NewTask creates a new task owned by the given user
+func NewTask(ownerID, title, description, input string) (*Task, error) {
+	if ownerID == "" {
+		return nil, errors.New("owner ID cannot be empty")
+	}
+
 	if title == "" {
 		return nil, errors.New("title cannot be empty")
 	}
 
 	return &Task{
+		OwnerID:     ownerID,
 		Title:       title,
 		Description: description,
 		Status:      TaskStatusPending,
 		Input:       input,
+		Shares:      []TaskShare{},
 		CreatedAt:   time.Now(),
 		UpdatedAt:   time.Now(),
 	}, nil
@@ -68,3 +99,87 @@ func (t *Task) Validate() error {
 	}
 	return nil
 }
+
+// Clone creates a new pending task for the given owner that copies this task's
+// title, description and input and links back to it as its source
+func (t *Task) Clone(ownerID string) (*Task, error) {
+	clone, err := NewTask(ownerID, t.Title, t.Description, t.Input)
+	if err != nil {
+		return nil, err
+	}
+
+	clone.SourceTaskID = t.ID
+	return clone, nil
+}
+
+// IsOwner reports whether the user owns the task
+func (t *Task) IsOwner(userID string) bool {
+	return userID != "" && t.OwnerID == userID
+}
+
+// CanRead reports whether the user may view the task
+func (t *Task) CanRead(userID string) bool {
+	if t.IsOwner(userID) {
+		return true
+	}
+
+	for _, share := range t.Shares {
+		if share.UserID == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// CanWrite reports whether the user may modify the task
+func (t *Task) CanWrite(userID string) bool {
+	if t.IsOwner(userID) {
+		return true
+	}
+
+	for _, share := range t.Shares {
+		if share.UserID == userID {
+			return share.Role == TaskRoleWrite
+		}
+	}
+	return false
+}
+
+// Share grants the user the given role, replacing any existing grant
+func (t *Task) Share(userID string, role TaskRole) error {
+	if userID == "" {
+		return errors.New("user ID cannot be empty")
+	}
+
+	if role != TaskRoleRead && role != TaskRoleWrite {
+		return errors.New("invalid task role")
+	}
+
+	if t.IsOwner(userID) {
+		return errors.New("cannot share a task with its owner")
+	}
+
+	for i, share := range t.Shares {
+		if share.UserID == userID {
+			t.Shares[i].Role = role
+			t.UpdatedAt = time.Now()
+			return nil
+		}
+	}
+
+	t.Shares = append(t.Shares, TaskShare{UserID: userID, Role: role})
+	t.UpdatedAt = time.Now()
+	return nil
+}
+
+// Unshare revokes the user's access to the task
+func (t *Task) Unshare(userID string) error {
+	for i, share := range t.Shares {
+		if share.UserID == userID {
+			t.Shares = append(t.Shares[:i], t.Shares[i+1:]...)
+			t.UpdatedAt = time.Now()
+			return nil
+		}
+	}
+	return errors.New("task is not shared with user")
+}
@@ -10,6 +10,7 @@ import (
 func TestNewTask(t *testing.T) {
 	tests := []struct {
 		name        string
+		ownerID     string
 		title       string
 		description string
 		input       string
@@ -17,6 +18,7 @@ func TestNewTask(t *testing.T) {
 	}{
 		{
 			name:        "Valid task",
+			ownerID:     "alice",
 			title:       "Test Task",
 			description: "This is a test task",
 			input:       "test input",
@@ -24,16 +26,25 @@ func TestNewTask(t *testing.T) {
 		},
 		{
 			name:        "Empty title",
+			ownerID:     "alice",
 			title:       "",
 			description: "This is a test task",
 			input:       "test input",
 			wantErr:     true,
 		},
+		{
+			name:        "Empty owner",
+			ownerID:     "",
+			title:       "Test Task",
+			description: "This is a test task",
+			input:       "test input",
+			wantErr:     true,
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			task, err := domain.NewTask(tt.title, tt.description, tt.input)
+			task, err := domain.NewTask(tt.ownerID, tt.title, tt.description, tt.input)
 			
 			if tt.wantErr {
 				if err == nil {
@@ -47,6 +58,10 @@ func TestNewTask(t *testing.T) {
 				return
 			}
 			
+			if task.OwnerID != tt.ownerID {
+				t.Errorf("Task.OwnerID = %v, want %v", task.OwnerID, tt.ownerID)
+			}
+
 			if task.Title != tt.title {
 				t.Errorf("Task.Title = %v, want %v", task.Title, tt.title)
 			}
@@ -77,7 +92,7 @@ func TestNewTask(t *testing.T) {
 }
 
 func TestUpdateStatus(t *testing.T) {
-	task, _ := domain.NewTask("Test Task", "This is a test task", "test input")
+	task, _ := domain.NewTask("alice", "Test Task", "This is a test task", "test input")
 	
 	tests := []struct {
 		name    string
@@ -137,7 +152,7 @@ func TestUpdateStatus(t *testing.T) {
 }
 
 func TestSetResult(t *testing.T) {
-	task, _ := domain.NewTask("Test Task", "This is a test task", "test input")
+	task, _ := domain.NewTask("alice", "Test Task", "This is a test task", "test input")
 	
 	tests := []struct {
 		name   string
@@ -212,3 +227,219 @@ func TestValidate(t *testing.T) {
 		})
 	}
 }
+
+func TestTaskAccess(t *testing.T) {
+	task, _ := domain.NewTask("alice", "Test Task", "This is a test task", "test input")
+	_ = task.Share("bob", domain.TaskRoleRead)
+	_ = task.Share("carol", domain.TaskRoleWrite)
+
+	tests := []struct {
+		name      string
+		userID    string
+		wantOwner bool
+		wantRead  bool
+		wantWrite bool
+	}{
+		{
+			name:      "Owner",
+			userID:    "alice",
+			wantOwner: true,
+			wantRead:  true,
+			wantWrite: true,
+		},
+		{
+			name:      "Read share",
+			userID:    "bob",
+			wantOwner: false,
+			wantRead:  true,
+			wantWrite: false,
+		},
+		{
+			name:      "Write share",
+			userID:    "carol",
+			wantOwner: false,
+			wantRead:  true,
+			wantWrite: true,
+		},
+		{
+			name:      "Stranger",
+			userID:    "dave",
+			wantOwner: false,
+			wantRead:  false,
+			wantWrite: false,
+		},
+		{
+			name:      "Anonymous",
+			userID:    "",
+			wantOwner: false,
+			wantRead:  false,
+			wantWrite: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := task.IsOwner(tt.userID); got != tt.wantOwner {
+				t.Errorf("IsOwner() = %v, want %v", got, tt.wantOwner)
+			}
+
+			if got := task.CanRead(tt.userID); got != tt.wantRead {
+				t.Errorf("CanRead() = %v, want %v", got, tt.wantRead)
+			}
+
+			if got := task.CanWrite(tt.userID); got != tt.wantWrite {
+				t.Errorf("CanWrite() = %v, want %v", got, tt.wantWrite)
+			}
+		})
+	}
+}
+
+func TestShare(t *testing.T) {
+	tests := []struct {
+		name       string
+		userID     string
+		role       domain.TaskRole
+		wantErr    bool
+		wantShares int
+	}{
+		{
+			name:       "Share read",
+			userID:     "bob",
+			role:       domain.TaskRoleRead,
+			wantErr:    false,
+			wantShares: 1,
+		},
+		{
+			name:       "Upgrade existing share",
+			userID:     "bob",
+			role:       domain.TaskRoleWrite,
+			wantErr:    false,
+			wantShares: 1,
+		},
+		{
+			name:       "Share with owner",
+			userID:     "alice",
+			role:       domain.TaskRoleRead,
+			wantErr:    true,
+			wantShares: 1,
+		},
+		{
+			name:       "Invalid role",
+			userID:     "carol",
+			role:       "admin",
+			wantErr:    true,
+			wantShares: 1,
+		},
+		{
+			name:       "Empty user",
+			userID:     "",
+			role:       domain.TaskRoleRead,
+			wantErr:    true,
+			wantShares: 1,
+		},
+	}
+
+	task, _ := domain.NewTask("alice", "Test Task", "This is a test task", "test input")
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := task.Share(tt.userID, tt.role)
+
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Share() error = nil, wantErr %v", tt.wantErr)
+				}
+			} else if err != nil {
+				t.Errorf("Share() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if len(task.Shares) != tt.wantShares {
+				t.Errorf("len(Task.Shares) = %v, want %v", len(task.Shares), tt.wantShares)
+			}
+		})
+	}
+
+	if !task.CanWrite("bob") {
+		t.Errorf("CanWrite(bob) = false after upgrade, want true")
+	}
+
+	if err := task.Unshare("bob"); err != nil {
+		t.Errorf("Unshare() error = %v", err)
+	}
+
+	if task.CanRead("bob") {
+		t.Errorf("CanRead(bob) = true after unshare, want false")
+	}
+
+	if err := task.Unshare("bob"); err == nil {
+		t.Errorf("Unshare() of missing share error = nil, want error")
+	}
+}
+
+func TestClone(t *testing.T) {
+	source, _ := domain.NewTask("alice", "Test Task", "This is a test task", "test input")
+	source.ID = "task_1"
+	_ = source.UpdateStatus(domain.TaskStatusFailed)
+	source.SetResult("boom")
+	_ = source.Share("bob", domain.TaskRoleRead)
+
+	tests := []struct {
+		name    string
+		ownerID string
+		wantErr bool
+	}{
+		{
+			name:    "Clone by owner",
+			ownerID: "alice",
+			wantErr: false,
+		},
+		{
+			name:    "Clone by sharee",
+			ownerID: "bob",
+			wantErr: false,
+		},
+		{
+			name:    "Empty owner",
+			ownerID: "",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clone, err := source.Clone(tt.ownerID)
+
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Clone() error = nil, wantErr %v", tt.wantErr)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Errorf("Clone() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			if clone.OwnerID != tt.ownerID {
+				t.Errorf("Clone.OwnerID = %v, want %v", clone.OwnerID, tt.ownerID)
+			}
+
+			if clone.SourceTaskID != source.ID {
+				t.Errorf("Clone.SourceTaskID = %v, want %v", clone.SourceTaskID, source.ID)
+			}
+
+			if clone.Title != source.Title || clone.Description != source.Description || clone.Input != source.Input {
+				t.Errorf("Clone did not copy title, description and input: %+v", clone)
+			}
+
+			if clone.Status != domain.TaskStatusPending || clone.Result != "" {
+				t.Errorf("Clone status/result = %v/%q, want pending with no result", clone.Status, clone.Result)
+			}
+
+			if clone.ID != "" || len(clone.Shares) != 0 {
+				t.Errorf("Clone should have no ID or shares, got ID %q and %d shares", clone.ID, len(clone.Shares))
+			}
+		})
+	}
+}
@@ -26,6 +26,8 @@ func NewSQLiteTaskRepository(dbPath string) (*SQLiteTaskRepository, error) {
 	_, err = db.Exec(`
 		CREATE TABLE IF NOT EXISTS tasks (
 			id TEXT PRIMARY KEY,
+			owner_id TEXT NOT NULL DEFAULT '',
+			source_task_id TEXT NOT NULL DEFAULT '',
 			title TEXT NOT NULL,
 			description TEXT,
 			status TEXT NOT NULL,
@@ -39,11 +41,82 @@ func NewSQLiteTaskRepository(dbPath string) (*SQLiteTaskRepository, error) {
 		return nil, fmt.Errorf("failed to create tasks table: %w", err)
 	}
 
+	// Databases created by older versions are missing the newer columns
+	if err := addColumnIfMissing(db, "tasks", "owner_id", "TEXT NOT NULL DEFAULT ''"); err != nil {
+		return nil, err
+	}
+
+	if err := addColumnIfMissing(db, "tasks", "source_task_id", "TEXT NOT NULL DEFAULT ''"); err != nil {
+		return nil, err
+	}
+
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS task_shares (
+			task_id TEXT NOT NULL,
+			user_id TEXT NOT NULL,
+			role TEXT NOT NULL,
+			PRIMARY KEY (task_id, user_id)
+		)
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create task_shares table: %w", err)
+	}
+
+	_, err = db.Exec(`CREATE INDEX IF NOT EXISTS idx_tasks_owner_id ON tasks (owner_id)`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create owner index: %w", err)
+	}
+
+	_, err = db.Exec(`CREATE INDEX IF NOT EXISTS idx_task_shares_user_id ON task_shares (user_id)`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create share index: %w", err)
+	}
+
+	_, err = db.Exec(`CREATE INDEX IF NOT EXISTS idx_tasks_source_task_id ON tasks (source_task_id)`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create source index: %w", err)
+	}
+
 	return &SQLiteTaskRepository{
 		db: db,
 	}, nil
 }
 
+// addColumnIfMissing adds a column to an existing table when it is not present yet
+func addColumnIfMissing(db *sql.DB, table, column, definition string) error {
+	rows, err := db.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return fmt.Errorf("failed to inspect %s table: %w", table, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			cid        int
+			name       string
+			colType    string
+			notNull    int
+			defaultVal sql.NullString
+			primaryKey int
+		)
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &defaultVal, &primaryKey); err != nil {
+			return fmt.Errorf("failed to scan %s columns: %w", table, err)
+		}
+		if name == column {
+			return nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error iterating %s columns: %w", table, err)
+	}
+
+	if _, err := db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, definition)); err != nil {
+		return fmt.Errorf("failed to add %s.%s column: %w", table, column, err)
+	}
+
+	return nil
+}
+
 // Create stores a new task
 func (r *SQLiteTaskRepository) Create(task *domain.Task) error {
 	// Generate a unique ID if not provided
@@ -51,10 +124,18 @@ func (r *SQLiteTaskRepository) Create(task *domain.Task) error {
 		task.ID = fmt.Sprintf("task_%d", time.Now().UnixNano())
 	}
 
-	_, err := r.db.Exec(
-		`INSERT INTO tasks (id, title, description, status, input, result, created_at, updated_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(
+		`INSERT INTO tasks (id, owner_id, source_task_id, title, description, status, input, result, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
 		task.ID,
+		task.OwnerID,
+		task.SourceTaskID,
 		task.Title,
 		task.Description,
 		task.Status,
@@ -67,22 +148,153 @@ func (r *SQLiteTaskRepository) Create(task *domain.Task) error {
 		return fmt.Errorf("failed to insert task: %w", err)
 	}
 
+	if err := insertShares(tx, task); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit task: %w", err)
+	}
+
 	return nil
 }
 
 // GetByID retrieves a task by its ID
 func (r *SQLiteTaskRepository) GetByID(id string) (*domain.Task, error) {
 	row := r.db.QueryRow(
-		`SELECT id, title, description, status, input, result, created_at, updated_at
-		FROM tasks WHERE id = ?`,
+		`SELECT `+taskColumns+` FROM tasks WHERE id = ?`,
 		id,
 	)
 
+	task, err := scanTask(row)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%w: %s", domain.ErrTaskNotFound, id)
+		}
+		return nil, err
+	}
+
+	if err := r.loadShares(task); err != nil {
+		return nil, err
+	}
+
+	return task, nil
+}
+
+// List retrieves all tasks owned by or shared with the given user
+func (r *SQLiteTaskRepository) List(userID string) ([]*domain.Task, error) {
+	return r.queryTasks(
+		`SELECT `+taskColumns+` FROM tasks
+		WHERE owner_id = ? OR id IN (SELECT task_id FROM task_shares WHERE user_id = ?)
+		ORDER BY created_at DESC`,
+		userID,
+		userID,
+	)
+}
+
+// ListBySource retrieves the clones of a task that are owned by or shared with the given user
+func (r *SQLiteTaskRepository) ListBySource(sourceID, userID string) ([]*domain.Task, error) {
+	return r.queryTasks(
+		`SELECT `+taskColumns+` FROM tasks
+		WHERE source_task_id = ?
+		AND (owner_id = ? OR id IN (SELECT task_id FROM task_shares WHERE user_id = ?))
+		ORDER BY created_at DESC`,
+		sourceID,
+		userID,
+		userID,
+	)
+}
+
+// Update updates an existing task, including its shares
+func (r *SQLiteTaskRepository) Update(task *domain.Task) error {
+	task.UpdatedAt = time.Now()
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(
+		`UPDATE tasks SET title = ?, description = ?, status = ?, input = ?, result = ?, updated_at = ?
+		WHERE id = ?`,
+		task.Title,
+		task.Description,
+		task.Status,
+		task.Input,
+		task.Result,
+		task.UpdatedAt,
+		task.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update task: %w", err)
+	}
+
+	if _, err := tx.Exec("DELETE FROM task_shares WHERE task_id = ?", task.ID); err != nil {
+		return fmt.Errorf("failed to clear task shares: %w", err)
+	}
+
+	if err := insertShares(tx, task); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit task: %w", err)
+	}
+
+	return nil
+}
+
+// Delete removes a task by its ID
+func (r *SQLiteTaskRepository) Delete(id string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec("DELETE FROM tasks WHERE id = ?", id)
+	if err != nil {
+		return fmt.Errorf("failed to delete task: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("%w: %s", domain.ErrTaskNotFound, id)
+	}
+
+	if _, err := tx.Exec("DELETE FROM task_shares WHERE task_id = ?", id); err != nil {
+		return fmt.Errorf("failed to delete task shares: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit delete: %w", err)
+	}
+
+	return nil
+}
+
+// taskColumns lists the task columns in the order expected by scanTask
+const taskColumns = `id, owner_id, source_task_id, title, description, status, input, result, created_at, updated_at`
+
+// rowScanner is implemented by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanTask reads a task selected with taskColumns
+func scanTask(row rowScanner) (*domain.Task, error) {
 	var task domain.Task
 	var createdAt, updatedAt string
 
 	err := row.Scan(
 		&task.ID,
+		&task.OwnerID,
+		&task.SourceTaskID,
 		&task.Title,
 		&task.Description,
 		&task.Status,
@@ -93,7 +305,7 @@ func (r *SQLiteTaskRepository) GetByID(id string) (*domain.Task, error) {
 	)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return nil, fmt.Errorf("task not found: %s", id)
+			return nil, err
 		}
 		return nil, fmt.Errorf("failed to scan task: %w", err)
 	}
@@ -105,12 +317,9 @@ func (r *SQLiteTaskRepository) GetByID(id string) (*domain.Task, error) {
 	return &task, nil
 }
 
-// List retrieves all tasks
-func (r *SQLiteTaskRepository) List() ([]*domain.Task, error) {
-	rows, err := r.db.Query(
-		`SELECT id, title, description, status, input, result, created_at, updated_at
-		FROM tasks ORDER BY created_at DESC`,
-	)
+// queryTasks runs a task query and loads the shares of every returned task
+func (r *SQLiteTaskRepository) queryTasks(query string, args ...interface{}) ([]*domain.Task, error) {
+	rows, err := r.db.Query(query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query tasks: %w", err)
 	}
@@ -119,73 +328,66 @@ func (r *SQLiteTaskRepository) List() ([]*domain.Task, error) {
 	var tasks []*domain.Task
 
 	for rows.Next() {
-		var task domain.Task
-		var createdAt, updatedAt string
-
-		err := rows.Scan(
-			&task.ID,
-			&task.Title,
-			&task.Description,
-			&task.Status,
-			&task.Input,
-			&task.Result,
-			&createdAt,
-			&updatedAt,
-		)
+		task, err := scanTask(rows)
 		if err != nil {
-			return nil, fmt.Errorf("failed to scan task: %w", err)
+			return nil, err
 		}
-
-		// Parse timestamps
-		task.CreatedAt, _ = time.Parse(time.RFC3339, createdAt)
-		task.UpdatedAt, _ = time.Parse(time.RFC3339, updatedAt)
-
-		tasks = append(tasks, &task)
+		tasks = append(tasks, task)
 	}
 
 	if err := rows.Err(); err != nil {
 		return nil, fmt.Errorf("error iterating tasks: %w", err)
 	}
 
+	for _, task := range tasks {
+		if err := r.loadShares(task); err != nil {
+			return nil, err
+		}
+	}
+
 	return tasks, nil
 }
 
-// Update updates an existing task
-func (r *SQLiteTaskRepository) Update(task *domain.Task) error {
-	task.UpdatedAt = time.Now()
-
-	_, err := r.db.Exec(
-		`UPDATE tasks SET title = ?, description = ?, status = ?, input = ?, result = ?, updated_at = ?
-		WHERE id = ?`,
-		task.Title,
-		task.Description,
-		task.Status,
-		task.Input,
-		task.Result,
-		task.UpdatedAt,
+// loadShares populates the shares of a task
+func (r *SQLiteTaskRepository) loadShares(task *domain.Task) error {
+	rows, err := r.db.Query(
+		`SELECT user_id, role FROM task_shares WHERE task_id = ? ORDER BY user_id`,
 		task.ID,
 	)
 	if err != nil {
-		return fmt.Errorf("failed to update task: %w", err)
+		return fmt.Errorf("failed to query task shares: %w", err)
 	}
+	defer rows.Close()
 
-	return nil
-}
+	task.Shares = []domain.TaskShare{}
 
-// Delete removes a task by its ID
-func (r *SQLiteTaskRepository) Delete(id string) error {
-	result, err := r.db.Exec("DELETE FROM tasks WHERE id = ?", id)
-	if err != nil {
-		return fmt.Errorf("failed to delete task: %w", err)
+	for rows.Next() {
+		var share domain.TaskShare
+		if err := rows.Scan(&share.UserID, &share.Role); err != nil {
+			return fmt.Errorf("failed to scan task share: %w", err)
+		}
+		task.Shares = append(task.Shares, share)
 	}
 
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		return fmt.Errorf("failed to get rows affected: %w", err)
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error iterating task shares: %w", err)
 	}
 
-	if rowsAffected == 0 {
-		return fmt.Errorf("task not found: %s", id)
+	return nil
+}
+
+// insertShares stores the shares of a task within a transaction
+func insertShares(tx *sql.Tx, task *domain.Task) error {
+	for _, share := range task.Shares {
+		_, err := tx.Exec(
+			`INSERT INTO task_shares (task_id, user_id, role) VALUES (?, ?, ?)`,
+			task.ID,
+			share.UserID,
+			share.Role,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to insert task share: %w", err)
+		}
 	}
 
 	return nil
@@ -22,6 +22,10 @@ func main() {
 	listTasksUseCase := usecase.NewListTasksUseCase(taskRepo)
 	updateTaskUseCase := usecase.NewUpdateTaskUseCase(taskRepo)
 	deleteTaskUseCase := usecase.NewDeleteTaskUseCase(taskRepo)
+	shareTaskUseCase := usecase.NewShareTaskUseCase(taskRepo)
+	unshareTaskUseCase := usecase.NewUnshareTaskUseCase(taskRepo)
+	cloneTaskUseCase := usecase.NewCloneTaskUseCase(taskRepo)
+	getTaskLineageUseCase := usecase.NewGetTaskLineageUseCase(taskRepo)
 
 	// Initialize Gin router
 	router := gin.Default()
@@ -34,6 +38,10 @@ func main() {
 		listTasksUseCase,
 		updateTaskUseCase,
 		deleteTaskUseCase,
+		shareTaskUseCase,
+		unshareTaskUseCase,
+		cloneTaskUseCase,
+		getTaskLineageUseCase,
 	)
 
 	// Start server
@@ -0,0 +1,67 @@
+package usecase
+
+import (
+	"errors"
+
+	"github.com/augment-local-manus-clone/backend/task-service/domain"
+)
+
+// CloneTaskInput represents the input for cloning a task; nil fields keep the source's value
+type CloneTaskInput struct {
+	ID          string  `json:"id"`
+	UserID      string  `json:"-"`
+	Title       *string `json:"title"`
+	Description *string `json:"description"`
+	Input       *string `json:"input"`
+}
+
+// CloneTaskUseCase handles re-running a task as a new, linked copy
+type CloneTaskUseCase struct {
+	taskRepo domain.TaskRepository
+}
+
+// NewCloneTaskUseCase creates a new instance of CloneTaskUseCase
+func NewCloneTaskUseCase(taskRepo domain.TaskRepository) *CloneTaskUseCase {
+	return &CloneTaskUseCase{
+		taskRepo: taskRepo,
+	}
+}
+
+// Execute clones a task the user can read; the clone is owned by the user
+func (uc *CloneTaskUseCase) Execute(input CloneTaskInput) (*domain.Task, error) {
+	if input.ID == "" {
+		return nil, errors.New("task ID cannot be empty")
+	}
+
+	source, err := getReadableTask(uc.taskRepo, input.ID, input.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	clone, err := source.Clone(input.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	if input.Title != nil {
+		clone.Title = *input.Title
+	}
+
+	if input.Description != nil {
+		clone.Description = *input.Description
+	}
+
+	if input.Input != nil {
+		clone.Input = *input.Input
+	}
+
+	if err := clone.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := uc.taskRepo.Create(clone); err != nil {
+		return nil, err
+	}
+
+	return clone, nil
+}
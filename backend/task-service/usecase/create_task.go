@@ -6,6 +6,7 @@ import (
 
 // CreateTaskInput represents the input for creating a task
 type CreateTaskInput struct {
+	OwnerID     string `json:"-"`
 	Title       string `json:"title"`
 	Description string `json:"description"`
 	Input       string `json:"input"`
@@ -25,7 +26,7 @@ func NewCreateTaskUseCase(taskRepo domain.TaskRepository) *CreateTaskUseCase {
 
 // Execute creates a new task
 func (uc *CreateTaskUseCase) Execute(input CreateTaskInput) (*domain.Task, error) {
-	task, err := domain.NewTask(input.Title, input.Description, input.Input)
+	task, err := domain.NewTask(input.OwnerID, input.Title, input.Description, input.Input)
 	if err != nil {
 		return nil, err
 	}
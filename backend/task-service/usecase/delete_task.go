@@ -18,17 +18,21 @@ func NewDeleteTaskUseCase(taskRepo domain.TaskRepository) *DeleteTaskUseCase {
 	}
 }
 
-// Execute deletes a task by its ID
-func (uc *DeleteTaskUseCase) Execute(id string) error {
+// Execute deletes a task by its ID; only the owner may delete a task
+func (uc *DeleteTaskUseCase) Execute(id, userID string) error {
 	if id == "" {
 		return errors.New("task ID cannot be empty")
 	}
 
-	// Check if the task exists
-	_, err := uc.taskRepo.GetByID(id)
+	// Check if the task exists and is visible to the user
+	task, err := getReadableTask(uc.taskRepo, id, userID)
 	if err != nil {
 		return err
 	}
 
+	if !task.IsOwner(userID) {
+		return domain.ErrTaskForbidden
+	}
+
 	return uc.taskRepo.Delete(id)
 }
@@ -2,6 +2,7 @@ package usecase
 
 import (
 	"errors"
+	"fmt"
 
 	"github.com/augment-local-manus-clone/backend/task-service/domain"
 )
@@ -18,11 +19,25 @@ func NewGetTaskUseCase(taskRepo domain.TaskRepository) *GetTaskUseCase {
 	}
 }
 
-// Execute retrieves a task by its ID
-func (uc *GetTaskUseCase) Execute(id string) (*domain.Task, error) {
+// Execute retrieves a task by its ID on behalf of the given user
+func (uc *GetTaskUseCase) Execute(id, userID string) (*domain.Task, error) {
 	if id == "" {
 		return nil, errors.New("task ID cannot be empty")
 	}
 
-	return uc.taskRepo.GetByID(id)
+	return getReadableTask(uc.taskRepo, id, userID)
+}
+
+// getReadableTask retrieves a task, hiding tasks the user cannot read as not found
+func getReadableTask(taskRepo domain.TaskRepository, id, userID string) (*domain.Task, error) {
+	task, err := taskRepo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if !task.CanRead(userID) {
+		return nil, fmt.Errorf("%w: %s", domain.ErrTaskNotFound, id)
+	}
+
+	return task, nil
 }
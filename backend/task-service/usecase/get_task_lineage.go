@@ -0,0 +1,75 @@
+package usecase
+
+import (
+	"errors"
+
+	"github.com/augment-local-manus-clone/backend/task-service/domain"
+)
+
+// maxLineageDepth bounds how far back the source chain of a task is followed
+const maxLineageDepth = 50
+
+// TaskLineage describes where a task was cloned from and which tasks were cloned from it
+type TaskLineage struct {
+	// Ancestors lists the task's sources, nearest first
+	Ancestors []*domain.Task `json:"ancestors"`
+
+	// Clones lists the tasks cloned directly from the task
+	Clones []*domain.Task `json:"clones"`
+}
+
+// GetTaskLineageUseCase handles retrieving the clone lineage of a task
+type GetTaskLineageUseCase struct {
+	taskRepo domain.TaskRepository
+}
+
+// NewGetTaskLineageUseCase creates a new instance of GetTaskLineageUseCase
+func NewGetTaskLineageUseCase(taskRepo domain.TaskRepository) *GetTaskLineageUseCase {
+	return &GetTaskLineageUseCase{
+		taskRepo: taskRepo,
+	}
+}
+
+// Execute retrieves the lineage of a task, limited to tasks the user can read
+func (uc *GetTaskLineageUseCase) Execute(id, userID string) (*TaskLineage, error) {
+	if id == "" {
+		return nil, errors.New("task ID cannot be empty")
+	}
+
+	task, err := getReadableTask(uc.taskRepo, id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	lineage := &TaskLineage{
+		Ancestors: []*domain.Task{},
+		Clones:    []*domain.Task{},
+	}
+
+	// Walk the source chain until it ends, leaves the user's view or loops
+	seen := map[string]bool{task.ID: true}
+	sourceID := task.SourceTaskID
+	for sourceID != "" && !seen[sourceID] && len(lineage.Ancestors) < maxLineageDepth {
+		source, err := getReadableTask(uc.taskRepo, sourceID, userID)
+		if err != nil {
+			if errors.Is(err, domain.ErrTaskNotFound) {
+				break
+			}
+			return nil, err
+		}
+
+		lineage.Ancestors = append(lineage.Ancestors, source)
+		seen[sourceID] = true
+		sourceID = source.SourceTaskID
+	}
+
+	clones, err := uc.taskRepo.ListBySource(task.ID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if clones != nil {
+		lineage.Clones = clones
+	}
+
+	return lineage, nil
+}
@@ -1,6 +1,8 @@
 package usecase
 
 import (
+	"errors"
+
 	"github.com/augment-local-manus-clone/backend/task-service/domain"
 )
 
@@ -16,7 +18,11 @@ func NewListTasksUseCase(taskRepo domain.TaskRepository) *ListTasksUseCase {
 	}
 }
 
-// Execute retrieves all tasks
-func (uc *ListTasksUseCase) Execute() ([]*domain.Task, error) {
-	return uc.taskRepo.List()
+// Execute retrieves all tasks visible to the given user
+func (uc *ListTasksUseCase) Execute(userID string) ([]*domain.Task, error) {
+	if userID == "" {
+		return nil, errors.New("user ID cannot be empty")
+	}
+
+	return uc.taskRepo.List(userID)
 }
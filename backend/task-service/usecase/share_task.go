@@ -0,0 +1,53 @@
+package usecase
+
+import (
+	"errors"
+
+	"github.com/augment-local-manus-clone/backend/task-service/domain"
+)
+
+// ShareTaskInput represents the input for sharing a task with another user
+type ShareTaskInput struct {
+	ID       string          `json:"id"`
+	CallerID string          `json:"-"`
+	UserID   string          `json:"user_id"`
+	Role     domain.TaskRole `json:"role"`
+}
+
+// ShareTaskUseCase handles granting other users access to a task
+type ShareTaskUseCase struct {
+	taskRepo domain.TaskRepository
+}
+
+// NewShareTaskUseCase creates a new instance of ShareTaskUseCase
+func NewShareTaskUseCase(taskRepo domain.TaskRepository) *ShareTaskUseCase {
+	return &ShareTaskUseCase{
+		taskRepo: taskRepo,
+	}
+}
+
+// Execute shares a task; only the owner may manage a task's shares
+func (uc *ShareTaskUseCase) Execute(input ShareTaskInput) (*domain.Task, error) {
+	if input.ID == "" {
+		return nil, errors.New("task ID cannot be empty")
+	}
+
+	task, err := getReadableTask(uc.taskRepo, input.ID, input.CallerID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !task.IsOwner(input.CallerID) {
+		return nil, domain.ErrTaskForbidden
+	}
+
+	if err := task.Share(input.UserID, input.Role); err != nil {
+		return nil, err
+	}
+
+	if err := uc.taskRepo.Update(task); err != nil {
+		return nil, err
+	}
+
+	return task, nil
+}
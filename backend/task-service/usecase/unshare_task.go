@@ -0,0 +1,45 @@
+package usecase
+
+import (
+	"errors"
+
+	"github.com/augment-local-manus-clone/backend/task-service/domain"
+)
+
+// UnshareTaskUseCase handles revoking a user's access to a task
+type UnshareTaskUseCase struct {
+	taskRepo domain.TaskRepository
+}
+
+// NewUnshareTaskUseCase creates a new instance of UnshareTaskUseCase
+func NewUnshareTaskUseCase(taskRepo domain.TaskRepository) *UnshareTaskUseCase {
+	return &UnshareTaskUseCase{
+		taskRepo: taskRepo,
+	}
+}
+
+// Execute revokes a share; the owner may revoke any share and users may remove themselves
+func (uc *UnshareTaskUseCase) Execute(id, callerID, userID string) (*domain.Task, error) {
+	if id == "" {
+		return nil, errors.New("task ID cannot be empty")
+	}
+
+	task, err := getReadableTask(uc.taskRepo, id, callerID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !task.IsOwner(callerID) && callerID != userID {
+		return nil, domain.ErrTaskForbidden
+	}
+
+	if err := task.Unshare(userID); err != nil {
+		return nil, err
+	}
+
+	if err := uc.taskRepo.Update(task); err != nil {
+		return nil, err
+	}
+
+	return task, nil
+}
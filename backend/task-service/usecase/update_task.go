@@ -8,9 +8,10 @@ import (
 
 // UpdateTaskInput represents the input for updating a task
 type UpdateTaskInput struct {
-	ID     string           `json:"id"`
+	ID     string            `json:"id"`
+	UserID string            `json:"-"`
 	Status domain.TaskStatus `json:"status"`
-	Result string           `json:"result"`
+	Result string            `json:"result"`
 }
 
 // UpdateTaskUseCase handles updating a task
@@ -31,11 +32,15 @@ func (uc *UpdateTaskUseCase) Execute(input UpdateTaskInput) (*domain.Task, error
 		return nil, errors.New("task ID cannot be empty")
 	}
 
-	task, err := uc.taskRepo.GetByID(input.ID)
+	task, err := getReadableTask(uc.taskRepo, input.ID, input.UserID)
 	if err != nil {
 		return nil, err
 	}
 
+	if !task.CanWrite(input.UserID) {
+		return nil, domain.ErrTaskForbidden
+	}
+
 	if input.Status != "" {
 		if err := task.UpdateStatus(input.Status); err != nil {
 			return nil, err
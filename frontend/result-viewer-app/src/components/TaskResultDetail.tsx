@@ -1,7 +1,8 @@
-import { useState } from 'react';
+import { useEffect, useState } from 'react';
 import { Prism as SyntaxHighlighter } from 'react-syntax-highlighter';
 import { vscDarkPlus } from 'react-syntax-highlighter/dist/esm/styles/prism';
-import { Task } from '../types/task';
+import { Task, TaskLineage } from '../types/task';
+import { taskService } from '../services/taskService';
 import TaskStatusBadge from './TaskStatusBadge';
 
 interface TaskResultDetailProps {
@@ -10,6 +11,28 @@ interface TaskResultDetailProps {
 
 function TaskResultDetail({ task }: TaskResultDetailProps) {
   const [activeTab, setActiveTab] = useState<'result' | 'input'>('result');
+  const [lineage, setLineage] = useState<TaskLineage | null>(null);
+
+  useEffect(() => {
+    let cancelled = false;
+
+    taskService
+      .getTaskLineage(task.id)
+      .then((fetchedLineage) => {
+        if (!cancelled) {
+          setLineage(fetchedLineage);
+        }
+      })
+      .catch(() => {
+        if (!cancelled) {
+          setLineage(null);
+        }
+      });
+
+    return () => {
+      cancelled = true;
+    };
+  }, [task.id]);
 
   const detectLanguage = (code: string): string => {
     // Simple language detection based on content
@@ -37,6 +60,34 @@ function TaskResultDetail({ task }: TaskResultDetailProps) {
           <TaskStatusBadge status={task.status} />
         </div>
         <p className="mt-1 max-w-2xl text-sm text-gray-500">{task.description}</p>
+        {lineage && (lineage.ancestors.length > 0 || lineage.clones.length > 0) && (
+          <div className="mt-3 text-sm text-gray-600 space-y-1">
+            {lineage.ancestors.length > 0 && (
+              <div>
+                <span className="font-medium">Cloned from:</span>{' '}
+                {lineage.ancestors.map((ancestor, index) => (
+                  <span key={ancestor.id}>
+                    {index > 0 && <span className="mx-1 text-gray-400">&larr;</span>}
+                    <span title={ancestor.id}>{ancestor.title}</span>{' '}
+                    <TaskStatusBadge status={ancestor.status} />
+                  </span>
+                ))}
+              </div>
+            )}
+            {lineage.clones.length > 0 && (
+              <div>
+                <span className="font-medium">Re-run as:</span>{' '}
+                {lineage.clones.map((clone, index) => (
+                  <span key={clone.id}>
+                    {index > 0 && <span className="mr-1">,</span>}
+                    <span title={clone.id}>{clone.title}</span>{' '}
+                    <TaskStatusBadge status={clone.status} />
+                  </span>
+                ))}
+              </div>
+            )}
+          </div>
+        )}
       </div>
       
       <div className="border-t border-gray-200">
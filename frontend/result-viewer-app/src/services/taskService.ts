@@ -1,10 +1,15 @@
-import { Task } from '../types/task';
+import { Task, TaskLineage } from '../types/task';
 
 const API_URL = 'http://localhost:8081';
 
+// The task service scopes tasks to the user identified by this header
+const USER_ID = localStorage.getItem('userId') || 'local-user';
+
 export const taskService = {
   async getTasks(): Promise<Task[]> {
-    const response = await fetch(`${API_URL}/tasks`);
+    const response = await fetch(`${API_URL}/tasks`, {
+      headers: { 'X-User-ID': USER_ID },
+    });
 
     if (!response.ok) {
       const error = await response.json();
@@ -15,7 +20,9 @@ export const taskService = {
   },
 
   async getTask(id: string): Promise<Task> {
-    const response = await fetch(`${API_URL}/tasks/${id}`);
+    const response = await fetch(`${API_URL}/tasks/${id}`, {
+      headers: { 'X-User-ID': USER_ID },
+    });
 
     if (!response.ok) {
       const error = await response.json();
@@ -24,4 +31,17 @@ export const taskService = {
 
     return response.json();
   },
+
+  async getTaskLineage(id: string): Promise<TaskLineage> {
+    const response = await fetch(`${API_URL}/tasks/${id}/lineage`, {
+      headers: { 'X-User-ID': USER_ID },
+    });
+
+    if (!response.ok) {
+      const error = await response.json();
+      throw new Error(error.error || 'Failed to fetch task lineage');
+    }
+
+    return response.json();
+  },
 };
@@ -1,12 +1,27 @@
 export type TaskStatus = 'pending' | 'running' | 'completed' | 'failed';
 
+export type TaskRole = 'read' | 'write';
+
+export interface TaskShare {
+  user_id: string;
+  role: TaskRole;
+}
+
 export interface Task {
   id: string;
+  owner_id: string;
+  source_task_id?: string;
   title: string;
   description: string;
   status: TaskStatus;
   input: string;
   result?: string;
+  shares: TaskShare[];
   created_at: string;
   updated_at: string;
 }
+
+export interface TaskLineage {
+  ancestors: Task[];
+  clones: Task[];
+}
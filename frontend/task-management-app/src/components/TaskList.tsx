@@ -35,6 +35,17 @@ function TaskList({ tasks, isLoading, setTasks, setIsLoading, setError }: TaskLi
     return () => clearInterval(interval);
   }, [setTasks, setIsLoading, setError]);
 
+  const handleClone = async (task: Task) => {
+    setError(null);
+
+    try {
+      const clone = await taskService.cloneTask(task.id);
+      setTasks([clone, ...tasks]);
+    } catch (error) {
+      setError(error instanceof Error ? error.message : 'An unknown error occurred');
+    }
+  };
+
   if (isLoading && tasks.length === 0) {
     return (
       <div className="flex justify-center items-center h-64">
@@ -65,6 +76,9 @@ function TaskList({ tasks, isLoading, setTasks, setIsLoading, setError }: TaskLi
             <th scope="col" className="px-6 py-3 text-left text-xs font-medium text-gray-500 uppercase tracking-wider">
               Created
             </th>
+            <th scope="col" className="px-6 py-3 text-right text-xs font-medium text-gray-500 uppercase tracking-wider">
+              Actions
+            </th>
           </tr>
         </thead>
         <tbody className="bg-white divide-y divide-gray-200">
@@ -80,6 +94,14 @@ function TaskList({ tasks, isLoading, setTasks, setIsLoading, setError }: TaskLi
               <td className="px-6 py-4 whitespace-nowrap text-sm text-gray-500">
                 {new Date(task.created_at).toLocaleString()}
               </td>
+              <td className="px-6 py-4 whitespace-nowrap text-right text-sm">
+                <button
+                  className="text-blue-600 hover:text-blue-900"
+                  onClick={() => handleClone(task)}
+                >
+                  {task.status === 'failed' ? 'Retry' : 'Clone'}
+                </button>
+              </td>
             </tr>
           ))}
         </tbody>
@@ -1,13 +1,17 @@
-import { CreateTaskInput, Task } from '../types/task';
+import { CloneTaskInput, CreateTaskInput, Task } from '../types/task';
 
 const API_URL = 'http://localhost:8081';
 
+// The task service scopes tasks to the user identified by this header
+const USER_ID = localStorage.getItem('userId') || 'local-user';
+
 export const taskService = {
   async createTask(input: CreateTaskInput): Promise<Task> {
     const response = await fetch(`${API_URL}/tasks`, {
       method: 'POST',
       headers: {
         'Content-Type': 'application/json',
+        'X-User-ID': USER_ID,
       },
       body: JSON.stringify(input),
     });
@@ -21,7 +25,9 @@ export const taskService = {
   },
 
   async getTasks(): Promise<Task[]> {
-    const response = await fetch(`${API_URL}/tasks`);
+    const response = await fetch(`${API_URL}/tasks`, {
+      headers: { 'X-User-ID': USER_ID },
+    });
 
     if (!response.ok) {
       const error = await response.json();
@@ -32,7 +38,9 @@ export const taskService = {
   },
 
   async getTask(id: string): Promise<Task> {
-    const response = await fetch(`${API_URL}/tasks/${id}`);
+    const response = await fetch(`${API_URL}/tasks/${id}`, {
+      headers: { 'X-User-ID': USER_ID },
+    });
 
     if (!response.ok) {
       const error = await response.json();
@@ -47,6 +55,7 @@ export const taskService = {
       method: 'PUT',
       headers: {
         'Content-Type': 'application/json',
+        'X-User-ID': USER_ID,
       },
       body: JSON.stringify({ status, result }),
     });
@@ -59,9 +68,28 @@ export const taskService = {
     return response.json();
   },
 
+  async cloneTask(id: string, overrides: CloneTaskInput = {}): Promise<Task> {
+    const response = await fetch(`${API_URL}/tasks/${id}/clone`, {
+      method: 'POST',
+      headers: {
+        'Content-Type': 'application/json',
+        'X-User-ID': USER_ID,
+      },
+      body: JSON.stringify(overrides),
+    });
+
+    if (!response.ok) {
+      const error = await response.json();
+      throw new Error(error.error || 'Failed to clone task');
+    }
+
+    return response.json();
+  },
+
   async deleteTask(id: string): Promise<void> {
     const response = await fetch(`${API_URL}/tasks/${id}`, {
       method: 'DELETE',
+      headers: { 'X-User-ID': USER_ID },
     });
 
     if (!response.ok) {
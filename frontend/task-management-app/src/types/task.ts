@@ -1,12 +1,22 @@
 export type TaskStatus = 'pending' | 'running' | 'completed' | 'failed';
 
+export type TaskRole = 'read' | 'write';
+
+export interface TaskShare {
+  user_id: string;
+  role: TaskRole;
+}
+
 export interface Task {
   id: string;
+  owner_id: string;
+  source_task_id?: string;
   title: string;
   description: string;
   status: TaskStatus;
   input: string;
   result?: string;
+  shares: TaskShare[];
   created_at: string;
   updated_at: string;
 }
@@ -16,3 +26,9 @@ export interface CreateTaskInput {
   description: string;
   input: string;
 }
+
+export interface CloneTaskInput {
+  title?: string;
+  description?: string;
+  input?: string;
+}